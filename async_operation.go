@@ -0,0 +1,355 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// OperationKind identifies the asynchronous work an Operation polls.
+type OperationKind string
+
+const (
+	OperationKindMCRIPRoutes                 OperationKind = "MCR_IP_ROUTES"
+	OperationKindMCRBGPNeighborRoutes        OperationKind = "MCR_BGP_NEIGHBOR_ROUTES"
+	OperationKindMCRPing                     OperationKind = "MCR_PING"
+	OperationKindMCRTraceroute               OperationKind = "MCR_TRACEROUTE"
+	OperationKindNATGatewayIPRoutes          OperationKind = "NAT_GATEWAY_IP_ROUTES"
+	OperationKindNATGatewayBGPRoutes         OperationKind = "NAT_GATEWAY_BGP_ROUTES"
+	OperationKindNATGatewayBGPNeighborRoutes OperationKind = "NAT_GATEWAY_BGP_NEIGHBOR_ROUTES"
+	OperationKindNATGatewayProvisioning      OperationKind = "NAT_GATEWAY_PROVISIONING"
+)
+
+// OperationState is the client-side view of an async operation's lifecycle.
+type OperationState string
+
+const (
+	OperationStatePending   OperationState = "PENDING"
+	OperationStateComplete  OperationState = "COMPLETE"
+	OperationStateFailed    OperationState = "FAILED"
+	OperationStateCancelled OperationState = "CANCELLED"
+)
+
+// Async operation errors.
+var (
+	ErrOperationCancelled     = errors.New("operation was cancelled")
+	ErrOperationFailed        = errors.New("operation failed")
+	ErrOperationKindMismatch  = errors.New("operation reference is for a different kind of operation")
+	ErrOperationRefIncomplete = errors.New("operation reference requires a product UID and operation ID")
+)
+
+// OperationRef is the serialisable identity of an async operation. Persist it
+// to resume polling later, possibly from another process, with the matching
+// Resume*Operation method of the owning service.
+type OperationRef struct {
+	Kind        OperationKind `json:"kind"`
+	ProductUID  string        `json:"productUid"`
+	OperationID string        `json:"operationId"`
+}
+
+// String returns a short human-readable description of the operation.
+func (r OperationRef) String() string {
+	return fmt.Sprintf("%s operation %s", r.Kind, r.OperationID)
+}
+
+// validate checks the reference is complete and of one of the given kinds.
+func (r OperationRef) validate(kinds ...OperationKind) error {
+	if r.ProductUID == "" || r.OperationID == "" {
+		return ErrOperationRefIncomplete
+	}
+	for _, k := range kinds {
+		if r.Kind == k {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: got %s", ErrOperationKindMismatch, r.Kind)
+}
+
+// OperationProgress is reported to PollOptions.OnProgress after every poll.
+type OperationProgress struct {
+	Ref     OperationRef
+	Attempt int
+	State   OperationState
+	Elapsed time.Duration
+	// NextPoll is the delay before the next poll, or zero once the
+	// operation has finished.
+	NextPoll time.Duration
+}
+
+// PollOptions controls how Operation.Wait polls. Zero fields fall back to the
+// defaults of the operation's kind.
+type PollOptions struct {
+	// InitialDelay is waited before the first poll.
+	InitialDelay time.Duration
+	// Interval is the delay between the first and second polls.
+	Interval time.Duration
+	// Multiplier grows the interval after every pending poll. Values of 1 or
+	// less poll at a fixed Interval.
+	Multiplier float64
+	// MaxInterval caps the interval growth.
+	MaxInterval time.Duration
+	// Timeout bounds the wait when the caller's context has no deadline.
+	Timeout time.Duration
+	// OnProgress, if set, is called synchronously after every poll.
+	OnProgress func(OperationProgress)
+}
+
+// merge returns o with every non-zero field of override applied.
+func (o PollOptions) merge(override *PollOptions) PollOptions {
+	if override == nil {
+		return o
+	}
+	if override.InitialDelay != 0 {
+		o.InitialDelay = override.InitialDelay
+	}
+	if override.Interval != 0 {
+		o.Interval = override.Interval
+	}
+	if override.Multiplier != 0 {
+		o.Multiplier = override.Multiplier
+	}
+	if override.MaxInterval != 0 {
+		o.MaxInterval = override.MaxInterval
+	}
+	if override.Timeout != 0 {
+		o.Timeout = override.Timeout
+	}
+	if override.OnProgress != nil {
+		o.OnProgress = override.OnProgress
+	}
+	return o
+}
+
+// nextInterval applies the backoff multiplier to cur, capped at MaxInterval.
+func (o PollOptions) nextInterval(cur time.Duration) time.Duration {
+	if o.Multiplier <= 1 {
+		return cur
+	}
+	next := time.Duration(float64(cur) * o.Multiplier)
+	if o.MaxInterval > 0 && next > o.MaxInterval {
+		next = o.MaxInterval
+	}
+	return next
+}
+
+// fixedIntervalPollOptions disables backoff so an operation polls at its
+// default interval throughout. The Wait* and List* convenience methods that
+// predate Operation use it to keep their original polling cadence.
+func fixedIntervalPollOptions() *PollOptions {
+	return &PollOptions{Multiplier: 1}
+}
+
+// operationPollFunc performs a single status check. done reports whether the
+// operation has finished; result is only meaningful when done is true. An
+// error wrapping ErrOperationFailed marks the operation as failed.
+type operationPollFunc[T any] func(ctx context.Context) (result T, done bool, err error)
+
+// Operation is a handle to an asynchronous diagnostics operation such as an
+// MCR ping or a NAT Gateway route query. It is safe for concurrent use.
+//
+// The Megaport API has no endpoint to abort a diagnostics operation, so
+// Cancel only stops client-side polling; the operation may still run to
+// completion server-side.
+type Operation[T any] struct {
+	ref        OperationRef
+	defaults   PollOptions
+	timeoutErr error
+	poll       operationPollFunc[T]
+
+	mu     sync.Mutex
+	state  OperationState
+	result T
+	err    error
+
+	cancelOnce sync.Once
+	cancelled  chan struct{}
+}
+
+// newOperation creates a pending Operation. timeoutErr is returned by Wait
+// when the SDK-managed PollOptions.Timeout elapses.
+func newOperation[T any](ref OperationRef, defaults PollOptions, timeoutErr error, poll operationPollFunc[T]) *Operation[T] {
+	return &Operation[T]{
+		ref:        ref,
+		defaults:   defaults,
+		timeoutErr: timeoutErr,
+		poll:       poll,
+		state:      OperationStatePending,
+		cancelled:  make(chan struct{}),
+	}
+}
+
+// Ref returns the serialisable reference used to resume the operation.
+func (op *Operation[T]) Ref() OperationRef {
+	return op.ref
+}
+
+// ID returns the operation ID assigned by the Megaport API.
+func (op *Operation[T]) ID() string {
+	return op.ref.OperationID
+}
+
+// State returns the operation's current client-side state.
+func (op *Operation[T]) State() OperationState {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return op.state
+}
+
+// Cancel stops any in-progress Wait and marks the operation cancelled. It is
+// a no-op once the operation has finished.
+func (op *Operation[T]) Cancel() {
+	op.mu.Lock()
+	if op.state == OperationStatePending {
+		op.state = OperationStateCancelled
+		op.err = ErrOperationCancelled
+	}
+	op.mu.Unlock()
+	op.cancelOnce.Do(func() { close(op.cancelled) })
+}
+
+// Poll checks the operation once. done is true when the operation has
+// completed, failed or been cancelled; finished operations return their
+// cached outcome without calling the API again.
+func (op *Operation[T]) Poll(ctx context.Context) (T, bool, error) {
+	var zero T
+	op.mu.Lock()
+	state, result, err := op.state, op.result, op.err
+	op.mu.Unlock()
+	switch state {
+	case OperationStateComplete:
+		return result, true, nil
+	case OperationStateFailed, OperationStateCancelled:
+		return zero, true, err
+	}
+
+	result, done, err := op.poll(ctx)
+	if err != nil {
+		if errors.Is(err, ErrOperationFailed) {
+			op.finish(OperationStateFailed, zero, err)
+			return zero, true, err
+		}
+		return zero, false, err
+	}
+	if !done {
+		return zero, false, nil
+	}
+	op.finish(OperationStateComplete, result, nil)
+	return result, true, nil
+}
+
+// finish records the terminal outcome unless the operation was already
+// cancelled.
+func (op *Operation[T]) finish(state OperationState, result T, err error) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	if op.state != OperationStatePending {
+		return
+	}
+	op.state, op.result, op.err = state, result, err
+}
+
+// Wait polls until the operation finishes, ctx is done, the operation is
+// cancelled, or the SDK-managed timeout elapses. opts may be nil to use the
+// defaults for the operation's kind. The timeout only applies when ctx has no
+// deadline; callers control the overall wait by passing a context with one.
+//
+// A caller cancellation or deadline is returned wrapping ctx.Err(); the SDK
+// timeout is returned as the owning service's timeout error (for example
+// ErrMCRDiagnosticsTimeout), and Cancel as ErrOperationCancelled.
+func (op *Operation[T]) Wait(ctx context.Context, opts *PollOptions) (T, error) {
+	var zero T
+	o := op.defaults.merge(opts)
+
+	pollCtx := ctx
+	if _, ok := ctx.Deadline(); !ok && o.Timeout > 0 {
+		var cancel context.CancelFunc
+		pollCtx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+	pollCtx, stop := context.WithCancel(pollCtx)
+	defer stop()
+	go func() {
+		select {
+		case <-op.cancelled:
+			stop()
+		case <-pollCtx.Done():
+		}
+	}()
+
+	// doneErr attributes a finished pollCtx to whichever source fired:
+	// Cancel, the caller's context, or the SDK-managed timeout.
+	doneErr := func() error {
+		if op.State() == OperationStateCancelled {
+			return ErrOperationCancelled
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("waiting for %s: %w", op.ref, err)
+		}
+		return fmt.Errorf("waiting for %s: %w", op.ref, op.timeoutErr)
+	}
+	// When pollCtx ends mid-request, Client.Do returns a wrapped context
+	// error. Map it through doneErr so callers get a consistent error while
+	// genuine API/network failures pass through untouched.
+	mapErr := func(err error) error {
+		if pollCtx.Err() != nil && (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+			return doneErr()
+		}
+		return err
+	}
+	wait := func(d time.Duration) bool {
+		if d <= 0 {
+			return pollCtx.Err() == nil
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-pollCtx.Done():
+			return false
+		case <-timer.C:
+			return true
+		}
+	}
+
+	start := time.Now()
+	interval := o.Interval
+	if !wait(o.InitialDelay) {
+		return zero, doneErr()
+	}
+	for attempt := 1; ; attempt++ {
+		result, done, err := op.Poll(pollCtx)
+		if o.OnProgress != nil {
+			p := OperationProgress{Ref: op.ref, Attempt: attempt, State: op.State(), Elapsed: time.Since(start)}
+			if !done && err == nil {
+				p.NextPoll = interval
+			}
+			o.OnProgress(p)
+		}
+		if err != nil {
+			return zero, mapErr(err)
+		}
+		if done {
+			return result, nil
+		}
+		if !wait(interval) {
+			return zero, doneErr()
+		}
+		interval = o.nextInterval(interval)
+	}
+}
+
+// waitWithin waits for op the way the SDK's convenience wrappers always
+// have: at a fixed interval, and bounded by timeout even when the caller's
+// context has a deadline of its own. It returns the operation's timeout
+// error when timeout is what elapsed.
+func (op *Operation[T]) waitWithin(ctx context.Context, timeout time.Duration) (T, error) {
+	pollCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := op.Wait(pollCtx, fixedIntervalPollOptions())
+	if err != nil && ctx.Err() == nil && pollCtx.Err() != nil && errors.Is(err, context.DeadlineExceeded) {
+		var zero T
+		return zero, op.timeoutErr
+	}
+	return result, err
+}
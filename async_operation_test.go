@@ -0,0 +1,186 @@
+package megaport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastPollOptions keeps Operation tests free of real-time waits.
+var fastPollOptions = PollOptions{Interval: time.Millisecond, Multiplier: 2, MaxInterval: 4 * time.Millisecond, Timeout: time.Second}
+
+func TestOperationWaitBackoffAndProgress(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	op := newOperation(OperationRef{Kind: OperationKindMCRPing, ProductUID: "mcr", OperationID: "op"}, fastPollOptions, ErrMCRDiagnosticsTimeout,
+		func(ctx context.Context) (string, bool, error) {
+			if calls.Add(1) < 4 {
+				return "", false, nil
+			}
+			return "done", true, nil
+		})
+
+	var progress []OperationProgress
+	got, err := op.Wait(context.Background(), &PollOptions{OnProgress: func(p OperationProgress) { progress = append(progress, p) }})
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if got != "done" || op.State() != OperationStateComplete {
+		t.Fatalf("got %q in state %s", got, op.State())
+	}
+	if len(progress) != 4 {
+		t.Fatalf("progress reports = %d, want 4", len(progress))
+	}
+	wantNext := []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 0}
+	for i, p := range progress {
+		if p.Attempt != i+1 || p.NextPoll != wantNext[i] {
+			t.Errorf("progress[%d] = attempt %d next %v, want attempt %d next %v", i, p.Attempt, p.NextPoll, i+1, wantNext[i])
+		}
+	}
+	if progress[3].State != OperationStateComplete {
+		t.Errorf("final progress state = %s", progress[3].State)
+	}
+
+	// A finished operation returns its cached result without polling again.
+	got, done, err := op.Poll(context.Background())
+	if err != nil || !done || got != "done" || calls.Load() != 4 {
+		t.Fatalf("cached Poll = %q %v %v after %d calls", got, done, err, calls.Load())
+	}
+}
+
+func TestOperationWaitFailure(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	op := newOperation(OperationRef{Kind: OperationKindMCRIPRoutes, ProductUID: "mcr", OperationID: "job"}, fastPollOptions, ErrMCRDiagnosticsTimeout,
+		func(ctx context.Context) (int, bool, error) {
+			calls.Add(1)
+			return 0, true, ErrOperationFailed
+		})
+	_, err := op.Wait(context.Background(), nil)
+	if !errors.Is(err, ErrOperationFailed) || op.State() != OperationStateFailed {
+		t.Fatalf("err = %v, state = %s", err, op.State())
+	}
+	if _, _, err := op.Poll(context.Background()); !errors.Is(err, ErrOperationFailed) || calls.Load() != 1 {
+		t.Fatalf("cached failure = %v after %d calls", err, calls.Load())
+	}
+}
+
+func TestOperationWaitTimeoutAndCallerDeadline(t *testing.T) {
+	t.Parallel()
+	pending := func(ctx context.Context) (int, bool, error) { return 0, false, nil }
+	ref := OperationRef{Kind: OperationKindNATGatewayIPRoutes, ProductUID: "gw", OperationID: "op"}
+
+	op := newOperation(ref, fastPollOptions, ErrNATGatewayDiagnosticsTimeout, pending)
+	_, err := op.Wait(context.Background(), &PollOptions{Timeout: 10 * time.Millisecond})
+	if !errors.Is(err, ErrNATGatewayDiagnosticsTimeout) {
+		t.Fatalf("SDK timeout err = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	op = newOperation(ref, fastPollOptions, ErrNATGatewayDiagnosticsTimeout, pending)
+	_, err = op.Wait(ctx, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrNATGatewayDiagnosticsTimeout) {
+		t.Fatalf("caller deadline err = %v", err)
+	}
+	if op.State() != OperationStatePending {
+		t.Fatalf("state after deadline = %s, want still pending", op.State())
+	}
+}
+
+func TestOperationCancel(t *testing.T) {
+	t.Parallel()
+	polled := make(chan struct{}, 1)
+	op := newOperation(OperationRef{Kind: OperationKindMCRTraceroute, ProductUID: "mcr", OperationID: "op"},
+		PollOptions{Interval: time.Hour}, ErrMCRDiagnosticsTimeout,
+		func(ctx context.Context) (int, bool, error) {
+			select {
+			case polled <- struct{}{}:
+			default:
+			}
+			return 0, false, nil
+		})
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := op.Wait(context.Background(), nil)
+		errc <- err
+	}()
+	<-polled
+	op.Cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, ErrOperationCancelled) {
+			t.Fatalf("Wait err = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after Cancel")
+	}
+	if op.State() != OperationStateCancelled {
+		t.Fatalf("state = %s", op.State())
+	}
+	if _, done, err := op.Poll(context.Background()); !done || !errors.Is(err, ErrOperationCancelled) {
+		t.Fatalf("Poll after Cancel = %v %v", done, err)
+	}
+	op.Cancel() // idempotent
+}
+
+func TestOperationRefValidate(t *testing.T) {
+	t.Parallel()
+	ref := OperationRef{Kind: OperationKindMCRPing, ProductUID: "mcr", OperationID: "op"}
+	b, err := json.Marshal(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded OperationRef
+	if err := json.Unmarshal(b, &decoded); err != nil || decoded != ref {
+		t.Fatalf("round trip = %+v, %v", decoded, err)
+	}
+
+	tests := []struct {
+		name    string
+		ref     OperationRef
+		kinds   []OperationKind
+		wantErr error
+	}{
+		{"match", ref, []OperationKind{OperationKindMCRPing}, nil},
+		{"one of several", ref, []OperationKind{OperationKindMCRTraceroute, OperationKindMCRPing}, nil},
+		{"wrong kind", ref, []OperationKind{OperationKindMCRTraceroute}, ErrOperationKindMismatch},
+		{"missing product", OperationRef{Kind: OperationKindMCRPing, OperationID: "op"}, []OperationKind{OperationKindMCRPing}, ErrOperationRefIncomplete},
+		{"missing operation", OperationRef{Kind: OperationKindMCRPing, ProductUID: "mcr"}, []OperationKind{OperationKindMCRPing}, ErrOperationRefIncomplete},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.ref.validate(tc.kinds...)
+			if tc.wantErr == nil && err != nil || tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Fatalf("validate = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestPollOptionsMergeAndBackoff(t *testing.T) {
+	t.Parallel()
+	base := PollOptions{InitialDelay: time.Second, Interval: 2 * time.Second, Multiplier: 1.5, MaxInterval: 4 * time.Second, Timeout: time.Minute}
+	if got := base.merge(nil); got.Interval != base.Interval || got.Timeout != base.Timeout {
+		t.Fatalf("merge(nil) = %+v", got)
+	}
+	got := base.merge(&PollOptions{Interval: time.Second, Multiplier: 1})
+	if got.Interval != time.Second || got.Multiplier != 1 || got.InitialDelay != time.Second || got.Timeout != time.Minute {
+		t.Fatalf("merge = %+v", got)
+	}
+	if next := got.nextInterval(time.Second); next != time.Second {
+		t.Fatalf("fixed interval grew to %v", next)
+	}
+	if next := base.nextInterval(2 * time.Second); next != 3*time.Second {
+		t.Fatalf("backoff = %v, want 3s", next)
+	}
+	if next := base.nextInterval(3 * time.Second); next != 4*time.Second {
+		t.Fatalf("capped backoff = %v, want 4s", next)
+	}
+}
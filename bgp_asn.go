@@ -0,0 +1,21 @@
+package megaport
+
+import "net/netip"
+
+// validPeerASN reports whether asn is a 2 or 4 byte ASN that is not
+// reserved (AS_TRANS, documentation or last ASNs).
+func validPeerASN(asn int64) bool {
+	switch {
+	case asn < 1 || asn >= 4294967295:
+		return false
+	case asn == 23456, asn >= 64496 && asn <= 64511, asn == 65535, asn >= 65536 && asn <= 65551:
+		return false
+	}
+	return true
+}
+
+// isPublicAddr reports whether a is a globally routable unicast address, as
+// required for public and Microsoft peering.
+func isPublicAddr(a netip.Addr) bool {
+	return a.IsGlobalUnicast() && !a.IsPrivate()
+}
@@ -0,0 +1,227 @@
+package megaport
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// billableService is the billing view of a port, MCR, MVE, VXC or NAT
+// Gateway shared by the contract and cost reports.
+type billableService struct {
+	uid         string
+	id          int
+	name        string
+	productType string
+	status      string
+	// costCentre is the service level reference; NAT Gateways call it
+	// ServiceLevelReference.
+	costCentre    string
+	term          int
+	contractEnd   time.Time
+	autoRenewTerm bool
+	// resourceTags is only known up front for NAT Gateways; other products
+	// need ListProductResourceTags.
+	resourceTags []ResourceTag
+	// pricing prices the service on its current configuration. Use
+	// withPricingTerm to set the term.
+	pricing    PriceBookRequest
+	vxc        *VXC
+	natGateway *NATGateway
+}
+
+// listBillableServices returns the ports, MCRs and MVEs from ListProducts
+// and their associated VXCs, each once, followed by the NAT Gateways when
+// includeNATGateways is set. Decommissioned and cancelled services are
+// skipped.
+func (svc *ProductServiceOp) listBillableServices(ctx context.Context, includeNATGateways bool, currency string) ([]*billableService, error) {
+	products, err := svc.ListProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	typeByUID := make(map[string]string, len(products))
+	for _, p := range products {
+		typeByUID[p.GetUID()] = strings.ToUpper(p.GetType())
+	}
+	seen := make(map[string]bool)
+	var services []*billableService
+	add := func(s *billableService) {
+		if s == nil || seen[s.uid] || s.status == STATUS_DECOMMISSIONED || s.status == STATUS_CANCELLED {
+			return
+		}
+		seen[s.uid] = true
+		services = append(services, s)
+	}
+	for _, p := range products {
+		add(newProductBillableService(p, currency))
+		for _, v := range p.GetAssociatedVXCs() {
+			if v != nil {
+				add(newVXCBillableService(v, typeByUID[v.AEndConfiguration.UID], currency))
+			}
+		}
+	}
+	if includeNATGateways {
+		gateways, err := svc.Client.NATGatewayService.ListNATGateways(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, gw := range gateways {
+			if gw != nil {
+				add(newNATGatewayBillableService(gw, currency))
+			}
+		}
+	}
+	return services, nil
+}
+
+// newProductBillableService returns nil for products other than ports, MCRs
+// and MVEs.
+func newProductBillableService(p Product, currency string) *billableService {
+	switch v := p.(type) {
+	case *Port:
+		return &billableService{
+			uid:         v.UID,
+			id:          v.ID,
+			name:        v.Name,
+			productType: v.Type,
+			status:      v.ProvisioningStatus,
+			costCentre:  v.CostCentre,
+			term:        v.ContractTermMonths,
+			contractEnd: contractTime(v.ContractEndDate),
+			pricing: &MegaportPriceBookRequest{
+				Currency:   currency,
+				LocationID: v.LocationID,
+				Speed:      v.PortSpeed,
+				ProductUID: v.UID,
+			},
+		}
+	case *MCR:
+		return &billableService{
+			uid:         v.UID,
+			id:          v.ID,
+			name:        v.Name,
+			productType: v.Type,
+			status:      v.ProvisioningStatus,
+			costCentre:  v.CostCentre,
+			term:        v.ContractTermMonths,
+			contractEnd: contractTime(v.ContractEndDate),
+			pricing: &MCRPriceBookRequest{
+				Currency:   currency,
+				LocationID: v.LocationID,
+				Speed:      v.PortSpeed,
+				ProductUID: v.UID,
+			},
+		}
+	case *MVE:
+		return &billableService{
+			uid:         v.UID,
+			id:          v.ID,
+			name:        v.Name,
+			productType: v.Type,
+			status:      v.ProvisioningStatus,
+			costCentre:  v.CostCentre,
+			term:        v.ContractTermMonths,
+			contractEnd: contractTime(v.ContractEndDate),
+			pricing: &MVEPriceBookRequest{
+				Currency:   currency,
+				LocationID: v.LocationID,
+				Size:       v.Size,
+				ProductUID: v.UID,
+			},
+		}
+	}
+	return nil
+}
+
+// newVXCBillableService prices the VXC with aEndProductType, the type of
+// its A-End product, and the connect type of its cloud end, if any.
+func newVXCBillableService(v *VXC, aEndProductType, currency string) *billableService {
+	var connectType string
+	if pc, err := v.CloudPartnerConfig(); err == nil {
+		connectType = partnerConfigConnectType(pc)
+	}
+	return &billableService{
+		uid:         v.UID,
+		id:          v.ID,
+		name:        v.Name,
+		productType: v.Type,
+		status:      v.ProvisioningStatus,
+		costCentre:  v.CostCentre,
+		term:        v.ContractTermMonths,
+		contractEnd: contractTime(v.ContractEndDate),
+		pricing: &VXCPriceBookRequest{
+			Currency:        currency,
+			ALocationID:     v.AEndConfiguration.LocationID,
+			BLocationID:     v.BEndConfiguration.LocationID,
+			Speed:           v.RateLimit,
+			AEndProductType: aEndProductType,
+			ConnectType:     connectType,
+			ProductUID:      v.UID,
+		},
+		vxc: v,
+	}
+}
+
+// newNATGatewayBillableService leaves contractEnd zero when the NAT
+// Gateway's contract end date is not RFC 3339, e.g. for designs.
+func newNATGatewayBillableService(gw *NATGateway, currency string) *billableService {
+	end, _ := time.Parse(time.RFC3339, gw.ContractEndDate)
+	tags := gw.ResourceTags
+	if tags == nil {
+		tags = []ResourceTag{}
+	}
+	return &billableService{
+		uid:           gw.ProductUID,
+		name:          gw.ProductName,
+		productType:   strings.ToUpper(PRODUCT_NAT_GATEWAY),
+		status:        gw.ProvisioningStatus,
+		costCentre:    gw.ServiceLevelReference,
+		term:          gw.Term,
+		contractEnd:   end,
+		autoRenewTerm: gw.AutoRenewTerm,
+		resourceTags:  tags,
+		pricing: &NATGatewayPriceBookRequest{
+			Currency:     currency,
+			LocationID:   gw.LocationID,
+			Speed:        gw.Speed,
+			SessionCount: gw.Config.SessionCount,
+			ProductUID:   gw.ProductUID,
+		},
+		natGateway: gw,
+	}
+}
+
+func contractTime(t *Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.Time
+}
+
+// withPricingTerm returns a copy of a billable service pricing request on
+// term.
+func withPricingTerm(req PriceBookRequest, term int) PriceBookRequest {
+	switch r := req.(type) {
+	case *MegaportPriceBookRequest:
+		c := *r
+		c.Term = term
+		return &c
+	case *MCRPriceBookRequest:
+		c := *r
+		c.Term = term
+		return &c
+	case *MVEPriceBookRequest:
+		c := *r
+		c.Term = term
+		return &c
+	case *VXCPriceBookRequest:
+		c := *r
+		c.Term = term
+		return &c
+	case *NATGatewayPriceBookRequest:
+		c := *r
+		c.Term = term
+		return &c
+	}
+	return req
+}
@@ -0,0 +1,307 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// Capacity errors.
+var (
+	// ErrCapacityExceeded is returned when a VXC order or rate limit increase
+	// would take a product's committed bandwidth past the allowed ratio.
+	ErrCapacityExceeded = errors.New("VXC would exceed the product's oversubscription limit")
+	// ErrCapacityPolicyInvalid is returned for a capacity policy without a
+	// positive ratio.
+	ErrCapacityPolicyInvalid = errors.New("capacity policy max ratio must be positive")
+)
+
+// CapacityPolicy limits the oversubscription of ports, LAGs and MCRs by new
+// VXCs and VXC rate limit increases. MVEs are not limited, as their
+// capacity is not known; see VXCCapacityCheck.Unchecked. Set it with
+// WithCapacityPolicy.
+type CapacityPolicy struct {
+	// MaxRatio is the highest committed bandwidth to capacity ratio
+	// allowed: 1 forbids oversubscription, 2 allows 2:1.
+	MaxRatio float64
+	// WarnOnly logs a warning instead of rejecting the order or update.
+	WarnOnly bool
+}
+
+// WithCapacityPolicy makes BuyVXC and UpdateVXC check the account's
+// products before ordering a VXC or raising its rate limit, and reject the
+// change with ErrCapacityExceeded, or log a warning for a WarnOnly policy,
+// when an end would exceed the policy's ratio. Each check lists the
+// account's products. MVE ends and moving a VXC end to another product are
+// not checked.
+func WithCapacityPolicy(p CapacityPolicy) ClientOpt {
+	return func(c *Client) error {
+		if p.MaxRatio <= 0 {
+			return ErrCapacityPolicyInvalid
+		}
+		c.capacityPolicy = &p
+		return nil
+	}
+}
+
+// ProductCapacity is the bandwidth committed on a port, LAG, MCR or MVE by
+// its active VXCs and IXs.
+type ProductCapacity struct {
+	ProductUID  string
+	ProductName string
+	ProductType string
+	// LAGID is set for a LAG, reported once under its primary port.
+	LAGID int
+	// CapacityMbps is the port or MCR speed, or the sum of the member
+	// speeds for a LAG. It is zero for an MVE, whose throughput depends on
+	// its size and image rather than a rate.
+	CapacityMbps  int
+	CommittedMbps int
+}
+
+// Ratio returns committed bandwidth as a ratio of capacity, or zero when
+// the capacity is unknown.
+func (c *ProductCapacity) Ratio() float64 {
+	if c.CapacityMbps == 0 {
+		return 0
+	}
+	return float64(c.CommittedMbps) / float64(c.CapacityMbps)
+}
+
+// HeadroomMbps returns the capacity not yet committed, negative when the
+// product is oversubscribed.
+func (c *ProductCapacity) HeadroomMbps() int {
+	return c.CapacityMbps - c.CommittedMbps
+}
+
+// Exceeds reports whether the ratio is above maxRatio. A product with
+// unknown capacity never exceeds it.
+func (c *ProductCapacity) Exceeds(maxRatio float64) bool {
+	return c.CapacityMbps > 0 && c.Ratio() > maxRatio
+}
+
+// With returns a copy of the capacity with mbps more committed.
+func (c *ProductCapacity) With(mbps int) *ProductCapacity {
+	out := *c
+	out.CommittedMbps += mbps
+	return &out
+}
+
+// CapacityReport is the committed bandwidth of every active port, LAG, MCR
+// and MVE, ordered by product type, then name.
+type CapacityReport struct {
+	Products []*ProductCapacity
+
+	byUID map[string]*ProductCapacity
+}
+
+// NewCapacityReport computes the capacity of products. LAG members are
+// reported together under the LAG's primary port. Decommissioned and
+// cancelled products are ignored.
+func NewCapacityReport(products []Product) *CapacityReport {
+	r := &CapacityReport{byUID: make(map[string]*ProductCapacity)}
+	var lagPorts []*Port
+	for _, p := range products {
+		if p == nil || p.GetProvisioningStatus() == STATUS_DECOMMISSIONED || p.GetProvisioningStatus() == STATUS_CANCELLED {
+			continue
+		}
+		c := &ProductCapacity{
+			ProductUID:    p.GetUID(),
+			ProductType:   p.GetType(),
+			CommittedMbps: committedMbps(p.GetAssociatedVXCs(), p.GetAssociatedIXs()),
+		}
+		switch t := p.(type) {
+		case *Port:
+			if lagID(t) != 0 {
+				lagPorts = append(lagPorts, t)
+				continue
+			}
+			c.ProductName, c.CapacityMbps = t.Name, t.PortSpeed
+		case *MCR:
+			c.ProductName, c.CapacityMbps = t.Name, t.PortSpeed
+		case *MVE:
+			c.ProductName = t.Name
+		}
+		r.add(c)
+	}
+	for _, l := range GroupLAGs(lagPorts) {
+		head := l.Members[0]
+		c := &ProductCapacity{
+			ProductUID:    head.UID,
+			ProductName:   head.Name,
+			ProductType:   head.Type,
+			LAGID:         l.ID,
+			CapacityMbps:  l.CapacityMbps(),
+			CommittedMbps: l.CommittedMbps(),
+		}
+		r.add(c)
+		for _, m := range l.Members[1:] {
+			r.byUID[m.UID] = c
+		}
+	}
+	sort.SliceStable(r.Products, func(i, j int) bool {
+		a, b := r.Products[i], r.Products[j]
+		if !strings.EqualFold(a.ProductType, b.ProductType) {
+			return strings.ToLower(a.ProductType) < strings.ToLower(b.ProductType)
+		}
+		if a.ProductName != b.ProductName {
+			return a.ProductName < b.ProductName
+		}
+		return a.ProductUID < b.ProductUID
+	})
+	return r
+}
+
+func (r *CapacityReport) add(c *ProductCapacity) {
+	r.Products = append(r.Products, c)
+	r.byUID[c.ProductUID] = c
+}
+
+// Find returns the capacity of a product, or of the LAG a port is a member
+// of, or nil when the product is not in the report.
+func (r *CapacityReport) Find(productUID string) *ProductCapacity {
+	return r.byUID[productUID]
+}
+
+// Exceeding returns the products whose ratio is above maxRatio; pass 1 for
+// the oversubscribed products.
+func (r *CapacityReport) Exceeding(maxRatio float64) []*ProductCapacity {
+	var out []*ProductCapacity
+	for _, c := range r.Products {
+		if c.Exceeds(maxRatio) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// AnalyzeCapacity computes the committed bandwidth of every active port,
+// LAG, MCR and MVE from their associated VXCs and IXs.
+func (svc *ProductServiceOp) AnalyzeCapacity(ctx context.Context) (*CapacityReport, error) {
+	products, err := svc.ListProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewCapacityReport(products), nil
+}
+
+// VXCCapacityCheck is the capacity of the account's products at the ends of
+// a new VXC or a VXC rate limit change, with the change applied.
+type VXCCapacityCheck struct {
+	// AddedMbps is the bandwidth the change adds at each end; it is zero or
+	// negative for a rate limit decrease.
+	AddedMbps int
+	// Ends are the products after the change. Ends the account does not
+	// own, such as cloud partner ports, are omitted, and a VXC with both
+	// ends on one product lists it once with the bandwidth added once, as
+	// the product's committed bandwidth counts each VXC once.
+	Ends []*ProductCapacity
+	// Unchecked are the ends whose capacity is unknown, such as MVEs, after
+	// the change. Err never fails because of them.
+	Unchecked []*ProductCapacity
+}
+
+// Err returns ErrCapacityExceeded, joined for each end whose ratio after
+// the change is above maxRatio, or nil. A change that adds no bandwidth
+// never fails.
+func (c *VXCCapacityCheck) Err(maxRatio float64) error {
+	if c.AddedMbps <= 0 {
+		return nil
+	}
+	var errs []error
+	for _, end := range c.Ends {
+		if end.Exceeds(maxRatio) {
+			errs = append(errs, fmt.Errorf("%w: %s %s would commit %d of %d Mbps (%.2f:1, limit %.2f:1)",
+				ErrCapacityExceeded, end.ProductType, end.ProductUID, end.CommittedMbps, end.CapacityMbps, end.Ratio(), maxRatio))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckVXCCapacity reports the capacity of both ends of a proposed VXC
+// with its rate limit added.
+func (svc *VXCServiceOp) CheckVXCCapacity(ctx context.Context, req *BuyVXCRequest) (*VXCCapacityCheck, error) {
+	if req == nil {
+		return nil, ErrBuyVXCRequestNil
+	}
+	aEnd := req.PortUID
+	if aEnd == "" {
+		aEnd = req.AEndConfiguration.ProductUID
+	}
+	return svc.checkVXCCapacity(ctx, req.RateLimit, aEnd, req.BEndConfiguration.ProductUID)
+}
+
+// CheckVXCRateLimitCapacity reports the capacity of both ends of a VXC with
+// its rate limit changed to rateLimit.
+func (svc *VXCServiceOp) CheckVXCRateLimitCapacity(ctx context.Context, id string, rateLimit int) (*VXCCapacityCheck, error) {
+	vxc, err := svc.GetVXC(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return svc.checkVXCCapacity(ctx, rateLimit-vxc.RateLimit, vxc.AEndConfiguration.UID, vxc.BEndConfiguration.UID)
+}
+
+func (svc *VXCServiceOp) checkVXCCapacity(ctx context.Context, addedMbps int, endUIDs ...string) (*VXCCapacityCheck, error) {
+	report, err := svc.Client.ProductService.AnalyzeCapacity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	check := &VXCCapacityCheck{AddedMbps: addedMbps}
+	seen := make(map[*ProductCapacity]bool)
+	for _, uid := range endUIDs {
+		before := report.Find(uid)
+		if before == nil || seen[before] {
+			continue
+		}
+		seen[before] = true
+		if before.CapacityMbps == 0 {
+			check.Unchecked = append(check.Unchecked, before.With(addedMbps))
+			continue
+		}
+		check.Ends = append(check.Ends, before.With(addedMbps))
+	}
+	return check, nil
+}
+
+// enforceCapacityPolicy applies the client's capacity policy to a check,
+// returning its error or, for a WarnOnly policy, logging it. A check that
+// failed with checkErr is logged and skipped under a WarnOnly policy.
+func (svc *VXCServiceOp) enforceCapacityPolicy(ctx context.Context, check *VXCCapacityCheck, checkErr error) error {
+	policy := svc.Client.capacityPolicy
+	if checkErr != nil {
+		if policy.WarnOnly {
+			svc.Client.Logger.WarnContext(ctx, "could not check VXC capacity", slog.String("error", checkErr.Error()))
+			return nil
+		}
+		return checkErr
+	}
+	err := check.Err(policy.MaxRatio)
+	if err != nil && policy.WarnOnly {
+		svc.Client.Logger.WarnContext(ctx, "VXC exceeds capacity policy", slog.String("error", err.Error()))
+		return nil
+	}
+	return err
+}
+
+// enforceBuyVXCCapacity applies the client's capacity policy, if any, to a
+// VXC order.
+func (svc *VXCServiceOp) enforceBuyVXCCapacity(ctx context.Context, req *BuyVXCRequest) error {
+	if svc.Client.capacityPolicy == nil {
+		return nil
+	}
+	check, err := svc.CheckVXCCapacity(ctx, req)
+	return svc.enforceCapacityPolicy(ctx, check, err)
+}
+
+// enforceUpdateVXCCapacity applies the client's capacity policy, if any, to
+// a VXC update that sets a rate limit.
+func (svc *VXCServiceOp) enforceUpdateVXCCapacity(ctx context.Context, id string, rateLimit *int) error {
+	if svc.Client.capacityPolicy == nil || rateLimit == nil {
+		return nil
+	}
+	check, err := svc.CheckVXCRateLimitCapacity(ctx, id, *rateLimit)
+	return svc.enforceCapacityPolicy(ctx, check, err)
+}
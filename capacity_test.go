@@ -0,0 +1,219 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestNewCapacityReport(t *testing.T) {
+	t.Parallel()
+	r := NewCapacityReport([]Product{
+		&Port{UID: "port-1", Name: "Port", Type: "MEGAPORT", PortSpeed: 1000,
+			AssociatedVXCs: []*VXC{{UID: "vxc-1", RateLimit: 800}, {UID: "vxc-2", RateLimit: 500}},
+			AssociatedIXs:  []*IX{{ProductUID: "ix-1", RateLimit: 200}}},
+		&Port{UID: "lag-b", Name: "LAG", Type: "MEGAPORT", PortSpeed: 10000, AggregationID: 4},
+		&Port{UID: "lag-a", Name: "LAG", Type: "MEGAPORT", PortSpeed: 10000, AggregationID: 4, LAGPrimary: true,
+			AssociatedVXCs: []*VXC{{UID: "vxc-3", RateLimit: 5000}}},
+		&MCR{UID: "mcr-1", Name: "MCR", Type: "MCR2", PortSpeed: 5000, AssociatedVXCs: []*VXC{{UID: "vxc-1", RateLimit: 800}}},
+		&MVE{UID: "mve-1", Name: "MVE", Type: "MVE", AssociatedVXCs: []*VXC{{UID: "vxc-4", RateLimit: 100000}}},
+		&Port{UID: "port-old", Type: "MEGAPORT", PortSpeed: 1000, ProvisioningStatus: STATUS_DECOMMISSIONED},
+	})
+
+	var got []string
+	for _, c := range r.Products {
+		got = append(got, fmt.Sprintf("%s %d/%d", c.ProductUID, c.CommittedMbps, c.CapacityMbps))
+	}
+	if want := "[mcr-1 800/5000 lag-a 5000/20000 port-1 1500/1000 mve-1 100000/0]"; fmt.Sprint(got) != want {
+		t.Fatalf("products = %v, want %s", got, want)
+	}
+	if c := r.Find("lag-b"); c == nil || c.ProductUID != "lag-a" || c.LAGID != 4 {
+		t.Fatalf("Find(lag-b) = %+v", c)
+	}
+	if r.Find("port-old") != nil {
+		t.Fatal("decommissioned port reported")
+	}
+
+	port := r.Find("port-1")
+	if port.Ratio() != 1.5 || port.HeadroomMbps() != -500 {
+		t.Fatalf("port ratio, headroom = %v, %d", port.Ratio(), port.HeadroomMbps())
+	}
+	if over := r.Exceeding(1); len(over) != 1 || over[0] != port {
+		t.Fatalf("Exceeding(1) = %v", over)
+	}
+	if over := r.Exceeding(2); len(over) != 0 {
+		t.Fatalf("Exceeding(2) = %v", over)
+	}
+	if mve := r.Find("mve-1"); mve.Ratio() != 0 || mve.Exceeds(1) {
+		t.Fatalf("MVE with unknown capacity exceeds: %+v", mve)
+	}
+	if with := r.Find("mcr-1").With(4500); with.HeadroomMbps() != -300 || r.Find("mcr-1").CommittedMbps != 800 {
+		t.Fatalf("With = %+v", with)
+	}
+}
+
+func TestVXCCapacityCheckErr(t *testing.T) {
+	t.Parallel()
+	ends := []*ProductCapacity{
+		{ProductUID: "port-1", ProductType: "MEGAPORT", CapacityMbps: 1000, CommittedMbps: 1500},
+		{ProductUID: "mcr-1", ProductType: "MCR2", CapacityMbps: 1000, CommittedMbps: 900},
+	}
+	tests := []struct {
+		name     string
+		added    int
+		maxRatio float64
+		wantErr  error
+	}{
+		{"over 1:1", 500, 1, ErrCapacityExceeded},
+		{"within 2:1", 500, 2, nil},
+		{"decrease", -100, 1, nil},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := (&VXCCapacityCheck{AddedMbps: tc.added, Ends: ends}).Err(tc.maxRatio)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// CapacityTestSuite tests the capacity analyzer and the capacity policy
+// applied by BuyVXC and UpdateVXC.
+type CapacityTestSuite struct {
+	ClientTestSuite
+	orders  int
+	updates int
+}
+
+func TestCapacityTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(CapacityTestSuite))
+}
+
+func (suite *CapacityTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+	suite.orders, suite.updates = 0, 0
+
+	suite.mux.HandleFunc("/v2/products", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprint(w, `{"message": "Products", "data": [
+            {"productUid": "port-1", "productType": "MEGAPORT", "provisioningStatus": "LIVE", "portSpeed": 1000,
+                "associatedVxcs": [{"productUid": "vxc-1", "rateLimit": 600, "provisioningStatus": "LIVE"}]},
+            {"productUid": "mcr-1", "productType": "MCR2", "provisioningStatus": "LIVE", "portSpeed": 5000,
+                "associatedVxcs": [{"productUid": "vxc-1", "rateLimit": 600, "provisioningStatus": "LIVE"}]},
+            {"productUid": "mve-1", "productType": "MVE", "provisioningStatus": "LIVE"}
+        ]}`)
+	})
+	suite.mux.HandleFunc("/v2/product/vxc-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "VXC", "data": {"productUid": "vxc-1", "rateLimit": 600, "provisioningStatus": "LIVE",
+            "aEnd": {"productUid": "mcr-1"}, "bEnd": {"productUid": "port-1"}}}`)
+	})
+	suite.mux.HandleFunc("/v3/product/vxc/vxc-1", func(w http.ResponseWriter, r *http.Request) {
+		suite.updates++
+		fmt.Fprint(w, `{"message": "Updated", "data": {"productUid": "vxc-1"}}`)
+	})
+	suite.mux.HandleFunc("/v2/product/vxc-2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"message": "Internal error"}`)
+	})
+	suite.mux.HandleFunc("/v3/product/vxc/vxc-2", func(w http.ResponseWriter, r *http.Request) {
+		suite.updates++
+		fmt.Fprint(w, `{"message": "Updated", "data": {"productUid": "vxc-2"}}`)
+	})
+	suite.mux.HandleFunc("/v4/networkdesign/buy", func(w http.ResponseWriter, r *http.Request) {
+		suite.orders++
+		fmt.Fprint(w, `{"message": "Ordered", "data": [{"technicalServiceUid": "vxc-new"}]}`)
+	})
+}
+
+func (suite *CapacityTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *CapacityTestSuite) TestCheckVXCCapacity() {
+	ctx := context.Background()
+	check, err := suite.client.VXCService.CheckVXCCapacity(ctx, &BuyVXCRequest{
+		PortUID:           "mcr-1",
+		RateLimit:         500,
+		BEndConfiguration: VXCOrderEndpointConfiguration{ProductUID: "port-1"},
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(check.Ends, 2)
+	suite.Equal("mcr-1", check.Ends[0].ProductUID)
+	suite.Equal(3900, check.Ends[0].HeadroomMbps())
+	suite.Equal(-100, check.Ends[1].HeadroomMbps())
+	suite.ErrorIs(check.Err(1), ErrCapacityExceeded)
+
+	check, err = suite.client.VXCService.CheckVXCRateLimitCapacity(ctx, "vxc-1", 1000)
+	suite.Require().NoError(err)
+	suite.Equal(400, check.AddedMbps)
+	suite.Equal(1000, check.Ends[1].CommittedMbps)
+	suite.NoError(check.Err(1))
+
+	check, err = suite.client.VXCService.CheckVXCCapacity(ctx, &BuyVXCRequest{PortUID: "port-1", RateLimit: 100,
+		BEndConfiguration: VXCOrderEndpointConfiguration{ProductUID: "port-1"}})
+	suite.Require().NoError(err)
+	suite.Require().Len(check.Ends, 1)
+	suite.Equal(700, check.Ends[0].CommittedMbps)
+
+	// An MVE's capacity is unknown, so it is reported but not checked.
+	check, err = suite.client.VXCService.CheckVXCCapacity(ctx, &BuyVXCRequest{PortUID: "mcr-1", RateLimit: 10000,
+		BEndConfiguration: VXCOrderEndpointConfiguration{ProductUID: "mve-1"}})
+	suite.Require().NoError(err)
+	suite.Require().Len(check.Ends, 1)
+	suite.Equal("mcr-1", check.Ends[0].ProductUID)
+	suite.Require().Len(check.Unchecked, 1)
+	suite.Equal("mve-1", check.Unchecked[0].ProductUID)
+	suite.Equal(10000, check.Unchecked[0].CommittedMbps)
+	suite.ErrorIs(check.Err(1), ErrCapacityExceeded, "the MCR end is still checked")
+}
+
+func (suite *CapacityTestSuite) TestCapacityPolicy() {
+	ctx := context.Background()
+	suite.Require().NoError(WithCapacityPolicy(CapacityPolicy{MaxRatio: 1})(suite.client))
+	buy := &BuyVXCRequest{PortUID: "mcr-1", VXCName: "vxc", Term: 12, RateLimit: 500,
+		BEndConfiguration: VXCOrderEndpointConfiguration{ProductUID: "port-1"}}
+
+	_, err := suite.client.VXCService.BuyVXC(ctx, buy)
+	suite.ErrorIs(err, ErrCapacityExceeded)
+	suite.Equal(0, suite.orders)
+
+	_, err = suite.client.VXCService.UpdateVXC(ctx, "vxc-1", &UpdateVXCRequest{RateLimit: PtrTo(1100)})
+	suite.ErrorIs(err, ErrCapacityExceeded)
+	suite.Equal(0, suite.updates)
+	_, err = suite.client.VXCService.UpdateVXC(ctx, "vxc-1", &UpdateVXCRequest{RateLimit: PtrTo(1000)})
+	suite.NoError(err)
+	suite.Equal(1, suite.updates)
+	_, err = suite.client.VXCService.UpdateVXC(ctx, "vxc-2", &UpdateVXCRequest{RateLimit: PtrTo(100)})
+	suite.Error(err)
+	suite.Equal(1, suite.updates)
+
+	suite.Require().NoError(WithCapacityPolicy(CapacityPolicy{MaxRatio: 1, WarnOnly: true})(suite.client))
+	suite.client.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	_, err = suite.client.VXCService.BuyVXC(ctx, buy)
+	suite.NoError(err)
+	suite.Equal(1, suite.orders)
+
+	// A WarnOnly policy lets the update through when the pre-check fails.
+	_, err = suite.client.VXCService.UpdateVXC(ctx, "vxc-2", &UpdateVXCRequest{RateLimit: PtrTo(100)})
+	suite.NoError(err)
+	suite.Equal(2, suite.updates)
+
+	suite.ErrorIs(WithCapacityPolicy(CapacityPolicy{})(suite.client), ErrCapacityPolicyInvalid)
+}
@@ -116,7 +116,16 @@ type Client struct {
 	// Optional extra HTTP headers to set on every request to the API.
 	headers map[string]string
 
+	// Optional oversubscription limit enforced by BuyVXC and UpdateVXC; see capacity.go.
+	capacityPolicy *CapacityPolicy
+
 	authMux sync.Mutex
+
+	// Token lifecycle; see token_manager.go.
+	tokenCache         TokenCache
+	tokenRefreshWindow time.Duration
+	loginFlight        *tokenFlight
+	rejectedToken      string
 }
 
 // accessTokenResponse is the response structure for the Login method containing the access token and expiration time.
@@ -188,6 +197,8 @@ func NewClient(httpClient *http.Client, base *url.URL) *Client {
 		BaseURL:    baseURL,
 		UserAgent:  userAgent,
 		Logger:     logger,
+
+		tokenRefreshWindow: defaultTokenRefreshWindow,
 	}
 
 	c.ProductService = NewProductService(c)
@@ -390,6 +401,9 @@ func (c *Client) NewRequest(ctx context.Context, method, urlStr string, body any
 			req.Header.Set("Authorization", "Bearer "+token)
 		}
 	} else {
+		if err := c.refreshStaleToken(ctx); err != nil {
+			return nil, err
+		}
 		c.authMux.Lock()
 		if c.accessToken != "" {
 			req.Header.Set("Authorization", "Bearer "+c.accessToken)
@@ -414,6 +428,16 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v any) (*http.Respon
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		if retry := c.unauthorizedRetry(ctx, req); retry != nil {
+			_ = resp.Body.Close()
+			req = retry
+			resp, err = DoRequestWithClient(ctx, c.HTTPClient, req)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 	if c.onRequestCompleted != nil {
 		c.onRequestCompleted(req, resp)
 	}
@@ -500,6 +524,9 @@ func (c *Client) SetTokenProvider(tp TokenProvider) {
 
 // Authorize performs an OAuth-style login using the client's AccessKey and SecretKey and updates the client's access token on a successful response.
 // If a TokenProvider is set, it will be used instead and this method returns immediately.
+// The current token is reused until it is within the refresh window of its expiry (see WithTokenRefreshWindow).
+// Concurrent calls share a single login, and a TokenCache set with WithTokenCache is consulted before logging in.
+// A caller whose context ends stops waiting without cancelling the shared login.
 func (c *Client) Authorize(ctx context.Context) (*AuthInfo, error) {
 	c.authMux.Lock()
 	provider := c.tokenProvider
@@ -514,9 +541,43 @@ func (c *Client) Authorize(ctx context.Context) (*AuthInfo, error) {
 		return &AuthInfo{AccessToken: token}, nil
 	}
 
+	c.authMux.Lock()
 	// Shortcut if we've already authenticated.
-	if time.Now().Before(c.tokenExpiry) {
-		return &AuthInfo{Expiration: c.tokenExpiry, AccessToken: c.accessToken}, nil
+	if c.tokenFreshLocked() {
+		info := &AuthInfo{Expiration: c.tokenExpiry, AccessToken: c.accessToken}
+		c.authMux.Unlock()
+		return info, nil
+	}
+	// Join a login already in progress rather than starting another.
+	if f := c.loginFlight; f != nil {
+		c.authMux.Unlock()
+		return f.wait(ctx)
+	}
+	f := &tokenFlight{done: make(chan struct{})}
+	c.loginFlight = f
+	c.authMux.Unlock()
+
+	// The login is shared, so it must not end with the context of whichever
+	// caller happened to start it.
+	loginCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loginTimeout)
+	go func() {
+		defer cancel()
+		f.info, f.err = c.login(loginCtx)
+
+		c.authMux.Lock()
+		c.loginFlight = nil
+		c.authMux.Unlock()
+		close(f.done)
+	}()
+	return f.wait(ctx)
+}
+
+// login obtains a new access token from the token cache or the token endpoint and stores it in the client.
+func (c *Client) login(ctx context.Context) (*AuthInfo, error) {
+	// The login request itself must not trigger a token refresh or an unauthorized retry.
+	ctx = context.WithValue(ctx, skipTokenRefresh, true)
+	if info := c.loadCachedToken(ctx); info != nil {
+		return info, nil
 	}
 
 	if c.AccessKey == "" {
@@ -588,15 +649,20 @@ func (c *Client) Authorize(ctx context.Context) (*AuthInfo, error) {
 		return nil, errors.New("authentication error: " + authResponse.Error)
 	}
 
+	info := &AuthInfo{
+		Expiration:  time.Now().Add(time.Duration(authResponse.ExpiresIn) * time.Second),
+		AccessToken: authResponse.AccessToken,
+	}
 	c.authMux.Lock()
 	// Store the access token and expiration in the client
-	c.tokenExpiry = time.Now().Add(time.Duration(authResponse.ExpiresIn) * time.Second)
-	c.accessToken = authResponse.AccessToken
+	c.tokenExpiry = info.Expiration
+	c.accessToken = info.AccessToken
 	c.authMux.Unlock()
 
 	c.Logger.DebugContext(ctx, "successful login")
+	c.storeCachedToken(ctx, info)
 
-	return &AuthInfo{Expiration: c.tokenExpiry, AccessToken: c.accessToken}, nil
+	return info, nil
 }
 
 // DoRequest submits an HTTP request.
@@ -0,0 +1,360 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrClientPoolKeyEmpty is returned when a pool account is added without a key.
+	ErrClientPoolKeyEmpty = errors.New("client pool account key must not be empty")
+	// ErrClientPoolDuplicate is returned when a key or account reference is already in the pool.
+	ErrClientPoolDuplicate = errors.New("client pool already has an account with that key")
+	// ErrClientPoolAccountNotFound is returned when no pool account matches a key or account reference.
+	ErrClientPoolAccountNotFound = errors.New("client pool account not found")
+	// ErrClientPoolRateLimit is returned when a pool rate limit is not positive.
+	ErrClientPoolRateLimit = errors.New("client pool rate limit and burst must be positive")
+)
+
+// PoolAccount identifies one account in a ClientPool. Key is usually the
+// company UID; AccountRef, when set, is accepted as an alias for lookups.
+type PoolAccount struct {
+	Key         string `json:"key"`
+	AccountRef  string `json:"accountRef,omitempty"`
+	AccountName string `json:"accountName,omitempty"`
+}
+
+// poolEntry is a pool account and the client bound to its credentials.
+type poolEntry struct {
+	account *PoolAccount
+	client  *Client
+}
+
+// ClientPool holds one Client per account, for Megaport partners operating
+// across the companies they manage. Every client in the pool has its own
+// credentials or TokenProvider but shares a single HTTP client, so requests
+// from all accounts reuse one transport and count against one rate limit.
+type ClientPool struct {
+	httpClient  *http.Client
+	clientOpts  []ClientOpt
+	concurrency int
+	rateLimit   float64
+	rateBurst   int
+
+	mu      sync.RWMutex
+	entries map[string]*poolEntry
+	refs    map[string]string
+}
+
+// ClientPoolOpt are options for NewClientPool.
+type ClientPoolOpt func(*ClientPool) error
+
+// NewClientPool returns an empty ClientPool. Add accounts with Add or
+// SyncManagedAccounts.
+func NewClientPool(opts ...ClientPoolOpt) (*ClientPool, error) {
+	p := &ClientPool{
+		httpClient: &http.Client{Transport: http.DefaultTransport},
+		entries:    make(map[string]*poolEntry),
+		refs:       make(map[string]string),
+	}
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+	if p.rateLimit > 0 {
+		p.httpClient.Transport = &rateLimitedTransport{
+			base:     p.httpClient.Transport,
+			interval: time.Duration(float64(time.Second) / p.rateLimit),
+			burst:    float64(p.rateBurst),
+			tokens:   float64(p.rateBurst),
+			last:     time.Now(),
+		}
+	}
+	return p, nil
+}
+
+// WithPoolHTTPClient sets the HTTP client shared by every client in the pool.
+// The pool uses a copy, so a rate limit does not affect other users of
+// httpClient.
+func WithPoolHTTPClient(httpClient *http.Client) ClientPoolOpt {
+	return func(p *ClientPool) error {
+		if httpClient == nil {
+			return nil
+		}
+		c := *httpClient
+		if c.Transport == nil {
+			c.Transport = http.DefaultTransport
+		}
+		p.httpClient = &c
+		return nil
+	}
+}
+
+// WithPoolRateLimit limits the requests sent by all clients in the pool
+// combined to requestsPerSecond, allowing bursts of up to burst requests.
+func WithPoolRateLimit(requestsPerSecond float64, burst int) ClientPoolOpt {
+	return func(p *ClientPool) error {
+		if requestsPerSecond <= 0 || burst < 1 {
+			return ErrClientPoolRateLimit
+		}
+		p.rateLimit, p.rateBurst = requestsPerSecond, burst
+		return nil
+	}
+}
+
+// WithPoolClientOptions sets options applied to every client in the pool
+// before its own options, for example WithEnvironment or WithLogHandler.
+func WithPoolClientOptions(opts ...ClientOpt) ClientPoolOpt {
+	return func(p *ClientPool) error {
+		p.clientOpts = append(p.clientOpts, opts...)
+		return nil
+	}
+}
+
+// WithPoolConcurrency sets how many accounts FanOut calls at once. The
+// default is 4.
+func WithPoolConcurrency(n int) ClientPoolOpt {
+	return func(p *ClientPool) error {
+		p.concurrency = n
+		return nil
+	}
+}
+
+// Add creates a client for account with the pool's shared options followed
+// by opts, which normally supply WithCredentials or WithTokenProvider.
+func (p *ClientPool) Add(account *PoolAccount, opts ...ClientOpt) (*Client, error) {
+	if account == nil || strings.TrimSpace(account.Key) == "" {
+		return nil, ErrClientPoolKeyEmpty
+	}
+	c, err := New(p.httpClient, append(append([]ClientOpt{}, p.clientOpts...), opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("account %s: %w", account.Key, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.lookupLocked(account.Key); ok {
+		return nil, fmt.Errorf("%w: %s", ErrClientPoolDuplicate, account.Key)
+	}
+	if account.AccountRef != "" {
+		if _, ok := p.lookupLocked(account.AccountRef); ok {
+			return nil, fmt.Errorf("%w: %s", ErrClientPoolDuplicate, account.AccountRef)
+		}
+		p.refs[account.AccountRef] = account.Key
+	}
+	acct := *account
+	p.entries[acct.Key] = &poolEntry{account: &acct, client: c}
+	return c, nil
+}
+
+// Remove drops the account with the given key or account reference. It
+// reports whether an account was removed.
+func (p *ClientPool) Remove(keyOrRef string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.lookupLocked(keyOrRef)
+	if !ok {
+		return false
+	}
+	delete(p.entries, e.account.Key)
+	if e.account.AccountRef != "" {
+		delete(p.refs, e.account.AccountRef)
+	}
+	return true
+}
+
+// Client returns the client for the account with the given key or account
+// reference.
+func (p *ClientPool) Client(keyOrRef string) (*Client, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	e, ok := p.lookupLocked(keyOrRef)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrClientPoolAccountNotFound, keyOrRef)
+	}
+	return e.client, nil
+}
+
+// Accounts returns the accounts in the pool ordered by key.
+func (p *ClientPool) Accounts() []*PoolAccount {
+	entries := p.snapshot()
+	accounts := make([]*PoolAccount, len(entries))
+	for i, e := range entries {
+		acct := *e.account
+		accounts[i] = &acct
+	}
+	return accounts
+}
+
+func (p *ClientPool) lookupLocked(keyOrRef string) (*poolEntry, bool) {
+	if e, ok := p.entries[keyOrRef]; ok {
+		return e, true
+	}
+	if key, ok := p.refs[keyOrRef]; ok {
+		return p.entries[key], true
+	}
+	return nil, false
+}
+
+func (p *ClientPool) snapshot() []*poolEntry {
+	p.mu.RLock()
+	entries := make([]*poolEntry, 0, len(p.entries))
+	for _, e := range p.entries {
+		entries = append(entries, e)
+	}
+	p.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].account.Key < entries[j].account.Key })
+	return entries
+}
+
+// SyncManagedAccounts lists the companies managed by partner and adds an
+// account for each one not already in the pool, keyed by company UID with
+// the account reference as an alias. configure returns the client options,
+// typically credentials, for a managed company; companies for which it
+// returns no options are skipped. Failures for individual companies are
+// joined into the returned error and do not stop the others being added.
+func (p *ClientPool) SyncManagedAccounts(ctx context.Context, partner *Client, configure func(*ManagedAccount) ([]ClientOpt, error)) ([]*PoolAccount, error) {
+	managed, err := partner.ManagedAccountService.ListManagedAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing managed accounts: %w", err)
+	}
+	var added []*PoolAccount
+	var errs []error
+	for _, m := range managed {
+		if m == nil || m.CompanyUID == "" {
+			continue
+		}
+		if _, err := p.Client(m.CompanyUID); err == nil {
+			continue
+		}
+		opts, err := configure(m)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("account %s: %w", m.CompanyUID, err))
+			continue
+		}
+		if len(opts) == 0 {
+			continue
+		}
+		acct := &PoolAccount{Key: m.CompanyUID, AccountRef: m.AccountRef, AccountName: m.AccountName}
+		if _, err := p.Add(acct, opts...); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		added = append(added, acct)
+	}
+	return added, errors.Join(errs...)
+}
+
+// PoolResult is the outcome of a FanOut call for one account.
+type PoolResult[T any] struct {
+	Account *PoolAccount
+	Value   T
+	Err     error
+}
+
+// FanOut calls fn once for every account in the pool, with a bounded number
+// of accounts in flight, and returns one result per account ordered by key.
+// Clients that log in with credentials are authorized first. The returned
+// error joins the per-account errors, each prefixed with the account key;
+// results for the accounts that succeeded are complete either way.
+func FanOut[T any](ctx context.Context, p *ClientPool, fn func(ctx context.Context, account *PoolAccount, c *Client) (T, error)) ([]*PoolResult[T], error) {
+	entries := p.snapshot()
+	results := make([]*PoolResult[T], len(entries))
+	forEachConcurrently(len(entries), p.concurrency, func(i int) {
+		e := entries[i]
+		acct := *e.account
+		r := &PoolResult[T]{Account: &acct}
+		results[i] = r
+		if e.client.managesTokens() {
+			if _, err := e.client.Authorize(ctx); err != nil {
+				r.Err = fmt.Errorf("authorizing: %w", err)
+				return
+			}
+		}
+		r.Value, r.Err = fn(ctx, r.Account, e.client)
+	})
+
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("account %s: %w", r.Account.Key, r.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// PoolProduct is a product listed by ClientPool.ListProducts and the account
+// that owns it.
+type PoolProduct struct {
+	Account *PoolAccount
+	Product Product
+}
+
+// ListProducts lists the products of every account in the pool and merges
+// them, ordered by account key and then in API order. Accounts that fail are
+// left out and reported in the returned error.
+func (p *ClientPool) ListProducts(ctx context.Context) ([]*PoolProduct, error) {
+	results, err := FanOut(ctx, p, func(ctx context.Context, _ *PoolAccount, c *Client) ([]Product, error) {
+		return c.ProductService.ListProducts(ctx)
+	})
+	var merged []*PoolProduct
+	for _, r := range results {
+		for _, prod := range r.Value {
+			merged = append(merged, &PoolProduct{Account: r.Account, Product: prod})
+		}
+	}
+	return merged, err
+}
+
+// rateLimitedTransport is a token bucket shared by every request sent
+// through it.
+type rateLimitedTransport struct {
+	base     http.RoundTripper
+	interval time.Duration
+	burst    float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// wait takes a token, sleeping until one is available or ctx is done.
+func (t *rateLimitedTransport) wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := time.Now()
+	t.tokens = min(t.burst, t.tokens+float64(now.Sub(t.last))/float64(t.interval))
+	t.last = now
+	t.tokens--
+	delay := time.Duration(-t.tokens * float64(t.interval))
+	t.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give back the reserved token.
+		t.mu.Lock()
+		t.tokens++
+		t.mu.Unlock()
+		return ctx.Err()
+	}
+}
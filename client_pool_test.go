@@ -0,0 +1,199 @@
+package megaport
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// ClientPoolTestSuite tests ClientPool against an API that issues one token
+// per access key and lists products per token.
+type ClientPoolTestSuite struct {
+	ClientTestSuite
+
+	mu     sync.Mutex
+	logins map[string]int
+	pool   *ClientPool
+}
+
+func TestClientPoolTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(ClientPoolTestSuite))
+}
+
+func (suite *ClientPoolTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+	suite.logins = make(map[string]int)
+
+	suite.mux.HandleFunc("/test/token", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(r.Header.Get("Authorization"), "Basic "))
+		key, _, _ := strings.Cut(string(b), ":")
+		suite.mu.Lock()
+		suite.logins[key]++
+		suite.mu.Unlock()
+		fmt.Fprintf(w, `{"access_token": "token-%s", "token_type": "Bearer", "expires_in": 3600}`, key)
+	})
+	suite.mux.HandleFunc("/v2/products", func(w http.ResponseWriter, r *http.Request) {
+		company := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer token-")
+		if company == "broken" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "Forbidden"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"message": "Products", "data": [
+            {"productUid": "%[1]s-port", "productName": "Port", "productType": "MEGAPORT", "provisioningStatus": "LIVE"},
+            {"productUid": "%[1]s-mcr", "productName": "MCR", "productType": "MCR2", "provisioningStatus": "LIVE"}
+        ]}`, company)
+	})
+	suite.mux.HandleFunc("/v2/managedCompanies", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "Managed", "data": [
+            {"accountRef": "REF-A", "accountName": "Company A", "companyUid": "company-a"},
+            {"accountRef": "REF-B", "accountName": "Company B", "companyUid": "company-b"},
+            {"accountRef": "REF-C", "accountName": "Company C", "companyUid": "company-c"}
+        ]}`)
+	})
+
+	pool, err := NewClientPool(
+		WithPoolClientOptions(WithBaseURL(suite.server.URL), WithTokenURL(suite.server.URL+"/test/token")),
+		WithPoolConcurrency(2),
+	)
+	suite.Require().NoError(err)
+	suite.pool = pool
+}
+
+func (suite *ClientPoolTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *ClientPoolTestSuite) TestSyncAndListProducts() {
+	ctx := context.Background()
+	_, err := suite.pool.Add(&PoolAccount{Key: "company-a"}, WithCredentials("company-a", "secret"))
+	suite.Require().NoError(err)
+
+	added, err := suite.pool.SyncManagedAccounts(ctx, suite.client, func(m *ManagedAccount) ([]ClientOpt, error) {
+		switch m.CompanyUID {
+		case "company-b":
+			return []ClientOpt{WithCredentials("company-b", "secret")}, nil
+		case "company-c":
+			return nil, nil
+		}
+		return nil, fmt.Errorf("configure must not be called for %s", m.CompanyUID)
+	})
+	suite.Require().NoError(err)
+	suite.Equal([]*PoolAccount{{Key: "company-b", AccountRef: "REF-B", AccountName: "Company B"}}, added)
+
+	byRef, err := suite.pool.Client("REF-B")
+	suite.Require().NoError(err)
+	byKey, _ := suite.pool.Client("company-b")
+	suite.Same(byKey, byRef)
+	suite.Same(byKey.HTTPClient, byRef.HTTPClient)
+
+	_, err = suite.pool.Add(&PoolAccount{Key: "broken"}, WithCredentials("broken", "secret"))
+	suite.Require().NoError(err)
+
+	products, err := suite.pool.ListProducts(ctx)
+	suite.Require().Error(err)
+	suite.Contains(err.Error(), "account broken:")
+	suite.NotContains(err.Error(), "company-a")
+	var uids []string
+	for _, p := range products {
+		uids = append(uids, p.Account.Key+"/"+p.Product.GetUID())
+	}
+	suite.Equal([]string{
+		"company-a/company-a-port", "company-a/company-a-mcr",
+		"company-b/company-b-port", "company-b/company-b-mcr",
+	}, uids)
+	suite.Equal(map[string]int{"company-a": 1, "company-b": 1, "broken": 1}, suite.logins)
+
+	// A second fan-out reuses the tokens.
+	results, err := FanOut(ctx, suite.pool, func(ctx context.Context, acct *PoolAccount, c *Client) (int, error) {
+		products, err := c.ProductService.ListProducts(ctx)
+		return len(products), err
+	})
+	suite.Error(err)
+	suite.Require().Len(results, 3)
+	suite.Equal("broken", results[0].Account.Key)
+	suite.Error(results[0].Err)
+	suite.Equal(2, results[1].Value)
+	suite.Equal(map[string]int{"company-a": 1, "company-b": 1, "broken": 1}, suite.logins)
+}
+
+func (suite *ClientPoolTestSuite) TestAccounts() {
+	_, err := suite.pool.Add(&PoolAccount{Key: "b", AccountRef: "REF"})
+	suite.Require().NoError(err)
+	_, err = suite.pool.Add(&PoolAccount{Key: "a"})
+	suite.Require().NoError(err)
+
+	_, err = suite.pool.Add(&PoolAccount{Key: " "})
+	suite.ErrorIs(err, ErrClientPoolKeyEmpty)
+	_, err = suite.pool.Add(&PoolAccount{Key: "REF"})
+	suite.ErrorIs(err, ErrClientPoolDuplicate)
+	_, err = suite.pool.Add(&PoolAccount{Key: "c", AccountRef: "a"})
+	suite.ErrorIs(err, ErrClientPoolDuplicate)
+
+	suite.Equal([]*PoolAccount{{Key: "a"}, {Key: "b", AccountRef: "REF"}}, suite.pool.Accounts())
+	suite.True(suite.pool.Remove("REF"))
+	suite.False(suite.pool.Remove("b"))
+	_, err = suite.pool.Client("REF")
+	suite.ErrorIs(err, ErrClientPoolAccountNotFound)
+}
+
+func TestRateLimitedTransport(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	var sent []time.Time
+	base := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		sent = append(sent, time.Now())
+		mu.Unlock()
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: r}, nil
+	})
+	pool, err := NewClientPool(WithPoolHTTPClient(&http.Client{Transport: base}), WithPoolRateLimit(50, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+		if _, err := pool.httpClient.Do(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Two requests fit in the burst; the other three wait 20ms each.
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Fatalf("5 requests took %v, want at least 60ms of waiting", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.invalid", nil)
+	if _, err := pool.httpClient.Do(req); err == nil {
+		t.Fatal("expected cancelled request to fail")
+	}
+	if len(sent) != 5 {
+		t.Fatalf("sent %d requests, want 5", len(sent))
+	}
+
+	if _, err := NewClientPool(WithPoolRateLimit(0, 1)); err != ErrClientPoolRateLimit {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
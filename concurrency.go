@@ -0,0 +1,28 @@
+package megaport
+
+import "sync"
+
+// defaultConcurrency bounds the concurrent API calls of bulk operations,
+// such as pricing the items of a cost estimate, when the caller does not
+// set a limit.
+const defaultConcurrency = 4
+
+// forEachConcurrently calls fn for 0..n-1 with at most concurrency calls in
+// flight. Zero concurrency uses defaultConcurrency.
+func forEachConcurrently(n, concurrency int, fn func(i int)) {
+	if concurrency < 1 {
+		concurrency = defaultConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
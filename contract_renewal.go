@@ -0,0 +1,328 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Contract renewal report errors.
+var (
+	ErrContractRenewalRequestNil = errors.New("contract renewal request is required")
+	ErrContractRenewalWindow     = errors.New("contract renewal window must be at least one day")
+	ErrContractRenewalTermBad    = errors.New("contract renewal terms must be valid contract terms")
+)
+
+// ContractRenewalRequest selects the contracts for ContractRenewalReport.
+type ContractRenewalRequest struct {
+	// WithinDays is the look-ahead window; contracts ending within this many
+	// days of Now are reported.
+	WithinDays int
+	// Now is the reference time. Zero uses time.Now().
+	Now time.Time
+	// IncludeExpired also reports contracts whose end date has passed.
+	IncludeExpired bool
+	// IncludeNATGateways adds NAT Gateways, which ListProducts does not
+	// return, to the report.
+	IncludeNATGateways bool
+	// Terms are the contract terms to price. Nil uses VALID_CONTRACT_TERMS.
+	Terms []int
+	// Currency optionally overrides the pricing currency.
+	Currency string
+	// CompanyUID optionally prices on behalf of another company, as
+	// GetProductPricingForCompany.
+	CompanyUID string
+	// ApprovalRequired marks the account as one whose orders are approved
+	// by its managing partner. Term changes are then flagged as TERM_CHANGE
+	// order approvals and pending approvals are looked up.
+	ApprovalRequired bool
+	// Concurrency bounds parallel pricing calls. Zero uses 4.
+	Concurrency int
+}
+
+// ContractTermOption is the price of a product on one contract term.
+type ContractTermOption struct {
+	Term            int
+	Currency        string
+	MonthlyRate     float64
+	MonthlyRackRate float64
+	// MonthlySavings is the current term's monthly rate less this one;
+	// negative when this term costs more.
+	MonthlySavings float64
+	// TermSavings is MonthlySavings over max(Term, 1) months.
+	TermSavings float64
+	Pricing     *PriceBookDTO
+}
+
+// ContractTermChange is a term change for one product, ready to submit.
+// Exactly one of ModifyProduct, UpdateVXC and UpdateNATGateway is set.
+type ContractTermChange struct {
+	ProductUID  string
+	ProductType string
+	FromTerm    int
+	ToTerm      int
+
+	ModifyProduct    *ModifyProductRequest
+	UpdateVXC        *UpdateVXCRequest
+	UpdateNATGateway *UpdateNATGatewayRequest
+
+	// RequiresApproval is set when the change will be queued with the
+	// OrderApprovalService as ApprovalType rather than applied directly.
+	RequiresApproval bool
+	ApprovalType     OrderApprovalType
+	// PendingApproval is an existing pending TERM_CHANGE approval for the
+	// product, if any.
+	PendingApproval *OrderApproval
+}
+
+// ContractRenewal is a product coming off contract.
+type ContractRenewal struct {
+	ProductUID         string
+	ProductID          int
+	ProductName        string
+	ProductType        string
+	ProvisioningStatus string
+	ContractEndDate    time.Time
+	// DaysRemaining is negative for contracts that have already ended.
+	DaysRemaining int
+	CurrentTerm   int
+	// AutoRenewTerm is only reported by NAT Gateways.
+	AutoRenewTerm bool
+
+	// Current is the price on CurrentTerm and Options the price on each
+	// other term, by ascending term. Current is nil when CurrentTerm is not
+	// one of the priced terms.
+	Current *ContractTermOption
+	Options []*ContractTermOption
+	// Recommended is the cheapest option per month, preferring the shorter
+	// term on a tie, or nil when no option is cheaper than Current.
+	Recommended *ContractTermOption
+	// Change moves the product to the Recommended term.
+	Change *ContractTermChange
+
+	service         *billableService
+	approvalFlagged bool
+	pending         *OrderApproval
+}
+
+// ContractRenewalReport lists contracts ending within a window.
+type ContractRenewalReport struct {
+	GeneratedAt time.Time
+	WithinDays  int
+	// Renewals are ordered by contract end date, then product UID.
+	Renewals []*ContractRenewal
+}
+
+// ContractRenewalReport lists the products, and the VXCs attached to them,
+// whose contracts end within the requested window. Each is priced on every
+// requested term so the savings of renewing on a different term are shown,
+// and the term change for the cheapest term is prepared. All pricing errors
+// are returned joined, each prefixed with the product UID.
+func (svc *ProductServiceOp) ContractRenewalReport(ctx context.Context, req *ContractRenewalRequest) (*ContractRenewalReport, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	now := req.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	terms := req.Terms
+	if terms == nil {
+		terms = VALID_CONTRACT_TERMS
+	}
+	report := &ContractRenewalReport{GeneratedAt: now, WithinDays: req.WithinDays}
+
+	services, err := svc.listBillableServices(ctx, req.IncludeNATGateways, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	window := now.Add(time.Duration(req.WithinDays) * 24 * time.Hour)
+	for _, s := range services {
+		if s.contractEnd.IsZero() || s.contractEnd.After(window) {
+			continue
+		}
+		if s.contractEnd.Before(now) && !req.IncludeExpired {
+			continue
+		}
+		report.Renewals = append(report.Renewals, &ContractRenewal{
+			ProductUID:         s.uid,
+			ProductID:          s.id,
+			ProductName:        s.name,
+			ProductType:        s.productType,
+			ProvisioningStatus: s.status,
+			ContractEndDate:    s.contractEnd,
+			DaysRemaining:      int(s.contractEnd.Sub(now).Hours() / 24),
+			CurrentTerm:        s.term,
+			AutoRenewTerm:      s.autoRenewTerm,
+			service:            s,
+			approvalFlagged:    req.ApprovalRequired,
+		})
+	}
+	sort.SliceStable(report.Renewals, func(i, j int) bool {
+		a, b := report.Renewals[i], report.Renewals[j]
+		if !a.ContractEndDate.Equal(b.ContractEndDate) {
+			return a.ContractEndDate.Before(b.ContractEndDate)
+		}
+		return a.ProductUID < b.ProductUID
+	})
+	if len(report.Renewals) == 0 {
+		return report, nil
+	}
+
+	if req.ApprovalRequired {
+		if err := svc.attachPendingTermChanges(ctx, report.Renewals); err != nil {
+			return nil, err
+		}
+	}
+	if err := svc.priceContractRenewals(ctx, req, terms, report.Renewals); err != nil {
+		return nil, err
+	}
+	for _, r := range report.Renewals {
+		r.recommend()
+	}
+	return report, nil
+}
+
+// TermChange builds the request that moves the product to term.
+func (r *ContractRenewal) TermChange(term int) *ContractTermChange {
+	c := &ContractTermChange{
+		ProductUID:       r.ProductUID,
+		ProductType:      r.ProductType,
+		FromTerm:         r.CurrentTerm,
+		ToTerm:           term,
+		RequiresApproval: r.approvalFlagged || r.pending != nil,
+		PendingApproval:  r.pending,
+	}
+	if c.RequiresApproval {
+		c.ApprovalType = OrderApprovalTypeTermChange
+	}
+	switch {
+	case r.service.vxc != nil:
+		c.UpdateVXC = &UpdateVXCRequest{Term: PtrTo(term)}
+	case r.service.natGateway != nil:
+		c.UpdateNATGateway = natGatewayUpdateFromGateway(r.service.natGateway)
+		c.UpdateNATGateway.Term = term
+	default:
+		// CostCentre is always sent, so carry the current one over rather
+		// than clearing it.
+		c.ModifyProduct = &ModifyProductRequest{
+			ProductID:          r.ProductUID,
+			ProductType:        strings.ToLower(r.ProductType),
+			Name:               r.service.name,
+			CostCentre:         r.service.costCentre,
+			ContractTermMonths: term,
+		}
+	}
+	return c
+}
+
+// recommend picks the cheapest option and prepares its term change.
+func (r *ContractRenewal) recommend() {
+	sort.Slice(r.Options, func(i, j int) bool { return r.Options[i].Term < r.Options[j].Term })
+	if r.Current == nil {
+		return
+	}
+	for _, o := range r.Options {
+		o.MonthlySavings = r.Current.MonthlyRate - o.MonthlyRate
+		o.TermSavings = o.MonthlySavings * float64(max(o.Term, 1))
+		if o.MonthlySavings > 0 && (r.Recommended == nil || o.MonthlyRate < r.Recommended.MonthlyRate) {
+			r.Recommended = o
+		}
+	}
+	if r.Recommended != nil {
+		r.Change = r.TermChange(r.Recommended.Term)
+	}
+}
+
+// attachPendingTermChanges records pending TERM_CHANGE approvals against
+// the renewals they belong to.
+func (svc *ProductServiceOp) attachPendingTermChanges(ctx context.Context, renewals []*ContractRenewal) error {
+	byID := make(map[int]*ContractRenewal)
+	var ids []int
+	for _, r := range renewals {
+		if r.ProductID > 0 {
+			byID[r.ProductID] = r
+			ids = append(ids, r.ProductID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	status := OrderApprovalStatusPending
+	resp, err := svc.Client.OrderApprovalService.ListOrderApprovals(ctx, &ListOrderApprovalsRequest{Status: &status, ServiceIDs: ids})
+	if err != nil {
+		return err
+	}
+	for _, a := range resp.OrderApprovals {
+		if a == nil || a.Type != OrderApprovalTypeTermChange {
+			continue
+		}
+		if r, ok := byID[a.ServiceID]; ok {
+			r.pending = a
+		}
+	}
+	return nil
+}
+
+// priceContractRenewals prices every renewal on every term concurrently.
+func (svc *ProductServiceOp) priceContractRenewals(ctx context.Context, req *ContractRenewalRequest, terms []int, renewals []*ContractRenewal) error {
+	type job struct {
+		renewal *ContractRenewal
+		term    int
+	}
+	var jobs []job
+	for _, r := range renewals {
+		for _, term := range terms {
+			jobs = append(jobs, job{r, term})
+		}
+	}
+
+	options := make([]*ContractTermOption, len(jobs))
+	errs := make([]error, len(jobs))
+	forEachConcurrently(len(jobs), req.Concurrency, func(i int) {
+		j := jobs[i]
+		pricing, err := svc.priceCostEstimateItem(ctx, req.CompanyUID, withPricingTerm(j.renewal.service.pricing, j.term))
+		if err != nil {
+			errs[i] = fmt.Errorf("pricing %s on a %d month term: %w", j.renewal.ProductUID, j.term, err)
+			return
+		}
+		options[i] = &ContractTermOption{
+			Term:            j.term,
+			Currency:        pricing.Currency,
+			MonthlyRate:     pricing.MonthlyRate,
+			MonthlyRackRate: pricing.MonthlyRackRate,
+			Pricing:         pricing,
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	for i, j := range jobs {
+		if j.term == j.renewal.CurrentTerm {
+			j.renewal.Current = options[i]
+		} else {
+			j.renewal.Options = append(j.renewal.Options, options[i])
+		}
+	}
+	return nil
+}
+
+func (req *ContractRenewalRequest) validate() error {
+	if req == nil {
+		return ErrContractRenewalRequestNil
+	}
+	if req.WithinDays < 1 {
+		return ErrContractRenewalWindow
+	}
+	for _, term := range req.Terms {
+		if !slices.Contains(VALID_CONTRACT_TERMS, term) {
+			return fmt.Errorf("%w: %d", ErrContractRenewalTermBad, term)
+		}
+	}
+	return nil
+}
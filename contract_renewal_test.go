@@ -0,0 +1,216 @@
+package megaport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// contractRenewalNow is the reference time for the contract renewal
+// fixtures: 2025-01-01T00:00:00Z.
+var contractRenewalNow = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// contractRenewalProductsJSON holds port-1 ending in 10 days with vxc-1
+// ending in 20, mcr-1 ending in 100, mve-1 ended 5 days ago and a
+// decommissioned port ending in 5.
+const contractRenewalProductsJSON = `{"message": "Products", "data": [
+    {"productId": 101, "productUid": "port-1", "productName": "Port 1", "productType": "MEGAPORT", "provisioningStatus": "LIVE",
+        "costCentre": "CC-NET", "locationId": 1, "portSpeed": 10000, "contractTermMonths": 12, "contractEndDate": 1736553600000,
+        "associatedVxcs": [
+            {"productId": 201, "productUid": "vxc-1", "productName": "VXC 1", "productType": "VXC", "provisioningStatus": "LIVE",
+                "rateLimit": 100, "contractTermMonths": 1, "contractEndDate": 1737417600000,
+                "aEnd": {"productUid": "port-1", "locationId": 1}, "bEnd": {"productUid": "mcr-1", "locationId": 2}}
+        ]},
+    {"productId": 102, "productUid": "mcr-1", "productName": "MCR 1", "productType": "MCR2", "provisioningStatus": "LIVE",
+        "locationId": 2, "portSpeed": 1000, "contractTermMonths": 12, "contractEndDate": 1744329600000},
+    {"productId": 103, "productUid": "mve-1", "productName": "MVE 1", "productType": "MVE", "provisioningStatus": "LIVE",
+        "costCentre": "CC-SEC", "locationId": 1, "mveSize": "SMALL", "contractTermMonths": 12, "contractEndDate": 1735257600000},
+    {"productId": 104, "productUid": "port-dead", "productName": "Old", "productType": "MEGAPORT", "provisioningStatus": "DECOMMISSIONED",
+        "locationId": 1, "portSpeed": 1000, "contractTermMonths": 12, "contractEndDate": 1736121600000}
+]}`
+
+const contractRenewalNATGatewaysJSON = `{"message": "NAT Gateways", "data": [
+    {"productUid": "nat-1", "productName": "NAT 1", "provisioningStatus": "LIVE", "locationId": 1, "speed": 1000,
+        "term": 12, "autoRenewTerm": true, "contractEndDate": "2025-01-31T00:00:00Z", "config": {"sessionCount": 1000}},
+    {"productUid": "nat-design", "productName": "Draft", "provisioningStatus": "DESIGN", "contractEndDate": ""}
+]}`
+
+// contractRenewalBaseRates and contractRenewalTermFactors give the monthly
+// rate served for a product type and term.
+var (
+	contractRenewalBaseRates   = map[string]float64{"MEGAPORT": 1000, "MCR2": 500, "MVE": 700, "VXC": 100, "NAT_GATEWAY": 300}
+	contractRenewalTermFactors = map[float64]float64{1: 1, 12: 0.9, 24: 0.8}
+)
+
+// ContractRenewalTestSuite tests ContractRenewalReport.
+type ContractRenewalTestSuite struct {
+	ClientTestSuite
+
+	approvalQuery url.Values
+}
+
+func TestContractRenewalTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(ContractRenewalTestSuite))
+}
+
+func (suite *ContractRenewalTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+	suite.approvalQuery = nil
+
+	suite.mux.HandleFunc("/v2/products", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprint(w, contractRenewalProductsJSON)
+	})
+	suite.mux.HandleFunc("/v3/products/nat_gateways", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, contractRenewalNATGatewaysJSON)
+	})
+	suite.mux.HandleFunc("/v3/order_approvals", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		suite.approvalQuery = r.URL.Query()
+		fmt.Fprint(w, `{"message": "Success", "data": [
+            {"uid": "approval-new", "status": "PENDING", "type": "NEW_ORDER", "serviceId": 101},
+            {"uid": "approval-term", "status": "PENDING", "type": "TERM_CHANGE", "serviceId": 201}
+        ]}`)
+	})
+	suite.mux.HandleFunc("/v4/pricebook/product", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodPost)
+		var body map[string]interface{}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		productType, _ := body["productType"].(string)
+		term, _ := body["term"].(float64)
+		base, ok := contractRenewalBaseRates[productType]
+		factor, termOK := contractRenewalTermFactors[term]
+		if !ok || !termOK {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message": "bad pricing request"}`)
+			return
+		}
+		rate := base * factor
+		fmt.Fprintf(w, `{"message": "OK", "data": {"productType": %q, "currency": "AUD", "monthlyRate": %v, "monthlyRackRate": %v, "prices": [], "discounts": []}}`,
+			productType, rate, base)
+	})
+}
+
+func (suite *ContractRenewalTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *ContractRenewalTestSuite) TestContractRenewalReport() {
+	ctx := context.Background()
+	report, err := suite.client.ProductService.ContractRenewalReport(ctx, &ContractRenewalRequest{
+		WithinDays:         30,
+		Now:                contractRenewalNow,
+		Terms:              []int{1, 12, 24},
+		IncludeNATGateways: true,
+		Concurrency:        2,
+	})
+	suite.Require().NoError(err)
+	suite.Nil(suite.approvalQuery)
+	suite.Require().Len(report.Renewals, 3)
+
+	port := report.Renewals[0]
+	suite.Equal("port-1", port.ProductUID)
+	suite.Equal(10, port.DaysRemaining)
+	suite.Equal(900.0, port.Current.MonthlyRate)
+	suite.Require().Len(port.Options, 2)
+	suite.Equal(1, port.Options[0].Term)
+	suite.Equal(-100.0, port.Options[0].MonthlySavings)
+	suite.Equal(24, port.Recommended.Term)
+	suite.Equal(100.0, port.Recommended.MonthlySavings)
+	suite.Equal(2400.0, port.Recommended.TermSavings)
+	suite.Equal(&ModifyProductRequest{ProductID: "port-1", ProductType: PRODUCT_MEGAPORT, Name: "Port 1", CostCentre: "CC-NET", ContractTermMonths: 24}, port.Change.ModifyProduct)
+	suite.False(port.Change.RequiresApproval)
+	suite.Empty(port.Change.ApprovalType)
+
+	vxc := report.Renewals[1]
+	suite.Equal("vxc-1", vxc.ProductUID)
+	suite.Equal(24, vxc.Change.ToTerm)
+	suite.Equal(1, vxc.Change.FromTerm)
+	suite.Equal(&UpdateVXCRequest{Term: PtrTo(24)}, vxc.Change.UpdateVXC)
+	suite.Nil(vxc.Change.ModifyProduct)
+
+	nat := report.Renewals[2]
+	suite.Equal("nat-1", nat.ProductUID)
+	suite.True(nat.AutoRenewTerm)
+	suite.Equal(270.0, nat.Current.MonthlyRate)
+	suite.Require().NotNil(nat.Change.UpdateNATGateway)
+	suite.Equal(24, nat.Change.UpdateNATGateway.Term)
+	suite.Equal(1000, nat.Change.UpdateNATGateway.Config.SessionCount)
+	suite.True(nat.Change.UpdateNATGateway.AutoRenewTerm)
+
+	// An alternate term can be requested explicitly.
+	suite.Equal(&ModifyProductRequest{ProductID: "port-1", ProductType: PRODUCT_MEGAPORT, Name: "Port 1", CostCentre: "CC-NET", ContractTermMonths: 1}, port.TermChange(1).ModifyProduct)
+}
+
+func (suite *ContractRenewalTestSuite) TestContractRenewalReportApprovals() {
+	ctx := context.Background()
+	report, err := suite.client.ProductService.ContractRenewalReport(ctx, &ContractRenewalRequest{
+		WithinDays:       30,
+		Now:              contractRenewalNow,
+		Terms:            []int{1, 12, 24},
+		IncludeExpired:   true,
+		ApprovalRequired: true,
+	})
+	suite.Require().NoError(err)
+	suite.Equal("PENDING", suite.approvalQuery.Get("status"))
+	suite.Equal("103,101,201", suite.approvalQuery.Get("serviceIds"))
+	suite.Require().Len(report.Renewals, 3)
+
+	mve := report.Renewals[0]
+	suite.Equal("mve-1", mve.ProductUID)
+	suite.Equal(-5, mve.DaysRemaining)
+	suite.Equal(&ModifyProductRequest{ProductID: "mve-1", ProductType: PRODUCT_MVE, Name: "MVE 1", CostCentre: "CC-SEC", ContractTermMonths: 24}, mve.Change.ModifyProduct)
+	suite.True(mve.Change.RequiresApproval)
+	suite.Equal(OrderApprovalTypeTermChange, mve.Change.ApprovalType)
+	suite.Nil(mve.Change.PendingApproval)
+
+	// Only TERM_CHANGE approvals are attached.
+	suite.Nil(report.Renewals[1].Change.PendingApproval)
+	suite.Equal("approval-term", report.Renewals[2].Change.PendingApproval.UID)
+}
+
+func (suite *ContractRenewalTestSuite) TestContractRenewalReportErrors() {
+	ctx := context.Background()
+	svc := suite.client.ProductService
+
+	tests := []struct {
+		name    string
+		req     *ContractRenewalRequest
+		wantErr error
+	}{
+		{"nil", nil, ErrContractRenewalRequestNil},
+		{"no window", &ContractRenewalRequest{}, ErrContractRenewalWindow},
+		{"bad term", &ContractRenewalRequest{WithinDays: 30, Terms: []int{6}}, ErrContractRenewalTermBad},
+	}
+	for _, tc := range tests {
+		suite.Run(tc.name, func() {
+			_, err := svc.ContractRenewalReport(ctx, tc.req)
+			suite.ErrorIs(err, tc.wantErr)
+		})
+	}
+
+	// Every failed price is reported; 36 months has no price in the fixture.
+	_, err := svc.ContractRenewalReport(ctx, &ContractRenewalRequest{WithinDays: 30, Now: contractRenewalNow, Terms: []int{12, 36}})
+	suite.Require().Error(err)
+	suite.Contains(err.Error(), "pricing port-1 on a 36 month term")
+	suite.Contains(err.Error(), "pricing vxc-1 on a 36 month term")
+	suite.NotContains(err.Error(), "12 month term")
+
+	// Nothing in the window is not an error.
+	report, err := svc.ContractRenewalReport(ctx, &ContractRenewalRequest{WithinDays: 1, Now: contractRenewalNow})
+	suite.Require().NoError(err)
+	suite.Empty(report.Renewals)
+}
@@ -0,0 +1,333 @@
+package megaport
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrCostAllocationTagKeyEmpty is returned when a cost allocation request
+// names an empty tag key.
+var ErrCostAllocationTagKeyEmpty = errors.New("cost allocation tag keys must not be empty")
+
+// CostAllocationIssueCode identifies a tagging problem found by
+// CostAllocationReport.
+type CostAllocationIssueCode string
+
+const (
+	// CostAllocationMissingCostCentre is a service without a cost centre
+	// when RequireCostCentre is set.
+	CostAllocationMissingCostCentre CostAllocationIssueCode = "MISSING_COST_CENTRE"
+	// CostAllocationUnknownCostCentre is a cost centre outside
+	// AllowedCostCentres.
+	CostAllocationUnknownCostCentre CostAllocationIssueCode = "UNKNOWN_COST_CENTRE"
+	// CostAllocationMissingTag is a required tag that is absent or empty.
+	CostAllocationMissingTag CostAllocationIssueCode = "MISSING_TAG"
+	// CostAllocationInvalidTag is a tag value outside AllowedTagValues.
+	CostAllocationInvalidTag CostAllocationIssueCode = "INVALID_TAG"
+)
+
+// CostAllocationRequest configures CostAllocationReport. Tag keys and
+// allowed values are matched case-insensitively.
+type CostAllocationRequest struct {
+	// TagKeys are the resource tag keys to roll spend up by, in addition to
+	// the cost centre.
+	TagKeys []string
+	// RequiredTags are tag keys every service must carry with a value.
+	RequiredTags []string
+	// AllowedTagValues restricts the values of the given tag keys.
+	AllowedTagValues map[string][]string
+	// RequireCostCentre flags services without a cost centre.
+	RequireCostCentre bool
+	// AllowedCostCentres, when set, flags any other cost centre.
+	AllowedCostCentres []string
+	// IncludeNATGateways adds NAT Gateways, which ListProducts does not
+	// return. Their ServiceLevelReference is used as the cost centre.
+	IncludeNATGateways bool
+	// Currency optionally overrides the pricing currency.
+	Currency string
+	// CompanyUID optionally prices on behalf of another company, as
+	// GetProductPricingForCompany.
+	CompanyUID string
+	// Concurrency bounds parallel tag and pricing calls. Zero uses 4.
+	Concurrency int
+}
+
+// CostAllocationIssue is a tagging problem on one service.
+type CostAllocationIssue struct {
+	Code CostAllocationIssueCode `json:"code"`
+	// TagKey is empty for cost centre issues.
+	TagKey string `json:"tagKey,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+// String returns the issue as CODE, CODE:key or CODE:key=value.
+func (i *CostAllocationIssue) String() string {
+	s := string(i.Code)
+	if i.TagKey != "" {
+		s += ":" + i.TagKey
+	}
+	if i.Value != "" {
+		s += "=" + i.Value
+	}
+	return s
+}
+
+// CostAllocationLine is the monthly cost of one service and how it is
+// attributed.
+type CostAllocationLine struct {
+	ProductUID  string `json:"productUid"`
+	ProductName string `json:"productName"`
+	ProductType string `json:"productType"`
+	CostCentre  string `json:"costCentre"`
+	// Tags holds every resource tag on the service.
+	Tags        map[string]string      `json:"tags"`
+	Term        int                    `json:"term"`
+	Currency    string                 `json:"currency"`
+	MonthlyRate float64                `json:"monthlyRate"`
+	Issues      []*CostAllocationIssue `json:"issues,omitempty"`
+}
+
+// Tag returns the value of a tag key, matched case-insensitively.
+func (l *CostAllocationLine) Tag(key string) string {
+	if v, ok := l.Tags[key]; ok {
+		return v
+	}
+	for k, v := range l.Tags {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// CostAllocationGroup is the monthly spend attributed to one cost centre or
+// tag value in one currency. An empty Value holds the unattributed spend.
+type CostAllocationGroup struct {
+	// TagKey is empty for cost centre groups.
+	TagKey      string   `json:"tagKey,omitempty"`
+	Value       string   `json:"value"`
+	Currency    string   `json:"currency"`
+	Monthly     float64  `json:"monthly"`
+	ProductUIDs []string `json:"productUids"`
+}
+
+// CostAllocationReport is the result of CostAllocationReport.
+type CostAllocationReport struct {
+	TagKeys []string `json:"tagKeys"`
+	// Lines are ordered by product UID.
+	Lines []*CostAllocationLine `json:"lines"`
+	// ByCostCentre and ByTag are ordered by tag key, value and currency.
+	ByCostCentre []*CostAllocationGroup `json:"byCostCentre"`
+	ByTag        []*CostAllocationGroup `json:"byTag"`
+	// Totals has the monthly spend per currency.
+	Totals []*CostAllocationGroup `json:"totals"`
+}
+
+// CostAllocationReport prices every port, MCR, MVE and attached VXC, and
+// optionally every NAT Gateway, on its current term and rolls the monthly
+// spend up by cost centre and by the requested resource tags. Services
+// missing required tags or carrying disallowed values are flagged on their
+// line. All tag and pricing errors are returned joined, each prefixed with
+// the product UID.
+func (svc *ProductServiceOp) CostAllocationReport(ctx context.Context, req *CostAllocationRequest) (*CostAllocationReport, error) {
+	if req == nil {
+		req = &CostAllocationRequest{}
+	}
+	for _, k := range append(append([]string{}, req.TagKeys...), req.RequiredTags...) {
+		if strings.TrimSpace(k) == "" {
+			return nil, ErrCostAllocationTagKeyEmpty
+		}
+	}
+	services, err := svc.listBillableServices(ctx, req.IncludeNATGateways, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]*CostAllocationLine, len(services))
+	errs := make([]error, len(services))
+	forEachConcurrently(len(services), req.Concurrency, func(i int) {
+		s := services[i]
+		line, err := svc.costAllocationLine(ctx, req.CompanyUID, s)
+		if err != nil {
+			errs[i] = fmt.Errorf("%s: %w", s.uid, err)
+			return
+		}
+		line.Issues = req.issues(line)
+		lines[i] = line
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].ProductUID < lines[j].ProductUID })
+
+	report := &CostAllocationReport{
+		TagKeys: req.TagKeys,
+		Lines:   lines,
+		Totals:  groupCostAllocation(lines, "", nil, func(*CostAllocationLine) string { return "" }),
+	}
+	if report.TagKeys == nil {
+		report.TagKeys = []string{}
+	}
+	if report.Lines == nil {
+		report.Lines = []*CostAllocationLine{}
+	}
+	report.ByCostCentre = groupCostAllocation(lines, "", req.AllowedCostCentres, func(l *CostAllocationLine) string { return l.CostCentre })
+	report.ByTag = []*CostAllocationGroup{}
+	for _, key := range req.TagKeys {
+		report.ByTag = append(report.ByTag, groupCostAllocation(lines, key, req.AllowedTagValues[key], func(l *CostAllocationLine) string { return l.Tag(key) })...)
+	}
+	return report, nil
+}
+
+// Flagged returns the lines with at least one issue.
+func (r *CostAllocationReport) Flagged() []*CostAllocationLine {
+	var flagged []*CostAllocationLine
+	for _, l := range r.Lines {
+		if len(l.Issues) > 0 {
+			flagged = append(flagged, l)
+		}
+	}
+	return flagged
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *CostAllocationReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes one row per service for chargeback. After the fixed
+// columns, each of TagKeys gets a "tag:<key>" column, followed by an issues
+// column joining the issues with semicolons.
+func (r *CostAllocationReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"product_uid", "product_name", "product_type", "cost_centre", "term", "currency", "monthly_rate"}
+	for _, k := range r.TagKeys {
+		header = append(header, "tag:"+k)
+	}
+	header = append(header, "issues")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, l := range r.Lines {
+		record := []string{l.ProductUID, l.ProductName, l.ProductType, l.CostCentre, strconv.Itoa(l.Term), l.Currency, formatAmount(l.MonthlyRate)}
+		for _, k := range r.TagKeys {
+			record = append(record, l.Tag(k))
+		}
+		issues := make([]string, len(l.Issues))
+		for i, issue := range l.Issues {
+			issues[i] = issue.String()
+		}
+		record = append(record, strings.Join(issues, ";"))
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// costAllocationLine fetches the tags and current-term price of a service.
+func (svc *ProductServiceOp) costAllocationLine(ctx context.Context, companyUID string, s *billableService) (*CostAllocationLine, error) {
+	tags := s.resourceTags
+	if tags == nil {
+		var err error
+		if tags, err = svc.ListProductResourceTags(ctx, s.uid); err != nil {
+			return nil, fmt.Errorf("listing resource tags: %w", err)
+		}
+	}
+	pricing, err := svc.priceCostEstimateItem(ctx, companyUID, withPricingTerm(s.pricing, s.term))
+	if err != nil {
+		return nil, fmt.Errorf("pricing: %w", err)
+	}
+	line := &CostAllocationLine{
+		ProductUID:  s.uid,
+		ProductName: s.name,
+		ProductType: s.productType,
+		CostCentre:  strings.TrimSpace(s.costCentre),
+		Tags:        make(map[string]string, len(tags)),
+		Term:        s.term,
+		Currency:    pricing.Currency,
+		MonthlyRate: pricing.MonthlyRate,
+	}
+	for _, t := range tags {
+		line.Tags[t.Key] = strings.TrimSpace(t.Value)
+	}
+	return line, nil
+}
+
+// issues checks a line against the tagging policy of the request.
+func (req *CostAllocationRequest) issues(l *CostAllocationLine) []*CostAllocationIssue {
+	var issues []*CostAllocationIssue
+	if l.CostCentre == "" {
+		if req.RequireCostCentre {
+			issues = append(issues, &CostAllocationIssue{Code: CostAllocationMissingCostCentre})
+		}
+	} else if len(req.AllowedCostCentres) > 0 && !containsFold(req.AllowedCostCentres, l.CostCentre) {
+		issues = append(issues, &CostAllocationIssue{Code: CostAllocationUnknownCostCentre, Value: l.CostCentre})
+	}
+	for _, key := range req.RequiredTags {
+		if l.Tag(key) == "" {
+			issues = append(issues, &CostAllocationIssue{Code: CostAllocationMissingTag, TagKey: key})
+		}
+	}
+	keys := make([]string, 0, len(req.AllowedTagValues))
+	for k := range req.AllowedTagValues {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if v := l.Tag(key); v != "" && !containsFold(req.AllowedTagValues[key], v) {
+			issues = append(issues, &CostAllocationIssue{Code: CostAllocationInvalidTag, TagKey: key, Value: v})
+		}
+	}
+	return issues
+}
+
+// groupCostAllocation sums the lines by the value returned by valueOf and by
+// currency. Values are grouped case-insensitively, as the policy checks
+// match them; a group takes its value's spelling from allowed, or else from
+// its first line.
+func groupCostAllocation(lines []*CostAllocationLine, tagKey string, allowed []string, valueOf func(*CostAllocationLine) string) []*CostAllocationGroup {
+	type groupKey struct{ value, currency string }
+	groups := make(map[groupKey]*CostAllocationGroup)
+	for _, l := range lines {
+		v := valueOf(l)
+		k := groupKey{strings.ToLower(v), l.Currency}
+		g, ok := groups[k]
+		if !ok {
+			if i := slices.IndexFunc(allowed, func(a string) bool { return strings.EqualFold(a, v) }); i >= 0 {
+				v = allowed[i]
+			}
+			g = &CostAllocationGroup{TagKey: tagKey, Value: v, Currency: l.Currency}
+			groups[k] = g
+		}
+		g.Monthly += l.MonthlyRate
+		g.ProductUIDs = append(g.ProductUIDs, l.ProductUID)
+	}
+	out := make([]*CostAllocationGroup, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Value != out[j].Value {
+			return out[i].Value < out[j].Value
+		}
+		return out[i].Currency < out[j].Currency
+	})
+	return out
+}
+
+// formatAmount formats a monetary value for CSV export.
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
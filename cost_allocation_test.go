@@ -0,0 +1,228 @@
+package megaport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const costAllocationProductsJSON = `{"message": "Products", "data": [
+    {"productUid": "port-1", "productName": "Port 1", "productType": "MEGAPORT", "provisioningStatus": "LIVE",
+        "locationId": 1, "portSpeed": 10000, "contractTermMonths": 12, "costCentre": "NET",
+        "associatedVxcs": [
+            {"productUid": "vxc-1", "productName": "VXC 1", "productType": "VXC", "provisioningStatus": "LIVE",
+                "rateLimit": 100, "contractTermMonths": 1, "costCentre": " ",
+                "aEnd": {"productUid": "port-1", "locationId": 1}, "bEnd": {"productUid": "mcr-1", "locationId": 2}}
+        ]},
+    {"productUid": "mcr-1", "productName": "MCR 1", "productType": "MCR2", "provisioningStatus": "LIVE",
+        "locationId": 2, "portSpeed": 1000, "contractTermMonths": 1, "costCentre": "FIN-99",
+        "associatedVxcs": [
+            {"productUid": "vxc-1", "productName": "VXC 1", "productType": "VXC", "provisioningStatus": "LIVE",
+                "rateLimit": 100, "contractTermMonths": 1,
+                "aEnd": {"productUid": "port-1", "locationId": 1}, "bEnd": {"productUid": "mcr-1", "locationId": 2}}
+        ]},
+    {"productUid": "port-gone", "productName": "Gone", "productType": "MEGAPORT", "provisioningStatus": "CANCELLED", "locationId": 1}
+]}`
+
+const costAllocationNATGatewaysJSON = `{"message": "NAT Gateways", "data": [
+    {"productUid": "nat-1", "productName": "NAT 1", "provisioningStatus": "LIVE", "locationId": 1, "speed": 1000, "term": 12,
+        "serviceLevelReference": "net", "resourceTags": [{"key": "Team", "value": "network"}], "config": {"sessionCount": 1000}}
+]}`
+
+var costAllocationTags = map[string]string{
+	"port-1": `[{"key": "team", "value": "network"}, {"key": "env", "value": "prod"}]`,
+	"vxc-1":  `[{"key": "team", "value": "apps"}, {"key": "env", "value": "dev1"}]`,
+	"mcr-1":  `[]`,
+}
+
+var costAllocationRates = map[string]string{
+	"MEGAPORT":    `"currency": "AUD", "monthlyRate": 1000`,
+	"VXC":         `"currency": "AUD", "monthlyRate": 100`,
+	"MCR2":        `"currency": "AUD", "monthlyRate": 500`,
+	"NAT_GATEWAY": `"currency": "USD", "monthlyRate": 300`,
+}
+
+// CostAllocationTestSuite tests CostAllocationReport.
+type CostAllocationTestSuite struct {
+	ClientTestSuite
+
+	// untagged names a product whose tag lookup fails.
+	untagged string
+}
+
+func TestCostAllocationTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(CostAllocationTestSuite))
+}
+
+func (suite *CostAllocationTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+	suite.untagged = ""
+
+	suite.mux.HandleFunc("/v2/products", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, costAllocationProductsJSON)
+	})
+	suite.mux.HandleFunc("/v3/products/nat_gateways", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, costAllocationNATGatewaysJSON)
+	})
+	suite.mux.HandleFunc("/v2/product/", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		uid := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v2/product/"), "/tags")
+		tags, ok := costAllocationTags[uid]
+		if !ok || uid == suite.untagged {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"message": "Tags", "data": {"resourceTags": %s}}`, tags)
+	})
+	suite.mux.HandleFunc("/v4/pricebook/product", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		productType, _ := body["productType"].(string)
+		rate, ok := costAllocationRates[productType]
+		if !ok || body["productUid"] == "port-1" && body["term"] != float64(12) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message": "bad pricing request"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"message": "OK", "data": {"productType": %q, %s, "prices": [], "discounts": []}}`, productType, rate)
+	})
+}
+
+func (suite *CostAllocationTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *CostAllocationTestSuite) TestCostAllocationReport() {
+	ctx := context.Background()
+	report, err := suite.client.ProductService.CostAllocationReport(ctx, &CostAllocationRequest{
+		TagKeys:            []string{"team"},
+		RequiredTags:       []string{"team", "env"},
+		AllowedTagValues:   map[string][]string{"env": {"prod", "dev"}},
+		RequireCostCentre:  true,
+		AllowedCostCentres: []string{"NET", "APPS"},
+		IncludeNATGateways: true,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(report.Lines, 4)
+	suite.Equal([]string{"mcr-1", "nat-1", "port-1", "vxc-1"}, []string{
+		report.Lines[0].ProductUID, report.Lines[1].ProductUID, report.Lines[2].ProductUID, report.Lines[3].ProductUID,
+	})
+
+	issues := make(map[string][]string)
+	for _, l := range report.Flagged() {
+		for _, i := range l.Issues {
+			issues[l.ProductUID] = append(issues[l.ProductUID], i.String())
+		}
+	}
+	suite.Equal(map[string][]string{
+		"mcr-1": {"UNKNOWN_COST_CENTRE=FIN-99", "MISSING_TAG:team", "MISSING_TAG:env"},
+		"nat-1": {"MISSING_TAG:env"},
+		"vxc-1": {"MISSING_COST_CENTRE", "INVALID_TAG:env=dev1"},
+	}, issues)
+
+	suite.Equal([]*CostAllocationGroup{
+		{Value: "", Currency: "AUD", Monthly: 100, ProductUIDs: []string{"vxc-1"}},
+		{Value: "FIN-99", Currency: "AUD", Monthly: 500, ProductUIDs: []string{"mcr-1"}},
+		{Value: "NET", Currency: "AUD", Monthly: 1000, ProductUIDs: []string{"port-1"}},
+		{Value: "NET", Currency: "USD", Monthly: 300, ProductUIDs: []string{"nat-1"}},
+	}, report.ByCostCentre)
+	suite.Equal([]*CostAllocationGroup{
+		{TagKey: "team", Value: "", Currency: "AUD", Monthly: 500, ProductUIDs: []string{"mcr-1"}},
+		{TagKey: "team", Value: "apps", Currency: "AUD", Monthly: 100, ProductUIDs: []string{"vxc-1"}},
+		{TagKey: "team", Value: "network", Currency: "AUD", Monthly: 1000, ProductUIDs: []string{"port-1"}},
+		{TagKey: "team", Value: "network", Currency: "USD", Monthly: 300, ProductUIDs: []string{"nat-1"}},
+	}, report.ByTag)
+	suite.Require().Len(report.Totals, 2)
+	suite.Equal(1600.0, report.Totals[0].Monthly)
+	suite.Equal(300.0, report.Totals[1].Monthly)
+
+	var buf bytes.Buffer
+	suite.Require().NoError(report.WriteCSV(&buf))
+	rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	suite.Equal("product_uid,product_name,product_type,cost_centre,term,currency,monthly_rate,tag:team,issues", rows[0])
+	suite.Equal("vxc-1,VXC 1,VXC,,1,AUD,100.00,apps,MISSING_COST_CENTRE;INVALID_TAG:env=dev1", rows[4])
+
+	buf.Reset()
+	suite.Require().NoError(report.WriteJSON(&buf))
+	var decoded CostAllocationReport
+	suite.Require().NoError(json.Unmarshal(buf.Bytes(), &decoded))
+	suite.Equal(report.ByTag, decoded.ByTag)
+	suite.Equal("network", decoded.Lines[2].Tags["team"])
+}
+
+func (suite *CostAllocationTestSuite) TestCostAllocationReportErrors() {
+	ctx := context.Background()
+	svc := suite.client.ProductService
+
+	_, err := svc.CostAllocationReport(ctx, &CostAllocationRequest{TagKeys: []string{" "}})
+	suite.ErrorIs(err, ErrCostAllocationTagKeyEmpty)
+
+	// A missing tag endpoint fails only the affected service.
+	suite.untagged = "mcr-1"
+	_, err = svc.CostAllocationReport(ctx, nil)
+	suite.Require().Error(err)
+	suite.Contains(err.Error(), "mcr-1: listing resource tags")
+	suite.NotContains(err.Error(), "port-1")
+}
+
+func TestNewVXCBillableServicePricing(t *testing.T) {
+	t.Parallel()
+	vxc := &VXC{
+		UID:               "vxc-aws",
+		RateLimit:         500,
+		AEndConfiguration: VXCEndConfiguration{UID: "mcr-1", LocationID: 2},
+		BEndConfiguration: VXCEndConfiguration{UID: "aws-port", LocationID: 30},
+		Resources: &VXCResources{CSPConnection: &CSPConnection{CSPConnection: []CSPConnectionConfig{
+			CSPConnectionVirtualRouter{ConnectType: "VROUTER"},
+			CSPConnectionAWSHC{ConnectType: CONNECT_TYPE_AWS_HOSTED_CONNECTION},
+		}}},
+	}
+	s := newVXCBillableService(vxc, "MCR2", "AUD")
+	pr, ok := s.pricing.(*VXCPriceBookRequest)
+	if !ok {
+		t.Fatalf("pricing = %T", s.pricing)
+	}
+	if pr.AEndProductType != "MCR2" || pr.ConnectType != CONNECT_TYPE_AWS_HOSTED_CONNECTION || pr.ALocationID != 2 || pr.BLocationID != 30 {
+		t.Fatalf("pricing = %+v", pr)
+	}
+
+	// A VXC between the account's own ports has no connect type.
+	vxc.Resources = nil
+	pr = newVXCBillableService(vxc, "MEGAPORT", "AUD").pricing.(*VXCPriceBookRequest)
+	if pr.AEndProductType != "MEGAPORT" || pr.ConnectType != "" {
+		t.Fatalf("pricing = %+v", pr)
+	}
+}
+
+func TestGroupCostAllocationIgnoresCase(t *testing.T) {
+	t.Parallel()
+	lines := []*CostAllocationLine{
+		{ProductUID: "a", CostCentre: "net", Currency: "AUD", MonthlyRate: 10},
+		{ProductUID: "b", CostCentre: "Net", Currency: "AUD", MonthlyRate: 20},
+		{ProductUID: "c", CostCentre: "apps", Currency: "AUD", MonthlyRate: 5},
+	}
+	groups := groupCostAllocation(lines, "", []string{"NET"}, func(l *CostAllocationLine) string { return l.CostCentre })
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	if g := groups[0]; g.Value != "NET" || g.Monthly != 30 || len(g.ProductUIDs) != 2 {
+		t.Fatalf("groups[0] = %+v", g)
+	}
+	if g := groups[1]; g.Value != "apps" || g.Monthly != 5 {
+		t.Fatalf("groups[1] = %+v", g)
+	}
+}
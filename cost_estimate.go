@@ -0,0 +1,437 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Cost estimation errors.
+var (
+	ErrCostEstimateRequestNil         = errors.New("cost estimate request is required")
+	ErrCostEstimateEmpty              = errors.New("cost estimate request contains no products")
+	ErrCostEstimateEndpointRequired   = errors.New("cost estimate requires VXC A-End and B-End product UIDs")
+	ErrCostEstimateEndpointUnresolved = errors.New("cannot resolve the location of product")
+)
+
+// CostEstimateItem is a single product to price. Items can be built by hand
+// for a topology plan or are derived from the buy requests in an
+// OrderCostEstimateRequest.
+type CostEstimateItem struct {
+	Name    string
+	Request PriceBookRequest
+	// Term is the contract term in months used for the term total. Zero
+	// means month-to-month.
+	Term int
+	// Quantity multiplies the price, e.g. the port count of a LAG. Zero
+	// means 1.
+	Quantity int
+}
+
+// OrderCostEstimateRequest is a set of orders to quote together.
+type OrderCostEstimateRequest struct {
+	// Currency optionally overrides the pricing currency for every item.
+	Currency string
+	// CompanyUID optionally prices on behalf of another company, as
+	// GetProductPricingForCompany.
+	CompanyUID string
+
+	Ports       []*BuyPortRequest
+	MCRs        []*BuyMCRRequest
+	MVEs        []*BuyMVERequest
+	VXCs        []*BuyVXCRequest
+	IXs         []*BuyIXRequest
+	NATGateways []*CreateNATGatewayRequest
+	// Items are priced as given, after the buy requests.
+	Items []*CostEstimateItem
+
+	// PortCrossConnects adds the cross-connect add-on to every port.
+	PortCrossConnects bool
+	// Locations maps product UIDs to location IDs for VXC ends and IX ports.
+	// Use it for placeholder UIDs of products in the same order that do not
+	// exist yet. Other UIDs are resolved from partner ports or by fetching
+	// the product.
+	Locations map[string]int
+	// Concurrency bounds parallel pricing calls. Zero uses 4.
+	Concurrency int
+}
+
+// CostEstimateLine is the priced breakdown of one item. Monetary values are
+// per unit in the line's currency; totals account for Quantity.
+type CostEstimateLine struct {
+	Name        string
+	ProductType string
+	Term        int
+	Quantity    int
+	Currency    string
+	// MonthlyRate is the discounted monthly rate and MonthlyRackRate the
+	// undiscounted one.
+	MonthlyRate     float64
+	MonthlyRackRate float64
+	// MonthlyAddOns is the part of the monthly charges from add-ons.
+	MonthlyAddOns float64
+	// OneOff sums the one-time charges.
+	OneOff float64
+	// TermDiscount sums discounts given for the contract term; OtherDiscounts
+	// sums every other discount.
+	TermDiscount   float64
+	OtherDiscounts float64
+	// TermTotal is Quantity × (MonthlyRate × max(Term, 1) + OneOff).
+	TermTotal float64
+	Pricing   *PriceBookDTO
+}
+
+// CostEstimateTotals sums the lines sharing a currency.
+type CostEstimateTotals struct {
+	Currency        string
+	Monthly         float64
+	MonthlyRackRate float64
+	OneOff          float64
+	TermDiscount    float64
+	TermTotal       float64
+}
+
+// OrderCostEstimate is the result of EstimateOrderCost.
+type OrderCostEstimate struct {
+	// Lines are in request order: ports, MCRs, MVEs, VXCs, IXs, NAT
+	// Gateways, then Items.
+	Lines []*CostEstimateLine
+	// Totals has one entry per currency, sorted by currency code.
+	Totals []*CostEstimateTotals
+}
+
+// EstimateOrderCost prices every product in the request concurrently and
+// returns per-item and per-currency totals. Buy requests are converted to
+// the matching PriceBookRequest, including IPsec and cross-connect add-ons;
+// VXC and IX locations are resolved from Locations, partner ports or the
+// existing products. All pricing errors are returned joined, each prefixed
+// with the item name.
+func (svc *ProductServiceOp) EstimateOrderCost(ctx context.Context, req *OrderCostEstimateRequest) (*OrderCostEstimate, error) {
+	if req == nil {
+		return nil, ErrCostEstimateRequestNil
+	}
+	items, err := svc.costEstimateItems(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrCostEstimateEmpty
+	}
+
+	lines := make([]*CostEstimateLine, len(items))
+	errs := make([]error, len(items))
+	forEachConcurrently(len(items), req.Concurrency, func(i int) {
+		item := items[i]
+		pricing, err := svc.priceCostEstimateItem(ctx, req.CompanyUID, item.Request)
+		if err != nil {
+			errs[i] = fmt.Errorf("pricing %s: %w", item.Name, err)
+			return
+		}
+		lines[i] = newCostEstimateLine(item, pricing)
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &OrderCostEstimate{Lines: lines, Totals: sumCostEstimateLines(lines)}, nil
+}
+
+func (svc *ProductServiceOp) priceCostEstimateItem(ctx context.Context, companyUID string, req PriceBookRequest) (*PriceBookDTO, error) {
+	if companyUID != "" {
+		return svc.GetProductPricingForCompany(ctx, &GetProductPricingRequest{Req: req, CompanyUID: companyUID})
+	}
+	return svc.GetProductPricing(ctx, req)
+}
+
+// costEstimateItems converts the buy requests into pricing items.
+func (svc *ProductServiceOp) costEstimateItems(ctx context.Context, req *OrderCostEstimateRequest) ([]*CostEstimateItem, error) {
+	var items []*CostEstimateItem
+	for i, p := range req.Ports {
+		pr := &MegaportPriceBookRequest{Currency: req.Currency, LocationID: p.LocationId, Speed: p.PortSpeed, Term: p.Term}
+		if req.PortCrossConnects {
+			requested := true
+			pr.AddOns = append(pr.AddOns, &ProductAddOnPriceBookRequest{AddOnType: PricingAddOnTypeCrossConnect, CrossConnectRequested: &requested})
+		}
+		items = append(items, &CostEstimateItem{Name: costEstimateName(p.Name, "port", i), Request: pr, Term: p.Term, Quantity: p.LagCount})
+	}
+	for i, m := range req.MCRs {
+		pr := &MCRPriceBookRequest{Currency: req.Currency, LocationID: m.LocationID, Speed: m.PortSpeed, Term: m.Term}
+		for _, addOn := range m.AddOns {
+			if ipsec, ok := addOn.(*MCRAddOnIPsecConfig); ok {
+				pr.AddOns = append(pr.AddOns, &ProductAddOnPriceBookRequest{AddOnType: PricingAddOnTypeIPSec, TunnelCount: ipsec.TunnelCount})
+			}
+		}
+		items = append(items, &CostEstimateItem{Name: costEstimateName(m.Name, "MCR", i), Request: pr, Term: m.Term})
+	}
+	for i, m := range req.MVEs {
+		size, label := mveSizeAndLabel(m.VendorConfig)
+		pr := &MVEPriceBookRequest{Currency: req.Currency, LocationID: m.LocationID, Size: size, MVELabel: label, Term: m.Term}
+		items = append(items, &CostEstimateItem{Name: costEstimateName(m.Name, "MVE", i), Request: pr, Term: m.Term})
+	}
+
+	resolver := &productLocationResolver{svc: svc, known: req.Locations}
+	for i, v := range req.VXCs {
+		aUID := v.PortUID
+		if aUID == "" {
+			aUID = v.AEndConfiguration.ProductUID
+		}
+		bUID := v.BEndConfiguration.ProductUID
+		name := costEstimateName(v.VXCName, "VXC", i)
+		if aUID == "" || bUID == "" {
+			return nil, fmt.Errorf("%s: %w", name, ErrCostEstimateEndpointRequired)
+		}
+		aEnd, err := resolver.resolve(ctx, aUID)
+		if err != nil {
+			return nil, fmt.Errorf("%s A-End: %w", name, err)
+		}
+		bEnd, err := resolver.resolve(ctx, bUID)
+		if err != nil {
+			return nil, fmt.Errorf("%s B-End: %w", name, err)
+		}
+		aEnd.fillFromOrderEnd(v.AEndConfiguration)
+		bEnd.fillFromOrderEnd(v.BEndConfiguration)
+		pr := &VXCPriceBookRequest{
+			Currency:        req.Currency,
+			ALocationID:     aEnd.locationID,
+			BLocationID:     bEnd.locationID,
+			Speed:           v.RateLimit,
+			AEndProductType: aEnd.productType,
+			ConnectType:     bEnd.connectType,
+			Term:            v.Term,
+		}
+		items = append(items, &CostEstimateItem{Name: name, Request: pr, Term: v.Term})
+	}
+	for i, ix := range req.IXs {
+		name := costEstimateName(ix.Name, "IX", i)
+		port, err := resolver.resolve(ctx, ix.ProductUID)
+		if err != nil {
+			return nil, fmt.Errorf("%s port: %w", name, err)
+		}
+		pr := &IXPriceBookRequest{Currency: req.Currency, PortLocationID: port.locationID, IXType: ix.NetworkServiceType, Speed: ix.RateLimit}
+		items = append(items, &CostEstimateItem{Name: name, Request: pr})
+	}
+	for i, n := range req.NATGateways {
+		pr := &NATGatewayPriceBookRequest{Currency: req.Currency, LocationID: n.LocationID, Speed: n.Speed, SessionCount: n.Config.SessionCount, Term: n.Term}
+		items = append(items, &CostEstimateItem{Name: costEstimateName(n.ProductName, "NAT Gateway", i), Request: pr, Term: n.Term})
+	}
+	for i, item := range req.Items {
+		if item == nil || item.Request == nil {
+			return nil, fmt.Errorf("item %d: %w", i+1, ErrPricingRequestNil)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func costEstimateName(name, kind string, i int) string {
+	if name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s %d", kind, i+1)
+}
+
+// mveSizeAndLabel extracts the product size and MVE label shared by every
+// vendor config.
+func mveSizeAndLabel(vc VendorConfig) (size, label string) {
+	switch c := vc.(type) {
+	case *SixwindVSRConfig:
+		return c.ProductSize, c.MVELabel
+	case *ArubaConfig:
+		return c.ProductSize, c.MVELabel
+	case *AviatrixConfig:
+		return c.ProductSize, c.MVELabel
+	case *CiscoConfig:
+		return c.ProductSize, c.MVELabel
+	case *FortinetConfig:
+		return c.ProductSize, c.MVELabel
+	case *PaloAltoConfig:
+		return c.ProductSize, c.MVELabel
+	case *PrismaConfig:
+		return c.ProductSize, c.MVELabel
+	case *VersaConfig:
+		return c.ProductSize, c.MVELabel
+	case *VmwareConfig:
+		return c.ProductSize, c.MVELabel
+	case *MerakiConfig:
+		return c.ProductSize, c.MVELabel
+	}
+	return "", ""
+}
+
+// newCostEstimateLine breaks a pricing response down for an item.
+func newCostEstimateLine(item *CostEstimateItem, pricing *PriceBookDTO) *CostEstimateLine {
+	line := &CostEstimateLine{
+		Name:            item.Name,
+		ProductType:     pricing.ProductType,
+		Term:            item.Term,
+		Quantity:        max(item.Quantity, 1),
+		Currency:        pricing.Currency,
+		MonthlyRate:     pricing.MonthlyRate,
+		MonthlyRackRate: pricing.MonthlyRackRate,
+		Pricing:         pricing,
+	}
+	for _, p := range pricing.Prices {
+		if p == nil {
+			continue
+		}
+		switch {
+		case p.Frequency == PricingFrequencyOnce:
+			line.OneOff += p.Amount
+		case p.ChargeReason == PriceBookChargeReasonAddOn && p.Frequency == PricingFrequencyMonthly:
+			line.MonthlyAddOns += p.Amount
+		}
+	}
+	for _, d := range pricing.Discounts {
+		if d == nil {
+			continue
+		}
+		if d.DiscountReason == DiscountReasonTerm {
+			line.TermDiscount += d.Amount
+		} else {
+			line.OtherDiscounts += d.Amount
+		}
+	}
+	line.TermTotal = float64(line.Quantity) * (line.MonthlyRate*float64(max(line.Term, 1)) + line.OneOff)
+	return line
+}
+
+// sumCostEstimateLines totals the lines per currency.
+func sumCostEstimateLines(lines []*CostEstimateLine) []*CostEstimateTotals {
+	byCurrency := make(map[string]*CostEstimateTotals)
+	for _, l := range lines {
+		t, ok := byCurrency[l.Currency]
+		if !ok {
+			t = &CostEstimateTotals{Currency: l.Currency}
+			byCurrency[l.Currency] = t
+		}
+		q := float64(l.Quantity)
+		t.Monthly += q * l.MonthlyRate
+		t.MonthlyRackRate += q * l.MonthlyRackRate
+		t.OneOff += q * l.OneOff
+		t.TermDiscount += q * l.TermDiscount
+		t.TermTotal += l.TermTotal
+	}
+	totals := make([]*CostEstimateTotals, 0, len(byCurrency))
+	for _, t := range byCurrency {
+		totals = append(totals, t)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Currency < totals[j].Currency })
+	return totals
+}
+
+// resolvedProduct is the pricing-relevant view of a VXC end or IX port.
+type resolvedProduct struct {
+	locationID  int
+	productType string
+	connectType string
+}
+
+// fillFromOrderEnd sets the product and connect types the resolver could
+// not determine, as for a placeholder UID from Locations, from the VXC
+// order's end configuration: a vRouter partner config marks an MCR, an MVE
+// config naming a vNIC an MVE, and a cloud partner config gives the connect
+// type.
+//
+// QinQ port ends also carry their inner VLAN in the MVE config, so one that
+// sets only InnerVLAN is not taken as an MVE. An MVE end on vNIC 0 with an
+// inner VLAN is therefore priced as a port unless its UID resolves to the
+// product.
+func (p *resolvedProduct) fillFromOrderEnd(e VXCOrderEndpointConfiguration) {
+	if p.productType == "" {
+		switch e.PartnerConfig.(type) {
+		case *VXCOrderVrouterPartnerConfig, VXCOrderVrouterPartnerConfig, *VXCOrderAEndPartnerConfig, VXCOrderAEndPartnerConfig:
+			p.productType = strings.ToUpper(PRODUCT_MCR)
+		}
+		if mve := e.VXCOrderMVEConfig; mve != nil && (mve.NetworkInterfaceIndex != 0 || mve.InnerVLAN == 0) {
+			p.productType = strings.ToUpper(PRODUCT_MVE)
+		}
+	}
+	if p.connectType == "" {
+		p.connectType = partnerConfigConnectType(e.PartnerConfig)
+	}
+}
+
+// partnerConfigConnectType returns the connect type of a cloud partner
+// config, or "" for an MCR or A-End partner config.
+func partnerConfigConnectType(pc VXCPartnerConfiguration) string {
+	switch c := pc.(type) {
+	case *VXCPartnerConfigAWS:
+		return c.ConnectType
+	case *VXCPartnerConfigAzure:
+		return c.ConnectType
+	case *VXCPartnerConfigGoogle:
+		return c.ConnectType
+	case *VXCPartnerConfigOracle:
+		return c.ConnectType
+	case *VXCPartnerConfigIBM:
+		return c.ConnectType
+	case *VXCPartnerConfigTransit:
+		return c.ConnectType
+	}
+	return ""
+}
+
+// productLocationResolver looks up product locations for pricing, caching
+// partner ports and fetched products.
+type productLocationResolver struct {
+	svc      *ProductServiceOp
+	known    map[string]int
+	partners map[string]*PartnerMegaport
+	fetched  map[string]resolvedProduct
+}
+
+func (r *productLocationResolver) resolve(ctx context.Context, uid string) (resolvedProduct, error) {
+	if id, ok := r.known[uid]; ok {
+		return resolvedProduct{locationID: id}, nil
+	}
+	if p, ok := r.fetched[uid]; ok {
+		return p, nil
+	}
+	if r.partners == nil {
+		partners, err := r.svc.Client.PartnerService.ListPartnerMegaports(ctx)
+		if err != nil {
+			return resolvedProduct{}, err
+		}
+		r.partners = make(map[string]*PartnerMegaport, len(partners))
+		for _, p := range partners {
+			r.partners[p.ProductUID] = p
+		}
+	}
+	if p, ok := r.partners[uid]; ok {
+		return resolvedProduct{locationID: p.LocationId, connectType: p.ConnectType}, nil
+	}
+
+	productType, err := r.svc.GetProductType(ctx, uid)
+	if err != nil {
+		return resolvedProduct{}, err
+	}
+	resolved := resolvedProduct{productType: strings.ToUpper(productType)}
+	switch strings.ToLower(productType) {
+	case PRODUCT_MEGAPORT:
+		port, err := r.svc.Client.PortService.GetPort(ctx, uid)
+		if err != nil {
+			return resolvedProduct{}, err
+		}
+		resolved.locationID = port.LocationID
+	case PRODUCT_MCR:
+		mcr, err := r.svc.Client.MCRService.GetMCR(ctx, uid)
+		if err != nil {
+			return resolvedProduct{}, err
+		}
+		resolved.locationID = mcr.LocationID
+	case PRODUCT_MVE:
+		mve, err := r.svc.Client.MVEService.GetMVE(ctx, uid)
+		if err != nil {
+			return resolvedProduct{}, err
+		}
+		resolved.locationID = mve.LocationID
+	default:
+		return resolvedProduct{}, fmt.Errorf("%w %s of type %s", ErrCostEstimateEndpointUnresolved, uid, productType)
+	}
+	if r.fetched == nil {
+		r.fetched = make(map[string]resolvedProduct)
+	}
+	r.fetched[uid] = resolved
+	return resolved, nil
+}
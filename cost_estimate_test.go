@@ -0,0 +1,227 @@
+package megaport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// costEstimatePricing maps product types to the pricing payload served by
+// CostEstimateTestSuite.
+var costEstimatePricing = map[string]string{
+	"MEGAPORT": `{"productType": "MEGAPORT", "currency": "AUD", "monthlyRate": 1000, "monthlyRackRate": 1200,
+        "prices": [
+            {"chargeReason": "CORE", "frequency": "MONTHLY", "amount": 900},
+            {"chargeReason": "ADD_ON_CHARGE", "frequency": "MONTHLY", "amount": 100, "addOnType": "CROSS_CONNECT"},
+            {"chargeReason": "CORE", "frequency": "ONCE", "amount": 250}
+        ],
+        "discounts": [{"discountReason": "TERM", "amount": 200}]}`,
+	"MCR2":        `{"productType": "MCR2", "currency": "AUD", "monthlyRate": 500, "monthlyRackRate": 500, "prices": [], "discounts": []}`,
+	"MVE":         `{"productType": "MVE", "currency": "AUD", "monthlyRate": 700, "monthlyRackRate": 800, "prices": [], "discounts": [{"discountReason": "PARTNER", "amount": 100}]}`,
+	"VXC":         `{"productType": "VXC", "currency": "AUD", "monthlyRate": 100, "monthlyRackRate": 100, "prices": [], "discounts": []}`,
+	"IX":          `{"productType": "IX", "currency": "AUD", "monthlyRate": 50, "monthlyRackRate": 50, "prices": [], "discounts": []}`,
+	"NAT_GATEWAY": `{"productType": "NAT_GATEWAY", "currency": "USD", "monthlyRate": 300, "monthlyRackRate": 300, "prices": [{"chargeReason": "CORE", "frequency": "ONCE", "amount": 30}], "discounts": []}`,
+}
+
+// CostEstimateTestSuite tests EstimateOrderCost.
+type CostEstimateTestSuite struct {
+	ClientTestSuite
+
+	mu     sync.Mutex
+	bodies map[string][]map[string]interface{}
+}
+
+func TestCostEstimateTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(CostEstimateTestSuite))
+}
+
+func (suite *CostEstimateTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+	suite.bodies = make(map[string][]map[string]interface{})
+
+	suite.mux.HandleFunc("/v4/pricebook/product", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodPost)
+		var body map[string]interface{}
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		productType, _ := body["productType"].(string)
+		suite.mu.Lock()
+		suite.bodies[productType] = append(suite.bodies[productType], body)
+		suite.mu.Unlock()
+		data, ok := costEstimatePricing[productType]
+		if !ok || body["speed"] == float64(999) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message": "bad pricing request"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"message": "OK", "data": %s}`, data)
+	})
+	suite.mux.HandleFunc("/v2/dropdowns/partner/megaports", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "Partner ports", "data": [{"productUid": "aws-1", "connectType": "AWS", "locationId": 30, "vxcPermitted": true}]}`)
+	})
+	suite.mux.HandleFunc("/v2/product/mcr-existing", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "Product", "data": {"productUid": "mcr-existing", "productType": "MCR2", "locationId": 20}}`)
+	})
+}
+
+func (suite *CostEstimateTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *CostEstimateTestSuite) TestEstimateOrderCost() {
+	ctx := context.Background()
+	estimate, err := suite.client.ProductService.EstimateOrderCost(ctx, &OrderCostEstimateRequest{
+		Currency: "AUD",
+		Ports: []*BuyPortRequest{
+			{Name: "Core LAG", LocationId: 10, PortSpeed: 10000, Term: 12, LagCount: 2},
+		},
+		PortCrossConnects: true,
+		MCRs: []*BuyMCRRequest{
+			{LocationID: 10, PortSpeed: 1000, Term: 1, AddOns: []MCRAddOn{&MCRAddOnIPsecConfig{AddOnType: AddOnTypeIPsec, TunnelCount: 10}}},
+		},
+		MVEs: []*BuyMVERequest{
+			{Name: "SD-WAN", LocationID: 10, Term: 12, VendorConfig: &ArubaConfig{ProductSize: "MEDIUM", MVELabel: "MVE 4/16"}},
+		},
+		VXCs: []*BuyVXCRequest{
+			{VXCName: "To AWS", PortUID: "new-port", RateLimit: 500, Term: 12, BEndConfiguration: VXCOrderEndpointConfiguration{ProductUID: "aws-1"}},
+			{VXCName: "To MCR", PortUID: "new-port", RateLimit: 100, BEndConfiguration: VXCOrderEndpointConfiguration{ProductUID: "mcr-existing"}},
+		},
+		IXs:         []*BuyIXRequest{{Name: "IX", ProductUID: "new-port", NetworkServiceType: "Sydney IX", RateLimit: 1000}},
+		NATGateways: []*CreateNATGatewayRequest{{LocationID: 10, Speed: 1000, Term: 1, Config: NATGatewayNetworkConfig{SessionCount: 1000}}},
+		Locations:   map[string]int{"new-port": 10},
+		Concurrency: 2,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Len(estimate.Lines, 7)
+
+	port := estimate.Lines[0]
+	suite.Equal("Core LAG", port.Name)
+	suite.Equal(2, port.Quantity)
+	suite.Equal(100.0, port.MonthlyAddOns)
+	suite.Equal(250.0, port.OneOff)
+	suite.Equal(200.0, port.TermDiscount)
+	suite.Equal(2*(1000.0*12+250), port.TermTotal)
+	suite.Equal("MCR 1", estimate.Lines[1].Name)
+	suite.Equal(100.0, estimate.Lines[2].OtherDiscounts)
+
+	suite.Require().Len(estimate.Totals, 2)
+	aud, usd := estimate.Totals[0], estimate.Totals[1]
+	suite.Equal("AUD", aud.Currency)
+	suite.Equal(2*1000.0+500+700+100+100+50, aud.Monthly)
+	suite.Equal(500.0, aud.OneOff)
+	suite.Equal(400.0, aud.TermDiscount)
+	suite.Equal("USD", usd.Currency)
+	suite.Equal(330.0, usd.TermTotal)
+
+	// The buy requests were converted with add-ons and resolved locations.
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	portBody := suite.bodies["MEGAPORT"][0]
+	suite.Equal("AUD", portBody["currency"])
+	suite.Equal([]interface{}{map[string]interface{}{"addOnType": "CROSS_CONNECT", "crossConnectRequested": true}}, portBody["addOns"])
+	suite.Equal([]interface{}{map[string]interface{}{"addOnType": "IP_SEC", "tunnelCount": float64(10)}}, suite.bodies["MCR2"][0]["addOns"])
+	suite.Equal("MEDIUM", suite.bodies["MVE"][0]["size"])
+	suite.Equal(float64(10), suite.bodies["IX"][0]["portLocationId"])
+	suite.Equal(float64(1000), suite.bodies["NAT_GATEWAY"][0]["sessionCount"])
+
+	vxcs := make(map[float64]map[string]interface{})
+	for _, b := range suite.bodies["VXC"] {
+		vxcs[b["speed"].(float64)] = b
+	}
+	suite.Equal(float64(30), vxcs[500]["bLocationId"])
+	suite.Equal("AWS", vxcs[500]["connectType"])
+	suite.Equal(float64(20), vxcs[100]["bLocationId"])
+	suite.Equal(float64(10), vxcs[100]["aLocationId"])
+}
+
+func (suite *CostEstimateTestSuite) TestEstimateOrderCostPlaceholderEnds() {
+	ctx := context.Background()
+	_, err := suite.client.ProductService.EstimateOrderCost(ctx, &OrderCostEstimateRequest{
+		VXCs: []*BuyVXCRequest{{
+			VXCName:           "MCR to AWS",
+			RateLimit:         200,
+			AEndConfiguration: VXCOrderEndpointConfiguration{ProductUID: "new-mcr", PartnerConfig: &VXCOrderVrouterPartnerConfig{}},
+			BEndConfiguration: VXCOrderEndpointConfiguration{ProductUID: "new-hc", PartnerConfig: &VXCPartnerConfigAWS{ConnectType: CONNECT_TYPE_AWS_HOSTED_CONNECTION}},
+		}},
+		Locations: map[string]int{"new-mcr": 10, "new-hc": 30},
+	})
+	suite.Require().NoError(err)
+
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	suite.Require().Len(suite.bodies["VXC"], 1)
+	body := suite.bodies["VXC"][0]
+	suite.Equal("MCR2", body["aEndProductType"])
+	suite.Equal("AWSHC", body["connectType"])
+	suite.Equal(float64(10), body["aLocationId"])
+	suite.Equal(float64(30), body["bLocationId"])
+}
+
+func (suite *CostEstimateTestSuite) TestEstimateOrderCostPlaceholderQinQPort() {
+	ctx := context.Background()
+	aEnd := VXCOrderEndpointConfiguration{ProductUID: "new-port"}
+	(&VLANReservation{VLAN: 100, InnerVLAN: 200}).ApplyTo(&aEnd)
+	_, err := suite.client.ProductService.EstimateOrderCost(ctx, &OrderCostEstimateRequest{
+		VXCs: []*BuyVXCRequest{{
+			VXCName:           "QinQ to MVE",
+			RateLimit:         200,
+			AEndConfiguration: aEnd,
+			BEndConfiguration: VXCOrderEndpointConfiguration{ProductUID: "new-mve", VXCOrderMVEConfig: &VXCOrderMVEConfig{NetworkInterfaceIndex: 1}},
+		}},
+		Locations: map[string]int{"new-port": 10, "new-mve": 20},
+	})
+	suite.Require().NoError(err)
+
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	suite.Require().Len(suite.bodies["VXC"], 1)
+	body := suite.bodies["VXC"][0]
+	suite.NotContains(body, "aEndProductType", "a QinQ port end is not an MVE")
+}
+
+func TestMVESizeAndLabel(t *testing.T) {
+	t.Parallel()
+	size, label := mveSizeAndLabel(&FortinetConfig{ProductSize: "LARGE", MVELabel: "MVE 8/32"})
+	if size != "LARGE" || label != "MVE 8/32" {
+		t.Fatalf("got %q, %q", size, label)
+	}
+	if size, label := mveSizeAndLabel(nil); size != "" || label != "" {
+		t.Fatalf("nil config got %q, %q", size, label)
+	}
+}
+
+func (suite *CostEstimateTestSuite) TestEstimateOrderCostErrors() {
+	ctx := context.Background()
+	svc := suite.client.ProductService
+
+	_, err := svc.EstimateOrderCost(ctx, nil)
+	suite.ErrorIs(err, ErrCostEstimateRequestNil)
+	_, err = svc.EstimateOrderCost(ctx, &OrderCostEstimateRequest{})
+	suite.ErrorIs(err, ErrCostEstimateEmpty)
+	_, err = svc.EstimateOrderCost(ctx, &OrderCostEstimateRequest{VXCs: []*BuyVXCRequest{{PortUID: "a"}}})
+	suite.ErrorIs(err, ErrCostEstimateEndpointRequired)
+	_, err = svc.EstimateOrderCost(ctx, &OrderCostEstimateRequest{Items: []*CostEstimateItem{{Name: "empty"}}})
+	suite.ErrorIs(err, ErrPricingRequestNil)
+
+	// Every failing item is reported, not just the first.
+	_, err = svc.EstimateOrderCost(ctx, &OrderCostEstimateRequest{
+		Ports: []*BuyPortRequest{{Name: "ok", LocationId: 1, PortSpeed: 1000}, {Name: "bad A", LocationId: 1, PortSpeed: 999}},
+		MCRs:  []*BuyMCRRequest{{Name: "bad B", LocationID: 1, PortSpeed: 999}},
+	})
+	suite.Require().Error(err)
+	suite.Contains(err.Error(), "pricing bad A")
+	suite.Contains(err.Error(), "pricing bad B")
+	suite.NotContains(err.Error(), "pricing ok")
+}
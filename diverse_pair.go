@@ -0,0 +1,467 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Diverse pair planner errors.
+var (
+	ErrDiversePairSpecNil           = errors.New("diverse pair spec is required")
+	ErrDiversePairProductInvalid    = errors.New("diverse pairs can only be planned for PORT, MCR or MVE")
+	ErrDiversePairSpeedRequired     = errors.New("diverse port and MCR pairs require a speed")
+	ErrDiversePairUnavailable       = errors.New("location cannot host a diverse pair for the requested spec")
+	ErrDiverseEndpointsInvalid      = errors.New("diverse VXCs require A-End product UIDs in distinct diversity zones")
+	ErrDiversePartnerUnavailable    = errors.New("no VXC-permitted partner port in the matching diversity zone")
+	ErrDiverseDeploymentTooFew      = errors.New("diversity validation requires at least two products")
+	ErrDiverseDeploymentUnsupported = errors.New("diversity validation supports ports, MCRs and MVEs only")
+)
+
+// DiversePairSpec describes a redundant pair to place in both diversity
+// zones of one location.
+type DiversePairSpec struct {
+	LocationID int
+	// Product is LocationProductPort (the default), LocationProductMCR or
+	// LocationProductMVE.
+	Product LocationProductKind
+	// SpeedMbps is the port or MCR speed, which must be offered in both zones.
+	SpeedMbps int
+	// MVECores is the minimum MVE CPU core count needed in both zones.
+	MVECores int
+}
+
+// DiversePair is a location whose red and blue zones can both host the
+// requested product.
+type DiversePair struct {
+	Location *LocationV3
+	Product  LocationProductKind
+	// Zones is always {DiversityZoneRed, DiversityZoneBlue}; the first zone is
+	// conventionally the primary.
+	Zones     [2]string
+	SpeedMbps int
+}
+
+// BuyPortRequests returns one request per zone, copied from base with the
+// location, speed and diversity zone filled in. The zone is appended to the
+// name.
+func (p *DiversePair) BuyPortRequests(base BuyPortRequest) []*BuyPortRequest {
+	reqs := make([]*BuyPortRequest, 0, len(p.Zones))
+	for _, zone := range p.Zones {
+		r := base
+		r.LocationId = p.Location.ID
+		r.PortSpeed = p.SpeedMbps
+		r.DiversityZone = zone
+		r.Name = diverseName(base.Name, zone)
+		reqs = append(reqs, &r)
+	}
+	return reqs
+}
+
+// BuyMCRRequests returns one MCR request per zone, as BuyPortRequests.
+func (p *DiversePair) BuyMCRRequests(base BuyMCRRequest) []*BuyMCRRequest {
+	reqs := make([]*BuyMCRRequest, 0, len(p.Zones))
+	for _, zone := range p.Zones {
+		r := base
+		r.LocationID = p.Location.ID
+		r.PortSpeed = p.SpeedMbps
+		r.DiversityZone = zone
+		r.Name = diverseName(base.Name, zone)
+		reqs = append(reqs, &r)
+	}
+	return reqs
+}
+
+// BuyMVERequests returns one MVE request per zone, as BuyPortRequests.
+func (p *DiversePair) BuyMVERequests(base BuyMVERequest) []*BuyMVERequest {
+	reqs := make([]*BuyMVERequest, 0, len(p.Zones))
+	for _, zone := range p.Zones {
+		r := base
+		r.LocationID = p.Location.ID
+		r.DiversityZone = zone
+		r.Name = diverseName(base.Name, zone)
+		reqs = append(reqs, &r)
+	}
+	return reqs
+}
+
+func diverseName(name, zone string) string {
+	if name == "" {
+		return zone
+	}
+	return name + " (" + zone + ")"
+}
+
+// PlanDiversePair checks that both diversity zones of the location can host
+// the requested product and returns the pair. The returned error wraps
+// ErrDiversePairUnavailable and names the zones that fall short.
+func (svc *LocationServiceOp) PlanDiversePair(ctx context.Context, spec *DiversePairSpec) (*DiversePair, error) {
+	if spec == nil {
+		return nil, ErrDiversePairSpecNil
+	}
+	product := spec.Product
+	if product == "" {
+		product = LocationProductPort
+	}
+	switch product {
+	case LocationProductPort, LocationProductMCR:
+		if spec.SpeedMbps < 1 {
+			return nil, ErrDiversePairSpeedRequired
+		}
+	case LocationProductMVE:
+	default:
+		return nil, ErrDiversePairProductInvalid
+	}
+
+	location, err := svc.GetLocationByIDV3(ctx, spec.LocationID)
+	if err != nil {
+		return nil, err
+	}
+	if !location.IsStatusOrderable() {
+		return nil, fmt.Errorf("%w: location %d is %s", ErrDiversePairUnavailable, location.ID, location.Status)
+	}
+
+	var missing []string
+	for _, zone := range []string{DiversityZoneRed, DiversityZoneBlue} {
+		if !diverseZoneSupports(location.DiversityZones.Zone(zone), product, spec) {
+			missing = append(missing, zone)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s not available in %s zone at location %d",
+			ErrDiversePairUnavailable, product, strings.Join(missing, " and "), location.ID)
+	}
+	return &DiversePair{
+		Location:  location,
+		Product:   product,
+		Zones:     [2]string{DiversityZoneRed, DiversityZoneBlue},
+		SpeedMbps: spec.SpeedMbps,
+	}, nil
+}
+
+// diverseZoneSupports reports whether a single zone can host the product.
+func diverseZoneSupports(z *LocationV3DiversityZone, product LocationProductKind, spec *DiversePairSpec) bool {
+	if z == nil {
+		return false
+	}
+	switch product {
+	case LocationProductPort:
+		return slices.Contains(z.MegaportSpeedMbps, spec.SpeedMbps)
+	case LocationProductMCR:
+		return slices.Contains(z.McrSpeedMbps, spec.SpeedMbps)
+	case LocationProductMVE:
+		if !z.MveAvailable {
+			return false
+		}
+		return spec.MVECores < 1 || z.MveMaxCpuCoreCount != nil && *z.MveMaxCpuCoreCount >= spec.MVECores
+	}
+	return false
+}
+
+// DiverseEndpoint is an A-End product (port, MCR or MVE) and the diversity
+// zone it was ordered in.
+type DiverseEndpoint struct {
+	ProductUID    string
+	DiversityZone string
+}
+
+// DiverseVXCLeg pairs an A-End with a partner port in the same diversity
+// zone.
+type DiverseVXCLeg struct {
+	AEnd    *DiverseEndpoint
+	Partner *PartnerMegaport
+}
+
+// BuyVXCRequest returns a copy of base connecting the leg's A-End to its
+// partner port, with the zone appended to the VXC name.
+func (l *DiverseVXCLeg) BuyVXCRequest(base BuyVXCRequest) *BuyVXCRequest {
+	r := base
+	r.PortUID = l.AEnd.ProductUID
+	r.VXCName = diverseName(base.VXCName, l.AEnd.DiversityZone)
+	r.AEndConfiguration.ProductUID = l.AEnd.ProductUID
+	r.BEndConfiguration.ProductUID = l.Partner.ProductUID
+	return &r
+}
+
+// PairDiverseVXCs matches each A-End with a VXC-permitted partner port in the
+// same diversity zone, so that a failure in one zone leaves the other leg
+// intact. partners is typically ListPartnerMegaports filtered to a single
+// provider and location. Ties are broken by partner rank, then product UID.
+func PairDiverseVXCs(aEnds []*DiverseEndpoint, partners []*PartnerMegaport) ([]*DiverseVXCLeg, error) {
+	zones := make(map[string]bool)
+	for _, e := range aEnds {
+		if e == nil || e.ProductUID == "" || e.DiversityZone == "" || zones[strings.ToLower(e.DiversityZone)] {
+			return nil, ErrDiverseEndpointsInvalid
+		}
+		zones[strings.ToLower(e.DiversityZone)] = true
+	}
+	if len(aEnds) < 2 {
+		return nil, ErrDiverseEndpointsInvalid
+	}
+
+	candidates := make([]*PartnerMegaport, 0, len(partners))
+	for _, p := range partners {
+		if p != nil && p.VXCPermitted {
+			candidates = append(candidates, p)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Rank != candidates[j].Rank {
+			return candidates[i].Rank < candidates[j].Rank
+		}
+		return candidates[i].ProductUID < candidates[j].ProductUID
+	})
+
+	legs := make([]*DiverseVXCLeg, 0, len(aEnds))
+	for _, e := range aEnds {
+		var match *PartnerMegaport
+		for _, p := range candidates {
+			if strings.EqualFold(p.DiversityZone, e.DiversityZone) {
+				match = p
+				break
+			}
+		}
+		if match == nil {
+			return nil, fmt.Errorf("%w: %s", ErrDiversePartnerUnavailable, e.DiversityZone)
+		}
+		legs = append(legs, &DiverseVXCLeg{AEnd: e, Partner: match})
+	}
+	return legs, nil
+}
+
+// DiversitySeverity grades a DiversityFinding.
+type DiversitySeverity string
+
+const (
+	// DiversitySeveritySPOF marks a single point of failure.
+	DiversitySeveritySPOF DiversitySeverity = "SPOF"
+	// DiversitySeverityWarning marks a configuration that weakens diversity
+	// without removing it.
+	DiversitySeverityWarning DiversitySeverity = "WARNING"
+)
+
+// DiversityFindingCode identifies the kind of DiversityFinding.
+type DiversityFindingCode string
+
+const (
+	DiversityFindingSameZone         DiversityFindingCode = "SAME_ZONE"
+	DiversityFindingUnknownZone      DiversityFindingCode = "UNKNOWN_ZONE"
+	DiversityFindingUnprotectedVXC   DiversityFindingCode = "UNPROTECTED_VXC"
+	DiversityFindingSharedBEnd       DiversityFindingCode = "SHARED_B_END"
+	DiversityFindingBEndSameZone     DiversityFindingCode = "B_END_SAME_ZONE"
+	DiversityFindingBEndZoneMismatch DiversityFindingCode = "B_END_ZONE_MISMATCH"
+)
+
+// DiversityFinding is a single issue found by ValidateDiverseDeployment.
+type DiversityFinding struct {
+	Severity DiversitySeverity
+	Code     DiversityFindingCode
+	Message  string
+	// ProductUIDs are the products or VXCs involved.
+	ProductUIDs []string
+}
+
+// DiverseProduct is a port, MCR or MVE examined by ValidateDiverseDeployment.
+type DiverseProduct struct {
+	UID           string
+	Name          string
+	Type          string
+	LocationID    int
+	DiversityZone string
+	VXCs          []*VXC
+}
+
+// DiversityReport is the result of ValidateDiverseDeployment.
+type DiversityReport struct {
+	Products []*DiverseProduct
+	Findings []*DiversityFinding
+}
+
+// HasSinglePointOfFailure reports whether any finding is a SPOF.
+func (r *DiversityReport) HasSinglePointOfFailure() bool {
+	for _, f := range r.Findings {
+		if f.Severity == DiversitySeveritySPOF {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateDiverseDeployment examines a set of products meant to back each
+// other up (ports, MCRs or MVEs) and their VXCs for single points of
+// failure: products sharing a location and zone, destinations reached from
+// only one product, VXCs landing on the same B-End, and partner B-Ends in
+// the same zone or in a different zone from their A-End.
+//
+// VXC destinations are grouped by partner company for partner B-Ends and by
+// B-End product UID otherwise.
+func (svc *ProductServiceOp) ValidateDiverseDeployment(ctx context.Context, productUIDs []string) (*DiversityReport, error) {
+	if len(productUIDs) < 2 {
+		return nil, ErrDiverseDeploymentTooFew
+	}
+	report := &DiversityReport{}
+	for _, uid := range productUIDs {
+		p, err := svc.diverseProduct(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		report.Products = append(report.Products, p)
+	}
+	partners, err := svc.Client.PartnerService.ListPartnerMegaports(ctx)
+	if err != nil {
+		return nil, err
+	}
+	partnerByUID := make(map[string]*PartnerMegaport, len(partners))
+	for _, p := range partners {
+		partnerByUID[p.ProductUID] = p
+	}
+
+	report.checkProductZones()
+	report.checkVXCs(partnerByUID)
+	return report, nil
+}
+
+// diverseProduct fetches a port, MCR or MVE by UID.
+func (svc *ProductServiceOp) diverseProduct(ctx context.Context, uid string) (*DiverseProduct, error) {
+	productType, err := svc.GetProductType(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	p := &DiverseProduct{UID: uid, Type: productType}
+	switch strings.ToLower(productType) {
+	case PRODUCT_MEGAPORT:
+		port, err := svc.Client.PortService.GetPort(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		p.Name, p.LocationID, p.DiversityZone, p.VXCs = port.Name, port.LocationID, port.DiversityZone, port.AssociatedVXCs
+	case PRODUCT_MCR:
+		mcr, err := svc.Client.MCRService.GetMCR(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		p.Name, p.LocationID, p.DiversityZone, p.VXCs = mcr.Name, mcr.LocationID, mcr.DiversityZone, mcr.AssociatedVXCs
+	case PRODUCT_MVE:
+		mve, err := svc.Client.MVEService.GetMVE(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		p.Name, p.LocationID, p.DiversityZone, p.VXCs = mve.Name, mve.LocationID, mve.DiversityZone, mve.AssociatedVXCs
+	default:
+		return nil, fmt.Errorf("%w: %s is %s", ErrDiverseDeploymentUnsupported, uid, productType)
+	}
+	return p, nil
+}
+
+func (r *DiversityReport) add(severity DiversitySeverity, code DiversityFindingCode, uids []string, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, &DiversityFinding{
+		Severity:    severity,
+		Code:        code,
+		Message:     fmt.Sprintf(format, args...),
+		ProductUIDs: uids,
+	})
+}
+
+// checkProductZones flags products without a zone and pairs of products in
+// the same zone of the same location.
+func (r *DiversityReport) checkProductZones() {
+	for i, a := range r.Products {
+		if a.DiversityZone == "" {
+			r.add(DiversitySeverityWarning, DiversityFindingUnknownZone, []string{a.UID},
+				"%s has no diversity zone", a.Name)
+			continue
+		}
+		for _, b := range r.Products[i+1:] {
+			if a.LocationID == b.LocationID && strings.EqualFold(a.DiversityZone, b.DiversityZone) {
+				r.add(DiversitySeveritySPOF, DiversityFindingSameZone, []string{a.UID, b.UID},
+					"%s and %s are both in the %s zone of location %d", a.Name, b.Name, a.DiversityZone, a.LocationID)
+			}
+		}
+	}
+}
+
+// diverseVXCLeg is one VXC from a product in the deployment.
+type diverseVXCLeg struct {
+	product *DiverseProduct
+	vxc     *VXC
+	bEnd    VXCEndConfiguration
+	partner *PartnerMegaport
+}
+
+// checkVXCs groups the products' VXCs by destination and flags destinations
+// without redundancy.
+func (r *DiversityReport) checkVXCs(partners map[string]*PartnerMegaport) {
+	legs := make(map[string][]*diverseVXCLeg)
+	var destinations []string
+	for _, p := range r.Products {
+		for _, v := range p.VXCs {
+			if v == nil {
+				continue
+			}
+			leg := &diverseVXCLeg{product: p, vxc: v, bEnd: v.BEndConfiguration}
+			if leg.bEnd.UID == p.UID {
+				leg.bEnd = v.AEndConfiguration
+			}
+			dest := leg.bEnd.UID
+			if partner, ok := partners[leg.bEnd.UID]; ok {
+				leg.partner = partner
+				dest = partner.CompanyName
+			}
+			if _, ok := legs[dest]; !ok {
+				destinations = append(destinations, dest)
+			}
+			legs[dest] = append(legs[dest], leg)
+		}
+	}
+
+	for _, dest := range destinations {
+		group := legs[dest]
+		uids := make([]string, 0, len(group))
+		products := make(map[string]bool)
+		bEnds := make(map[string]bool)
+		for _, leg := range group {
+			uids = append(uids, leg.vxc.UID)
+			products[leg.product.UID] = true
+			bEnds[leg.bEnd.UID] = true
+			if leg.partner != nil && leg.product.DiversityZone != "" && leg.partner.DiversityZone != "" &&
+				!strings.EqualFold(leg.partner.DiversityZone, leg.product.DiversityZone) {
+				r.add(DiversitySeverityWarning, DiversityFindingBEndZoneMismatch, []string{leg.vxc.UID},
+					"VXC %s connects %s zone %s to a partner port in zone %s", leg.vxc.Name, leg.product.Name, leg.product.DiversityZone, leg.partner.DiversityZone)
+			}
+		}
+
+		if len(products) < 2 {
+			r.add(DiversitySeveritySPOF, DiversityFindingUnprotectedVXC, uids,
+				"%s is only reached from %s", dest, group[0].product.Name)
+			continue
+		}
+		if len(bEnds) < 2 {
+			r.add(DiversitySeveritySPOF, DiversityFindingSharedBEnd, uids,
+				"every VXC to %s terminates on B-End %s", dest, group[0].bEnd.UID)
+			continue
+		}
+		if sameZonePartners(group) {
+			r.add(DiversitySeveritySPOF, DiversityFindingBEndSameZone, uids,
+				"every VXC to %s terminates in the %s zone of location %d", dest, group[0].partner.DiversityZone, group[0].partner.LocationId)
+		}
+	}
+}
+
+// sameZonePartners reports whether every leg lands on a partner port in one
+// location and zone.
+func sameZonePartners(group []*diverseVXCLeg) bool {
+	first := group[0].partner
+	if first == nil || first.DiversityZone == "" {
+		return false
+	}
+	for _, leg := range group[1:] {
+		p := leg.partner
+		if p == nil || p.LocationId != first.LocationId || !strings.EqualFold(p.DiversityZone, first.DiversityZone) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// diverseLocationsJSON holds location 1, which supports 10G ports and 8-core
+// MVEs in both zones but 5G MCRs only in red, and location 2, which has only
+// a red zone.
+const diverseLocationsJSON = `{"message": "List public locations", "data": [
+    {"id": 1, "name": "Sydney", "status": "Active", "diversityZones": {
+        "red": {"megaportSpeedMbps": [1000, 10000], "mcrSpeedMbps": [1000, 5000], "mveAvailable": true, "mveMaxCpuCoreCount": 8},
+        "blue": {"megaportSpeedMbps": [1000, 10000], "mcrSpeedMbps": [1000], "mveAvailable": true, "mveMaxCpuCoreCount": 8}
+    }},
+    {"id": 2, "name": "Melbourne", "status": "Active", "diversityZones": {
+        "red": {"megaportSpeedMbps": [1000, 10000]}
+    }}
+]}`
+
+const diversePartnersJSON = `{"message": "Partner ports", "data": [
+    {"productUid": "aws-1", "companyName": "AWS", "diversityZone": "red", "locationId": 1, "vxcPermitted": true},
+    {"productUid": "az-1", "companyName": "Azure", "diversityZone": "red", "locationId": 1, "vxcPermitted": true},
+    {"productUid": "az-2", "companyName": "Azure", "diversityZone": "blue", "locationId": 1, "vxcPermitted": true},
+    {"productUid": "gcp-1", "companyName": "GCP", "diversityZone": "red", "locationId": 1, "vxcPermitted": true}
+]}`
+
+// diverseProducts maps product UIDs to the GET /v2/product/{uid} payload.
+var diverseProducts = map[string]string{
+	"port-red": `{"productUid": "port-red", "productName": "Port Red", "productType": "MEGAPORT", "locationId": 1, "diversityZone": "red",
+        "associatedVxcs": [
+            {"productUid": "vxc-aws-1", "productName": "AWS 1", "aEnd": {"productUid": "port-red"}, "bEnd": {"productUid": "aws-1"}},
+            {"productUid": "vxc-az-1", "productName": "Azure 1", "aEnd": {"productUid": "port-red"}, "bEnd": {"productUid": "az-1"}},
+            {"productUid": "vxc-gcp-1", "productName": "GCP 1", "aEnd": {"productUid": "port-red"}, "bEnd": {"productUid": "gcp-1"}}
+        ]}`,
+	"port-blue": `{"productUid": "port-blue", "productName": "Port Blue", "productType": "MEGAPORT", "locationId": 1, "diversityZone": "blue",
+        "associatedVxcs": [
+            {"productUid": "vxc-aws-2", "productName": "AWS 2", "aEnd": {"productUid": "port-blue"}, "bEnd": {"productUid": "aws-1"}},
+            {"productUid": "vxc-az-2", "productName": "Azure 2", "aEnd": {"productUid": "port-blue"}, "bEnd": {"productUid": "az-2"}}
+        ]}`,
+	"mcr-red":   `{"productUid": "mcr-red", "productName": "MCR Red", "productType": "MCR2", "locationId": 1, "diversityZone": "red"}`,
+	"vxc-aws-1": `{"productUid": "vxc-aws-1", "productName": "AWS 1", "productType": "VXC"}`,
+}
+
+// DiversePairTestSuite tests the diverse pair planner and validator.
+type DiversePairTestSuite struct {
+	ClientTestSuite
+}
+
+func TestDiversePairTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(DiversePairTestSuite))
+}
+
+func (suite *DiversePairTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+
+	suite.mux.HandleFunc("/v3/locations", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, diverseLocationsJSON)
+	})
+	suite.mux.HandleFunc("/v2/dropdowns/partner/megaports", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, diversePartnersJSON)
+	})
+	suite.mux.HandleFunc("/v2/product/", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		data, ok := diverseProducts[strings.TrimPrefix(r.URL.Path, "/v2/product/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"message": "Product", "data": %s}`, data)
+	})
+}
+
+func (suite *DiversePairTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *DiversePairTestSuite) TestPlanDiversePair() {
+	ctx := context.Background()
+	svc := suite.client.LocationService
+
+	pair, err := svc.PlanDiversePair(ctx, &DiversePairSpec{LocationID: 1, SpeedMbps: 10000})
+	suite.Require().NoError(err)
+	suite.Equal(LocationProductPort, pair.Product)
+	reqs := pair.BuyPortRequests(BuyPortRequest{Name: "Core", Term: 12})
+	suite.Require().Len(reqs, 2)
+	suite.Equal("Core (red)", reqs[0].Name)
+	suite.Equal(DiversityZoneBlue, reqs[1].DiversityZone)
+	suite.Equal(10000, reqs[1].PortSpeed)
+	suite.Equal(1, reqs[1].LocationId)
+	suite.Equal(12, reqs[1].Term)
+
+	pair, err = svc.PlanDiversePair(ctx, &DiversePairSpec{LocationID: 1, Product: LocationProductMVE, MVECores: 8})
+	suite.Require().NoError(err)
+	mves := pair.BuyMVERequests(BuyMVERequest{})
+	suite.Equal("red", mves[0].Name)
+	suite.Equal(DiversityZoneBlue, mves[1].DiversityZone)
+
+	tests := []struct {
+		name    string
+		spec    *DiversePairSpec
+		wantErr error
+		wantMsg string
+	}{
+		{"nil", nil, ErrDiversePairSpecNil, ""},
+		{"no speed", &DiversePairSpec{LocationID: 1, Product: LocationProductMCR}, ErrDiversePairSpeedRequired, ""},
+		{"cross connect", &DiversePairSpec{LocationID: 1, Product: LocationProductCrossConnect}, ErrDiversePairProductInvalid, ""},
+		{"MCR speed in red only", &DiversePairSpec{LocationID: 1, Product: LocationProductMCR, SpeedMbps: 5000}, ErrDiversePairUnavailable, "blue zone"},
+		{"too many MVE cores", &DiversePairSpec{LocationID: 1, Product: LocationProductMVE, MVECores: 16}, ErrDiversePairUnavailable, "red and blue"},
+		{"single zone location", &DiversePairSpec{LocationID: 2, SpeedMbps: 1000}, ErrDiversePairUnavailable, "blue zone"},
+		{"unknown location", &DiversePairSpec{LocationID: 9, SpeedMbps: 1000}, ErrLocationNotFound, ""},
+	}
+	for _, tc := range tests {
+		suite.Run(tc.name, func() {
+			_, err := svc.PlanDiversePair(ctx, tc.spec)
+			suite.True(errors.Is(err, tc.wantErr), "got %v", err)
+			suite.Contains(fmt.Sprint(err), tc.wantMsg)
+		})
+	}
+}
+
+func (suite *DiversePairTestSuite) TestValidateDiverseDeployment() {
+	ctx := context.Background()
+	report, err := suite.client.ProductService.ValidateDiverseDeployment(ctx, []string{"port-red", "port-blue"})
+	suite.Require().NoError(err)
+	suite.Len(report.Products, 2)
+	suite.True(report.HasSinglePointOfFailure())
+
+	got := make(map[DiversityFindingCode][]string)
+	for _, f := range report.Findings {
+		got[f.Code] = f.ProductUIDs
+	}
+	suite.Equal(map[DiversityFindingCode][]string{
+		DiversityFindingBEndZoneMismatch: {"vxc-aws-2"},
+		DiversityFindingSharedBEnd:       {"vxc-aws-1", "vxc-aws-2"},
+		DiversityFindingUnprotectedVXC:   {"vxc-gcp-1"},
+	}, got)
+
+	report, err = suite.client.ProductService.ValidateDiverseDeployment(ctx, []string{"port-red", "mcr-red"})
+	suite.Require().NoError(err)
+	suite.Equal(DiversityFindingSameZone, report.Findings[0].Code)
+	suite.Equal(DiversitySeveritySPOF, report.Findings[0].Severity)
+
+	_, err = suite.client.ProductService.ValidateDiverseDeployment(ctx, []string{"port-red"})
+	suite.ErrorIs(err, ErrDiverseDeploymentTooFew)
+	_, err = suite.client.ProductService.ValidateDiverseDeployment(ctx, []string{"port-red", "vxc-aws-1"})
+	suite.ErrorIs(err, ErrDiverseDeploymentUnsupported)
+}
+
+func TestPairDiverseVXCs(t *testing.T) {
+	t.Parallel()
+	partners := []*PartnerMegaport{
+		{ProductUID: "aws-red-2", DiversityZone: "red", Rank: 2, VXCPermitted: true},
+		{ProductUID: "aws-red-1", DiversityZone: "red", Rank: 1, VXCPermitted: true},
+		{ProductUID: "aws-blue-closed", DiversityZone: "blue", Rank: 0, VXCPermitted: false},
+		{ProductUID: "aws-blue", DiversityZone: "Blue", Rank: 3, VXCPermitted: true},
+	}
+	red := &DiverseEndpoint{ProductUID: "mcr-red", DiversityZone: DiversityZoneRed}
+	blue := &DiverseEndpoint{ProductUID: "mcr-blue", DiversityZone: DiversityZoneBlue}
+
+	legs, err := PairDiverseVXCs([]*DiverseEndpoint{red, blue}, partners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legs[0].Partner.ProductUID != "aws-red-1" || legs[1].Partner.ProductUID != "aws-blue" {
+		t.Fatalf("legs = %s, %s", legs[0].Partner.ProductUID, legs[1].Partner.ProductUID)
+	}
+	req := legs[1].BuyVXCRequest(BuyVXCRequest{VXCName: "AWS", RateLimit: 500})
+	if req.PortUID != "mcr-blue" || req.VXCName != "AWS (blue)" || req.BEndConfiguration.ProductUID != "aws-blue" || req.RateLimit != 500 {
+		t.Fatalf("request = %+v", req)
+	}
+
+	tests := []struct {
+		name     string
+		aEnds    []*DiverseEndpoint
+		partners []*PartnerMegaport
+		wantErr  error
+	}{
+		{"single A-End", []*DiverseEndpoint{red}, partners, ErrDiverseEndpointsInvalid},
+		{"same zone", []*DiverseEndpoint{red, {ProductUID: "mcr-2", DiversityZone: "RED"}}, partners, ErrDiverseEndpointsInvalid},
+		{"missing zone", []*DiverseEndpoint{red, {ProductUID: "mcr-2"}}, partners, ErrDiverseEndpointsInvalid},
+		{"no blue partner", []*DiverseEndpoint{red, blue}, partners[:3], ErrDiversePartnerUnavailable},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := PairDiverseVXCs(tc.aEnds, tc.partners); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -23,6 +23,9 @@ type IXService interface {
 	// ValidateIXOrder validates an Internet Exchange order without submitting it
 	ValidateIXOrder(ctx context.Context, req *BuyIXRequest) error
 
+	// CheckIXOrder checks an Internet Exchange order against its port and the IXPs at the port's location
+	CheckIXOrder(ctx context.Context, req *BuyIXRequest) error
+
 	// UpdateIX updates an existing Internet Exchange
 	UpdateIX(ctx context.Context, id string, req *UpdateIXRequest) (*IX, error)
 
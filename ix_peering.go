@@ -0,0 +1,229 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// IX peering validation errors.
+var (
+	// ErrIXOrderInvalid is returned for an IX order with a missing or
+	// out-of-range field.
+	ErrIXOrderInvalid = errors.New("invalid IX order")
+	// ErrIXMACAddressInvalid is returned for a MAC address that is not a
+	// 48-bit unicast address.
+	ErrIXMACAddressInvalid = errors.New("IX MAC address must be a 48-bit unicast address")
+	// ErrIXASNInvalid is returned for an ASN that is reserved or out of range.
+	ErrIXASNInvalid = errors.New("invalid IX ASN")
+	// ErrIXRateLimitExceedsPort is returned for a rate limit above the port speed.
+	ErrIXRateLimitExceedsPort = errors.New("IX rate limit exceeds the port speed")
+	// ErrIXNetworkServiceTypeUnavailable is returned when no IXP at the
+	// port's metro has the requested network service type.
+	ErrIXNetworkServiceTypeUnavailable = errors.New("IX network service type is not available at the port's location")
+	// ErrIXNotProvisioned is returned when an IX has no BGP connections or
+	// IP addresses to summarize yet.
+	ErrIXNotProvisioned = errors.New("IX has no provisioned peering resources")
+)
+
+// Validate checks the order's name, network service type, ASN, MAC address
+// and VLAN, and that the rate limit is positive and, when portSpeed is not
+// zero, at most portSpeed. All problems are returned joined.
+func (o *AssociatedIXOrder) Validate(portSpeed int) error {
+	if o == nil {
+		return ErrBuyIXRequestNil
+	}
+	var errs []error
+	if o.ProductName == "" {
+		errs = append(errs, fmt.Errorf("%w: name is required", ErrIXOrderInvalid))
+	}
+	if o.NetworkServiceType == "" {
+		errs = append(errs, fmt.Errorf("%w: network service type is required", ErrIXOrderInvalid))
+	}
+	if !validPeerASN(int64(o.ASN)) {
+		errs = append(errs, fmt.Errorf("%w: %d", ErrIXASNInvalid, o.ASN))
+	}
+	if err := validateIXMACAddress(o.MACAddress); err != nil {
+		errs = append(errs, err)
+	}
+	switch {
+	case o.RateLimit <= 0:
+		errs = append(errs, fmt.Errorf("%w: rate limit must be positive", ErrIXOrderInvalid))
+	case portSpeed > 0 && o.RateLimit > portSpeed:
+		errs = append(errs, fmt.Errorf("%w: %d Mbps on a %d Mbps port", ErrIXRateLimitExceedsPort, o.RateLimit, portSpeed))
+	}
+	if o.VLAN != VLAN_AUTO_ASSIGN && o.VLAN != VLAN_UNTAGGED && (o.VLAN < VLAN_MIN || o.VLAN > VLAN_MAX) {
+		errs = append(errs, fmt.Errorf("%w: VLAN %d is outside %d-%d", ErrIXOrderInvalid, o.VLAN, VLAN_MIN, VLAN_MAX))
+	}
+	return errors.Join(errs...)
+}
+
+func validateIXMACAddress(s string) error {
+	mac, err := net.ParseMAC(s)
+	if err != nil || len(mac) != 6 {
+		return fmt.Errorf("%w: %q", ErrIXMACAddressInvalid, s)
+	}
+	if mac[0]&1 != 0 || mac.String() == "00:00:00:00:00:00" {
+		return fmt.Errorf("%w: %s is a multicast, broadcast or zero address", ErrIXMACAddressInvalid, mac)
+	}
+	return nil
+}
+
+// CheckIXOrder validates an IX order against the port it attaches to: the
+// checks of AssociatedIXOrder.Validate with the port's speed, and that an
+// IXP in the port's metro offers the network service type. Unlike
+// ValidateIXOrder it does not send the order to the API.
+func (svc *IXServiceOp) CheckIXOrder(ctx context.Context, req *BuyIXRequest) error {
+	if req == nil {
+		return ErrBuyIXRequestNil
+	}
+	port, err := svc.Client.PortService.GetPort(ctx, req.ProductUID)
+	if err != nil {
+		return err
+	}
+	order := ConvertBuyIXRequestToIXOrder(*req)[0].AssociatedIXs[0]
+	errs := []error{order.Validate(port.PortSpeed)}
+	if req.NetworkServiceType != "" {
+		if err := svc.checkNetworkServiceType(ctx, port.LocationID, req.NetworkServiceType); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (svc *IXServiceOp) checkNetworkServiceType(ctx context.Context, locationID int, networkServiceType string) error {
+	location, err := svc.Client.LocationService.GetLocationByIDV3(ctx, locationID)
+	if err != nil {
+		return err
+	}
+	ixps, err := svc.ListIXPs(ctx, &ListIXPsRequest{Metro: location.Metro})
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, ixp := range ixps {
+		if strings.EqualFold(ixp.Name, networkServiceType) {
+			return nil
+		}
+		names = append(names, ixp.Name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("%w: %q at %s, available: %s", ErrIXNetworkServiceTypeUnavailable, networkServiceType, location.Metro, strings.Join(names, ", "))
+}
+
+// IXRouteServerSession is a BGP session to an IX route server.
+type IXRouteServerSession struct {
+	Name string
+	// Version is 4 or 6.
+	Version int
+	// LocalAddress is the customer address in CIDR form.
+	LocalAddress string
+	LocalASN     int
+	// PeerAddress is the route server address.
+	PeerAddress string
+	PeerASN     int
+	PeerPolicy  string
+	// MaxPrefixes is the route server's limit on prefixes received from
+	// the customer.
+	MaxPrefixes int
+}
+
+// IXPeeringSummary is the customer side peering of a provisioned IX.
+type IXPeeringSummary struct {
+	ProductUID         string
+	ProductName        string
+	NetworkServiceType string
+	ASN                int
+	VLAN               int
+	MACAddress         string
+	RateLimit          int
+	// IPv4Address and IPv6Address are the customer addresses in CIDR form,
+	// empty when the IX has none of that version.
+	IPv4Address string
+	IPv6Address string
+	ReverseDNS  map[string]string
+	// RouteServers are ordered by version, then name.
+	RouteServers []*IXRouteServerSession
+}
+
+// PeeringSummary extracts the route server sessions and addresses of a
+// provisioned IX. It returns ErrIXNotProvisioned while the IX has no BGP
+// connections or IP addresses.
+func (ix *IX) PeeringSummary() (*IXPeeringSummary, error) {
+	res := ix.Resources
+	if len(res.BGPConnections) == 0 && len(res.IPAddresses) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrIXNotProvisioned, ix.ProductUID)
+	}
+	s := &IXPeeringSummary{
+		ProductUID:         ix.ProductUID,
+		ProductName:        ix.ProductName,
+		NetworkServiceType: ix.NetworkServiceType,
+		ASN:                ix.ASN,
+		VLAN:               ix.VLAN,
+		MACAddress:         ix.MACAddress,
+		RateLimit:          ix.RateLimit,
+		ReverseDNS:         map[string]string{},
+	}
+	if res.VPLSInterface.VLAN != 0 {
+		s.VLAN = res.VPLSInterface.VLAN
+	}
+	if res.VPLSInterface.MACAddress != "" {
+		s.MACAddress = res.VPLSInterface.MACAddress
+	}
+	for _, a := range res.IPAddresses {
+		switch ixAddressVersion(a.Address, a.Version) {
+		case 4:
+			s.IPv4Address = a.Address
+		case 6:
+			s.IPv6Address = a.Address
+		}
+		if a.ReverseDNS != "" {
+			s.ReverseDNS[a.Address] = a.ReverseDNS
+		}
+	}
+	for _, c := range res.BGPConnections {
+		localASN := c.CustomerASN
+		if localASN == 0 {
+			localASN = c.ASN
+		}
+		s.RouteServers = append(s.RouteServers, &IXRouteServerSession{
+			Name:         c.ResourceName,
+			Version:      ixAddressVersion(c.ISPIPAddress, 0),
+			LocalAddress: c.CustomerIPAddress,
+			LocalASN:     localASN,
+			PeerAddress:  c.ISPIPAddress,
+			PeerASN:      c.ISPASN,
+			PeerPolicy:   c.IXPeerPolicy,
+			MaxPrefixes:  c.MaxPrefixes,
+		})
+	}
+	sort.SliceStable(s.RouteServers, func(i, j int) bool {
+		a, b := s.RouteServers[i], s.RouteServers[j]
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		return a.Name < b.Name
+	})
+	return s, nil
+}
+
+// ixAddressVersion returns the IP version of an address with or without a
+// prefix length, or version when it cannot be parsed.
+func ixAddressVersion(address string, version int) int {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		prefix, perr := netip.ParsePrefix(address)
+		if perr != nil {
+			return version
+		}
+		addr = prefix.Addr()
+	}
+	if addr.Is4() {
+		return 4
+	}
+	return 6
+}
@@ -0,0 +1,163 @@
+package megaport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestAssociatedIXOrderValidate(t *testing.T) {
+	t.Parallel()
+	valid := AssociatedIXOrder{ProductName: "IX", NetworkServiceType: "Sydney IX", ASN: 65000, MACAddress: "00:11:22:33:44:55", RateLimit: 1000, VLAN: 100}
+	with := func(o AssociatedIXOrder, edit func(*AssociatedIXOrder)) AssociatedIXOrder {
+		edit(&o)
+		return o
+	}
+	tests := []struct {
+		name      string
+		order     AssociatedIXOrder
+		portSpeed int
+		wantErr   error
+	}{
+		{"valid", valid, 10000, nil},
+		{"rate limit equals port speed", with(valid, func(o *AssociatedIXOrder) { o.RateLimit = 10000 }), 10000, nil},
+		{"unknown port speed", with(valid, func(o *AssociatedIXOrder) { o.RateLimit = 100000 }), 0, nil},
+		{"untagged", with(valid, func(o *AssociatedIXOrder) { o.VLAN = VLAN_UNTAGGED }), 10000, nil},
+		{"dashed MAC", with(valid, func(o *AssociatedIXOrder) { o.MACAddress = "00-11-22-33-44-55" }), 10000, nil},
+		{"missing name", with(valid, func(o *AssociatedIXOrder) { o.ProductName = "" }), 10000, ErrIXOrderInvalid},
+		{"missing network service type", with(valid, func(o *AssociatedIXOrder) { o.NetworkServiceType = "" }), 10000, ErrIXOrderInvalid},
+		{"bad MAC", with(valid, func(o *AssociatedIXOrder) { o.MACAddress = "00:11:22:33:44" }), 10000, ErrIXMACAddressInvalid},
+		{"EUI-64 MAC", with(valid, func(o *AssociatedIXOrder) { o.MACAddress = "00:11:22:33:44:55:66:77" }), 10000, ErrIXMACAddressInvalid},
+		{"multicast MAC", with(valid, func(o *AssociatedIXOrder) { o.MACAddress = "01:00:5e:00:00:01" }), 10000, ErrIXMACAddressInvalid},
+		{"zero MAC", with(valid, func(o *AssociatedIXOrder) { o.MACAddress = "00:00:00:00:00:00" }), 10000, ErrIXMACAddressInvalid},
+		{"zero ASN", with(valid, func(o *AssociatedIXOrder) { o.ASN = 0 }), 10000, ErrIXASNInvalid},
+		{"reserved ASN", with(valid, func(o *AssociatedIXOrder) { o.ASN = 23456 }), 10000, ErrIXASNInvalid},
+		{"zero rate limit", with(valid, func(o *AssociatedIXOrder) { o.RateLimit = 0 }), 10000, ErrIXOrderInvalid},
+		{"rate limit over port speed", with(valid, func(o *AssociatedIXOrder) { o.RateLimit = 10001 }), 10000, ErrIXRateLimitExceedsPort},
+		{"VLAN out of range", with(valid, func(o *AssociatedIXOrder) { o.VLAN = 4094 }), 10000, ErrIXOrderInvalid},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.order.Validate(tc.portSpeed)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestIXPeeringSummary(t *testing.T) {
+	t.Parallel()
+	var ix IX
+	err := json.Unmarshal([]byte(`{
+		"productUid": "ix-1", "productName": "IX", "networkServiceType": "Los Angeles IX",
+		"asn": 65000, "vlan": 2001, "macAddress": "00:11:22:33:44:55", "rateLimit": 1000,
+		"resources": {
+			"bgp_connection": [
+				{"asn": 65000, "customer_asn": 65000, "customer_ip_address": "2001:504:30::ba06:5000:1/64", "isp_asn": 6939, "isp_ip_address": "2001:504:30::ba06:5939:1", "ix_peer_policy": "open", "max_prefixes": 100, "resource_name": "rs1"},
+				{"asn": 65000, "customer_ip_address": "206.53.172.30/24", "isp_asn": 6939, "isp_ip_address": "206.53.172.2", "ix_peer_policy": "open", "max_prefixes": 1000, "resource_name": "rs2"},
+				{"asn": 65000, "customer_asn": 65000, "customer_ip_address": "206.53.172.30/24", "isp_asn": 6939, "isp_ip_address": "206.53.172.1", "ix_peer_policy": "open", "max_prefixes": 1000, "resource_name": "rs1"}
+			],
+			"ip_address": [
+				{"address": "206.53.172.30/24", "version": 4, "reverse_dns": "ix.example.net"},
+				{"address": "2001:504:30::ba06:5000:1/64", "version": 6}
+			],
+			"vpls_interface": {"mac_address": "00:11:22:33:44:66", "rate_limit_mbps": 1000, "vlan": 2002}
+		}
+	}`), &ix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := ix.PeeringSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.VLAN != 2002 || s.MACAddress != "00:11:22:33:44:66" {
+		t.Fatalf("VLAN, MAC = %d, %s; want the VPLS interface values", s.VLAN, s.MACAddress)
+	}
+	if s.IPv4Address != "206.53.172.30/24" || s.IPv6Address != "2001:504:30::ba06:5000:1/64" {
+		t.Fatalf("addresses = %q, %q", s.IPv4Address, s.IPv6Address)
+	}
+	if s.ReverseDNS["206.53.172.30/24"] != "ix.example.net" {
+		t.Fatalf("ReverseDNS = %v", s.ReverseDNS)
+	}
+	var got []string
+	for _, rs := range s.RouteServers {
+		got = append(got, fmt.Sprintf("v%d %s %s AS%d local AS%d", rs.Version, rs.Name, rs.PeerAddress, rs.PeerASN, rs.LocalASN))
+	}
+	want := []string{
+		"v4 rs1 206.53.172.1 AS6939 local AS65000",
+		"v4 rs2 206.53.172.2 AS6939 local AS65000",
+		"v6 rs1 2001:504:30::ba06:5939:1 AS6939 local AS65000",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("route servers = %q, want %q", got, want)
+	}
+
+	if _, err := (&IX{ProductUID: "ix-2"}).PeeringSummary(); !errors.Is(err, ErrIXNotProvisioned) {
+		t.Fatalf("unprovisioned err = %v, want %v", err, ErrIXNotProvisioned)
+	}
+}
+
+// IXOrderCheckTestSuite tests CheckIXOrder against the port, location and
+// IXP endpoints.
+type IXOrderCheckTestSuite struct {
+	ClientTestSuite
+}
+
+func TestIXOrderCheckTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(IXOrderCheckTestSuite))
+}
+
+func (suite *IXOrderCheckTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+
+	suite.mux.HandleFunc("/v2/product/port-1", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprint(w, `{"message": "Found Product port-1", "data": {"productUid": "port-1", "productType": "MEGAPORT", "portSpeed": 1000, "locationId": 2}}`)
+	})
+	suite.mux.HandleFunc("/v3/locations", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "List public locations", "data": [{"id": 2, "name": "Equinix SY1", "metro": "Sydney", "status": "Active"}]}`)
+	})
+	suite.mux.HandleFunc("/v2/ixp", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "Found 2 Internet Exchange Points", "data": [
+            {"id": 1234, "asn": 58941, "name": "Sydney IX", "metro": "Sydney"},
+            {"id": 5678, "asn": 12345, "name": "Los Angeles IX", "metro": "Los Angeles"}
+        ]}`)
+	})
+}
+
+func (suite *IXOrderCheckTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *IXOrderCheckTestSuite) TestCheckIXOrder() {
+	ctx := context.Background()
+	req := &BuyIXRequest{ProductUID: "port-1", Name: "IX", NetworkServiceType: "sydney ix", ASN: 65000, MACAddress: "00:11:22:33:44:55", RateLimit: 1000, VLAN: 100}
+	suite.NoError(suite.client.IXService.CheckIXOrder(ctx, req))
+
+	req.NetworkServiceType = "Los Angeles IX"
+	req.RateLimit = 10000
+	err := suite.client.IXService.CheckIXOrder(ctx, req)
+	suite.ErrorIs(err, ErrIXNetworkServiceTypeUnavailable)
+	suite.ErrorIs(err, ErrIXRateLimitExceedsPort)
+	suite.ErrorContains(err, "available: Sydney IX")
+
+	suite.ErrorIs(suite.client.IXService.CheckIXOrder(ctx, nil), ErrBuyIXRequestNil)
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/lithammer/fuzzysearch/fuzzy"
 )
@@ -57,6 +58,15 @@ type LocationService interface {
 	// FilterLocationsByNATGatewaySpeedV3 filters locations by NAT Gateway
 	// speed availability (Mbps) advertised in the v3 API diversity zones.
 	FilterLocationsByNATGatewaySpeedV3(ctx context.Context, speedMbps int, locations []*LocationV3) []*LocationV3
+	// FindNearestLocations returns up to n locations matching filters, nearest
+	// first by great-circle distance from lat/lon. filters may be nil.
+	FindNearestLocations(ctx context.Context, lat, lon float64, n int, filters *LocationQuery) ([]*LocationDistance, error)
+	// FindLocationsWithinRadius returns the locations matching filters within
+	// radiusKm of lat/lon, nearest first. filters may be nil.
+	FindLocationsWithinRadius(ctx context.Context, lat, lon, radiusKm float64, filters *LocationQuery) ([]*LocationDistance, error)
+	// PlanDiversePair checks that both diversity zones of a location can host
+	// the requested port, MCR or MVE and returns the red/blue pair.
+	PlanDiversePair(ctx context.Context, spec *DiversePairSpec) (*DiversePair, error)
 
 	// Shared methods (work with both v2 and v3)
 	// ListCountries returns a list of all countries in the Megaport Network Regions API.
@@ -161,6 +171,28 @@ type LocationV3DiversityZones struct {
 	Blue *LocationV3DiversityZone `json:"blue,omitempty"`
 }
 
+// Diversity zone names used by LocationV3DiversityZones and in orders.
+const (
+	DiversityZoneRed  = "red"
+	DiversityZoneBlue = "blue"
+)
+
+// Zone returns the named diversity zone ("red" or "blue", case-insensitive),
+// or nil when the zone is absent or the name is unknown.
+func (z *LocationV3DiversityZones) Zone(name string) *LocationV3DiversityZone {
+	if z == nil {
+		return nil
+	}
+	switch strings.ToLower(name) {
+	case DiversityZoneRed:
+		return z.Red
+	case DiversityZoneBlue:
+		return z.Blue
+	default:
+		return nil
+	}
+}
+
 // LocationV3DiversityZone represents a single diversity zone with product availability
 type LocationV3DiversityZone struct {
 	McrSpeedMbps        []int `json:"mcrSpeedMbps,omitempty"`
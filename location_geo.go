@@ -0,0 +1,330 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// earthRadiusKm is the mean Earth radius used for great-circle distances.
+const earthRadiusKm = 6371.0088
+
+// Geospatial search validation errors.
+var (
+	ErrInvalidLatitude       = errors.New("latitude must be between -90 and 90 degrees")
+	ErrInvalidLongitude      = errors.New("longitude must be between -180 and 180 degrees")
+	ErrInvalidLocationCount  = errors.New("number of locations must be at least 1")
+	ErrInvalidSearchRadius   = errors.New("search radius must be greater than 0 km")
+	ErrInvalidDiversityZone  = errors.New("diversity zone must be red or blue")
+	ErrLocationQueryNegative = errors.New("location query minimums must not be negative")
+)
+
+// LocationDistance is a location paired with its great-circle distance from
+// a search point.
+type LocationDistance struct {
+	Location   *LocationV3
+	DistanceKm float64
+}
+
+// GreatCircleDistanceKm returns the haversine distance in kilometres between
+// two points given in decimal degrees.
+func GreatCircleDistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// DistanceKm returns the great-circle distance in kilometres from the
+// location to the given point.
+func (l *LocationV3) DistanceKm(lat, lon float64) float64 {
+	return GreatCircleDistanceKm(l.Latitude, l.Longitude, lat, lon)
+}
+
+// hasCoordinates reports whether the location carries coordinates. The API
+// returns 0,0 for locations without them.
+func (l *LocationV3) hasCoordinates() bool {
+	return l.Latitude != 0 || l.Longitude != 0
+}
+
+// LocationQuery is a composable filter over LocationV3 values. Build one with
+// NewLocationQuery and chain the methods; a nil *LocationQuery matches every
+// location.
+//
+// Product, speed and MVE constraints are evaluated per diversity zone: a
+// location matches only if a single zone satisfies all of them, so that, for
+// example, a 10 Gbps port and an MVE can be placed side by side.
+type LocationQuery struct {
+	orderable    []LocationProductKind
+	zone         string
+	minPortSpeed int
+	minMCRSpeed  int
+	minMVECores  int
+	natSpeed     int
+	markets      []string
+	metros       []string
+	predicates   []func(*LocationV3) bool
+	err          error
+}
+
+// NewLocationQuery returns an empty query that matches every location.
+func NewLocationQuery() *LocationQuery {
+	return &LocationQuery{}
+}
+
+// Orderable requires the location to be orderable (see LocationV3.IsOrderable)
+// for every given product, in the same diversity zone.
+func (q *LocationQuery) Orderable(products ...LocationProductKind) *LocationQuery {
+	q.orderable = append(q.orderable, products...)
+	return q
+}
+
+// InDiversityZone restricts zone-scoped constraints to the named zone
+// (DiversityZoneRed or DiversityZoneBlue).
+func (q *LocationQuery) InDiversityZone(zone string) *LocationQuery {
+	zone = strings.ToLower(zone)
+	if zone != DiversityZoneRed && zone != DiversityZoneBlue {
+		q.err = ErrInvalidDiversityZone
+	}
+	q.zone = zone
+	return q
+}
+
+// MinPortSpeed requires a Megaport port speed of at least mbps.
+func (q *LocationQuery) MinPortSpeed(mbps int) *LocationQuery {
+	q.minPortSpeed = q.checkMinimum(mbps)
+	return q
+}
+
+// MinMCRSpeed requires an MCR speed of at least mbps.
+func (q *LocationQuery) MinMCRSpeed(mbps int) *LocationQuery {
+	q.minMCRSpeed = q.checkMinimum(mbps)
+	return q
+}
+
+// MinMVECores requires MVE availability with at least cores CPU cores.
+func (q *LocationQuery) MinMVECores(cores int) *LocationQuery {
+	q.minMVECores = q.checkMinimum(cores)
+	return q
+}
+
+// NATGatewaySpeed requires the exact NAT Gateway speed to be offered.
+func (q *LocationQuery) NATGatewaySpeed(mbps int) *LocationQuery {
+	q.natSpeed = q.checkMinimum(mbps)
+	return q
+}
+
+// Market restricts results to the given market codes (case-insensitive).
+func (q *LocationQuery) Market(codes ...string) *LocationQuery {
+	q.markets = append(q.markets, codes...)
+	return q
+}
+
+// Metro restricts results to the given metro names (case-insensitive).
+func (q *LocationQuery) Metro(metros ...string) *LocationQuery {
+	q.metros = append(q.metros, metros...)
+	return q
+}
+
+// Where adds an arbitrary predicate.
+func (q *LocationQuery) Where(fn func(*LocationV3) bool) *LocationQuery {
+	q.predicates = append(q.predicates, fn)
+	return q
+}
+
+// Err returns the first invalid argument passed to the builder, if any.
+func (q *LocationQuery) Err() error {
+	if q == nil {
+		return nil
+	}
+	return q.err
+}
+
+func (q *LocationQuery) checkMinimum(v int) int {
+	if v < 0 {
+		q.err = ErrLocationQueryNegative
+	}
+	return v
+}
+
+// Matches reports whether the location satisfies every constraint.
+func (q *LocationQuery) Matches(l *LocationV3) bool {
+	if l == nil {
+		return false
+	}
+	if q == nil {
+		return true
+	}
+	if len(q.markets) > 0 && !containsFold(q.markets, l.Market) {
+		return false
+	}
+	if len(q.metros) > 0 && !containsFold(q.metros, l.Metro) {
+		return false
+	}
+	for _, p := range q.orderable {
+		if !l.IsOrderable(p) {
+			return false
+		}
+	}
+	for _, fn := range q.predicates {
+		if !fn(l) {
+			return false
+		}
+	}
+	if !q.zoneScoped() {
+		return true
+	}
+
+	zones := []string{DiversityZoneRed, DiversityZoneBlue}
+	if q.zone != "" {
+		zones = []string{q.zone}
+	}
+	for _, name := range zones {
+		if z := l.DiversityZones.Zone(name); z != nil && q.zoneMatches(z) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the locations that match the query, preserving order.
+func (q *LocationQuery) Filter(locations []*LocationV3) []*LocationV3 {
+	var out []*LocationV3
+	for _, l := range locations {
+		if q.Matches(l) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// zoneScoped reports whether any constraint must be checked per zone.
+func (q *LocationQuery) zoneScoped() bool {
+	return q.zone != "" || len(q.orderable) > 0 || q.minPortSpeed > 0 || q.minMCRSpeed > 0 || q.minMVECores > 0 || q.natSpeed > 0
+}
+
+// zoneMatches checks the zone-scoped constraints against a single zone.
+func (q *LocationQuery) zoneMatches(z *LocationV3DiversityZone) bool {
+	for _, p := range q.orderable {
+		switch p {
+		case LocationProductPort:
+			if len(z.MegaportSpeedMbps) == 0 {
+				return false
+			}
+		case LocationProductMCR:
+			if len(z.McrSpeedMbps) == 0 {
+				return false
+			}
+		case LocationProductMVE:
+			if !z.MveAvailable {
+				return false
+			}
+		case LocationProductNATGateway:
+			if len(z.NATGatewaySpeedMbps) == 0 {
+				return false
+			}
+		case LocationProductCrossConnect:
+			// Cross connects are a location-level add-on, already checked by IsOrderable.
+		}
+	}
+	if q.minPortSpeed > 0 && (len(z.MegaportSpeedMbps) == 0 || slices.Max(z.MegaportSpeedMbps) < q.minPortSpeed) {
+		return false
+	}
+	if q.minMCRSpeed > 0 && (len(z.McrSpeedMbps) == 0 || slices.Max(z.McrSpeedMbps) < q.minMCRSpeed) {
+		return false
+	}
+	if q.minMVECores > 0 && (!z.MveAvailable || z.MveMaxCpuCoreCount == nil || *z.MveMaxCpuCoreCount < q.minMVECores) {
+		return false
+	}
+	if q.natSpeed > 0 && !slices.Contains(z.NATGatewaySpeedMbps, q.natSpeed) {
+		return false
+	}
+	return true
+}
+
+// FindNearestLocations returns up to n locations matching filters, ordered by
+// great-circle distance from the given point. filters may be nil. Locations
+// without coordinates are ignored.
+func (svc *LocationServiceOp) FindNearestLocations(ctx context.Context, lat, lon float64, n int, filters *LocationQuery) ([]*LocationDistance, error) {
+	if n < 1 {
+		return nil, ErrInvalidLocationCount
+	}
+	results, err := svc.locationsByDistance(ctx, lat, lon, filters)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) > n {
+		results = results[:n]
+	}
+	return results, nil
+}
+
+// FindLocationsWithinRadius returns the locations matching filters within
+// radiusKm of the given point, nearest first. filters may be nil.
+func (svc *LocationServiceOp) FindLocationsWithinRadius(ctx context.Context, lat, lon, radiusKm float64, filters *LocationQuery) ([]*LocationDistance, error) {
+	if radiusKm <= 0 {
+		return nil, ErrInvalidSearchRadius
+	}
+	results, err := svc.locationsByDistance(ctx, lat, lon, filters)
+	if err != nil {
+		return nil, err
+	}
+	i := sort.Search(len(results), func(i int) bool { return results[i].DistanceKm > radiusKm })
+	return results[:i], nil
+}
+
+// locationsByDistance lists all locations, applies filters and sorts the
+// matches by distance from the point, breaking ties by location ID.
+func (svc *LocationServiceOp) locationsByDistance(ctx context.Context, lat, lon float64, filters *LocationQuery) ([]*LocationDistance, error) {
+	if err := validateCoordinates(lat, lon); err != nil {
+		return nil, err
+	}
+	if err := filters.Err(); err != nil {
+		return nil, err
+	}
+	locations, err := svc.ListLocationsV3(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sortLocationsByDistance(filters.Filter(locations), lat, lon), nil
+}
+
+// sortLocationsByDistance pairs each location with its distance from the
+// point, drops locations without coordinates and sorts nearest first.
+func sortLocationsByDistance(locations []*LocationV3, lat, lon float64) []*LocationDistance {
+	out := make([]*LocationDistance, 0, len(locations))
+	for _, l := range locations {
+		if l == nil || !l.hasCoordinates() {
+			continue
+		}
+		out = append(out, &LocationDistance{Location: l, DistanceKm: l.DistanceKm(lat, lon)})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].DistanceKm != out[j].DistanceKm {
+			return out[i].DistanceKm < out[j].DistanceKm
+		}
+		return out[i].Location.ID < out[j].Location.ID
+	})
+	return out
+}
+
+// validateCoordinates checks a latitude/longitude pair in decimal degrees.
+func validateCoordinates(lat, lon float64) error {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return ErrInvalidLatitude
+	}
+	if math.IsNaN(lon) || lon < -180 || lon > 180 {
+		return ErrInvalidLongitude
+	}
+	return nil
+}
+
+// containsFold reports whether values contains s, ignoring case.
+func containsFold(values []string, s string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, s) })
+}
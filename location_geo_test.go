@@ -0,0 +1,214 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// geoLocationsJSON holds Sydney, Melbourne, Singapore, a Sydney site
+// without coordinates and an inactive Sydney site.
+const geoLocationsJSON = `{
+    "message": "List public locations",
+    "data": [
+        {"id": 1, "name": "Sydney A", "metro": "Sydney", "market": "AU", "status": "Active",
+         "latitude": -33.92, "longitude": 151.19,
+         "diversityZones": {
+            "red": {"megaportSpeedMbps": [1000, 10000], "mveAvailable": false},
+            "blue": {"megaportSpeedMbps": [1000], "mveAvailable": true, "mveMaxCpuCoreCount": 8}
+         }},
+        {"id": 2, "name": "Melbourne A", "metro": "Melbourne", "market": "AU", "status": "Active",
+         "latitude": -37.81, "longitude": 144.96,
+         "diversityZones": {
+            "red": {"megaportSpeedMbps": [1000, 10000, 100000], "mcrSpeedMbps": [1000, 5000], "mveAvailable": true, "mveMaxCpuCoreCount": 16, "natGatewaySpeedMbps": [1000]}
+         }},
+        {"id": 3, "name": "Singapore A", "metro": "Singapore", "market": "SG", "status": "Active",
+         "latitude": 1.29, "longitude": 103.85,
+         "diversityZones": {
+            "blue": {"megaportSpeedMbps": [10000], "mveAvailable": true, "mveMaxCpuCoreCount": 32}
+         }},
+        {"id": 4, "name": "Sydney Unknown", "metro": "Sydney", "market": "AU", "status": "Active",
+         "latitude": 0, "longitude": 0,
+         "diversityZones": {"red": {"megaportSpeedMbps": [100000]}}},
+        {"id": 5, "name": "Sydney Closed", "metro": "Sydney", "market": "AU", "status": "Deployment",
+         "latitude": -33.87, "longitude": 151.21,
+         "diversityZones": {"red": {"megaportSpeedMbps": [100000], "mveAvailable": true, "mveMaxCpuCoreCount": 64}}}
+    ]
+}`
+
+// sydneyLat and sydneyLon are the Sydney CBD search point used in tests.
+const (
+	sydneyLat = -33.8688
+	sydneyLon = 151.2093
+)
+
+// LocationGeoTestSuite tests geospatial location search.
+type LocationGeoTestSuite struct {
+	ClientTestSuite
+}
+
+func TestLocationGeoTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(LocationGeoTestSuite))
+}
+
+func (suite *LocationGeoTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+
+	suite.mux.HandleFunc("/v3/locations", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprint(w, geoLocationsJSON)
+	})
+}
+
+func (suite *LocationGeoTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func locationIDs(results []*LocationDistance) []int {
+	ids := make([]int, 0, len(results))
+	for _, r := range results {
+		ids = append(ids, r.Location.ID)
+	}
+	return ids
+}
+
+func (suite *LocationGeoTestSuite) TestFindNearestLocations() {
+	ctx := context.Background()
+	results, err := suite.client.LocationService.FindNearestLocations(ctx, sydneyLat, sydneyLon, 3, nil)
+	suite.NoError(err)
+	// The 0,0 location is skipped; the inactive site is not filtered without a query.
+	suite.Equal([]int{5, 1, 2}, locationIDs(results))
+	suite.InDelta(713, results[2].DistanceKm, 5)
+
+	results, err = suite.client.LocationService.FindNearestLocations(ctx, sydneyLat, sydneyLon, 1,
+		NewLocationQuery().Orderable(LocationProductPort).MinPortSpeed(100000))
+	suite.NoError(err)
+	suite.Equal([]int{2}, locationIDs(results))
+}
+
+func (suite *LocationGeoTestSuite) TestFindLocationsWithinRadius() {
+	ctx := context.Background()
+	results, err := suite.client.LocationService.FindLocationsWithinRadius(ctx, sydneyLat, sydneyLon, 800,
+		NewLocationQuery().Orderable(LocationProductPort))
+	suite.NoError(err)
+	suite.Equal([]int{1, 2}, locationIDs(results))
+
+	results, err = suite.client.LocationService.FindLocationsWithinRadius(ctx, sydneyLat, sydneyLon, 50, NewLocationQuery().Market("sg"))
+	suite.NoError(err)
+	suite.Empty(results)
+}
+
+func (suite *LocationGeoTestSuite) TestGeoSearchValidation() {
+	ctx := context.Background()
+	svc := suite.client.LocationService
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr error
+	}{
+		{"latitude", func() error { _, err := svc.FindNearestLocations(ctx, 91, 0, 1, nil); return err }, ErrInvalidLatitude},
+		{"longitude", func() error { _, err := svc.FindNearestLocations(ctx, 0, -181, 1, nil); return err }, ErrInvalidLongitude},
+		{"NaN", func() error { _, err := svc.FindNearestLocations(ctx, math.NaN(), 0, 1, nil); return err }, ErrInvalidLatitude},
+		{"count", func() error { _, err := svc.FindNearestLocations(ctx, 0, 0, 0, nil); return err }, ErrInvalidLocationCount},
+		{"radius", func() error { _, err := svc.FindLocationsWithinRadius(ctx, 0, 0, 0, nil); return err }, ErrInvalidSearchRadius},
+		{"zone", func() error {
+			_, err := svc.FindNearestLocations(ctx, 0, 0, 1, NewLocationQuery().InDiversityZone("green"))
+			return err
+		}, ErrInvalidDiversityZone},
+		{"negative", func() error {
+			_, err := svc.FindNearestLocations(ctx, 0, 0, 1, NewLocationQuery().MinMVECores(-1))
+			return err
+		}, ErrLocationQueryNegative},
+	}
+	for _, tc := range tests {
+		suite.Run(tc.name, func() {
+			suite.True(errors.Is(tc.call(), tc.wantErr))
+		})
+	}
+}
+
+func TestLocationQueryMatches(t *testing.T) {
+	t.Parallel()
+	intPtr := func(i int) *int { return &i }
+	sydney := &LocationV3{
+		ID: 1, Metro: "Sydney", Market: "AU", Status: LocationStatusActive,
+		DiversityZones: &LocationV3DiversityZones{
+			Red:  &LocationV3DiversityZone{MegaportSpeedMbps: []int{1000, 10000}},
+			Blue: &LocationV3DiversityZone{MegaportSpeedMbps: []int{1000}, MveAvailable: true, MveMaxCpuCoreCount: intPtr(8)},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		query *LocationQuery
+		want  bool
+	}{
+		{"nil query", nil, true},
+		{"empty query", NewLocationQuery(), true},
+		{"market", NewLocationQuery().Market("au"), true},
+		{"other metro", NewLocationQuery().Metro("Melbourne"), false},
+		{"port in red", NewLocationQuery().Orderable(LocationProductPort).InDiversityZone("RED"), true},
+		{"10G port", NewLocationQuery().MinPortSpeed(10000), true},
+		{"8 core MVE", NewLocationQuery().Orderable(LocationProductMVE).MinMVECores(8), true},
+		{"16 core MVE", NewLocationQuery().MinMVECores(16), false},
+		// 10G ports are only in red and the MVE only in blue.
+		{"10G port beside MVE", NewLocationQuery().MinPortSpeed(10000).Orderable(LocationProductMVE), false},
+		{"1G port beside MVE", NewLocationQuery().MinPortSpeed(1000).Orderable(LocationProductMVE), true},
+		{"MVE in red", NewLocationQuery().InDiversityZone(DiversityZoneRed).Orderable(LocationProductMVE), false},
+		{"MCR", NewLocationQuery().Orderable(LocationProductMCR), false},
+		{"NAT Gateway speed", NewLocationQuery().NATGatewaySpeed(1000), false},
+		{"predicate", NewLocationQuery().Where(func(l *LocationV3) bool { return l.ID == 1 }), true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tc.query.Matches(sydney); got != tc.want {
+				t.Fatalf("Matches = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	closed := *sydney
+	closed.Status = "Deployment"
+	if NewLocationQuery().Orderable(LocationProductPort).Matches(&closed) {
+		t.Fatal("inactive location should not be orderable")
+	}
+}
+
+func TestGreatCircleDistanceKm(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"same point", sydneyLat, sydneyLon, sydneyLat, sydneyLon, 0},
+		{"Sydney to Melbourne", sydneyLat, sydneyLon, -37.8136, 144.9631, 714},
+		{"London to New York", 51.5074, -0.1278, 40.7128, -74.0060, 5570},
+		{"antipodes", 0, 0, 0, 180, math.Pi * earthRadiusKm},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := GreatCircleDistanceKm(tc.lat1, tc.lon1, tc.lat2, tc.lon2)
+			if math.Abs(got-tc.want) > 5 {
+				t.Fatalf("distance = %.1f km, want ~%.0f km", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package megaport
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RTT matrix errors.
+var (
+	ErrRTTMatrixNoSources = errors.New("RTT matrix requires at least one source location")
+	ErrRTTMatrixNoMonths  = errors.New("RTT matrix requires at least one month")
+)
+
+// RTTMonth identifies a calendar month of round-trip-time statistics.
+type RTTMonth struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+}
+
+// String returns the month as YYYY-MM.
+func (m RTTMonth) String() string {
+	return fmt.Sprintf("%04d-%02d", m.Year, m.Month)
+}
+
+// ordinal returns a monotonically increasing month number for sorting and
+// trend fitting.
+func (m RTTMonth) ordinal() int {
+	return m.Year*12 + m.Month - 1
+}
+
+// validate checks the month before it is sent to GetRoundTripTimes.
+func (m RTTMonth) validate() error {
+	if m.Year < 0 {
+		return ErrInvalidYear
+	}
+	if m.Month < 1 || m.Month > 12 {
+		return ErrInvalidMonth
+	}
+	return nil
+}
+
+// RecentRTTMonths returns the n complete calendar months before now, oldest
+// first. The current month is excluded because its statistics are not
+// reliably available.
+func RecentRTTMonths(now time.Time, n int) []RTTMonth {
+	months := make([]RTTMonth, n)
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		t := first.AddDate(0, -(n - i), 0)
+		months[i] = RTTMonth{Year: t.Year(), Month: int(t.Month())}
+	}
+	return months
+}
+
+// RTTMatrixEntry is a single median RTT sample in an RTTMatrix.
+type RTTMatrixEntry struct {
+	Month       RTTMonth `json:"month"`
+	SrcLocation int      `json:"srcLocation"`
+	DstLocation int      `json:"dstLocation"`
+	MedianRTT   float64  `json:"medianRTT"`
+}
+
+// RTTSample is one month's median RTT within an RTTTrend.
+type RTTSample struct {
+	Month     RTTMonth `json:"month"`
+	MedianRTT float64  `json:"medianRTT"`
+}
+
+// RTTTrend summarises how the median RTT between two locations has moved
+// over the loaded months. All values are in milliseconds.
+type RTTTrend struct {
+	SrcLocation int          `json:"srcLocation"`
+	DstLocation int          `json:"dstLocation"`
+	Samples     []*RTTSample `json:"samples"`
+	Min         float64      `json:"min"`
+	Max         float64      `json:"max"`
+	Mean        float64      `json:"mean"`
+	// Change is the latest sample minus the earliest.
+	Change float64 `json:"change"`
+	// SlopePerMonth is the least-squares slope across the samples; positive
+	// values mean latency is getting worse.
+	SlopePerMonth float64 `json:"slopePerMonth"`
+}
+
+type rttKey struct {
+	src   int
+	month RTTMonth
+}
+
+// RTTMatrix fetches and caches median RTTs from GetRoundTripTimes across
+// several source locations and months. Lookups treat RTTs as symmetric: when
+// a src→dst sample is missing the dst→src sample is used. It is safe for
+// concurrent use.
+type RTTMatrix struct {
+	client *Client
+
+	mu      sync.RWMutex
+	samples map[rttKey]map[int]float64
+}
+
+// NewRTTMatrix creates an empty RTTMatrix backed by the client's
+// LocationService.
+func NewRTTMatrix(c *Client) *RTTMatrix {
+	return &RTTMatrix{
+		client:  c,
+		samples: make(map[rttKey]map[int]float64),
+	}
+}
+
+// Load fetches the RTTs for every source and month that is not already
+// cached. A month with no published data is cached as empty.
+func (m *RTTMatrix) Load(ctx context.Context, sources []int, months []RTTMonth) error {
+	if len(sources) == 0 {
+		return ErrRTTMatrixNoSources
+	}
+	if len(months) == 0 {
+		return ErrRTTMatrixNoMonths
+	}
+	for _, month := range months {
+		if err := month.validate(); err != nil {
+			return fmt.Errorf("%s: %w", month, err)
+		}
+	}
+	for _, src := range sources {
+		for _, month := range months {
+			m.mu.RLock()
+			_, cached := m.samples[rttKey{src, month}]
+			m.mu.RUnlock()
+			if cached {
+				continue
+			}
+			rtts, err := m.client.LocationService.GetRoundTripTimes(ctx, src, month.Year, month.Month)
+			if err != nil {
+				return fmt.Errorf("loading RTTs from location %d for %s: %w", src, month, err)
+			}
+			m.Add(src, month, rtts)
+		}
+	}
+	return nil
+}
+
+// Add records RTTs for a source location and month, replacing any cached
+// values. It can be used to seed the matrix from a previous export.
+func (m *RTTMatrix) Add(src int, month RTTMonth, rtts []*RoundTripTime) {
+	dst := make(map[int]float64, len(rtts))
+	for _, r := range rtts {
+		if r != nil {
+			dst[r.DstLocation] = r.MedianRTT
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.samples[rttKey{src, month}] = dst
+}
+
+// RTT returns the median RTT between two locations for a month. Identical
+// locations have an RTT of zero.
+func (m *RTTMatrix) RTT(src, dst int, month RTTMonth) (float64, bool) {
+	if src == dst {
+		return 0, true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if rtt, ok := m.samples[rttKey{src, month}][dst]; ok {
+		return rtt, true
+	}
+	rtt, ok := m.samples[rttKey{dst, month}][src]
+	return rtt, ok
+}
+
+// MeanRTT averages the RTT between two locations over the given months,
+// ignoring months without data. ok is false when no month has data.
+func (m *RTTMatrix) MeanRTT(src, dst int, months []RTTMonth) (mean float64, ok bool) {
+	var sum float64
+	var n int
+	for _, month := range months {
+		if rtt, found := m.RTT(src, dst, month); found {
+			sum += rtt
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
+// Months returns the cached months, oldest first.
+func (m *RTTMatrix) Months() []RTTMonth {
+	m.mu.RLock()
+	seen := make(map[RTTMonth]bool)
+	for k := range m.samples {
+		seen[k.month] = true
+	}
+	m.mu.RUnlock()
+	months := make([]RTTMonth, 0, len(seen))
+	for month := range seen {
+		months = append(months, month)
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].ordinal() < months[j].ordinal() })
+	return months
+}
+
+// Trend returns the RTT trend between two locations over the cached months,
+// or nil when there is no data for the pair.
+func (m *RTTMatrix) Trend(src, dst int) *RTTTrend {
+	t := &RTTTrend{SrcLocation: src, DstLocation: dst}
+	for _, month := range m.Months() {
+		if rtt, ok := m.RTT(src, dst, month); ok {
+			t.Samples = append(t.Samples, &RTTSample{Month: month, MedianRTT: rtt})
+		}
+	}
+	if len(t.Samples) == 0 {
+		return nil
+	}
+
+	t.Min, t.Max = t.Samples[0].MedianRTT, t.Samples[0].MedianRTT
+	var sumX, sumY float64
+	for _, s := range t.Samples {
+		t.Min = min(t.Min, s.MedianRTT)
+		t.Max = max(t.Max, s.MedianRTT)
+		sumX += float64(s.Month.ordinal())
+		sumY += s.MedianRTT
+	}
+	n := float64(len(t.Samples))
+	t.Mean = sumY / n
+	t.Change = t.Samples[len(t.Samples)-1].MedianRTT - t.Samples[0].MedianRTT
+
+	meanX := sumX / n
+	var num, den float64
+	for _, s := range t.Samples {
+		dx := float64(s.Month.ordinal()) - meanX
+		num += dx * (s.MedianRTT - t.Mean)
+		den += dx * dx
+	}
+	if den > 0 {
+		t.SlopePerMonth = num / den
+	}
+	return t
+}
+
+// Entries returns every cached sample ordered by month, source and
+// destination.
+func (m *RTTMatrix) Entries() []*RTTMatrixEntry {
+	m.mu.RLock()
+	var entries []*RTTMatrixEntry
+	for k, dsts := range m.samples {
+		for dst, rtt := range dsts {
+			entries = append(entries, &RTTMatrixEntry{Month: k.month, SrcLocation: k.src, DstLocation: dst, MedianRTT: rtt})
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Month != b.Month {
+			return a.Month.ordinal() < b.Month.ordinal()
+		}
+		if a.SrcLocation != b.SrcLocation {
+			return a.SrcLocation < b.SrcLocation
+		}
+		return a.DstLocation < b.DstLocation
+	})
+	return entries
+}
+
+// WriteJSON encodes the cached samples as an indented JSON array of
+// RTTMatrixEntry.
+func (m *RTTMatrix) WriteJSON(w io.Writer) error {
+	entries := m.Entries()
+	if entries == nil {
+		entries = []*RTTMatrixEntry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// WriteCSV writes the cached samples as CSV with a header row of month,
+// src_location, dst_location and median_rtt_ms.
+func (m *RTTMatrix) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"month", "src_location", "dst_location", "median_rtt_ms"}); err != nil {
+		return err
+	}
+	for _, e := range m.Entries() {
+		record := []string{e.Month.String(), strconv.Itoa(e.SrcLocation), strconv.Itoa(e.DstLocation), formatMs(e.MedianRTT)}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatMs formats a millisecond value for CSV export.
+func formatMs(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
@@ -0,0 +1,177 @@
+package megaport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rttFixture maps "src/yy/month" to the destination RTTs served by
+// newRTTTestClient.
+var rttFixture = map[string]map[int]float64{
+	"10/26/1": {20: 12, 30: 14},
+	"10/26/2": {20: 14, 30: 14},
+	"20/26/1": {30: 25},
+	"20/26/2": {30: 25},
+}
+
+// newRTTTestClient returns a client whose RTT endpoint serves rttFixture and
+// counts requests in calls.
+func newRTTTestClient(t *testing.T, mux *http.ServeMux, calls *atomic.Int32) *Client {
+	t.Helper()
+	if mux == nil {
+		mux = http.NewServeMux()
+	}
+	mux.HandleFunc("/v2/locations/rtt", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		q := r.URL.Query()
+		key := fmt.Sprintf("%s/%s/%s", q.Get("srcLocation"), q.Get("year"), q.Get("month"))
+		src := q.Get("srcLocation")
+		var data []string
+		for dst, rtt := range rttFixture[key] {
+			data = append(data, fmt.Sprintf(`{"srcLocation": %s, "dstLocation": %d, "medianRTT": %g}`, src, dst, rtt))
+		}
+		fmt.Fprintf(w, `{"message": "RTT", "data": [%s]}`, strings.Join(data, ","))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	client := NewClient(nil, nil)
+	client.BaseURL, _ = url.Parse(server.URL)
+	return client
+}
+
+var rttTestMonths = []RTTMonth{{2026, 1}, {2026, 2}}
+
+func TestRTTMatrixLoadCachesAndIsSymmetric(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	m := NewRTTMatrix(newRTTTestClient(t, nil, &calls))
+	ctx := context.Background()
+
+	if err := m.Load(ctx, []int{10, 20}, rttTestMonths); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := m.Load(ctx, []int{20, 10}, rttTestMonths[1:]); err != nil {
+		t.Fatalf("cached Load: %v", err)
+	}
+	if calls.Load() != 4 {
+		t.Fatalf("RTT requests = %d, want 4", calls.Load())
+	}
+
+	if rtt, ok := m.RTT(30, 10, RTTMonth{2026, 2}); !ok || rtt != 14 {
+		t.Fatalf("symmetric RTT = %v %v", rtt, ok)
+	}
+	if rtt, ok := m.RTT(30, 30, RTTMonth{2026, 2}); !ok || rtt != 0 {
+		t.Fatalf("self RTT = %v %v", rtt, ok)
+	}
+	if _, ok := m.RTT(30, 40, RTTMonth{2026, 2}); ok {
+		t.Fatal("unexpected RTT for unknown pair")
+	}
+	if mean, ok := m.MeanRTT(10, 20, rttTestMonths); !ok || mean != 13 {
+		t.Fatalf("MeanRTT = %v %v", mean, ok)
+	}
+}
+
+func TestRTTMatrixLoadValidation(t *testing.T) {
+	t.Parallel()
+	m := NewRTTMatrix(NewClient(nil, nil))
+	tests := []struct {
+		name    string
+		sources []int
+		months  []RTTMonth
+		wantErr error
+	}{
+		{"no sources", nil, rttTestMonths, ErrRTTMatrixNoSources},
+		{"no months", []int{1}, nil, ErrRTTMatrixNoMonths},
+		{"bad month", []int{1}, []RTTMonth{{2026, 13}}, ErrInvalidMonth},
+		{"bad year", []int{1}, []RTTMonth{{-1, 1}}, ErrInvalidYear},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := m.Load(context.Background(), tc.sources, tc.months); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Load = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRTTMatrixTrend(t *testing.T) {
+	t.Parallel()
+	m := NewRTTMatrix(nil)
+	m.Add(1, RTTMonth{2025, 12}, []*RoundTripTime{{SrcLocation: 1, DstLocation: 2, MedianRTT: 10}})
+	m.Add(1, RTTMonth{2026, 1}, []*RoundTripTime{{SrcLocation: 1, DstLocation: 2, MedianRTT: 12}})
+	m.Add(2, RTTMonth{2026, 2}, []*RoundTripTime{{SrcLocation: 2, DstLocation: 1, MedianRTT: 14}})
+
+	trend := m.Trend(2, 1)
+	if trend == nil || len(trend.Samples) != 3 {
+		t.Fatalf("trend = %+v", trend)
+	}
+	if trend.Min != 10 || trend.Max != 14 || trend.Mean != 12 || trend.Change != 4 {
+		t.Errorf("trend stats = %+v", trend)
+	}
+	if math.Abs(trend.SlopePerMonth-2) > 1e-9 {
+		t.Errorf("slope = %v, want 2", trend.SlopePerMonth)
+	}
+	if m.Trend(1, 3) != nil {
+		t.Error("trend for unknown pair should be nil")
+	}
+}
+
+func TestRTTMatrixExport(t *testing.T) {
+	t.Parallel()
+	m := NewRTTMatrix(nil)
+	m.Add(2, RTTMonth{2026, 1}, []*RoundTripTime{{DstLocation: 1, MedianRTT: 3.25}})
+	m.Add(1, RTTMonth{2026, 1}, []*RoundTripTime{{DstLocation: 3, MedianRTT: 7}, {DstLocation: 2, MedianRTT: 3.5}})
+
+	var buf bytes.Buffer
+	if err := m.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "month,src_location,dst_location,median_rtt_ms\n" +
+		"2026-01,1,2,3.500\n" +
+		"2026-01,1,3,7.000\n" +
+		"2026-01,2,1,3.250\n"
+	if buf.String() != want {
+		t.Fatalf("CSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := m.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var entries []*RTTMatrixEntry
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil || len(entries) != 3 || entries[0].DstLocation != 2 {
+		t.Fatalf("JSON entries = %+v, %v", entries, err)
+	}
+
+	buf.Reset()
+	if err := NewRTTMatrix(nil).WriteJSON(&buf); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Fatalf("empty JSON = %q, %v", buf.String(), err)
+	}
+}
+
+func TestRecentRTTMonths(t *testing.T) {
+	t.Parallel()
+	got := RecentRTTMonths(time.Date(2026, time.February, 15, 0, 0, 0, 0, time.UTC), 3)
+	want := []RTTMonth{{2025, 11}, {2025, 12}, {2026, 1}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
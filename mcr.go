@@ -50,6 +50,9 @@ type MCRService interface {
 	// A zero timeout defaults to 5 minutes. Returns ErrMCRNotFound or ErrMCRDecommissioned
 	// if the MCR is deleted or decommissioned while polling.
 	WaitForMCRReady(ctx context.Context, mcrID string, timeout time.Duration) error
+	// PlanMCRPlacement ranks candidate locations for an MCR by expected
+	// round-trip time to customer sites and cloud on-ramps.
+	PlanMCRPlacement(ctx context.Context, req *MCRPlacementRequest) (*MCRPlacementPlan, error)
 
 	// GetMCRPrefixFilterLists returns prefix filter lists for the specified MCR2.
 	//
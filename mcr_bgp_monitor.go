@@ -0,0 +1,428 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BGPSessionEventType identifies what a BGPSessionEvent reports.
+type BGPSessionEventType string
+
+const (
+	// BGPSessionEventStateChange reports a session status change, e.g. UP to DOWN.
+	BGPSessionEventStateChange BGPSessionEventType = "STATE_CHANGE"
+	// BGPSessionEventReset reports a session that stayed UP across polls but
+	// whose uptime went backwards, meaning it dropped and re-established in between.
+	BGPSessionEventReset BGPSessionEventType = "RESET"
+	// BGPSessionEventFlap reports a session whose transitions within the
+	// flap window reached the flap threshold. It fires once per flapping episode.
+	BGPSessionEventFlap BGPSessionEventType = "FLAP"
+	// BGPSessionEventPrefixThreshold reports received prefixes crossing
+	// outside the configured minimum or maximum.
+	BGPSessionEventPrefixThreshold BGPSessionEventType = "PREFIX_THRESHOLD"
+	// BGPSessionEventPrefixThresholdCleared reports received prefixes
+	// returning within the configured thresholds.
+	BGPSessionEventPrefixThresholdCleared BGPSessionEventType = "PREFIX_THRESHOLD_CLEARED"
+	// BGPSessionEventPrefixChange reports a change in received prefixes
+	// between polls larger than the configured percentage.
+	BGPSessionEventPrefixChange BGPSessionEventType = "PREFIX_CHANGE"
+	// BGPSessionEventSessionAdded reports a session seen for the first time
+	// after the initial poll.
+	BGPSessionEventSessionAdded BGPSessionEventType = "SESSION_ADDED"
+	// BGPSessionEventSessionRemoved reports a session no longer returned by ListBGPSessions.
+	BGPSessionEventSessionRemoved BGPSessionEventType = "SESSION_REMOVED"
+)
+
+// Default BGP session monitor settings.
+const (
+	defaultBGPMonitorInterval      = time.Minute
+	defaultBGPMonitorFlapWindow    = 10 * time.Minute
+	defaultBGPMonitorFlapThreshold = 3
+	defaultBGPMonitorVXCRetry      = 10 * time.Minute
+)
+
+// BGP session monitor configuration errors.
+var (
+	ErrBGPSessionMonitorNoMCRs            = errors.New("BGP session monitor requires at least one MCR UID")
+	ErrBGPSessionMonitorNotifierRequired  = errors.New("BGP session monitor requires a notifier")
+	ErrBGPSessionMonitorThresholdsInvalid = errors.New("BGP session monitor minimum prefixes must not exceed maximum prefixes")
+)
+
+// BGPSessionEvent is delivered to a BGPSessionNotifier.
+type BGPSessionEvent struct {
+	Type    BGPSessionEventType
+	MCRUID  string
+	Time    time.Time
+	Message string
+	// Session is the latest observation. For SESSION_REMOVED it is the last
+	// observation before the session disappeared.
+	Session *LookingGlassBGPSession
+	// Previous is the observation from the prior poll, if any.
+	Previous *LookingGlassBGPSession
+	// VXC and BGPConnection identify the VXC and its vRouter BGP connection
+	// that define the session. Either may be nil if no match was found.
+	VXC           *VXC
+	BGPConnection *BgpConnectionConfig
+}
+
+// BGPSessionNotifier receives events from a BGPSessionMonitor. Notify is
+// called synchronously from the polling loop, so slow notifiers delay the
+// next poll.
+type BGPSessionNotifier interface {
+	Notify(ctx context.Context, event *BGPSessionEvent)
+}
+
+// BGPSessionNotifierFunc adapts a function to the BGPSessionNotifier interface.
+type BGPSessionNotifierFunc func(ctx context.Context, event *BGPSessionEvent)
+
+// Notify calls f(ctx, event).
+func (f BGPSessionNotifierFunc) Notify(ctx context.Context, event *BGPSessionEvent) {
+	f(ctx, event)
+}
+
+// BGPSessionThresholds configures prefix count alerts on received prefixes.
+// Nil or zero values disable the corresponding check.
+type BGPSessionThresholds struct {
+	MinPrefixesIn *int
+	MaxPrefixesIn *int
+	// PrefixChangePercent raises PREFIX_CHANGE when received prefixes change
+	// between consecutive polls by more than this percentage.
+	PrefixChangePercent float64
+}
+
+// BGPSessionMonitorConfig configures a BGPSessionMonitor.
+type BGPSessionMonitorConfig struct {
+	MCRUIDs  []string
+	Notifier BGPSessionNotifier
+	// Interval between polls. Defaults to one minute.
+	Interval time.Duration
+	// FlapWindow and FlapThreshold define a flap: FlapThreshold or more
+	// transitions within FlapWindow. Default to 10 minutes and 3.
+	FlapWindow    time.Duration
+	FlapThreshold int
+	Thresholds    BGPSessionThresholds
+	// VXCRetry is how long a session's VXC that its MCR did not list stays
+	// unresolved before the MCR's VXCs are fetched again. Defaults to 10
+	// minutes.
+	VXCRetry time.Duration
+	// OnError, if set, is called with errors from Run's polls. Run keeps
+	// polling after errors.
+	OnError func(mcrUID string, err error)
+}
+
+// BGPSessionMonitor watches the BGP sessions of one or more MCRs through the
+// Looking Glass and emits events on state transitions, flaps and prefix
+// count changes. Each session is correlated to its VXC and BgpConnectionConfig
+// by VXC ID and peer address.
+type BGPSessionMonitor struct {
+	client *Client
+	cfg    BGPSessionMonitorConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]map[string]*bgpSessionState // MCR UID -> session ID -> state
+	vxcs     map[string]map[int]*VXC                // MCR UID -> VXC ID -> VXC
+	vxcsAt   map[string]time.Time                   // MCR UID -> time its VXCs were fetched
+}
+
+// bgpSessionState is the per-session history kept between polls.
+type bgpSessionState struct {
+	last        *LookingGlassBGPSession
+	transitions []time.Time
+	flapping    bool
+	breached    bool
+}
+
+// NewBGPSessionMonitor creates a monitor using the client's MCR and MCR
+// Looking Glass services.
+func NewBGPSessionMonitor(c *Client, cfg *BGPSessionMonitorConfig) (*BGPSessionMonitor, error) {
+	if cfg == nil || len(cfg.MCRUIDs) == 0 {
+		return nil, ErrBGPSessionMonitorNoMCRs
+	}
+	if cfg.Notifier == nil {
+		return nil, ErrBGPSessionMonitorNotifierRequired
+	}
+	t := cfg.Thresholds
+	if t.MinPrefixesIn != nil && t.MaxPrefixesIn != nil && *t.MinPrefixesIn > *t.MaxPrefixesIn {
+		return nil, ErrBGPSessionMonitorThresholdsInvalid
+	}
+	m := &BGPSessionMonitor{
+		client:   c,
+		cfg:      *cfg,
+		now:      time.Now,
+		sessions: map[string]map[string]*bgpSessionState{},
+		vxcs:     map[string]map[int]*VXC{},
+		vxcsAt:   map[string]time.Time{},
+	}
+	if m.cfg.Interval <= 0 {
+		m.cfg.Interval = defaultBGPMonitorInterval
+	}
+	if m.cfg.FlapWindow <= 0 {
+		m.cfg.FlapWindow = defaultBGPMonitorFlapWindow
+	}
+	if m.cfg.FlapThreshold <= 0 {
+		m.cfg.FlapThreshold = defaultBGPMonitorFlapThreshold
+	}
+	if m.cfg.VXCRetry <= 0 {
+		m.cfg.VXCRetry = defaultBGPMonitorVXCRetry
+	}
+	return m, nil
+}
+
+// Run polls every configured interval until ctx is done, then returns ctx.Err().
+func (m *BGPSessionMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		for _, uid := range m.cfg.MCRUIDs {
+			if err := m.pollMCR(ctx, uid); err != nil && m.cfg.OnError != nil && ctx.Err() == nil {
+				m.cfg.OnError(uid, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll performs a single poll of every configured MCR. The first poll of an
+// MCR establishes its baseline and only raises prefix threshold events.
+func (m *BGPSessionMonitor) Poll(ctx context.Context) error {
+	var errs []error
+	for _, uid := range m.cfg.MCRUIDs {
+		if err := m.pollMCR(ctx, uid); err != nil {
+			errs = append(errs, fmt.Errorf("polling BGP sessions of MCR %s: %w", uid, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pollMCR fetches the MCR's sessions, updates state and notifies events.
+func (m *BGPSessionMonitor) pollMCR(ctx context.Context, mcrUID string) error {
+	sessions, err := m.client.MCRLookingGlassService.ListBGPSessions(ctx, mcrUID)
+	if err != nil {
+		return err
+	}
+	if err := m.refreshVXCs(ctx, mcrUID, sessions); err != nil {
+		return err
+	}
+
+	now := m.now()
+	m.mu.Lock()
+	events := m.evaluate(mcrUID, sessions, now)
+	m.mu.Unlock()
+
+	for _, e := range events {
+		m.cfg.Notifier.Notify(ctx, e)
+	}
+	return nil
+}
+
+// refreshVXCs loads the MCR's associated VXCs on first use and whenever a
+// session references a VXC that is not yet known. VXC IDs the MCR does not
+// list are recorded as nil so that they do not trigger a refresh every poll;
+// they are looked up again once VXCRetry has passed since the last fetch.
+func (m *BGPSessionMonitor) refreshVXCs(ctx context.Context, mcrUID string, sessions []*LookingGlassBGPSession) error {
+	m.mu.Lock()
+	known, loaded := m.vxcs[mcrUID]
+	retry := m.now().Sub(m.vxcsAt[mcrUID]) >= m.cfg.VXCRetry
+	stale := !loaded
+	for _, s := range sessions {
+		if s == nil || s.VXCID == 0 {
+			continue
+		}
+		if v, ok := known[s.VXCID]; !ok || (v == nil && retry) {
+			stale = true
+			break
+		}
+	}
+	m.mu.Unlock()
+	if !stale {
+		return nil
+	}
+
+	mcr, err := m.client.MCRService.GetMCR(ctx, mcrUID)
+	if err != nil {
+		return err
+	}
+	byID := make(map[int]*VXC, len(mcr.AssociatedVXCs))
+	for _, v := range mcr.AssociatedVXCs {
+		if v != nil {
+			byID[v.ID] = v
+		}
+	}
+	for _, s := range sessions {
+		if s == nil || s.VXCID == 0 {
+			continue
+		}
+		if _, ok := byID[s.VXCID]; !ok {
+			byID[s.VXCID] = nil
+		}
+	}
+	m.mu.Lock()
+	m.vxcs[mcrUID] = byID
+	m.vxcsAt[mcrUID] = m.now()
+	m.mu.Unlock()
+	return nil
+}
+
+// evaluate compares the polled sessions with the stored state. m.mu must be held.
+func (m *BGPSessionMonitor) evaluate(mcrUID string, sessions []*LookingGlassBGPSession, now time.Time) []*BGPSessionEvent {
+	states, baseline := m.sessions[mcrUID], false
+	if states == nil {
+		states, baseline = map[string]*bgpSessionState{}, true
+		m.sessions[mcrUID] = states
+	}
+
+	var events []*BGPSessionEvent
+	emit := func(t BGPSessionEventType, cur, prev *LookingGlassBGPSession, msg string) {
+		vxc, conn := m.correlate(mcrUID, cur)
+		events = append(events, &BGPSessionEvent{
+			Type: t, MCRUID: mcrUID, Time: now, Message: msg,
+			Session: cur, Previous: prev, VXC: vxc, BGPConnection: conn,
+		})
+	}
+
+	seen := make(map[string]bool, len(sessions))
+	for _, cur := range sessions {
+		if cur == nil {
+			continue
+		}
+		seen[cur.SessionID] = true
+		st := states[cur.SessionID]
+		if st == nil {
+			st = &bgpSessionState{}
+			states[cur.SessionID] = st
+			if !baseline {
+				emit(BGPSessionEventSessionAdded, cur, nil, fmt.Sprintf("BGP session to %s added (%s)", cur.NeighborAddress, cur.Status))
+			}
+		}
+		prev := st.last
+		st.last = cur
+
+		if prev != nil {
+			if prev.Status != cur.Status {
+				st.transitions = append(st.transitions, now)
+				emit(BGPSessionEventStateChange, cur, prev, fmt.Sprintf("BGP session to %s changed from %s to %s", cur.NeighborAddress, prev.Status, cur.Status))
+			} else if cur.Status == BGPSessionStatusUp && prev.Uptime != nil && cur.Uptime != nil && *cur.Uptime < *prev.Uptime {
+				// Down and up again between polls.
+				st.transitions = append(st.transitions, now, now)
+				emit(BGPSessionEventReset, cur, prev, fmt.Sprintf("BGP session to %s re-established between polls (uptime %ds)", cur.NeighborAddress, *cur.Uptime))
+			}
+			if msg, ok := m.prefixChange(prev, cur); ok {
+				emit(BGPSessionEventPrefixChange, cur, prev, msg)
+			}
+		}
+
+		st.transitions = pruneTransitions(st.transitions, now.Add(-m.cfg.FlapWindow))
+		if len(st.transitions) >= m.cfg.FlapThreshold {
+			if !st.flapping {
+				st.flapping = true
+				emit(BGPSessionEventFlap, cur, prev, fmt.Sprintf("BGP session to %s flapped %d times within %s", cur.NeighborAddress, len(st.transitions), m.cfg.FlapWindow))
+			}
+		} else {
+			st.flapping = false
+		}
+
+		if msg, breached := m.prefixThreshold(cur); breached != st.breached {
+			st.breached = breached
+			if breached {
+				emit(BGPSessionEventPrefixThreshold, cur, prev, msg)
+			} else if prev != nil {
+				emit(BGPSessionEventPrefixThresholdCleared, cur, prev, fmt.Sprintf("BGP session to %s received prefixes back within thresholds", cur.NeighborAddress))
+			}
+		}
+	}
+
+	for id, st := range states {
+		if seen[id] {
+			continue
+		}
+		delete(states, id)
+		emit(BGPSessionEventSessionRemoved, st.last, st.last, fmt.Sprintf("BGP session to %s removed", st.last.NeighborAddress))
+	}
+	return events
+}
+
+// prefixThreshold reports whether the session's received prefixes are
+// outside the configured minimum or maximum.
+func (m *BGPSessionMonitor) prefixThreshold(s *LookingGlassBGPSession) (string, bool) {
+	if s.PrefixesIn == nil {
+		return "", false
+	}
+	t := m.cfg.Thresholds
+	n := *s.PrefixesIn
+	if t.MinPrefixesIn != nil && n < *t.MinPrefixesIn {
+		return fmt.Sprintf("BGP session to %s received %d prefixes, below minimum %d", s.NeighborAddress, n, *t.MinPrefixesIn), true
+	}
+	if t.MaxPrefixesIn != nil && n > *t.MaxPrefixesIn {
+		return fmt.Sprintf("BGP session to %s received %d prefixes, above maximum %d", s.NeighborAddress, n, *t.MaxPrefixesIn), true
+	}
+	return "", false
+}
+
+// prefixChange reports whether received prefixes moved by more than the
+// configured percentage between prev and cur.
+func (m *BGPSessionMonitor) prefixChange(prev, cur *LookingGlassBGPSession) (string, bool) {
+	pct := m.cfg.Thresholds.PrefixChangePercent
+	if pct <= 0 || prev.PrefixesIn == nil || cur.PrefixesIn == nil || *prev.PrefixesIn == *cur.PrefixesIn {
+		return "", false
+	}
+	before, after := *prev.PrefixesIn, *cur.PrefixesIn
+	if before != 0 {
+		delta := float64(after-before) / float64(before) * 100
+		if delta < 0 {
+			delta = -delta
+		}
+		if delta <= pct {
+			return "", false
+		}
+	}
+	return fmt.Sprintf("BGP session to %s received prefixes changed from %d to %d", cur.NeighborAddress, before, after), true
+}
+
+// correlate finds the VXC and BgpConnectionConfig defining a session. m.mu must be held.
+func (m *BGPSessionMonitor) correlate(mcrUID string, s *LookingGlassBGPSession) (*VXC, *BgpConnectionConfig) {
+	vxc := m.vxcs[mcrUID][s.VXCID]
+	if vxc == nil {
+		return nil, nil
+	}
+	return vxc, vxcBGPConnection(vxc, s.NeighborAddress)
+}
+
+// vxcBGPConnection returns the vRouter BGP connection on the VXC whose peer
+// address matches, or nil.
+func vxcBGPConnection(vxc *VXC, peerAddress string) *BgpConnectionConfig {
+	if vxc.Resources == nil || vxc.Resources.CSPConnection == nil {
+		return nil
+	}
+	for _, c := range vxc.Resources.CSPConnection.CSPConnection {
+		vr, ok := c.(CSPConnectionVirtualRouter)
+		if !ok {
+			continue
+		}
+		for _, iface := range vr.Interfaces {
+			for i := range iface.BGPConnections {
+				if iface.BGPConnections[i].PeerIpAddress == peerAddress {
+					conn := iface.BGPConnections[i]
+					return &conn
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// pruneTransitions drops transitions before cutoff.
+func pruneTransitions(ts []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(ts) && ts[i].Before(cutoff) {
+		i++
+	}
+	return ts[i:]
+}
@@ -0,0 +1,256 @@
+package megaport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// BGPSessionMonitorTestSuite tests the MCR BGP session monitor.
+type BGPSessionMonitorTestSuite struct {
+	ClientTestSuite
+
+	mu       sync.Mutex
+	sessions string
+	events   []*BGPSessionEvent
+	mcrGets  int
+}
+
+func TestBGPSessionMonitorTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(BGPSessionMonitorTestSuite))
+}
+
+func (suite *BGPSessionMonitorTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+
+	suite.events = nil
+	suite.mcrGets = 0
+	suite.mux.HandleFunc("/v2/product/mcr-1", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		suite.mu.Lock()
+		suite.mcrGets++
+		suite.mu.Unlock()
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":{
+			"productUid": "mcr-1", "productType": "MCR2",
+			"associatedVxcs": [{
+				"productId": 101, "productUid": "vxc-a", "productName": "to-dc",
+				"resources": {"csp_connection": {
+					"connectType": "VROUTER",
+					"interfaces": [{"bgpConnections": [
+						{"peerAsn": 65001, "peerIpAddress": "10.0.0.2", "localIpAddress": "10.0.0.1", "description": "dc primary"},
+						{"peerAsn": 65002, "peerIpAddress": "10.0.0.6", "localIpAddress": "10.0.0.5"}
+					]}]
+				}}
+			}]
+		}}`)
+	})
+	suite.mux.HandleFunc("/v2/product/mcr2/mcr-1/lookingGlass/bgpSessions", func(w http.ResponseWriter, r *http.Request) {
+		suite.mu.Lock()
+		defer suite.mu.Unlock()
+		fmt.Fprintf(w, `{"message":"ok","terms":"","data":[%s]}`, suite.sessions)
+	})
+}
+
+func (suite *BGPSessionMonitorTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *BGPSessionMonitorTestSuite) setSessions(s ...string) {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	suite.sessions = ""
+	for i, v := range s {
+		if i > 0 {
+			suite.sessions += ","
+		}
+		suite.sessions += v
+	}
+}
+
+func bgpSessionJSON(id, peer string, status BGPSessionStatus, uptime, prefixes int) string {
+	return fmt.Sprintf(`{"sessionId":%q,"neighborAddress":%q,"neighborAsn":65001,"status":%q,"uptime":%d,"prefixesIn":%d,"vxcId":101}`,
+		id, peer, status, uptime, prefixes)
+}
+
+func (suite *BGPSessionMonitorTestSuite) newMonitor(cfg *BGPSessionMonitorConfig) (*BGPSessionMonitor, *time.Time) {
+	cfg.MCRUIDs = []string{"mcr-1"}
+	cfg.Notifier = BGPSessionNotifierFunc(func(_ context.Context, e *BGPSessionEvent) {
+		suite.events = append(suite.events, e)
+	})
+	m, err := NewBGPSessionMonitor(suite.client, cfg)
+	suite.Require().NoError(err)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func (suite *BGPSessionMonitorTestSuite) drain() []BGPSessionEventType {
+	var types []BGPSessionEventType
+	for _, e := range suite.events {
+		types = append(types, e.Type)
+	}
+	suite.events = nil
+	return types
+}
+
+func (suite *BGPSessionMonitorTestSuite) TestStateChangesAndCorrelation() {
+	ctx := context.Background()
+	m, now := suite.newMonitor(&BGPSessionMonitorConfig{})
+
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusUp, 600, 10))
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Empty(suite.drain(), "baseline poll emits nothing")
+
+	*now = now.Add(time.Minute)
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusDown, 0, 0))
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Require().Len(suite.events, 1)
+	e := suite.events[0]
+	suite.Equal(BGPSessionEventStateChange, e.Type)
+	suite.Equal(BGPSessionStatusUp, e.Previous.Status)
+	suite.Equal(BGPSessionStatusDown, e.Session.Status)
+	suite.Require().NotNil(e.VXC)
+	suite.Equal("vxc-a", e.VXC.UID)
+	suite.Require().NotNil(e.BGPConnection)
+	suite.Equal("dc primary", e.BGPConnection.Description)
+	suite.drain()
+
+	// A new session triggers an MCR refresh only when its VXC is unknown.
+	*now = now.Add(time.Minute)
+	suite.setSessions(
+		bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusUp, 30, 10),
+		bgpSessionJSON("s2", "10.0.0.6", BGPSessionStatusUp, 30, 5),
+	)
+	suite.Require().NoError(m.Poll(ctx))
+	suite.ElementsMatch([]BGPSessionEventType{BGPSessionEventStateChange, BGPSessionEventSessionAdded}, suite.drain())
+	suite.Equal(1, suite.mcrGets)
+
+	*now = now.Add(time.Minute)
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusUp, 90, 10))
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Equal([]BGPSessionEventType{BGPSessionEventSessionRemoved}, suite.drain())
+}
+
+func (suite *BGPSessionMonitorTestSuite) TestUnknownVXCRefreshedOnce() {
+	ctx := context.Background()
+	m, now := suite.newMonitor(&BGPSessionMonitorConfig{})
+
+	suite.setSessions(`{"sessionId":"s9","neighborAddress":"10.9.0.2","status":"UP","uptime":60,"vxcId":999}`)
+	for i := 0; i < 3; i++ {
+		suite.Require().NoError(m.Poll(ctx))
+		*now = now.Add(time.Minute)
+	}
+	suite.Equal(1, suite.mcrGets, "a VXC missing from the MCR is only looked up once")
+
+	// A session on a VXC not seen before still triggers a refresh.
+	suite.setSessions(
+		`{"sessionId":"s9","neighborAddress":"10.9.0.2","status":"UP","uptime":120,"vxcId":999}`,
+		`{"sessionId":"s10","neighborAddress":"10.9.0.6","status":"UP","uptime":60,"vxcId":102}`,
+	)
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Equal(2, suite.mcrGets)
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Equal(2, suite.mcrGets)
+
+	// Unresolved VXCs are looked up again once VXCRetry has passed.
+	*now = now.Add(defaultBGPMonitorVXCRetry)
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Equal(3, suite.mcrGets)
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Equal(3, suite.mcrGets)
+}
+
+func (suite *BGPSessionMonitorTestSuite) TestFlapAndReset() {
+	ctx := context.Background()
+	m, now := suite.newMonitor(&BGPSessionMonitorConfig{FlapWindow: 10 * time.Minute, FlapThreshold: 3})
+
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusUp, 600, 10))
+	suite.Require().NoError(m.Poll(ctx))
+
+	// Uptime going backwards counts as down+up between polls.
+	*now = now.Add(time.Minute)
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusUp, 20, 10))
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Equal([]BGPSessionEventType{BGPSessionEventReset}, suite.drain())
+
+	*now = now.Add(time.Minute)
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusDown, 0, 0))
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Equal([]BGPSessionEventType{BGPSessionEventStateChange, BGPSessionEventFlap}, suite.drain())
+
+	// Still flapping: no repeat FLAP event.
+	*now = now.Add(time.Minute)
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusUp, 10, 10))
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Equal([]BGPSessionEventType{BGPSessionEventStateChange}, suite.drain())
+
+	// Once transitions age out of the window, a new episode can fire again.
+	*now = now.Add(time.Hour)
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusUp, 3600, 10))
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Empty(suite.drain())
+	suite.False(m.sessions["mcr-1"]["s1"].flapping)
+}
+
+func (suite *BGPSessionMonitorTestSuite) TestPrefixThresholds() {
+	ctx := context.Background()
+	m, now := suite.newMonitor(&BGPSessionMonitorConfig{Thresholds: BGPSessionThresholds{
+		MinPrefixesIn:       PtrTo(5),
+		MaxPrefixesIn:       PtrTo(100),
+		PrefixChangePercent: 50,
+	}})
+
+	// Breaches are reported even on the baseline poll.
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusUp, 600, 2))
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Equal([]BGPSessionEventType{BGPSessionEventPrefixThreshold}, suite.drain())
+
+	*now = now.Add(time.Minute)
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusUp, 660, 40))
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Equal([]BGPSessionEventType{BGPSessionEventPrefixChange, BGPSessionEventPrefixThresholdCleared}, suite.drain())
+
+	*now = now.Add(time.Minute)
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusUp, 720, 50))
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Empty(suite.drain(), "a 25 percent change is within the threshold")
+
+	*now = now.Add(time.Minute)
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusUp, 780, 500))
+	suite.Require().NoError(m.Poll(ctx))
+	suite.Equal([]BGPSessionEventType{BGPSessionEventPrefixChange, BGPSessionEventPrefixThreshold}, suite.drain())
+}
+
+func (suite *BGPSessionMonitorTestSuite) TestRunStopsOnCancel() {
+	m, _ := suite.newMonitor(&BGPSessionMonitorConfig{Interval: time.Millisecond})
+	suite.setSessions(bgpSessionJSON("s1", "10.0.0.2", BGPSessionStatusUp, 600, 10))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	suite.ErrorIs(m.Run(ctx), context.DeadlineExceeded)
+}
+
+func (suite *BGPSessionMonitorTestSuite) TestConfigValidation() {
+	notifier := BGPSessionNotifierFunc(func(context.Context, *BGPSessionEvent) {})
+	_, err := NewBGPSessionMonitor(suite.client, nil)
+	suite.ErrorIs(err, ErrBGPSessionMonitorNoMCRs)
+	_, err = NewBGPSessionMonitor(suite.client, &BGPSessionMonitorConfig{MCRUIDs: []string{"m"}})
+	suite.ErrorIs(err, ErrBGPSessionMonitorNotifierRequired)
+	_, err = NewBGPSessionMonitor(suite.client, &BGPSessionMonitorConfig{
+		MCRUIDs: []string{"m"}, Notifier: notifier,
+		Thresholds: BGPSessionThresholds{MinPrefixesIn: PtrTo(10), MaxPrefixesIn: PtrTo(1)},
+	})
+	suite.ErrorIs(err, ErrBGPSessionMonitorThresholdsInvalid)
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/url"
 	"time"
@@ -62,6 +61,22 @@ type MCRLookingGlassService interface {
 	WaitForMCRPing(ctx context.Context, mcrUID, operationID string) (*LookingGlassPingResult, error)
 	// WaitForMCRTraceroute polls until the traceroute result is available or context is cancelled.
 	WaitForMCRTraceroute(ctx context.Context, mcrUID, operationID string) (*LookingGlassTracerouteResult, error)
+	// SubmitIPRoutesOperation starts an async IP routes query and returns an Operation handle to wait on, poll or cancel.
+	SubmitIPRoutesOperation(ctx context.Context, mcrUID string) (*Operation[[]*LookingGlassIPRoute], error)
+	// SubmitBGPNeighborRoutesOperation starts an async BGP neighbor routes query and returns an Operation handle.
+	SubmitBGPNeighborRoutesOperation(ctx context.Context, req *ListBGPNeighborRoutesRequest) (*Operation[[]*LookingGlassBGPNeighborRoute], error)
+	// SubmitPingOperation starts a ping from the MCR and returns an Operation handle.
+	SubmitPingOperation(ctx context.Context, req *MCRPingRequest) (*Operation[*LookingGlassPingResult], error)
+	// SubmitTracerouteOperation starts a traceroute from the MCR and returns an Operation handle.
+	SubmitTracerouteOperation(ctx context.Context, req *MCRTracerouteRequest) (*Operation[*LookingGlassTracerouteResult], error)
+	// ResumeIPRoutesOperation returns a handle to an IP routes query from a persisted OperationRef.
+	ResumeIPRoutesOperation(ref OperationRef) (*Operation[[]*LookingGlassIPRoute], error)
+	// ResumeBGPNeighborRoutesOperation returns a handle to a BGP neighbor routes query from a persisted OperationRef.
+	ResumeBGPNeighborRoutesOperation(ref OperationRef) (*Operation[[]*LookingGlassBGPNeighborRoute], error)
+	// ResumePingOperation returns a handle to a ping from a persisted OperationRef.
+	ResumePingOperation(ref OperationRef) (*Operation[*LookingGlassPingResult], error)
+	// ResumeTracerouteOperation returns a handle to a traceroute from a persisted OperationRef.
+	ResumeTracerouteOperation(ref OperationRef) (*Operation[*LookingGlassTracerouteResult], error)
 }
 
 // defaultAsyncJobTimeout is applied to WaitForAsync* calls when the caller
@@ -73,16 +88,29 @@ const defaultAsyncJobTimeout = 5 * time.Minute
 // WaitForMCRTraceroute when the caller does not provide a context with a deadline.
 const mcrDiagnosticsPollTimeout = 5 * time.Minute
 
-// mcrDiagnosticsPollInterval is the interval between poll attempts for MCR diagnostics.
+// mcrDiagnosticsPollInterval is the initial interval between poll attempts for MCR diagnostics.
 const mcrDiagnosticsPollInterval = 3 * time.Second
 
+// lookingGlassAsyncJobPollInterval is the initial interval between polls of a
+// Looking Glass async job. These jobs are diagnostic and typically complete
+// faster than provisioning workflows (which use a 30s polling interval).
+const lookingGlassAsyncJobPollInterval = 5 * time.Second
+
+// Backoff applied by MCR diagnostics Operation handles: each pending poll
+// grows the interval by lookingGlassPollMultiplier up to
+// lookingGlassPollMaxInterval. The Wait* methods poll at a fixed interval.
+const (
+	lookingGlassPollMultiplier  = 1.5
+	lookingGlassPollMaxInterval = 30 * time.Second
+)
+
 // MCRLookingGlassServiceOp handles communication with MCR Looking Glass methods of the Megaport API.
 type MCRLookingGlassServiceOp struct {
 	Client *Client
-	// pollInterval overrides mcrDiagnosticsPollInterval when non-zero.
+	// pollInterval overrides mcrDiagnosticsPollInterval and lookingGlassAsyncJobPollInterval when non-zero.
 	// Intended for tests that want to avoid real-time waits.
 	pollInterval time.Duration
-	// pollTimeout overrides mcrDiagnosticsPollTimeout when non-zero.
+	// pollTimeout overrides mcrDiagnosticsPollTimeout and defaultAsyncJobTimeout when non-zero.
 	// Intended for tests that want to avoid real-time waits.
 	pollTimeout time.Duration
 }
@@ -110,51 +138,31 @@ func (svc *MCRLookingGlassServiceOp) diagnosticsPollTimeout() time.Duration {
 	return mcrDiagnosticsPollTimeout
 }
 
-// pollMCRDiagnostics is a generic helper that polls fetch until it returns a
-// non-nil result, pollCtx is done, or pollDoneErr fires. It performs an
-// immediate poll before starting the ticker so callers receive results without
-// delay when the operation is already complete.
-func pollMCRDiagnostics[T any](
-	pollCtx context.Context,
-	pollDoneErr func() error,
-	fetch func(context.Context) (*T, error),
-	interval time.Duration,
-) (*T, error) {
-	// When the poll context expires mid-request, Client.Do returns a wrapped
-	// context error. Attribute it to the deadline/cancellation via pollDoneErr
-	// so callers get a consistent error instead of a raw context error, while
-	// leaving genuine API/network failures untouched.
-	mapErr := func(err error) error {
-		if pollCtx.Err() != nil && (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
-			return pollDoneErr()
-		}
-		return err
+// diagnosticsPollOptions returns the default PollOptions for ping and traceroute operations.
+func (svc *MCRLookingGlassServiceOp) diagnosticsPollOptions() PollOptions {
+	return PollOptions{
+		Interval:    svc.diagnosticsPollInterval(),
+		Multiplier:  lookingGlassPollMultiplier,
+		MaxInterval: lookingGlassPollMaxInterval,
+		Timeout:     svc.diagnosticsPollTimeout(),
 	}
+}
 
-	result, err := fetch(pollCtx)
-	if err != nil {
-		return nil, mapErr(err)
-	}
-	if result != nil {
-		return result, nil
-	}
-
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-pollCtx.Done():
-			return nil, pollDoneErr()
-		case <-ticker.C:
-			result, err = fetch(pollCtx)
-			if err != nil {
-				return nil, mapErr(err)
-			}
-			if result != nil {
-				return result, nil
-			}
-		}
+// asyncJobPollOptions returns the default PollOptions for Looking Glass async jobs.
+func (svc *MCRLookingGlassServiceOp) asyncJobPollOptions() PollOptions {
+	interval := lookingGlassAsyncJobPollInterval
+	if svc.pollInterval != 0 {
+		interval = svc.pollInterval
+	}
+	timeout := defaultAsyncJobTimeout
+	if svc.pollTimeout != 0 {
+		timeout = svc.pollTimeout
+	}
+	return PollOptions{
+		Interval:    interval,
+		Multiplier:  lookingGlassPollMultiplier,
+		MaxInterval: lookingGlassPollMaxInterval,
+		Timeout:     timeout,
 	}
 }
 
@@ -465,78 +473,6 @@ func (svc *MCRLookingGlassServiceOp) GetAsyncBGPNeighborRoutes(ctx context.Conte
 	return apiResponse.Data, nil
 }
 
-// WaitForAsyncIPRoutes polls for async IP routes results until the job
-// completes or the context is cancelled. If the context has no deadline,
-// defaultAsyncJobTimeout is applied so callers who pass a bare context
-// are still protected from hanging indefinitely.
-func (svc *MCRLookingGlassServiceOp) WaitForAsyncIPRoutes(ctx context.Context, mcrUID string, jobID string) ([]*LookingGlassIPRoute, error) {
-	if mcrUID == "" {
-		return nil, fmt.Errorf("wait for async IP routes request MCRID cannot be empty")
-	}
-	if jobID == "" {
-		return nil, fmt.Errorf("wait for async IP routes request jobID cannot be empty")
-	}
-
-	if _, ok := ctx.Deadline(); !ok {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, defaultAsyncJobTimeout)
-		defer cancel()
-	}
-
-	// Check immediately before starting the ticker to return results without
-	// delay when the job is already complete.
-	result, err := svc.GetAsyncIPRoutes(ctx, mcrUID, jobID)
-	if err != nil {
-		return nil, err
-	}
-	if result == nil {
-		return nil, fmt.Errorf("async IP routes job %s returned nil result", jobID)
-	}
-	switch result.Status {
-	case LookingGlassAsyncStatusComplete:
-		return result.Routes, nil
-	case LookingGlassAsyncStatusFailed:
-		return nil, fmt.Errorf("async IP routes job %s failed", jobID)
-	case LookingGlassAsyncStatusPending, LookingGlassAsyncStatusProcessing:
-		// Continue to ticker-based polling below.
-	default:
-		return nil, fmt.Errorf("unknown async job status: %s", result.Status)
-	}
-
-	// Looking Glass async jobs are diagnostic and typically complete faster than provisioning
-	// workflows (which use a 30s polling interval). We poll more frequently (5s) here to
-	// return results sooner while still avoiding excessive request volume.
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, fmt.Errorf("waiting for async IP routes job %s: %w", jobID, ctx.Err())
-		case <-ticker.C:
-			result, err := svc.GetAsyncIPRoutes(ctx, mcrUID, jobID)
-			if err != nil {
-				return nil, err
-			}
-			if result == nil {
-				return nil, fmt.Errorf("async IP routes job %s returned nil result", jobID)
-			}
-
-			switch result.Status {
-			case LookingGlassAsyncStatusComplete:
-				return result.Routes, nil
-			case LookingGlassAsyncStatusFailed:
-				return nil, fmt.Errorf("async IP routes job %s failed", jobID)
-			case LookingGlassAsyncStatusPending, LookingGlassAsyncStatusProcessing:
-				// Continue polling
-				continue
-			default:
-				return nil, fmt.Errorf("unknown async job status: %s", result.Status)
-			}
-		}
-	}
-}
-
 // PingMCR initiates an ICMP ping from the MCR and returns the operation ID.
 func (svc *MCRLookingGlassServiceOp) PingMCR(ctx context.Context, req *MCRPingRequest) (string, error) {
 	if req == nil {
@@ -703,40 +639,60 @@ func (svc *MCRLookingGlassServiceOp) GetMCRTracerouteResult(ctx context.Context,
 	return apiResponse.Data, nil
 }
 
-// WaitForMCRPing polls until the ping result is available or context is cancelled.
-// If the context has no deadline, mcrDiagnosticsPollTimeout is applied.
-func (svc *MCRLookingGlassServiceOp) WaitForMCRPing(ctx context.Context, mcrUID, operationID string) (*LookingGlassPingResult, error) {
+// WaitForAsyncIPRoutes polls for async IP routes results until the job
+// completes or the context is cancelled. If the context has no deadline,
+// defaultAsyncJobTimeout is applied so callers who pass a bare context
+// are still protected from hanging indefinitely.
+func (svc *MCRLookingGlassServiceOp) WaitForAsyncIPRoutes(ctx context.Context, mcrUID string, jobID string) ([]*LookingGlassIPRoute, error) {
 	if mcrUID == "" {
-		return nil, ErrMCRDiagnosticsMCRUIDRequired
+		return nil, fmt.Errorf("wait for async IP routes request MCRID cannot be empty")
 	}
-	if operationID == "" {
-		return nil, ErrMCRDiagnosticsOperationEmpty
+	if jobID == "" {
+		return nil, fmt.Errorf("wait for async IP routes request jobID cannot be empty")
 	}
-
-	// pollCtx carries the SDK-managed deadline; ctx is the caller's original
-	// context. pollDoneErr distinguishes caller cancellation from SDK timeout.
-	pollCtx := ctx
+	// A bare context gets defaultAsyncJobTimeout as its own deadline, so the
+	// timeout surfaces as context.DeadlineExceeded rather than
+	// ErrMCRDiagnosticsTimeout.
 	if _, ok := ctx.Deadline(); !ok {
 		var cancel context.CancelFunc
-		pollCtx, cancel = context.WithTimeout(ctx, svc.diagnosticsPollTimeout())
+		ctx, cancel = context.WithTimeout(ctx, svc.asyncJobPollOptions().Timeout)
 		defer cancel()
 	}
+	return svc.ipRoutesOperation(mcrUID, jobID).Wait(ctx, fixedIntervalPollOptions())
+}
 
-	pollDoneErr := func() error {
-		if err := ctx.Err(); err != nil {
-			return err // caller cancelled or deadline exceeded
-		}
-		return ErrMCRDiagnosticsTimeout // SDK-managed timeout fired
+// WaitForAsyncBGPNeighborRoutes polls for async BGP neighbor routes results
+// until the job completes or the context is cancelled. If the context has
+// no deadline, defaultAsyncJobTimeout is applied so callers who pass a
+// bare context are still protected from hanging indefinitely.
+func (svc *MCRLookingGlassServiceOp) WaitForAsyncBGPNeighborRoutes(ctx context.Context, mcrUID string, jobID string) ([]*LookingGlassBGPNeighborRoute, error) {
+	if mcrUID == "" {
+		return nil, fmt.Errorf("wait for async BGP neighbor routes request MCRID cannot be empty")
+	}
+	if jobID == "" {
+		return nil, fmt.Errorf("wait for async BGP neighbor routes request jobID cannot be empty")
+	}
+	// A bare context gets defaultAsyncJobTimeout as its own deadline, so the
+	// timeout surfaces as context.DeadlineExceeded rather than
+	// ErrMCRDiagnosticsTimeout.
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, svc.asyncJobPollOptions().Timeout)
+		defer cancel()
 	}
+	return svc.bgpNeighborRoutesOperation(mcrUID, jobID).Wait(ctx, fixedIntervalPollOptions())
+}
 
-	return pollMCRDiagnostics(
-		pollCtx,
-		pollDoneErr,
-		func(c context.Context) (*LookingGlassPingResult, error) {
-			return svc.GetMCRPingResult(c, mcrUID, operationID)
-		},
-		svc.diagnosticsPollInterval(),
-	)
+// WaitForMCRPing polls until the ping result is available or context is cancelled.
+// If the context has no deadline, mcrDiagnosticsPollTimeout is applied.
+func (svc *MCRLookingGlassServiceOp) WaitForMCRPing(ctx context.Context, mcrUID, operationID string) (*LookingGlassPingResult, error) {
+	if mcrUID == "" {
+		return nil, ErrMCRDiagnosticsMCRUIDRequired
+	}
+	if operationID == "" {
+		return nil, ErrMCRDiagnosticsOperationEmpty
+	}
+	return svc.pingOperation(mcrUID, operationID).Wait(ctx, fixedIntervalPollOptions())
 }
 
 // WaitForMCRTraceroute polls until the traceroute result is available or context is cancelled.
@@ -748,101 +704,149 @@ func (svc *MCRLookingGlassServiceOp) WaitForMCRTraceroute(ctx context.Context, m
 	if operationID == "" {
 		return nil, ErrMCRDiagnosticsOperationEmpty
 	}
+	return svc.tracerouteOperation(mcrUID, operationID).Wait(ctx, fixedIntervalPollOptions())
+}
 
-	// pollCtx carries the SDK-managed deadline; ctx is the caller's original
-	// context. pollDoneErr distinguishes caller cancellation from SDK timeout.
-	pollCtx := ctx
-	if _, ok := ctx.Deadline(); !ok {
-		var cancel context.CancelFunc
-		pollCtx, cancel = context.WithTimeout(ctx, svc.diagnosticsPollTimeout())
-		defer cancel()
+// SubmitIPRoutesOperation starts an async IP routes query and returns a handle to it.
+func (svc *MCRLookingGlassServiceOp) SubmitIPRoutesOperation(ctx context.Context, mcrUID string) (*Operation[[]*LookingGlassIPRoute], error) {
+	job, err := svc.ListIPRoutesAsync(ctx, mcrUID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.JobID == "" {
+		return nil, ErrMCRDiagnosticsOperationEmpty
 	}
+	return svc.ipRoutesOperation(mcrUID, job.JobID), nil
+}
 
-	pollDoneErr := func() error {
-		if err := ctx.Err(); err != nil {
-			return err // caller cancelled or deadline exceeded
-		}
-		return ErrMCRDiagnosticsTimeout // SDK-managed timeout fired
+// SubmitBGPNeighborRoutesOperation starts an async BGP neighbor routes query and returns a handle to it.
+func (svc *MCRLookingGlassServiceOp) SubmitBGPNeighborRoutesOperation(ctx context.Context, req *ListBGPNeighborRoutesRequest) (*Operation[[]*LookingGlassBGPNeighborRoute], error) {
+	job, err := svc.ListBGPNeighborRoutesAsync(ctx, req)
+	if err != nil {
+		return nil, err
 	}
+	if job == nil || job.JobID == "" {
+		return nil, ErrMCRDiagnosticsOperationEmpty
+	}
+	return svc.bgpNeighborRoutesOperation(req.MCRID, job.JobID), nil
+}
 
-	return pollMCRDiagnostics(
-		pollCtx,
-		pollDoneErr,
-		func(c context.Context) (*LookingGlassTracerouteResult, error) {
-			return svc.GetMCRTracerouteResult(c, mcrUID, operationID)
-		},
-		svc.diagnosticsPollInterval(),
-	)
+// SubmitPingOperation starts a ping from the MCR and returns a handle to it.
+func (svc *MCRLookingGlassServiceOp) SubmitPingOperation(ctx context.Context, req *MCRPingRequest) (*Operation[*LookingGlassPingResult], error) {
+	operationID, err := svc.PingMCR(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return svc.pingOperation(req.MCRID, operationID), nil
 }
 
-// WaitForAsyncBGPNeighborRoutes polls for async BGP neighbor routes results
-// until the job completes or the context is cancelled. If the context has
-// no deadline, defaultAsyncJobTimeout is applied so callers who pass a
-// bare context are still protected from hanging indefinitely.
-func (svc *MCRLookingGlassServiceOp) WaitForAsyncBGPNeighborRoutes(ctx context.Context, mcrUID string, jobID string) ([]*LookingGlassBGPNeighborRoute, error) {
-	if mcrUID == "" {
-		return nil, fmt.Errorf("wait for async BGP neighbor routes request MCRID cannot be empty")
+// SubmitTracerouteOperation starts a traceroute from the MCR and returns a handle to it.
+func (svc *MCRLookingGlassServiceOp) SubmitTracerouteOperation(ctx context.Context, req *MCRTracerouteRequest) (*Operation[*LookingGlassTracerouteResult], error) {
+	operationID, err := svc.TracerouteMCR(ctx, req)
+	if err != nil {
+		return nil, err
 	}
-	if jobID == "" {
-		return nil, fmt.Errorf("wait for async BGP neighbor routes request jobID cannot be empty")
+	return svc.tracerouteOperation(req.MCRID, operationID), nil
+}
+
+// ResumeIPRoutesOperation returns a handle to a previously submitted IP routes query.
+func (svc *MCRLookingGlassServiceOp) ResumeIPRoutesOperation(ref OperationRef) (*Operation[[]*LookingGlassIPRoute], error) {
+	if err := ref.validate(OperationKindMCRIPRoutes); err != nil {
+		return nil, err
 	}
+	return svc.ipRoutesOperation(ref.ProductUID, ref.OperationID), nil
+}
 
-	if _, ok := ctx.Deadline(); !ok {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, defaultAsyncJobTimeout)
-		defer cancel()
+// ResumeBGPNeighborRoutesOperation returns a handle to a previously submitted BGP neighbor routes query.
+func (svc *MCRLookingGlassServiceOp) ResumeBGPNeighborRoutesOperation(ref OperationRef) (*Operation[[]*LookingGlassBGPNeighborRoute], error) {
+	if err := ref.validate(OperationKindMCRBGPNeighborRoutes); err != nil {
+		return nil, err
 	}
+	return svc.bgpNeighborRoutesOperation(ref.ProductUID, ref.OperationID), nil
+}
 
-	// Check immediately before starting the ticker to return results without
-	// delay when the job is already complete.
-	result, err := svc.GetAsyncBGPNeighborRoutes(ctx, mcrUID, jobID)
-	if err != nil {
+// ResumePingOperation returns a handle to a previously submitted ping.
+func (svc *MCRLookingGlassServiceOp) ResumePingOperation(ref OperationRef) (*Operation[*LookingGlassPingResult], error) {
+	if err := ref.validate(OperationKindMCRPing); err != nil {
 		return nil, err
 	}
-	if result == nil {
-		return nil, fmt.Errorf("async BGP neighbor routes job %s returned nil result", jobID)
+	return svc.pingOperation(ref.ProductUID, ref.OperationID), nil
+}
+
+// ResumeTracerouteOperation returns a handle to a previously submitted traceroute.
+func (svc *MCRLookingGlassServiceOp) ResumeTracerouteOperation(ref OperationRef) (*Operation[*LookingGlassTracerouteResult], error) {
+	if err := ref.validate(OperationKindMCRTraceroute); err != nil {
+		return nil, err
 	}
-	switch result.Status {
+	return svc.tracerouteOperation(ref.ProductUID, ref.OperationID), nil
+}
+
+// ipRoutesOperation builds the Operation polling GetAsyncIPRoutes.
+func (svc *MCRLookingGlassServiceOp) ipRoutesOperation(mcrUID, jobID string) *Operation[[]*LookingGlassIPRoute] {
+	ref := OperationRef{Kind: OperationKindMCRIPRoutes, ProductUID: mcrUID, OperationID: jobID}
+	return newOperation(ref, svc.asyncJobPollOptions(), ErrMCRDiagnosticsTimeout, func(ctx context.Context) ([]*LookingGlassIPRoute, bool, error) {
+		result, err := svc.GetAsyncIPRoutes(ctx, mcrUID, jobID)
+		if err != nil {
+			return nil, false, err
+		}
+		if result == nil {
+			return nil, false, fmt.Errorf("async IP routes job %s returned nil result", jobID)
+		}
+		done, err := lookingGlassAsyncJobDone(result.Status, "IP routes", jobID)
+		if !done || err != nil {
+			return nil, done, err
+		}
+		return result.Routes, true, nil
+	})
+}
+
+// bgpNeighborRoutesOperation builds the Operation polling GetAsyncBGPNeighborRoutes.
+func (svc *MCRLookingGlassServiceOp) bgpNeighborRoutesOperation(mcrUID, jobID string) *Operation[[]*LookingGlassBGPNeighborRoute] {
+	ref := OperationRef{Kind: OperationKindMCRBGPNeighborRoutes, ProductUID: mcrUID, OperationID: jobID}
+	return newOperation(ref, svc.asyncJobPollOptions(), ErrMCRDiagnosticsTimeout, func(ctx context.Context) ([]*LookingGlassBGPNeighborRoute, bool, error) {
+		result, err := svc.GetAsyncBGPNeighborRoutes(ctx, mcrUID, jobID)
+		if err != nil {
+			return nil, false, err
+		}
+		if result == nil {
+			return nil, false, fmt.Errorf("async BGP neighbor routes job %s returned nil result", jobID)
+		}
+		done, err := lookingGlassAsyncJobDone(result.Status, "BGP neighbor routes", jobID)
+		if !done || err != nil {
+			return nil, done, err
+		}
+		return result.Routes, true, nil
+	})
+}
+
+// pingOperation builds the Operation polling GetMCRPingResult. A nil result means pending.
+func (svc *MCRLookingGlassServiceOp) pingOperation(mcrUID, operationID string) *Operation[*LookingGlassPingResult] {
+	ref := OperationRef{Kind: OperationKindMCRPing, ProductUID: mcrUID, OperationID: operationID}
+	return newOperation(ref, svc.diagnosticsPollOptions(), ErrMCRDiagnosticsTimeout, func(ctx context.Context) (*LookingGlassPingResult, bool, error) {
+		result, err := svc.GetMCRPingResult(ctx, mcrUID, operationID)
+		return result, result != nil, err
+	})
+}
+
+// tracerouteOperation builds the Operation polling GetMCRTracerouteResult. A nil result means pending.
+func (svc *MCRLookingGlassServiceOp) tracerouteOperation(mcrUID, operationID string) *Operation[*LookingGlassTracerouteResult] {
+	ref := OperationRef{Kind: OperationKindMCRTraceroute, ProductUID: mcrUID, OperationID: operationID}
+	return newOperation(ref, svc.diagnosticsPollOptions(), ErrMCRDiagnosticsTimeout, func(ctx context.Context) (*LookingGlassTracerouteResult, bool, error) {
+		result, err := svc.GetMCRTracerouteResult(ctx, mcrUID, operationID)
+		return result, result != nil, err
+	})
+}
+
+// lookingGlassAsyncJobDone interprets a Looking Glass async job status.
+func lookingGlassAsyncJobDone(status LookingGlassAsyncStatus, what, jobID string) (bool, error) {
+	switch status {
 	case LookingGlassAsyncStatusComplete:
-		return result.Routes, nil
+		return true, nil
 	case LookingGlassAsyncStatusFailed:
-		return nil, fmt.Errorf("async BGP neighbor routes job %s failed", jobID)
+		return true, fmt.Errorf("async %s job %s: %w", what, jobID, ErrOperationFailed)
 	case LookingGlassAsyncStatusPending, LookingGlassAsyncStatusProcessing:
-		// Continue to ticker-based polling below.
+		return false, nil
 	default:
-		return nil, fmt.Errorf("unknown async job status: %s", result.Status)
-	}
-
-	// Looking Glass async jobs are diagnostic and typically complete faster than provisioning
-	// workflows (which use a 30s polling interval). We poll more frequently (5s) here to
-	// return results sooner while still avoiding excessive request volume.
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, fmt.Errorf("waiting for async BGP neighbor routes job %s: %w", jobID, ctx.Err())
-		case <-ticker.C:
-			result, err := svc.GetAsyncBGPNeighborRoutes(ctx, mcrUID, jobID)
-			if err != nil {
-				return nil, err
-			}
-			if result == nil {
-				return nil, fmt.Errorf("async BGP neighbor routes job %s returned nil result", jobID)
-			}
-
-			switch result.Status {
-			case LookingGlassAsyncStatusComplete:
-				return result.Routes, nil
-			case LookingGlassAsyncStatusFailed:
-				return nil, fmt.Errorf("async BGP neighbor routes job %s failed", jobID)
-			case LookingGlassAsyncStatusPending, LookingGlassAsyncStatusProcessing:
-				// Continue polling
-				continue
-			default:
-				return nil, fmt.Errorf("unknown async job status: %s", result.Status)
-			}
-		}
+		return false, fmt.Errorf("unknown async job status: %s", status)
 	}
 }
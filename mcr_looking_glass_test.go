@@ -1437,6 +1437,28 @@ func (suite *MCRLookingGlassClientTestSuite) TestWaitForMCRTracerouteTimeoutDuri
 	suite.ErrorIs(err, ErrMCRDiagnosticsTimeout)
 }
 
+// TestWaitForAsyncIPRoutesTimeout verifies that the SDK-managed timeout for
+// WaitForAsyncIPRoutes surfaces as context.DeadlineExceeded.
+func (suite *MCRLookingGlassClientTestSuite) TestWaitForAsyncIPRoutesTimeout() {
+	lgSvc := suite.client.MCRLookingGlassService
+	mcrUID := "36b3f68e-2f54-4331-bf94-f8984449365f"
+	jobID := "job-timeout"
+
+	op, ok := lgSvc.(*MCRLookingGlassServiceOp)
+	suite.Require().True(ok)
+	op.pollInterval = time.Millisecond
+	op.pollTimeout = 20 * time.Millisecond
+
+	path := fmt.Sprintf("/v2/product/mcr2/%s/lookingGlass/routes/async/%s", mcrUID, jobID)
+	suite.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":{"status":"PENDING"}}`)
+	})
+
+	_, err := lgSvc.WaitForAsyncIPRoutes(context.Background(), mcrUID, jobID)
+	suite.ErrorIs(err, context.DeadlineExceeded)
+	suite.False(errors.Is(err, ErrMCRDiagnosticsTimeout))
+}
+
 // TestWaitForMCRPingCallerDeadlineDuringRequest verifies that a caller-provided
 // deadline firing mid-request surfaces as the caller's context error, not the
 // SDK timeout sentinel.
@@ -1457,3 +1479,72 @@ func (suite *MCRLookingGlassClientTestSuite) TestWaitForMCRPingCallerDeadlineDur
 	suite.ErrorIs(err, context.DeadlineExceeded)
 	suite.False(errors.Is(err, ErrMCRDiagnosticsTimeout))
 }
+
+// TestSubmitAndResumePingOperation verifies a ping submitted as an Operation
+// can be resumed from its persisted reference and waited on.
+func (suite *MCRLookingGlassClientTestSuite) TestSubmitAndResumePingOperation() {
+	ctx := context.Background()
+	lgSvc := suite.client.MCRLookingGlassService
+	mcrUID := "36b3f68e-2f54-4331-bf94-f8984449365f"
+
+	op, ok := lgSvc.(*MCRLookingGlassServiceOp)
+	suite.Require().True(ok)
+	op.pollInterval = 5 * time.Millisecond
+
+	suite.mux.HandleFunc(fmt.Sprintf("/v2/product/mcr2/%s/diagnostics/ping", mcrUID), func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":"op-resume"}`)
+	})
+	var calls atomic.Int32
+	suite.mux.HandleFunc(fmt.Sprintf("/v2/product/mcr2/%s/diagnostics/routes/operation", mcrUID), func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("op-resume", r.URL.Query().Get("operationId"))
+		if calls.Add(1) == 1 {
+			fmt.Fprint(w, `{"message":"pending","terms":"","data":null}`)
+			return
+		}
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":{"rawOutput":"PING 8.8.8.8"}}`)
+	})
+
+	submitted, err := lgSvc.SubmitPingOperation(ctx, &MCRPingRequest{MCRID: mcrUID, DestinationAddress: "8.8.8.8"})
+	suite.Require().NoError(err)
+	suite.Equal("op-resume", submitted.ID())
+	suite.Equal(OperationRef{Kind: OperationKindMCRPing, ProductUID: mcrUID, OperationID: "op-resume"}, submitted.Ref())
+
+	_, done, err := submitted.Poll(ctx)
+	suite.NoError(err)
+	suite.False(done)
+
+	resumed, err := lgSvc.ResumePingOperation(submitted.Ref())
+	suite.Require().NoError(err)
+	var attempts int
+	result, err := resumed.Wait(ctx, &PollOptions{OnProgress: func(p OperationProgress) { attempts = p.Attempt }})
+	suite.Require().NoError(err)
+	suite.Equal("PING 8.8.8.8", result.RawOutput)
+	suite.Equal(1, attempts)
+	suite.Equal(OperationStateComplete, resumed.State())
+
+	_, err = lgSvc.ResumeTracerouteOperation(submitted.Ref())
+	suite.ErrorIs(err, ErrOperationKindMismatch)
+}
+
+// TestSubmitIPRoutesOperationFailed verifies a FAILED async job marks the Operation failed.
+func (suite *MCRLookingGlassClientTestSuite) TestSubmitIPRoutesOperationFailed() {
+	ctx := context.Background()
+	lgSvc := suite.client.MCRLookingGlassService
+	mcrUID := "36b3f68e-2f54-4331-bf94-f8984449365f"
+
+	suite.mux.HandleFunc(fmt.Sprintf("/v2/product/mcr2/%s/lookingGlass/routes", mcrUID), func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("true", r.URL.Query().Get("async"))
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":{"jobId":"job-1","status":"PENDING"}}`)
+	})
+	suite.mux.HandleFunc(fmt.Sprintf("/v2/product/mcr2/%s/lookingGlass/routes/async/job-1", mcrUID), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":{"jobId":"job-1","status":"FAILED"}}`)
+	})
+
+	op, err := lgSvc.SubmitIPRoutesOperation(ctx, mcrUID)
+	suite.Require().NoError(err)
+	suite.Equal(OperationKindMCRIPRoutes, op.Ref().Kind)
+	_, err = op.Wait(ctx, nil)
+	suite.ErrorIs(err, ErrOperationFailed)
+	suite.Equal(OperationStateFailed, op.State())
+}
@@ -0,0 +1,321 @@
+package megaport
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// MCR placement planner errors.
+var (
+	ErrMCRPlacementNoTargets      = errors.New("MCR placement requires at least one site or on-ramp")
+	ErrMCRPlacementSiteInvalid    = errors.New("MCR placement sites require a location ID and a non-negative weight")
+	ErrMCRPlacementNoCandidates   = errors.New("no candidate locations are orderable for MCR")
+	ErrMCRPlacementWeightNegative = errors.New("MCR placement on-ramp weight must not be negative")
+)
+
+// defaultMCRPlacementMonths is the number of past months of RTT data used
+// when MCRPlacementRequest.Months is empty.
+const defaultMCRPlacementMonths = 3
+
+// PlacementTargetKind distinguishes customer sites from cloud on-ramps in an
+// MCR placement plan.
+type PlacementTargetKind string
+
+const (
+	PlacementTargetSite   PlacementTargetKind = "SITE"
+	PlacementTargetOnRamp PlacementTargetKind = "ONRAMP"
+)
+
+// MCRPlacementSite is a customer site, identified by the Megaport location
+// its port is (or will be) in.
+type MCRPlacementSite struct {
+	Name       string
+	LocationID int
+	// Weight scales the site's RTT in the score. Zero means 1.
+	Weight float64
+}
+
+// MCRPlacementRequest describes the sites and cloud on-ramps an MCR must
+// reach.
+type MCRPlacementRequest struct {
+	Sites []*MCRPlacementSite
+	// OnRamps are partner ports, typically from ListPartnerMegaports filtered
+	// by provider. They are grouped by company name and, for each group, the
+	// on-ramp nearest the candidate is used, since the MCR would connect to
+	// the closest one.
+	OnRamps []*PartnerMegaport
+	// OnRampWeight scales each on-ramp group's RTT in the score. Zero means 1.
+	OnRampWeight float64
+	// CandidateLocationIDs limits the locations considered. When empty every
+	// location orderable for MCR is a candidate.
+	CandidateLocationIDs []int
+	// Months of RTT data to average. Defaults to the last three complete
+	// months.
+	Months []RTTMonth
+	// Matrix optionally supplies a shared RTT cache across plans.
+	Matrix *RTTMatrix
+	// MaxResults limits the number of ranked candidates; zero returns all.
+	MaxResults int
+}
+
+// PlacementRTT is the expected RTT from a candidate to one target.
+type PlacementRTT struct {
+	Kind       PlacementTargetKind `json:"kind"`
+	Name       string              `json:"name"`
+	LocationID int                 `json:"locationId"`
+	// ProductUID is the chosen partner port for on-ramp targets.
+	ProductUID string  `json:"productUid,omitempty"`
+	RTT        float64 `json:"rttMs"`
+}
+
+// MCRPlacementCandidate is a ranked candidate location.
+type MCRPlacementCandidate struct {
+	Rank       int    `json:"rank"`
+	LocationID int    `json:"locationId"`
+	Name       string `json:"name"`
+	Metro      string `json:"metro"`
+	// Score is the weighted mean RTT to every target in milliseconds.
+	Score float64 `json:"scoreMs"`
+	// MaxRTT is the worst RTT to any single target in milliseconds.
+	MaxRTT  float64         `json:"maxRttMs"`
+	Targets []*PlacementRTT `json:"targets"`
+}
+
+// MCRPlacementPlan is the result of PlanMCRPlacement.
+type MCRPlacementPlan struct {
+	Months     []RTTMonth               `json:"months"`
+	Candidates []*MCRPlacementCandidate `json:"candidates"`
+	// Incomplete lists candidate location IDs skipped because RTT data to
+	// at least one target was missing.
+	Incomplete []int `json:"incomplete,omitempty"`
+}
+
+// placementTarget is a site or on-ramp group resolved from the request.
+type placementTarget struct {
+	kind      PlacementTargetKind
+	name      string
+	weight    float64
+	locations []int
+	uids      []string
+}
+
+// PlanMCRPlacement ranks candidate locations for an MCR by expected latency
+// to the requested sites and cloud on-ramps. Latency is the mean of the
+// monthly median RTTs from GetRoundTripTimes; RTTs are fetched once per site
+// and on-ramp location and treated as symmetric. Candidates are ordered by
+// score, then by worst-case RTT, then by location ID.
+func (svc *MCRServiceOp) PlanMCRPlacement(ctx context.Context, req *MCRPlacementRequest) (*MCRPlacementPlan, error) {
+	if req == nil {
+		return nil, ErrMCRPlacementNoTargets
+	}
+	targets, err := mcrPlacementTargets(req)
+	if err != nil {
+		return nil, err
+	}
+
+	months := req.Months
+	if len(months) == 0 {
+		months = RecentRTTMonths(time.Now(), defaultMCRPlacementMonths)
+	}
+	matrix := req.Matrix
+	if matrix == nil {
+		matrix = NewRTTMatrix(svc.Client)
+	}
+	var sources []int
+	seen := make(map[int]bool)
+	for _, t := range targets {
+		for _, id := range t.locations {
+			if !seen[id] {
+				seen[id] = true
+				sources = append(sources, id)
+			}
+		}
+	}
+	if err := matrix.Load(ctx, sources, months); err != nil {
+		return nil, err
+	}
+
+	candidates, err := svc.mcrPlacementCandidates(ctx, req.CandidateLocationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &MCRPlacementPlan{Months: months}
+	for _, loc := range candidates {
+		c, ok := scoreMCRPlacement(matrix, months, loc, targets)
+		if !ok {
+			plan.Incomplete = append(plan.Incomplete, loc.ID)
+			continue
+		}
+		plan.Candidates = append(plan.Candidates, c)
+	}
+	sort.Slice(plan.Candidates, func(i, j int) bool {
+		a, b := plan.Candidates[i], plan.Candidates[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		if a.MaxRTT != b.MaxRTT {
+			return a.MaxRTT < b.MaxRTT
+		}
+		return a.LocationID < b.LocationID
+	})
+	if req.MaxResults > 0 && len(plan.Candidates) > req.MaxResults {
+		plan.Candidates = plan.Candidates[:req.MaxResults]
+	}
+	for i, c := range plan.Candidates {
+		c.Rank = i + 1
+	}
+	sort.Ints(plan.Incomplete)
+	return plan, nil
+}
+
+// mcrPlacementTargets validates the request and resolves sites and on-ramp
+// groups, in request order.
+func mcrPlacementTargets(req *MCRPlacementRequest) ([]*placementTarget, error) {
+	if req.OnRampWeight < 0 {
+		return nil, ErrMCRPlacementWeightNegative
+	}
+	var targets []*placementTarget
+	for _, s := range req.Sites {
+		if s == nil || s.LocationID < 1 || s.Weight < 0 {
+			return nil, ErrMCRPlacementSiteInvalid
+		}
+		name := s.Name
+		if name == "" {
+			name = strconv.Itoa(s.LocationID)
+		}
+		targets = append(targets, &placementTarget{
+			kind:      PlacementTargetSite,
+			name:      name,
+			weight:    weightOrOne(s.Weight),
+			locations: []int{s.LocationID},
+			uids:      []string{""},
+		})
+	}
+
+	groups := make(map[string]*placementTarget)
+	for _, p := range req.OnRamps {
+		if p == nil {
+			continue
+		}
+		name := p.CompanyName
+		if name == "" {
+			name = p.ProductName
+		}
+		g, ok := groups[name]
+		if !ok {
+			g = &placementTarget{kind: PlacementTargetOnRamp, name: name, weight: weightOrOne(req.OnRampWeight)}
+			groups[name] = g
+			targets = append(targets, g)
+		}
+		g.locations = append(g.locations, p.LocationId)
+		g.uids = append(g.uids, p.ProductUID)
+	}
+
+	if len(targets) == 0 {
+		return nil, ErrMCRPlacementNoTargets
+	}
+	return targets, nil
+}
+
+func weightOrOne(w float64) float64 {
+	if w == 0 {
+		return 1
+	}
+	return w
+}
+
+// mcrPlacementCandidates returns the MCR-orderable locations, limited to ids
+// when given.
+func (svc *MCRServiceOp) mcrPlacementCandidates(ctx context.Context, ids []int) ([]*LocationV3, error) {
+	locations, err := svc.Client.LocationService.ListLocationsV3(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := NewLocationQuery().Orderable(LocationProductMCR)
+	if len(ids) > 0 {
+		wanted := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			wanted[id] = true
+		}
+		query.Where(func(l *LocationV3) bool { return wanted[l.ID] })
+	}
+	candidates := query.Filter(locations)
+	if len(candidates) == 0 {
+		return nil, ErrMCRPlacementNoCandidates
+	}
+	return candidates, nil
+}
+
+// scoreMCRPlacement computes a candidate's RTT to every target. ok is false
+// when any target has no RTT data.
+func scoreMCRPlacement(matrix *RTTMatrix, months []RTTMonth, loc *LocationV3, targets []*placementTarget) (*MCRPlacementCandidate, bool) {
+	c := &MCRPlacementCandidate{LocationID: loc.ID, Name: loc.Name, Metro: loc.Metro}
+	var weighted, weights float64
+	for _, t := range targets {
+		best := -1
+		var bestRTT float64
+		for i, id := range t.locations {
+			rtt, ok := matrix.MeanRTT(id, loc.ID, months)
+			if ok && (best < 0 || rtt < bestRTT) {
+				best, bestRTT = i, rtt
+			}
+		}
+		if best < 0 {
+			return nil, false
+		}
+		c.Targets = append(c.Targets, &PlacementRTT{
+			Kind:       t.kind,
+			Name:       t.name,
+			LocationID: t.locations[best],
+			ProductUID: t.uids[best],
+			RTT:        bestRTT,
+		})
+		weighted += bestRTT * t.weight
+		weights += t.weight
+		c.MaxRTT = max(c.MaxRTT, bestRTT)
+	}
+	if weights > 0 {
+		c.Score = weighted / weights
+	}
+	return c, true
+}
+
+// WriteJSON encodes the plan as indented JSON.
+func (p *MCRPlacementPlan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// WriteCSV writes one row per ranked candidate. After the fixed columns,
+// each target gets an RTT column named "<kind>:<name>", in request order.
+func (p *MCRPlacementPlan) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"rank", "location_id", "location_name", "metro", "score_ms", "max_rtt_ms"}
+	if len(p.Candidates) > 0 {
+		for _, t := range p.Candidates[0].Targets {
+			header = append(header, string(t.Kind)+":"+t.Name)
+		}
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, c := range p.Candidates {
+		record := []string{strconv.Itoa(c.Rank), strconv.Itoa(c.LocationID), c.Name, c.Metro, formatMs(c.Score), formatMs(c.MaxRTT)}
+		for _, t := range c.Targets {
+			record = append(record, formatMs(t.RTT))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,161 @@
+package megaport
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+// placementLocationsJSON holds four MCR-orderable locations (50 has no RTT
+// data) and one location without MCR support.
+const placementLocationsJSON = `{"message": "List public locations", "data": [
+    {"id": 10, "name": "Sydney", "metro": "Sydney", "status": "Active", "diversityZones": {"red": {"mcrSpeedMbps": [1000]}}},
+    {"id": 20, "name": "Melbourne", "metro": "Melbourne", "status": "Active", "diversityZones": {"red": {"mcrSpeedMbps": [1000]}}},
+    {"id": 30, "name": "Brisbane", "metro": "Brisbane", "status": "Active", "diversityZones": {"blue": {"mcrSpeedMbps": [1000]}}},
+    {"id": 40, "name": "Canberra", "metro": "Canberra", "status": "Active", "diversityZones": {"red": {"megaportSpeedMbps": [1000]}}},
+    {"id": 50, "name": "Perth", "metro": "Perth", "status": "Active", "diversityZones": {"red": {"mcrSpeedMbps": [1000]}}}
+]}`
+
+func newPlacementTestClient(t *testing.T, calls *atomic.Int32) *Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/locations", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, placementLocationsJSON)
+	})
+	return newRTTTestClient(t, mux, calls)
+}
+
+func placementTestRequest() *MCRPlacementRequest {
+	return &MCRPlacementRequest{
+		Sites: []*MCRPlacementSite{
+			{Name: "HQ", LocationID: 10, Weight: 2},
+			{Name: "Branch", LocationID: 20},
+		},
+		OnRamps: []*PartnerMegaport{
+			{CompanyName: "AWS", ProductUID: "aws-bne", LocationId: 30},
+			{CompanyName: "AWS", ProductUID: "aws-mel", LocationId: 20},
+			{CompanyName: "Azure", ProductUID: "azure-syd", LocationId: 10},
+		},
+		Months: rttTestMonths,
+	}
+}
+
+func TestPlanMCRPlacement(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	client := newPlacementTestClient(t, &calls)
+	req := placementTestRequest()
+	req.Matrix = NewRTTMatrix(client)
+
+	plan, err := client.MCRService.PlanMCRPlacement(context.Background(), req)
+	if err != nil {
+		t.Fatalf("PlanMCRPlacement: %v", err)
+	}
+	// Sources are the site and on-ramp locations 10, 20 and 30, for two months.
+	if calls.Load() != 6 {
+		t.Fatalf("RTT requests = %d, want 6", calls.Load())
+	}
+
+	wantIDs := []int{10, 20, 30}
+	wantScores := []float64{5.2, 7.8, 13.4}
+	if len(plan.Candidates) != len(wantIDs) {
+		t.Fatalf("candidates = %d, want %d", len(plan.Candidates), len(wantIDs))
+	}
+	for i, c := range plan.Candidates {
+		if c.Rank != i+1 || c.LocationID != wantIDs[i] || !floatsClose(c.Score, wantScores[i]) {
+			t.Errorf("candidate %d = rank %d location %d score %v", i, c.Rank, c.LocationID, c.Score)
+		}
+	}
+	if len(plan.Incomplete) != 1 || plan.Incomplete[0] != 50 {
+		t.Errorf("incomplete = %v, want [50]", plan.Incomplete)
+	}
+
+	// Sydney reaches AWS via the Melbourne on-ramp (13ms) rather than Brisbane (14ms).
+	aws := plan.Candidates[0].Targets[2]
+	if aws.Kind != PlacementTargetOnRamp || aws.Name != "AWS" || aws.ProductUID != "aws-mel" || aws.RTT != 13 {
+		t.Errorf("AWS target = %+v", aws)
+	}
+	if plan.Candidates[0].MaxRTT != 13 {
+		t.Errorf("max RTT = %v", plan.Candidates[0].MaxRTT)
+	}
+
+	// A second plan sharing the matrix makes no further RTT requests.
+	req.MaxResults = 1
+	req.CandidateLocationIDs = []int{20, 30}
+	plan, err = client.MCRService.PlanMCRPlacement(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 6 || len(plan.Candidates) != 1 || plan.Candidates[0].LocationID != 20 {
+		t.Fatalf("cached plan = %+v after %d requests", plan.Candidates, calls.Load())
+	}
+}
+
+func TestPlanMCRPlacementValidation(t *testing.T) {
+	t.Parallel()
+	client := newPlacementTestClient(t, new(atomic.Int32))
+	tests := []struct {
+		name    string
+		req     *MCRPlacementRequest
+		wantErr error
+	}{
+		{"nil", nil, ErrMCRPlacementNoTargets},
+		{"no targets", &MCRPlacementRequest{}, ErrMCRPlacementNoTargets},
+		{"site without location", &MCRPlacementRequest{Sites: []*MCRPlacementSite{{Name: "HQ"}}}, ErrMCRPlacementSiteInvalid},
+		{"negative weight", &MCRPlacementRequest{Sites: []*MCRPlacementSite{{LocationID: 10, Weight: -1}}}, ErrMCRPlacementSiteInvalid},
+		{"negative on-ramp weight", &MCRPlacementRequest{Sites: []*MCRPlacementSite{{LocationID: 10}}, OnRampWeight: -1}, ErrMCRPlacementWeightNegative},
+		{"no candidates", &MCRPlacementRequest{Sites: []*MCRPlacementSite{{LocationID: 10}}, Months: rttTestMonths, CandidateLocationIDs: []int{40}}, ErrMCRPlacementNoCandidates},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := client.MCRService.PlanMCRPlacement(context.Background(), tc.req); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMCRPlacementPlanWriteCSV(t *testing.T) {
+	t.Parallel()
+	client := newPlacementTestClient(t, new(atomic.Int32))
+	req := placementTestRequest()
+	req.MaxResults = 2
+	plan, err := client.MCRService.PlanMCRPlacement(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := plan.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantHeader := []string{"rank", "location_id", "location_name", "metro", "score_ms", "max_rtt_ms", "SITE:HQ", "SITE:Branch", "ONRAMP:AWS", "ONRAMP:Azure"}
+	if fmt.Sprint(records[0]) != fmt.Sprint(wantHeader) {
+		t.Fatalf("header = %v", records[0])
+	}
+	wantRow := []string{"1", "10", "Sydney", "Sydney", "5.200", "13.000", "0.000", "13.000", "13.000", "0.000"}
+	if len(records) != 3 || fmt.Sprint(records[1]) != fmt.Sprint(wantRow) {
+		t.Fatalf("rows = %v", records)
+	}
+
+	buf.Reset()
+	if err := plan.WriteJSON(&buf); err != nil || !bytes.Contains(buf.Bytes(), []byte(`"scoreMs": 5.2`)) {
+		t.Fatalf("JSON = %s, %v", buf.String(), err)
+	}
+}
+
+func floatsClose(a, b float64) bool {
+	d := a - b
+	return d < 1e-9 && d > -1e-9
+}
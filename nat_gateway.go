@@ -28,6 +28,10 @@ type NATGatewayService interface {
 	ListNATGatewaySessions(ctx context.Context) ([]*NATGatewaySession, error)
 	// GetNATGatewayTelemetry returns telemetry data for a NAT Gateway product.
 	GetNATGatewayTelemetry(ctx context.Context, req *GetNATGatewayTelemetryRequest) (*ServiceTelemetryResponse, error)
+	// AdviseNATGatewayCapacity recommends the cheapest speed/session
+	// configuration at a location that covers observed peaks plus growth,
+	// using the session matrix, location speeds and product pricing.
+	AdviseNATGatewayCapacity(ctx context.Context, req *NATGatewayCapacityRequest) (*NATGatewayCapacityAdvice, error)
 	// ValidateNATGatewayOrder validates a NAT Gateway design via
 	// POST /v3/networkdesign/validate. The gateway must be in DESIGN state.
 	// Returns an order preview including pricing.
@@ -38,6 +42,9 @@ type NATGatewayService interface {
 	// DEPLOYABLE -> CONFIGURED -> LIVE lifecycle. Returns the provisioning
 	// service record.
 	BuyNATGateway(ctx context.Context, productUID string) (*NATGatewayBuyResult, error)
+	// ProvisionNATGateway runs the full create, validate, buy, wait and
+	// attach workflow with resumable checkpoints and progress callbacks.
+	ProvisionNATGateway(ctx context.Context, req *ProvisionNATGatewayRequest) (*ProvisionNATGatewayResponse, error)
 
 	// ListNATGatewayPacketFilters returns all packet filter summaries for
 	// a NAT Gateway.
@@ -100,6 +107,22 @@ type NATGatewayService interface {
 	// ListNATGatewayBGPNeighborRoutes submits a BGP neighbor routes
 	// diagnostics request and polls until the routes are available.
 	ListNATGatewayBGPNeighborRoutes(ctx context.Context, req *NATGatewayBGPNeighborRoutesRequest) ([]*NATGatewayBGPRoute, error)
+	// SubmitNATGatewayIPRoutesOperation submits an IP routes diagnostics
+	// request and returns an Operation handle to wait on, poll or cancel.
+	SubmitNATGatewayIPRoutesOperation(ctx context.Context, productUID, ipAddress string) (*Operation[[]*NATGatewayIPRoute], error)
+	// SubmitNATGatewayBGPRoutesOperation submits a BGP routes diagnostics
+	// request and returns an Operation handle.
+	SubmitNATGatewayBGPRoutesOperation(ctx context.Context, productUID, ipAddress string) (*Operation[[]*NATGatewayBGPRoute], error)
+	// SubmitNATGatewayBGPNeighborRoutesOperation submits a BGP neighbor
+	// routes diagnostics request and returns an Operation handle.
+	SubmitNATGatewayBGPNeighborRoutesOperation(ctx context.Context, req *NATGatewayBGPNeighborRoutesRequest) (*Operation[[]*NATGatewayBGPRoute], error)
+	// ResumeNATGatewayIPRoutesOperation returns a handle to an IP routes
+	// diagnostics request from a persisted OperationRef.
+	ResumeNATGatewayIPRoutesOperation(ref OperationRef) (*Operation[[]*NATGatewayIPRoute], error)
+	// ResumeNATGatewayBGPRoutesOperation returns a handle to a BGP routes
+	// or BGP neighbor routes diagnostics request from a persisted
+	// OperationRef.
+	ResumeNATGatewayBGPRoutesOperation(ref OperationRef) (*Operation[[]*NATGatewayBGPRoute], error)
 }
 
 // NewNATGatewayService creates a new instance of the NAT Gateway Service.
@@ -112,6 +135,9 @@ func NewNATGatewayService(c *Client) *NATGatewayServiceOp {
 // NATGatewayServiceOp handles communication with NAT Gateway methods of the Megaport API.
 type NATGatewayServiceOp struct {
 	Client *Client
+	// pollOptions overrides the diagnostics poll defaults when set.
+	// Intended for tests that want to avoid real-time waits.
+	pollOptions *PollOptions
 }
 
 // GetNATGatewayTelemetryRequest represents a request to get telemetry data for a NAT Gateway.
@@ -0,0 +1,228 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// Capacity advisor validation errors.
+var (
+	ErrNATGatewayCapacityLocationRequired = errors.New("capacity advice requires a location ID or an existing gateway")
+	ErrNATGatewayCapacityPeakInvalid      = errors.New("observed peaks must not be negative")
+	ErrNATGatewayCapacityGrowthInvalid    = errors.New("growth percent must not be negative")
+	ErrNATGatewayCapacityNoOption         = errors.New("no NAT Gateway speed/session configuration at this location satisfies the projected demand")
+)
+
+// NATGatewayCapacityRequest describes the observed demand on a NAT Gateway
+// (or the expected demand on a new one) that AdviseNATGatewayCapacity sizes
+// against.
+type NATGatewayCapacityRequest struct {
+	// ProductUID optionally identifies an existing NAT Gateway. When set, the
+	// gateway is fetched and its location, term and configuration are used as
+	// the base for the returned UpdateNATGatewayRequest.
+	ProductUID string
+	// LocationID is the location to size for. Ignored when ProductUID is set.
+	LocationID int
+	// Term is the contract term in months used for pricing. Defaults to the
+	// existing gateway's term, or 1 when sizing a new gateway.
+	Term int
+	// Currency optionally overrides the pricing currency.
+	Currency string
+
+	// PeakThroughputMbps is the highest observed throughput in Mbps.
+	PeakThroughputMbps float64
+	// PeakSessions is the highest observed concurrent session count.
+	PeakSessions int
+	// GrowthPercent is the headroom applied on top of both peaks, e.g. 25
+	// sizes for 125% of the observed values.
+	GrowthPercent float64
+}
+
+// NATGatewayCapacityOption is a single priced speed/session configuration
+// that satisfies the projected demand.
+type NATGatewayCapacityOption struct {
+	SpeedMbps    int
+	SessionCount int
+	Pricing      *PriceBookDTO
+}
+
+// NATGatewayCapacityAdvice is the result of AdviseNATGatewayCapacity.
+type NATGatewayCapacityAdvice struct {
+	// RequiredSpeedMbps and RequiredSessions are the observed peaks with
+	// growth applied, rounded up.
+	RequiredSpeedMbps int
+	RequiredSessions  int
+	// Recommended is the cheapest option by monthly rate. Ties are broken by
+	// the lower speed, then the lower session count.
+	Recommended *NATGatewayCapacityOption
+	// Options lists every priced option, cheapest first.
+	Options []*NATGatewayCapacityOption
+	// Update is a ready-to-apply request resizing the gateway to the
+	// recommended option on the priced term. It is only set when ProductUID
+	// was supplied.
+	Update *UpdateNATGatewayRequest
+}
+
+// AdviseNATGatewayCapacity recommends the cheapest NAT Gateway speed/session
+// configuration at a location that covers the supplied peaks plus growth.
+// Candidates are the speeds advertised by the location's diversity zones
+// intersected with the ListNATGatewaySessions matrix; each candidate is priced
+// with GetProductPricing. The minimal qualifying session count is not assumed
+// to be the cheapest, so every qualifying pair is priced.
+func (svc *NATGatewayServiceOp) AdviseNATGatewayCapacity(ctx context.Context, req *NATGatewayCapacityRequest) (*NATGatewayCapacityAdvice, error) {
+	if req == nil {
+		return nil, ErrNATGatewayRequestNil
+	}
+	if req.PeakThroughputMbps < 0 || req.PeakSessions < 0 {
+		return nil, ErrNATGatewayCapacityPeakInvalid
+	}
+	if req.GrowthPercent < 0 {
+		return nil, ErrNATGatewayCapacityGrowthInvalid
+	}
+
+	var gw *NATGateway
+	locationID, term := req.LocationID, req.Term
+	if req.ProductUID != "" {
+		var err error
+		gw, err = svc.GetNATGateway(ctx, req.ProductUID)
+		if err != nil {
+			return nil, err
+		}
+		locationID = gw.LocationID
+		if term == 0 {
+			term = gw.Term
+		}
+	}
+	if locationID < 1 {
+		return nil, ErrNATGatewayCapacityLocationRequired
+	}
+	if term == 0 {
+		term = 1
+	}
+
+	location, err := svc.Client.LocationService.GetLocationByIDV3(ctx, locationID)
+	if err != nil {
+		return nil, err
+	}
+	matrix, err := svc.ListNATGatewaySessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	growth := 1 + req.GrowthPercent/100
+	advice := &NATGatewayCapacityAdvice{
+		RequiredSpeedMbps: int(math.Ceil(req.PeakThroughputMbps * growth)),
+		RequiredSessions:  int(math.Ceil(float64(req.PeakSessions) * growth)),
+	}
+
+	for _, c := range natGatewayCapacityCandidates(matrix, location.GetNATGatewaySpeeds(), advice.RequiredSpeedMbps, advice.RequiredSessions) {
+		pricing, err := svc.Client.ProductService.GetProductPricing(ctx, &NATGatewayPriceBookRequest{
+			Currency:     req.Currency,
+			LocationID:   locationID,
+			Speed:        c.SpeedMbps,
+			SessionCount: c.SessionCount,
+			Term:         term,
+			ProductUID:   req.ProductUID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("pricing %d Mbps / %d sessions: %w", c.SpeedMbps, c.SessionCount, err)
+		}
+		c.Pricing = pricing
+		advice.Options = append(advice.Options, c)
+	}
+	if len(advice.Options) == 0 {
+		return nil, ErrNATGatewayCapacityNoOption
+	}
+
+	sort.SliceStable(advice.Options, func(i, j int) bool {
+		a, b := advice.Options[i], advice.Options[j]
+		if a.Pricing.MonthlyRate != b.Pricing.MonthlyRate {
+			return a.Pricing.MonthlyRate < b.Pricing.MonthlyRate
+		}
+		if a.SpeedMbps != b.SpeedMbps {
+			return a.SpeedMbps < b.SpeedMbps
+		}
+		return a.SessionCount < b.SessionCount
+	})
+	advice.Recommended = advice.Options[0]
+
+	if gw != nil {
+		advice.Update = natGatewayUpdateFromGateway(gw)
+		advice.Update.Term = term
+		advice.Update.Speed = advice.Recommended.SpeedMbps
+		advice.Update.Config.SessionCount = advice.Recommended.SessionCount
+	}
+	return advice, nil
+}
+
+// natGatewayCapacityCandidates returns every speed/session pair from the
+// availability matrix whose speed is offered at the location and which meets
+// both minimums. Results are ordered by speed then session count.
+func natGatewayCapacityCandidates(matrix []*NATGatewaySession, locationSpeeds []int, minSpeed, minSessions int) []*NATGatewayCapacityOption {
+	offered := make(map[int]bool, len(locationSpeeds))
+	for _, s := range locationSpeeds {
+		offered[s] = true
+	}
+	var out []*NATGatewayCapacityOption
+	for _, entry := range matrix {
+		if entry == nil || !offered[entry.SpeedMbps] || entry.SpeedMbps < minSpeed {
+			continue
+		}
+		for _, sessions := range entry.SessionCount {
+			if sessions >= minSessions {
+				out = append(out, &NATGatewayCapacityOption{SpeedMbps: entry.SpeedMbps, SessionCount: sessions})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].SpeedMbps != out[j].SpeedMbps {
+			return out[i].SpeedMbps < out[j].SpeedMbps
+		}
+		return out[i].SessionCount < out[j].SessionCount
+	})
+	return out
+}
+
+// natGatewayUpdateFromGateway builds an UpdateNATGatewayRequest that, applied
+// unchanged, leaves the gateway as it is. Callers adjust the fields they want
+// to change.
+func natGatewayUpdateFromGateway(gw *NATGateway) *UpdateNATGatewayRequest {
+	return &UpdateNATGatewayRequest{
+		ProductUID:            gw.ProductUID,
+		AutoRenewTerm:         gw.AutoRenewTerm,
+		Config:                gw.Config,
+		LocationID:            gw.LocationID,
+		ProductName:           gw.ProductName,
+		PromoCode:             gw.PromoCode,
+		ResourceTags:          slices.Clone(gw.ResourceTags),
+		ServiceLevelReference: gw.ServiceLevelReference,
+		Speed:                 gw.Speed,
+		Term:                  gw.Term,
+	}
+}
+
+// Peak returns the highest sample value across the telemetry series whose
+// Subtype matches subtype, or across all series when subtype is empty. The
+// value is in the unit reported by the series; callers sizing a NAT Gateway
+// should convert to Mbps before passing it to AdviseNATGatewayCapacity.
+func (r *ServiceTelemetryResponse) Peak(subtype string) float64 {
+	var peak float64
+	if r == nil {
+		return peak
+	}
+	for _, series := range r.Data {
+		if series == nil || (subtype != "" && series.Subtype != subtype) {
+			continue
+		}
+		for _, s := range series.Samples {
+			if s.Value > peak {
+				peak = s.Value
+			}
+		}
+	}
+	return peak
+}
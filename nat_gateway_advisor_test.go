@@ -0,0 +1,179 @@
+package megaport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// NATGatewayAdvisorTestSuite tests the NAT Gateway capacity advisor.
+type NATGatewayAdvisorTestSuite struct {
+	ClientTestSuite
+}
+
+func TestNATGatewayAdvisorTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(NATGatewayAdvisorTestSuite))
+}
+
+func (suite *NATGatewayAdvisorTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+}
+
+func (suite *NATGatewayAdvisorTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+// registerAdvisorFixtures serves a location offering 1000/2000/5000 Mbps, a
+// session matrix, and pricing where 5000 Mbps @ 64000 sessions undercuts
+// 2000 Mbps @ 128000 sessions.
+func (suite *NATGatewayAdvisorTestSuite) registerAdvisorFixtures() {
+	suite.mux.HandleFunc("/v3/locations", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":[{
+			"id": 42, "name": "Test DC", "status": "Active",
+			"diversityZones": {
+				"red":  {"natGatewaySpeedMbps": [1000, 2000]},
+				"blue": {"natGatewaySpeedMbps": [2000, 5000]}
+			}
+		}]}`)
+	})
+	suite.mux.HandleFunc("/v3/products/nat_gateways/sessions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":[
+			{"speedMbps": 1000, "sessionCount": [16000, 32000]},
+			{"speedMbps": 2000, "sessionCount": [32000, 128000]},
+			{"speedMbps": 5000, "sessionCount": [64000]},
+			{"speedMbps": 10000, "sessionCount": [256000]}
+		]}`)
+	})
+	suite.mux.HandleFunc("/v4/pricebook/product", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodPost)
+		var body NATGatewayPriceBookRequest
+		suite.NoError(json.NewDecoder(r.Body).Decode(&body))
+		suite.Equal(42, body.LocationID)
+		suite.Equal(12, body.Term)
+		rate := map[string]float64{
+			"2000/32000":  300,
+			"2000/128000": 500,
+			"5000/64000":  450,
+		}[fmt.Sprintf("%d/%d", body.Speed, body.SessionCount)]
+		fmt.Fprintf(w, `{"message":"ok","terms":"","data":{"productType":"NAT_GATEWAY","currency":"USD","monthlyRate":%v}}`, rate)
+	})
+}
+
+func (suite *NATGatewayAdvisorTestSuite) TestAdviseNewGateway() {
+	suite.registerAdvisorFixtures()
+
+	advice, err := suite.client.NATGatewayService.AdviseNATGatewayCapacity(context.Background(), &NATGatewayCapacityRequest{
+		LocationID:         42,
+		Term:               12,
+		PeakThroughputMbps: 1500,
+		PeakSessions:       40000,
+		GrowthPercent:      20,
+	})
+	suite.Require().NoError(err)
+	suite.Equal(1800, advice.RequiredSpeedMbps)
+	suite.Equal(48000, advice.RequiredSessions)
+	// 10000 Mbps is in the matrix but not offered at the location, and
+	// 2000/32000 falls short on sessions.
+	suite.Require().Len(advice.Options, 2)
+	suite.Equal(5000, advice.Recommended.SpeedMbps)
+	suite.Equal(64000, advice.Recommended.SessionCount)
+	suite.Equal(450.0, advice.Recommended.Pricing.MonthlyRate)
+	suite.Nil(advice.Update)
+}
+
+func (suite *NATGatewayAdvisorTestSuite) TestAdviseExistingGatewayBuildsUpdate() {
+	suite.registerAdvisorFixtures()
+	suite.mux.HandleFunc("/v3/products/nat_gateways/gw-1", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":{
+			"productUid": "gw-1", "productName": "edge", "locationId": 42, "term": 1,
+			"speed": 1000, "autoRenewTerm": true,
+			"config": {"asn": 64512, "diversityZone": "blue", "sessionCount": 16000},
+			"resourceTags": [{"key": "team", "value": "net"}]
+		}}`)
+	})
+
+	advice, err := suite.client.NATGatewayService.AdviseNATGatewayCapacity(context.Background(), &NATGatewayCapacityRequest{
+		ProductUID:         "gw-1",
+		Term:               12,
+		PeakThroughputMbps: 1900,
+		PeakSessions:       20000,
+	})
+	suite.Require().NoError(err)
+	suite.Equal(2000, advice.Recommended.SpeedMbps)
+	suite.Equal(32000, advice.Recommended.SessionCount)
+	suite.Require().NotNil(advice.Update)
+	suite.Equal("gw-1", advice.Update.ProductUID)
+	suite.Equal("edge", advice.Update.ProductName)
+	suite.Equal(2000, advice.Update.Speed)
+	suite.Equal(32000, advice.Update.Config.SessionCount)
+	suite.Equal(64512, advice.Update.Config.ASN)
+	suite.Equal("blue", advice.Update.Config.DiversityZone)
+	suite.True(advice.Update.AutoRenewTerm)
+	// The update moves the gateway to the term the options were priced on.
+	suite.Equal(12, advice.Update.Term)
+	suite.NoError(validateUpdateNATGatewayRequest(advice.Update))
+}
+
+func (suite *NATGatewayAdvisorTestSuite) TestUpdateFromGatewayCopiesTags() {
+	gw := &NATGateway{ResourceTags: []ResourceTag{{Key: "team", Value: "net"}}}
+	update := natGatewayUpdateFromGateway(gw)
+	update.ResourceTags[0].Value = "ops"
+	suite.Equal("net", gw.ResourceTags[0].Value)
+}
+
+func (suite *NATGatewayAdvisorTestSuite) TestAdviseNoOption() {
+	suite.registerAdvisorFixtures()
+
+	_, err := suite.client.NATGatewayService.AdviseNATGatewayCapacity(context.Background(), &NATGatewayCapacityRequest{
+		LocationID:         42,
+		Term:               12,
+		PeakThroughputMbps: 9000,
+	})
+	suite.ErrorIs(err, ErrNATGatewayCapacityNoOption)
+}
+
+func (suite *NATGatewayAdvisorTestSuite) TestAdviseValidation() {
+	svc := suite.client.NATGatewayService
+	ctx := context.Background()
+
+	_, err := svc.AdviseNATGatewayCapacity(ctx, nil)
+	suite.ErrorIs(err, ErrNATGatewayRequestNil)
+	_, err = svc.AdviseNATGatewayCapacity(ctx, &NATGatewayCapacityRequest{})
+	suite.ErrorIs(err, ErrNATGatewayCapacityLocationRequired)
+	_, err = svc.AdviseNATGatewayCapacity(ctx, &NATGatewayCapacityRequest{LocationID: 1, PeakSessions: -1})
+	suite.ErrorIs(err, ErrNATGatewayCapacityPeakInvalid)
+	_, err = svc.AdviseNATGatewayCapacity(ctx, &NATGatewayCapacityRequest{LocationID: 1, GrowthPercent: -5})
+	suite.ErrorIs(err, ErrNATGatewayCapacityGrowthInvalid)
+}
+
+func TestServiceTelemetryResponsePeak(t *testing.T) {
+	t.Parallel()
+	resp := &ServiceTelemetryResponse{Data: []*TelemetryMetricData{
+		{Subtype: "In", Samples: []TelemetrySample{{Value: 10}, {Value: 250}}},
+		{Subtype: "Out", Samples: []TelemetrySample{{Value: 400}}},
+		nil,
+	}}
+	if got := resp.Peak("In"); got != 250 {
+		t.Fatalf("Peak(In) = %v, want 250", got)
+	}
+	if got := resp.Peak(""); got != 400 {
+		t.Fatalf("Peak(\"\") = %v, want 400", got)
+	}
+	var nilResp *ServiceTelemetryResponse
+	if got := nilResp.Peak(""); got != 0 {
+		t.Fatalf("nil Peak = %v, want 0", got)
+	}
+}
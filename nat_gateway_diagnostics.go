@@ -17,15 +17,30 @@ var (
 	ErrNATGatewayDiagnosticsTimeout          = errors.New("timed out waiting for diagnostics operation to complete")
 )
 
-// Default polling cadence used by the convenience diagnostics wrappers:
-// an initial delay before the first poll, the interval between polls, and
-// the overall timeout for the operation.
+// Default polling cadence used by diagnostics operations: an initial delay
+// before the first poll, the interval between the first polls, the backoff
+// applied while the operation is pending, and the overall timeout. Operation
+// handles apply the timeout only when the caller's context has no deadline;
+// the List* wrappers poll without backoff and always apply it.
 const (
 	diagnosticsPollInitialDelay = 2 * time.Second
 	diagnosticsPollInterval     = 3 * time.Second
+	diagnosticsPollMultiplier   = 1.5
+	diagnosticsPollMaxInterval  = 10 * time.Second
 	diagnosticsPollTimeout      = 60 * time.Second
 )
 
+// diagnosticsPollOptions returns the default PollOptions for diagnostics operations.
+func (svc *NATGatewayServiceOp) diagnosticsPollOptions() PollOptions {
+	return PollOptions{
+		InitialDelay: diagnosticsPollInitialDelay,
+		Interval:     diagnosticsPollInterval,
+		Multiplier:   diagnosticsPollMultiplier,
+		MaxInterval:  diagnosticsPollMaxInterval,
+		Timeout:      diagnosticsPollTimeout,
+	}.merge(svc.pollOptions)
+}
+
 // ListNATGatewayIPRoutesAsync submits an IP routes diagnostics request.
 func (svc *NATGatewayServiceOp) ListNATGatewayIPRoutesAsync(ctx context.Context, productUID, ipAddress string) (string, error) {
 	if productUID == "" {
@@ -105,100 +120,120 @@ func (svc *NATGatewayServiceOp) GetNATGatewayDiagnosticsRoutes(ctx context.Conte
 	return envelope.Data, nil
 }
 
-// pollDiagnosticsRoutes polls GetNATGatewayDiagnosticsRoutes until the
-// operation returns a non-empty result, the SDK-managed
-// diagnosticsPollTimeout elapses, or the caller's context is cancelled.
-// Empty responses are treated as "still processing".
-func (svc *NATGatewayServiceOp) pollDiagnosticsRoutes(ctx context.Context, productUID, operationID string) ([]*NATGatewayRoute, error) {
-	pollCtx, cancel := context.WithTimeout(ctx, diagnosticsPollTimeout)
-	defer cancel()
-	// pollDoneErr returns ctx.Err() when the caller's context is the one that
-	// fired (cancellation or caller-imposed deadline) and
-	// ErrNATGatewayDiagnosticsTimeout when the SDK-managed
-	// diagnosticsPollTimeout is what elapsed. This lets callers tell
-	// "my deadline hit" from "the diagnostics op never completed".
-	pollDoneErr := func() error {
-		if err := ctx.Err(); err != nil {
-			return err
-		}
-		return ErrNATGatewayDiagnosticsTimeout
-	}
-	select {
-	case <-pollCtx.Done():
-		return nil, pollDoneErr()
-	case <-time.After(diagnosticsPollInitialDelay):
-	}
-	ticker := time.NewTicker(diagnosticsPollInterval)
-	defer ticker.Stop()
-	for {
-		routes, err := svc.GetNATGatewayDiagnosticsRoutes(pollCtx, productUID, operationID)
-		if err != nil {
-			return nil, err
-		}
-		if len(routes) > 0 {
-			return routes, nil
-		}
-		select {
-		case <-pollCtx.Done():
-			return nil, pollDoneErr()
-		case <-ticker.C:
-		}
-	}
-}
-
 // ListNATGatewayIPRoutes submits an IP routes request and polls until results are available.
 func (svc *NATGatewayServiceOp) ListNATGatewayIPRoutes(ctx context.Context, productUID, ipAddress string) ([]*NATGatewayIPRoute, error) {
-	opID, err := svc.ListNATGatewayIPRoutesAsync(ctx, productUID, ipAddress)
+	op, err := svc.SubmitNATGatewayIPRoutesOperation(ctx, productUID, ipAddress)
 	if err != nil {
 		return nil, err
 	}
-	routes, err := svc.pollDiagnosticsRoutes(ctx, productUID, opID)
+	return op.waitWithin(ctx, svc.diagnosticsPollOptions().Timeout)
+}
+
+// ListNATGatewayBGPRoutes submits a BGP routes request and polls until results are available.
+func (svc *NATGatewayServiceOp) ListNATGatewayBGPRoutes(ctx context.Context, productUID, ipAddress string) ([]*NATGatewayBGPRoute, error) {
+	op, err := svc.SubmitNATGatewayBGPRoutesOperation(ctx, productUID, ipAddress)
 	if err != nil {
 		return nil, err
 	}
-	out := make([]*NATGatewayIPRoute, 0, len(routes))
-	for _, r := range routes {
-		if r.IP != nil {
-			out = append(out, r.IP)
-		}
+	return op.waitWithin(ctx, svc.diagnosticsPollOptions().Timeout)
+}
+
+// ListNATGatewayBGPNeighborRoutes submits a BGP neighbor routes request and polls for results.
+func (svc *NATGatewayServiceOp) ListNATGatewayBGPNeighborRoutes(ctx context.Context, req *NATGatewayBGPNeighborRoutesRequest) ([]*NATGatewayBGPRoute, error) {
+	op, err := svc.SubmitNATGatewayBGPNeighborRoutesOperation(ctx, req)
+	if err != nil {
+		return nil, err
 	}
-	return out, nil
+	return op.waitWithin(ctx, svc.diagnosticsPollOptions().Timeout)
 }
 
-// ListNATGatewayBGPRoutes submits a BGP routes request and polls until results are available.
-func (svc *NATGatewayServiceOp) ListNATGatewayBGPRoutes(ctx context.Context, productUID, ipAddress string) ([]*NATGatewayBGPRoute, error) {
-	opID, err := svc.ListNATGatewayBGPRoutesAsync(ctx, productUID, ipAddress)
+// SubmitNATGatewayIPRoutesOperation submits an IP routes request and returns a handle to it.
+func (svc *NATGatewayServiceOp) SubmitNATGatewayIPRoutesOperation(ctx context.Context, productUID, ipAddress string) (*Operation[[]*NATGatewayIPRoute], error) {
+	opID, err := svc.ListNATGatewayIPRoutesAsync(ctx, productUID, ipAddress)
 	if err != nil {
 		return nil, err
 	}
-	routes, err := svc.pollDiagnosticsRoutes(ctx, productUID, opID)
+	if opID == "" {
+		return nil, ErrNATGatewayDiagnosticsOperationEmpty
+	}
+	return svc.ipRoutesOperation(productUID, opID), nil
+}
+
+// SubmitNATGatewayBGPRoutesOperation submits a BGP routes request and returns a handle to it.
+func (svc *NATGatewayServiceOp) SubmitNATGatewayBGPRoutesOperation(ctx context.Context, productUID, ipAddress string) (*Operation[[]*NATGatewayBGPRoute], error) {
+	opID, err := svc.ListNATGatewayBGPRoutesAsync(ctx, productUID, ipAddress)
 	if err != nil {
 		return nil, err
 	}
-	out := make([]*NATGatewayBGPRoute, 0, len(routes))
-	for _, r := range routes {
-		if r.BGP != nil {
-			out = append(out, r.BGP)
-		}
+	if opID == "" {
+		return nil, ErrNATGatewayDiagnosticsOperationEmpty
 	}
-	return out, nil
+	return svc.bgpRoutesOperation(OperationKindNATGatewayBGPRoutes, productUID, opID), nil
 }
 
-// ListNATGatewayBGPNeighborRoutes submits a BGP neighbor routes request and polls for results.
-func (svc *NATGatewayServiceOp) ListNATGatewayBGPNeighborRoutes(ctx context.Context, req *NATGatewayBGPNeighborRoutesRequest) ([]*NATGatewayBGPRoute, error) {
+// SubmitNATGatewayBGPNeighborRoutesOperation submits a BGP neighbor routes request and returns a handle to it.
+func (svc *NATGatewayServiceOp) SubmitNATGatewayBGPNeighborRoutesOperation(ctx context.Context, req *NATGatewayBGPNeighborRoutesRequest) (*Operation[[]*NATGatewayBGPRoute], error) {
 	opID, err := svc.ListNATGatewayBGPNeighborRoutesAsync(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	routes, err := svc.pollDiagnosticsRoutes(ctx, req.ProductUID, opID)
-	if err != nil {
+	if opID == "" {
+		return nil, ErrNATGatewayDiagnosticsOperationEmpty
+	}
+	return svc.bgpRoutesOperation(OperationKindNATGatewayBGPNeighborRoutes, req.ProductUID, opID), nil
+}
+
+// ResumeNATGatewayIPRoutesOperation returns a handle to a previously submitted IP routes request.
+func (svc *NATGatewayServiceOp) ResumeNATGatewayIPRoutesOperation(ref OperationRef) (*Operation[[]*NATGatewayIPRoute], error) {
+	if err := ref.validate(OperationKindNATGatewayIPRoutes); err != nil {
 		return nil, err
 	}
-	out := make([]*NATGatewayBGPRoute, 0, len(routes))
-	for _, r := range routes {
-		if r.BGP != nil {
-			out = append(out, r.BGP)
-		}
+	return svc.ipRoutesOperation(ref.ProductUID, ref.OperationID), nil
+}
+
+// ResumeNATGatewayBGPRoutesOperation returns a handle to a previously submitted
+// BGP routes or BGP neighbor routes request.
+func (svc *NATGatewayServiceOp) ResumeNATGatewayBGPRoutesOperation(ref OperationRef) (*Operation[[]*NATGatewayBGPRoute], error) {
+	if err := ref.validate(OperationKindNATGatewayBGPRoutes, OperationKindNATGatewayBGPNeighborRoutes); err != nil {
+		return nil, err
 	}
-	return out, nil
+	return svc.bgpRoutesOperation(ref.Kind, ref.ProductUID, ref.OperationID), nil
+}
+
+// ipRoutesOperation builds the Operation polling GetNATGatewayDiagnosticsRoutes
+// for IP routes. An empty response is treated as "still processing".
+func (svc *NATGatewayServiceOp) ipRoutesOperation(productUID, operationID string) *Operation[[]*NATGatewayIPRoute] {
+	ref := OperationRef{Kind: OperationKindNATGatewayIPRoutes, ProductUID: productUID, OperationID: operationID}
+	return newOperation(ref, svc.diagnosticsPollOptions(), ErrNATGatewayDiagnosticsTimeout, func(ctx context.Context) ([]*NATGatewayIPRoute, bool, error) {
+		routes, err := svc.GetNATGatewayDiagnosticsRoutes(ctx, productUID, operationID)
+		if err != nil || len(routes) == 0 {
+			return nil, false, err
+		}
+		out := make([]*NATGatewayIPRoute, 0, len(routes))
+		for _, r := range routes {
+			if r.IP != nil {
+				out = append(out, r.IP)
+			}
+		}
+		return out, true, nil
+	})
+}
+
+// bgpRoutesOperation builds the Operation polling GetNATGatewayDiagnosticsRoutes
+// for BGP or BGP neighbor routes. An empty response is treated as "still processing".
+func (svc *NATGatewayServiceOp) bgpRoutesOperation(kind OperationKind, productUID, operationID string) *Operation[[]*NATGatewayBGPRoute] {
+	ref := OperationRef{Kind: kind, ProductUID: productUID, OperationID: operationID}
+	return newOperation(ref, svc.diagnosticsPollOptions(), ErrNATGatewayDiagnosticsTimeout, func(ctx context.Context) ([]*NATGatewayBGPRoute, bool, error) {
+		routes, err := svc.GetNATGatewayDiagnosticsRoutes(ctx, productUID, operationID)
+		if err != nil || len(routes) == 0 {
+			return nil, false, err
+		}
+		out := make([]*NATGatewayBGPRoute, 0, len(routes))
+		for _, r := range routes {
+			if r.BGP != nil {
+				out = append(out, r.BGP)
+			}
+		}
+		return out, true, nil
+	})
 }
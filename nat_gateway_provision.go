@@ -0,0 +1,504 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Provisioning workflow errors.
+var (
+	ErrNATGatewayProvisionGatewayRequired       = errors.New("provision request requires a gateway design or a checkpoint with a product UID")
+	ErrNATGatewayProvisionTerminalState         = errors.New("nat gateway is in a terminal state and cannot be provisioned")
+	ErrNATGatewayProvisionTimeout               = errors.New("time expired waiting for nat gateway to provision")
+	ErrNATGatewayProvisionPacketFilterNameEmpty = errors.New("packet filter name is required")
+	ErrNATGatewayProvisionPacketFilterUnknown   = errors.New("vxc references an unknown packet filter name")
+	ErrNATGatewayProvisionVXCKeyDuplicate       = errors.New("vxc keys must be unique")
+)
+
+// natGatewayCleanupTimeout bounds deleting a DESIGN gateway after a failed
+// create, validate or buy phase. The cleanup runs even when the caller's
+// context has ended, which is often why the phase failed.
+const natGatewayCleanupTimeout = time.Minute
+
+// NATGatewayProvisionPhase identifies a step of ProvisionNATGateway.
+type NATGatewayProvisionPhase string
+
+const (
+	NATGatewayProvisionPhaseCreate        NATGatewayProvisionPhase = "CREATE"
+	NATGatewayProvisionPhaseValidate      NATGatewayProvisionPhase = "VALIDATE"
+	NATGatewayProvisionPhaseBuy           NATGatewayProvisionPhase = "BUY"
+	NATGatewayProvisionPhaseWait          NATGatewayProvisionPhase = "WAIT_FOR_PROVISION"
+	NATGatewayProvisionPhasePacketFilters NATGatewayProvisionPhase = "PACKET_FILTERS"
+	NATGatewayProvisionPhaseAttachVXCs    NATGatewayProvisionPhase = "ATTACH_VXCS"
+	NATGatewayProvisionPhaseCleanup       NATGatewayProvisionPhase = "CLEANUP"
+)
+
+// NATGatewayProvisionStatus describes what happened to a phase.
+type NATGatewayProvisionStatus string
+
+const (
+	NATGatewayProvisionStatusStarted   NATGatewayProvisionStatus = "STARTED"
+	NATGatewayProvisionStatusCompleted NATGatewayProvisionStatus = "COMPLETED"
+	NATGatewayProvisionStatusSkipped   NATGatewayProvisionStatus = "SKIPPED"
+	NATGatewayProvisionStatusFailed    NATGatewayProvisionStatus = "FAILED"
+)
+
+// NATGatewayProvisionCheckpoint records how far ProvisionNATGateway got. It
+// is JSON-serialisable; persist the copy delivered with each event and pass
+// it back in ProvisionNATGatewayRequest.Checkpoint to resume after a crash.
+type NATGatewayProvisionCheckpoint struct {
+	ProductUID string `json:"productUid,omitempty"`
+	// Purchased is set once BuyNATGateway has succeeded.
+	Purchased bool `json:"purchased,omitempty"`
+	// PacketFilterIDs maps NATGatewayProvisionPacketFilter.Name to the
+	// server-assigned packet filter ID.
+	PacketFilterIDs map[string]int `json:"packetFilterIds,omitempty"`
+	// VXCUIDs maps NATGatewayProvisionVXC.Key to the ordered VXC's UID.
+	VXCUIDs map[string]string `json:"vxcUids,omitempty"`
+}
+
+func (cp *NATGatewayProvisionCheckpoint) clone() *NATGatewayProvisionCheckpoint {
+	return &NATGatewayProvisionCheckpoint{
+		ProductUID:      cp.ProductUID,
+		Purchased:       cp.Purchased,
+		PacketFilterIDs: maps.Clone(cp.PacketFilterIDs),
+		VXCUIDs:         maps.Clone(cp.VXCUIDs),
+	}
+}
+
+// NATGatewayProvisionEvent is delivered to ProvisionNATGatewayRequest.OnProgress
+// as each phase starts, completes, is skipped on resume, or fails.
+type NATGatewayProvisionEvent struct {
+	Phase  NATGatewayProvisionPhase
+	Status NATGatewayProvisionStatus
+	// Detail identifies the packet filter name or VXC key for per-item
+	// events, and is empty otherwise.
+	Detail string
+	// Checkpoint is a snapshot taken after the event; safe to retain.
+	Checkpoint *NATGatewayProvisionCheckpoint
+	Err        error
+}
+
+// NATGatewayProvisionPacketFilter is a packet filter to create on the
+// gateway. Name is the key VXCs use to reference it and is recorded in the
+// checkpoint.
+type NATGatewayProvisionPacketFilter struct {
+	Name   string
+	Filter *NATGatewayPacketFilterRequest
+}
+
+// NATGatewayProvisionVXC is a VXC to order with the gateway as its A-End.
+// The workflow sets PortUID and AEndConfiguration.ProductUID to the gateway;
+// when the A-End partner config is a VXCOrderVrouterPartnerConfig, the named
+// packet filters are bound to each of its interfaces that does not already
+// set one.
+type NATGatewayProvisionVXC struct {
+	// Key identifies the VXC in the checkpoint. Defaults to Request.VXCName.
+	Key             string
+	Request         *BuyVXCRequest
+	PacketFilterIn  string
+	PacketFilterOut string
+}
+
+// ProvisionNATGatewayRequest drives ProvisionNATGateway.
+type ProvisionNATGatewayRequest struct {
+	// Gateway is the design to create. It may be nil when resuming from a
+	// checkpoint that already has a ProductUID.
+	Gateway       *CreateNATGatewayRequest
+	PacketFilters []NATGatewayProvisionPacketFilter
+	VXCs          []NATGatewayProvisionVXC
+
+	// Checkpoint resumes a previous run. Phases it records as done are skipped.
+	Checkpoint *NATGatewayProvisionCheckpoint
+	// OnProgress, if set, is called synchronously for every phase event.
+	OnProgress func(NATGatewayProvisionEvent)
+
+	// KeepDesignOnFailure leaves a DESIGN-state gateway in place when the
+	// create/validate/buy phases fail. By default it is deleted.
+	KeepDesignOnFailure bool
+
+	WaitForTime  time.Duration // How long to wait for the gateway to provision (default is 15 minutes)
+	PollInterval time.Duration // How often to poll while waiting (default is 30 seconds)
+}
+
+// ProvisionNATGatewayResponse is the outcome of a successful ProvisionNATGateway.
+type ProvisionNATGatewayResponse struct {
+	Gateway    *NATGateway
+	Checkpoint *NATGatewayProvisionCheckpoint
+}
+
+// ProvisionNATGateway stands up a NAT Gateway end to end: create the design,
+// validate and buy it, wait for CONFIGURED/LIVE, create packet filters, then
+// order the VXCs that attach to it. Each phase is reported through
+// OnProgress with a checkpoint so a crashed run can be resumed from the
+// DESIGN or ordered state.
+//
+// When resuming an ordered gateway, packet filters and VXCs missing from the
+// checkpoint are first looked up on the gateway, by filter description and
+// VXC name, so those created after the last persisted checkpoint are not
+// ordered twice.
+//
+// If a create, validate or buy phase fails while the gateway is still in
+// DESIGN, the design is deleted (unless KeepDesignOnFailure is set) and the
+// checkpoint's ProductUID is cleared. The deletion runs even when ctx has
+// ended, bounded by a one minute timeout. Purchased gateways are never cancelled
+// by the workflow; the error is returned along with the checkpoint state in
+// the final FAILED event so the caller can decide.
+func (svc *NATGatewayServiceOp) ProvisionNATGateway(ctx context.Context, req *ProvisionNATGatewayRequest) (*ProvisionNATGatewayResponse, error) {
+	if err := validateProvisionNATGatewayRequest(req); err != nil {
+		return nil, err
+	}
+
+	cp := &NATGatewayProvisionCheckpoint{}
+	if req.Checkpoint != nil {
+		cp = req.Checkpoint.clone()
+	}
+	if cp.PacketFilterIDs == nil {
+		cp.PacketFilterIDs = map[string]int{}
+	}
+	if cp.VXCUIDs == nil {
+		cp.VXCUIDs = map[string]string{}
+	}
+
+	emit := func(phase NATGatewayProvisionPhase, status NATGatewayProvisionStatus, detail string, err error) {
+		svc.Client.Logger.DebugContext(ctx, "nat gateway provisioning",
+			slog.String("phase", string(phase)),
+			slog.String("status", string(status)),
+			slog.String("product_uid", cp.ProductUID),
+			slog.String("detail", detail))
+		if req.OnProgress != nil {
+			req.OnProgress(NATGatewayProvisionEvent{Phase: phase, Status: status, Detail: detail, Checkpoint: cp.clone(), Err: err})
+		}
+	}
+	fail := func(phase NATGatewayProvisionPhase, detail string, err error) (*ProvisionNATGatewayResponse, error) {
+		emit(phase, NATGatewayProvisionStatusFailed, detail, err)
+		return nil, fmt.Errorf("nat gateway provisioning failed in phase %s: %w", phase, err)
+	}
+
+	// Reconcile the checkpoint with the gateway's actual lifecycle state.
+	resumedOrder := false
+	if cp.ProductUID != "" {
+		gw, err := svc.GetNATGateway(ctx, cp.ProductUID)
+		if err != nil {
+			return fail(NATGatewayProvisionPhaseCreate, "", err)
+		}
+		switch gw.ProvisioningStatus {
+		case STATUS_DESIGN:
+			cp.Purchased = false
+		case STATUS_CANCELLED, STATUS_DECOMMISSIONED:
+			return fail(NATGatewayProvisionPhaseCreate, "", ErrNATGatewayProvisionTerminalState)
+		default:
+			cp.Purchased = true
+			resumedOrder = true
+		}
+	}
+
+	if cp.ProductUID == "" {
+		emit(NATGatewayProvisionPhaseCreate, NATGatewayProvisionStatusStarted, "", nil)
+		gw, err := svc.CreateNATGateway(ctx, req.Gateway)
+		if err != nil {
+			return fail(NATGatewayProvisionPhaseCreate, "", err)
+		}
+		cp.ProductUID = gw.ProductUID
+		emit(NATGatewayProvisionPhaseCreate, NATGatewayProvisionStatusCompleted, "", nil)
+	} else {
+		emit(NATGatewayProvisionPhaseCreate, NATGatewayProvisionStatusSkipped, "", nil)
+	}
+
+	if !cp.Purchased {
+		for _, step := range []struct {
+			phase NATGatewayProvisionPhase
+			run   func() error
+		}{
+			{NATGatewayProvisionPhaseValidate, func() error {
+				_, err := svc.ValidateNATGatewayOrder(ctx, cp.ProductUID)
+				return err
+			}},
+			{NATGatewayProvisionPhaseBuy, func() error {
+				if _, err := svc.BuyNATGateway(ctx, cp.ProductUID); err != nil {
+					return err
+				}
+				cp.Purchased = true
+				return nil
+			}},
+		} {
+			emit(step.phase, NATGatewayProvisionStatusStarted, "", nil)
+			if err := step.run(); err != nil {
+				emit(step.phase, NATGatewayProvisionStatusFailed, "", err)
+				if !req.KeepDesignOnFailure {
+					cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), natGatewayCleanupTimeout)
+					svc.cleanupNATGatewayDesign(cleanupCtx, cp, emit)
+					cancel()
+				}
+				return nil, fmt.Errorf("nat gateway provisioning failed in phase %s: %w", step.phase, err)
+			}
+			emit(step.phase, NATGatewayProvisionStatusCompleted, "", nil)
+		}
+	} else {
+		emit(NATGatewayProvisionPhaseValidate, NATGatewayProvisionStatusSkipped, "", nil)
+		emit(NATGatewayProvisionPhaseBuy, NATGatewayProvisionStatusSkipped, "", nil)
+	}
+
+	emit(NATGatewayProvisionPhaseWait, NATGatewayProvisionStatusStarted, "", nil)
+	gw, err := svc.waitForNATGatewayReady(ctx, cp.ProductUID, req.WaitForTime, req.PollInterval)
+	if err != nil {
+		return fail(NATGatewayProvisionPhaseWait, "", err)
+	}
+	emit(NATGatewayProvisionPhaseWait, NATGatewayProvisionStatusCompleted, "", nil)
+
+	if resumedOrder {
+		if err := svc.adoptNATGatewayPacketFilters(ctx, req, cp); err != nil {
+			return fail(NATGatewayProvisionPhasePacketFilters, "", err)
+		}
+	}
+	for _, pf := range req.PacketFilters {
+		if _, done := cp.PacketFilterIDs[pf.Name]; done {
+			emit(NATGatewayProvisionPhasePacketFilters, NATGatewayProvisionStatusSkipped, pf.Name, nil)
+			continue
+		}
+		emit(NATGatewayProvisionPhasePacketFilters, NATGatewayProvisionStatusStarted, pf.Name, nil)
+		created, err := svc.CreateNATGatewayPacketFilter(ctx, cp.ProductUID, pf.Filter)
+		if err != nil {
+			return fail(NATGatewayProvisionPhasePacketFilters, pf.Name, err)
+		}
+		cp.PacketFilterIDs[pf.Name] = created.ID
+		emit(NATGatewayProvisionPhasePacketFilters, NATGatewayProvisionStatusCompleted, pf.Name, nil)
+	}
+
+	if resumedOrder {
+		if err := svc.adoptNATGatewayVXCs(ctx, req, cp); err != nil {
+			return fail(NATGatewayProvisionPhaseAttachVXCs, "", err)
+		}
+	}
+	for _, v := range req.VXCs {
+		key := v.key()
+		if _, done := cp.VXCUIDs[key]; done {
+			emit(NATGatewayProvisionPhaseAttachVXCs, NATGatewayProvisionStatusSkipped, key, nil)
+			continue
+		}
+		emit(NATGatewayProvisionPhaseAttachVXCs, NATGatewayProvisionStatusStarted, key, nil)
+		buyReq := natGatewayVXCBuyRequest(cp.ProductUID, v, cp.PacketFilterIDs)
+		res, err := svc.Client.VXCService.BuyVXC(ctx, buyReq)
+		if err != nil {
+			return fail(NATGatewayProvisionPhaseAttachVXCs, key, err)
+		}
+		cp.VXCUIDs[key] = res.TechnicalServiceUID
+		emit(NATGatewayProvisionPhaseAttachVXCs, NATGatewayProvisionStatusCompleted, key, nil)
+	}
+
+	return &ProvisionNATGatewayResponse{Gateway: gw, Checkpoint: cp.clone()}, nil
+}
+
+// adoptNATGatewayPacketFilters records in the checkpoint the packet filters
+// that already exist on the gateway but are missing from the checkpoint,
+// matching them by description. Each existing filter is adopted once.
+func (svc *NATGatewayServiceOp) adoptNATGatewayPacketFilters(ctx context.Context, req *ProvisionNATGatewayRequest, cp *NATGatewayProvisionCheckpoint) error {
+	missing := slices.ContainsFunc(req.PacketFilters, func(pf NATGatewayProvisionPacketFilter) bool {
+		_, done := cp.PacketFilterIDs[pf.Name]
+		return !done
+	})
+	if !missing {
+		return nil
+	}
+	existing, err := svc.ListNATGatewayPacketFilters(ctx, cp.ProductUID)
+	if err != nil {
+		return err
+	}
+	adopted := map[int]bool{}
+	for _, id := range cp.PacketFilterIDs {
+		adopted[id] = true
+	}
+	for _, pf := range req.PacketFilters {
+		if _, done := cp.PacketFilterIDs[pf.Name]; done {
+			continue
+		}
+		for _, e := range existing {
+			if e != nil && !adopted[e.ID] && e.Description == pf.Filter.Description {
+				cp.PacketFilterIDs[pf.Name] = e.ID
+				adopted[e.ID] = true
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// adoptNATGatewayVXCs records in the checkpoint the VXCs from the gateway
+// that already exist but are missing from the checkpoint, matching them by
+// name. Each existing VXC is adopted once.
+func (svc *NATGatewayServiceOp) adoptNATGatewayVXCs(ctx context.Context, req *ProvisionNATGatewayRequest, cp *NATGatewayProvisionCheckpoint) error {
+	missing := slices.ContainsFunc(req.VXCs, func(v NATGatewayProvisionVXC) bool {
+		_, done := cp.VXCUIDs[v.key()]
+		return !done
+	})
+	if !missing {
+		return nil
+	}
+	existing, err := svc.Client.VXCService.ListVXCs(ctx, &ListVXCsRequest{AEndProductUID: cp.ProductUID})
+	if err != nil {
+		return err
+	}
+	adopted := map[string]bool{}
+	for _, uid := range cp.VXCUIDs {
+		adopted[uid] = true
+	}
+	for _, v := range req.VXCs {
+		if _, done := cp.VXCUIDs[v.key()]; done {
+			continue
+		}
+		for _, e := range existing {
+			if !adopted[e.UID] && e.Name == v.Request.VXCName {
+				cp.VXCUIDs[v.key()] = e.UID
+				adopted[e.UID] = true
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// cleanupNATGatewayDesign deletes the checkpoint's gateway if, and only if,
+// it is still in DESIGN state. Failures are reported but not returned: the
+// caller is already surfacing the error that triggered the cleanup.
+func (svc *NATGatewayServiceOp) cleanupNATGatewayDesign(ctx context.Context, cp *NATGatewayProvisionCheckpoint, emit func(NATGatewayProvisionPhase, NATGatewayProvisionStatus, string, error)) {
+	emit(NATGatewayProvisionPhaseCleanup, NATGatewayProvisionStatusStarted, "", nil)
+	gw, err := svc.GetNATGateway(ctx, cp.ProductUID)
+	if err != nil {
+		emit(NATGatewayProvisionPhaseCleanup, NATGatewayProvisionStatusFailed, "", err)
+		return
+	}
+	if gw.ProvisioningStatus != STATUS_DESIGN {
+		emit(NATGatewayProvisionPhaseCleanup, NATGatewayProvisionStatusSkipped, "", nil)
+		return
+	}
+	if err := svc.DeleteNATGateway(ctx, cp.ProductUID); err != nil {
+		emit(NATGatewayProvisionPhaseCleanup, NATGatewayProvisionStatusFailed, "", err)
+		return
+	}
+	cp.ProductUID = ""
+	emit(NATGatewayProvisionPhaseCleanup, NATGatewayProvisionStatusCompleted, "", nil)
+}
+
+// waitForNATGatewayReady polls until the gateway reaches a ready
+// provisioning state. Zero durations default to 15 minutes / 30 seconds.
+func (svc *NATGatewayServiceOp) waitForNATGatewayReady(ctx context.Context, productUID string, timeout, interval time.Duration) (*NATGateway, error) {
+	if timeout == 0 {
+		timeout = 15 * time.Minute
+	}
+	if interval == 0 {
+		interval = 30 * time.Second
+	}
+	ref := OperationRef{Kind: OperationKindNATGatewayProvisioning, ProductUID: productUID, OperationID: productUID}
+	op := newOperation(ref, PollOptions{Interval: interval, Multiplier: 1}, ErrNATGatewayProvisionTimeout, func(ctx context.Context) (*NATGateway, bool, error) {
+		gw, err := svc.GetNATGateway(ctx, productUID)
+		if err != nil {
+			return nil, false, err
+		}
+		if gw.ProvisioningStatus == STATUS_DECOMMISSIONED || gw.ProvisioningStatus == STATUS_CANCELLED {
+			return nil, false, fmt.Errorf("%w: %w", ErrOperationFailed, ErrNATGatewayProvisionTerminalState)
+		}
+		return gw, slices.Contains(SERVICE_STATE_READY, gw.ProvisioningStatus), nil
+	})
+	return op.waitWithin(ctx, timeout)
+}
+
+func (v NATGatewayProvisionVXC) key() string {
+	if v.Key != "" {
+		return v.Key
+	}
+	if v.Request != nil {
+		return v.Request.VXCName
+	}
+	return ""
+}
+
+// natGatewayVXCBuyRequest returns a copy of v.Request with the gateway set as
+// the A-End and the referenced packet filters bound. The caller's request
+// and its interface slice are not modified.
+func natGatewayVXCBuyRequest(productUID string, v NATGatewayProvisionVXC, filterIDs map[string]int) *BuyVXCRequest {
+	buyReq := *v.Request
+	buyReq.PortUID = productUID
+	buyReq.AEndConfiguration.ProductUID = productUID
+
+	var in, out *int64
+	if id, ok := filterIDs[v.PacketFilterIn]; ok && v.PacketFilterIn != "" {
+		in = PtrTo(int64(id))
+	}
+	if id, ok := filterIDs[v.PacketFilterOut]; ok && v.PacketFilterOut != "" {
+		out = PtrTo(int64(id))
+	}
+	if in == nil && out == nil {
+		return &buyReq
+	}
+
+	bind := func(ifaces []PartnerConfigInterface) []PartnerConfigInterface {
+		ifaces = slices.Clone(ifaces)
+		for i := range ifaces {
+			if ifaces[i].PacketFilterIn == nil {
+				ifaces[i].PacketFilterIn = in
+			}
+			if ifaces[i].PacketFilterOut == nil {
+				ifaces[i].PacketFilterOut = out
+			}
+		}
+		return ifaces
+	}
+	switch pc := buyReq.AEndConfiguration.PartnerConfig.(type) {
+	case VXCOrderVrouterPartnerConfig:
+		pc.Interfaces = bind(pc.Interfaces)
+		buyReq.AEndConfiguration.PartnerConfig = pc
+	case *VXCOrderVrouterPartnerConfig:
+		if pc != nil {
+			cp := *pc
+			cp.Interfaces = bind(cp.Interfaces)
+			buyReq.AEndConfiguration.PartnerConfig = &cp
+		}
+	}
+	return &buyReq
+}
+
+func validateProvisionNATGatewayRequest(req *ProvisionNATGatewayRequest) error {
+	if req == nil {
+		return ErrNATGatewayRequestNil
+	}
+	resuming := req.Checkpoint != nil && req.Checkpoint.ProductUID != ""
+	if !resuming {
+		if req.Gateway == nil {
+			return ErrNATGatewayProvisionGatewayRequired
+		}
+		if err := validateCreateNATGatewayRequest(req.Gateway); err != nil {
+			return err
+		}
+	}
+	names := map[string]bool{}
+	for _, pf := range req.PacketFilters {
+		if pf.Name == "" {
+			return ErrNATGatewayProvisionPacketFilterNameEmpty
+		}
+		if err := validateNATGatewayPacketFilterRequest(pf.Filter); err != nil {
+			return fmt.Errorf("packet filter %q: %w", pf.Name, err)
+		}
+		names[pf.Name] = true
+	}
+	keys := map[string]bool{}
+	for _, v := range req.VXCs {
+		if v.Request == nil {
+			return ErrBuyVXCRequestNil
+		}
+		if keys[v.key()] {
+			return fmt.Errorf("%w: %q", ErrNATGatewayProvisionVXCKeyDuplicate, v.key())
+		}
+		keys[v.key()] = true
+		for _, ref := range []string{v.PacketFilterIn, v.PacketFilterOut} {
+			if ref != "" && !names[ref] {
+				return fmt.Errorf("%w: %q", ErrNATGatewayProvisionPacketFilterUnknown, ref)
+			}
+		}
+	}
+	return nil
+}
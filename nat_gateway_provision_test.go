@@ -0,0 +1,385 @@
+package megaport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// NATGatewayProvisionTestSuite tests the ProvisionNATGateway workflow.
+type NATGatewayProvisionTestSuite struct {
+	ClientTestSuite
+
+	mu     sync.Mutex
+	status string // current provisioning status served by GET
+	calls  []string
+	// existingFilters and existingVXCs are the packet filter summaries and
+	// VXC list entries already on the gateway, as JSON arrays.
+	existingFilters string
+	existingVXCs    string
+}
+
+func TestNATGatewayProvisionTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(NATGatewayProvisionTestSuite))
+}
+
+func (suite *NATGatewayProvisionTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+
+	suite.status = ""
+	suite.calls = nil
+	suite.existingFilters, suite.existingVXCs = "[]", "[]"
+}
+
+func (suite *NATGatewayProvisionTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *NATGatewayProvisionTestSuite) record(call string) {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	suite.calls = append(suite.calls, call)
+}
+
+func (suite *NATGatewayProvisionTestSuite) setStatus(s string) {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	suite.status = s
+}
+
+const provisionTestUID = "gw-prov-1"
+
+// registerGateway serves the NAT Gateway create/get/delete and network
+// design endpoints. buyStatus is the status served after a successful buy;
+// an empty buyStatus makes the buy endpoint fail.
+func (suite *NATGatewayProvisionTestSuite) registerGateway(buyStatus string) {
+	suite.mux.HandleFunc("/v3/products/nat_gateways", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodPost)
+		suite.record("create")
+		suite.setStatus(STATUS_DESIGN)
+		fmt.Fprintf(w, `{"message":"ok","terms":"","data":{"productUid":%q,"provisioningStatus":"DESIGN"}}`, provisionTestUID)
+	})
+	suite.mux.HandleFunc("/v3/products/nat_gateways/"+provisionTestUID, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			suite.mu.Lock()
+			status := suite.status
+			suite.mu.Unlock()
+			fmt.Fprintf(w, `{"message":"ok","terms":"","data":{"productUid":%q,"provisioningStatus":%q}}`, provisionTestUID, status)
+		case http.MethodDelete:
+			suite.record("delete")
+			suite.setStatus("")
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	suite.mux.HandleFunc("/v3/networkdesign/validate", func(w http.ResponseWriter, r *http.Request) {
+		suite.record("validate")
+		fmt.Fprintf(w, `{"message":"ok","terms":"","data":[{"productUid":%q}]}`, provisionTestUID)
+	})
+	suite.mux.HandleFunc("/v3/networkdesign/buy", func(w http.ResponseWriter, r *http.Request) {
+		suite.record("buy")
+		if buyStatus == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message":"insufficient credit"}`)
+			return
+		}
+		suite.setStatus(buyStatus)
+		fmt.Fprintf(w, `{"message":"ok","terms":"","data":[{"uid":%q,"provisioningStatus":"DEPLOYABLE"}]}`, provisionTestUID)
+	})
+}
+
+func (suite *NATGatewayProvisionTestSuite) registerAttachments() {
+	suite.mux.HandleFunc("/v3/products/nat_gateways/"+provisionTestUID+"/packet_filter_summaries", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprintf(w, `{"message":"ok","terms":"","data":%s}`, suite.existingFilters)
+	})
+	suite.mux.HandleFunc("/v2/products", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprintf(w, `{"message":"ok","terms":"","data":[{"productUid":"port-1","productType":"MEGAPORT","provisioningStatus":"LIVE","associatedVxcs":%s}]}`, suite.existingVXCs)
+	})
+	suite.mux.HandleFunc("/v3/products/nat_gateways/"+provisionTestUID+"/packet_filters", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodPost)
+		suite.record("packet_filter")
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":{"id":77,"description":"allow-https","entries":[]}}`)
+	})
+	suite.mux.HandleFunc("/v4/networkdesign/buy", func(w http.ResponseWriter, r *http.Request) {
+		suite.record("vxc")
+		body, err := io.ReadAll(r.Body)
+		suite.NoError(err)
+		var payload struct {
+			NetworkDesign []struct {
+				PortID         string `json:"productUid"`
+				AssociatedVXCs []struct {
+					AEnd struct {
+						ProductUID    string `json:"productUid"`
+						PartnerConfig struct {
+							Interfaces []struct {
+								PacketFilterIn *int64 `json:"packetFilterIn"`
+							} `json:"interfaces"`
+						} `json:"partnerConfig"`
+					} `json:"aEnd"`
+				} `json:"associatedVxcs"`
+			} `json:"networkDesign"`
+		}
+		suite.NoError(json.Unmarshal(body, &payload))
+		suite.Require().Len(payload.NetworkDesign, 1)
+		order := payload.NetworkDesign[0]
+		suite.Equal(provisionTestUID, order.PortID)
+		suite.Equal(provisionTestUID, order.AssociatedVXCs[0].AEnd.ProductUID)
+		ifaces := order.AssociatedVXCs[0].AEnd.PartnerConfig.Interfaces
+		suite.Require().Len(ifaces, 1)
+		suite.Require().NotNil(ifaces[0].PacketFilterIn)
+		suite.EqualValues(77, *ifaces[0].PacketFilterIn)
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":[{"vxcJTechnicalServiceUid":"vxc-1"}]}`)
+	})
+}
+
+func provisionTestRequest() *ProvisionNATGatewayRequest {
+	return &ProvisionNATGatewayRequest{
+		Gateway: &CreateNATGatewayRequest{
+			ProductName: "edge",
+			LocationID:  10,
+			Speed:       1000,
+			Term:        1,
+		},
+		PacketFilters: []NATGatewayProvisionPacketFilter{{
+			Name: "https",
+			Filter: &NATGatewayPacketFilterRequest{
+				Description: "allow-https",
+				Entries: []NATGatewayPacketFilterEntry{{
+					Action: PacketFilterActionPermit, SourceAddress: "0.0.0.0/0", DestinationAddress: "0.0.0.0/0",
+				}},
+			},
+		}},
+		VXCs: []NATGatewayProvisionVXC{{
+			Request: &BuyVXCRequest{
+				VXCName:   "edge-uplink",
+				RateLimit: 100,
+				Term:      1,
+				AEndConfiguration: VXCOrderEndpointConfiguration{
+					PartnerConfig: VXCOrderVrouterPartnerConfig{
+						Interfaces: []PartnerConfigInterface{{IpAddresses: []string{"10.0.0.1/30"}}},
+					},
+				},
+				BEndConfiguration: VXCOrderEndpointConfiguration{ProductUID: "port-1", VLAN: 100},
+			},
+			PacketFilterIn: "https",
+		}},
+		PollInterval: 10 * time.Millisecond,
+		WaitForTime:  time.Second,
+	}
+}
+
+func (suite *NATGatewayProvisionTestSuite) TestProvisionFullLifecycle() {
+	suite.registerGateway(SERVICE_LIVE)
+	suite.registerAttachments()
+
+	req := provisionTestRequest()
+	var events []NATGatewayProvisionEvent
+	req.OnProgress = func(e NATGatewayProvisionEvent) { events = append(events, e) }
+
+	res, err := suite.client.NATGatewayService.ProvisionNATGateway(context.Background(), req)
+	suite.Require().NoError(err)
+	suite.Equal(SERVICE_LIVE, res.Gateway.ProvisioningStatus)
+	suite.Equal([]string{"create", "validate", "buy", "packet_filter", "vxc"}, suite.calls)
+	suite.Equal(&NATGatewayProvisionCheckpoint{
+		ProductUID:      provisionTestUID,
+		Purchased:       true,
+		PacketFilterIDs: map[string]int{"https": 77},
+		VXCUIDs:         map[string]string{"edge-uplink": "vxc-1"},
+	}, res.Checkpoint)
+
+	// The caller's VXC request must not have been mutated.
+	pc := req.VXCs[0].Request.AEndConfiguration.PartnerConfig.(VXCOrderVrouterPartnerConfig)
+	suite.Nil(pc.Interfaces[0].PacketFilterIn)
+	suite.Empty(req.VXCs[0].Request.PortUID)
+
+	last := events[len(events)-1]
+	suite.Equal(NATGatewayProvisionPhaseAttachVXCs, last.Phase)
+	suite.Equal(NATGatewayProvisionStatusCompleted, last.Status)
+	suite.Equal("edge-uplink", last.Detail)
+	for _, e := range events {
+		suite.NotEqual(NATGatewayProvisionStatusFailed, e.Status)
+	}
+}
+
+func (suite *NATGatewayProvisionTestSuite) TestProvisionBuyFailureCleansUpDesign() {
+	suite.registerGateway("")
+
+	req := provisionTestRequest()
+	var last NATGatewayProvisionEvent
+	req.OnProgress = func(e NATGatewayProvisionEvent) { last = e }
+
+	_, err := suite.client.NATGatewayService.ProvisionNATGateway(context.Background(), req)
+	suite.Require().Error(err)
+	suite.Contains(err.Error(), "phase BUY")
+	suite.Equal([]string{"create", "validate", "buy", "delete"}, suite.calls)
+	suite.Equal(NATGatewayProvisionPhaseCleanup, last.Phase)
+	suite.Equal(NATGatewayProvisionStatusCompleted, last.Status)
+	suite.Empty(last.Checkpoint.ProductUID)
+}
+
+func (suite *NATGatewayProvisionTestSuite) TestProvisionKeepDesignOnFailure() {
+	suite.registerGateway("")
+
+	req := provisionTestRequest()
+	req.KeepDesignOnFailure = true
+
+	_, err := suite.client.NATGatewayService.ProvisionNATGateway(context.Background(), req)
+	suite.Require().Error(err)
+	suite.Equal([]string{"create", "validate", "buy"}, suite.calls)
+}
+
+func (suite *NATGatewayProvisionTestSuite) TestProvisionResumeFromDesign() {
+	suite.registerGateway(SERVICE_CONFIGURED)
+	suite.registerAttachments()
+	suite.setStatus(STATUS_DESIGN)
+
+	req := provisionTestRequest()
+	req.Gateway = nil
+	req.Checkpoint = &NATGatewayProvisionCheckpoint{ProductUID: provisionTestUID}
+
+	res, err := suite.client.NATGatewayService.ProvisionNATGateway(context.Background(), req)
+	suite.Require().NoError(err)
+	suite.Equal([]string{"validate", "buy", "packet_filter", "vxc"}, suite.calls)
+	suite.True(res.Checkpoint.Purchased)
+}
+
+func (suite *NATGatewayProvisionTestSuite) TestProvisionResumeAfterOrder() {
+	suite.registerGateway(SERVICE_LIVE)
+	suite.registerAttachments()
+	suite.setStatus(SERVICE_LIVE)
+
+	req := provisionTestRequest()
+	req.Checkpoint = &NATGatewayProvisionCheckpoint{
+		ProductUID:      provisionTestUID,
+		Purchased:       true,
+		PacketFilterIDs: map[string]int{"https": 77},
+	}
+
+	res, err := suite.client.NATGatewayService.ProvisionNATGateway(context.Background(), req)
+	suite.Require().NoError(err)
+	suite.Equal([]string{"vxc"}, suite.calls)
+	suite.Equal("vxc-1", res.Checkpoint.VXCUIDs["edge-uplink"])
+	// The caller's checkpoint is not mutated in place.
+	suite.Empty(req.Checkpoint.VXCUIDs)
+}
+
+func (suite *NATGatewayProvisionTestSuite) TestProvisionResumeAdoptsExistingAttachments() {
+	suite.registerGateway(SERVICE_LIVE)
+	suite.registerAttachments()
+	suite.setStatus(SERVICE_LIVE)
+	// The previous run created both before its last checkpoint was saved.
+	suite.existingFilters = `[{"id":77,"description":"allow-https"}]`
+	suite.existingVXCs = fmt.Sprintf(`[{"productUid":"vxc-9","productName":"edge-uplink","provisioningStatus":"LIVE","aEnd":{"productUid":%q}}]`, provisionTestUID)
+
+	req := provisionTestRequest()
+	req.Checkpoint = &NATGatewayProvisionCheckpoint{ProductUID: provisionTestUID, Purchased: true}
+
+	res, err := suite.client.NATGatewayService.ProvisionNATGateway(context.Background(), req)
+	suite.Require().NoError(err)
+	suite.Empty(suite.calls)
+	suite.Equal(map[string]int{"https": 77}, res.Checkpoint.PacketFilterIDs)
+	suite.Equal(map[string]string{"edge-uplink": "vxc-9"}, res.Checkpoint.VXCUIDs)
+}
+
+func (suite *NATGatewayProvisionTestSuite) TestProvisionCleanupAfterContextEnds() {
+	suite.registerGateway("")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req := provisionTestRequest()
+	var last NATGatewayProvisionEvent
+	req.OnProgress = func(e NATGatewayProvisionEvent) {
+		if e.Phase == NATGatewayProvisionPhaseBuy && e.Status == NATGatewayProvisionStatusStarted {
+			cancel()
+		}
+		last = e
+	}
+
+	_, err := suite.client.NATGatewayService.ProvisionNATGateway(ctx, req)
+	suite.ErrorIs(err, context.Canceled)
+	suite.Equal([]string{"create", "validate", "delete"}, suite.calls)
+	suite.Equal(NATGatewayProvisionPhaseCleanup, last.Phase)
+	suite.Equal(NATGatewayProvisionStatusCompleted, last.Status)
+}
+
+func (suite *NATGatewayProvisionTestSuite) TestProvisionReadyTimeout() {
+	suite.registerGateway("DEPLOYABLE")
+	suite.registerAttachments()
+
+	req := provisionTestRequest()
+	req.WaitForTime = 50 * time.Millisecond
+	req.KeepDesignOnFailure = true
+
+	// WaitForTime bounds the wait even when the caller's context has a
+	// later deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err := suite.client.NATGatewayService.ProvisionNATGateway(ctx, req)
+	suite.ErrorIs(err, ErrNATGatewayProvisionTimeout)
+	suite.Equal([]string{"create", "validate", "buy"}, suite.calls)
+}
+
+func (suite *NATGatewayProvisionTestSuite) TestProvisionResumeTerminal() {
+	suite.registerGateway(SERVICE_LIVE)
+	suite.setStatus(STATUS_CANCELLED)
+
+	req := provisionTestRequest()
+	req.Checkpoint = &NATGatewayProvisionCheckpoint{ProductUID: provisionTestUID}
+
+	_, err := suite.client.NATGatewayService.ProvisionNATGateway(context.Background(), req)
+	suite.ErrorIs(err, ErrNATGatewayProvisionTerminalState)
+	suite.Empty(suite.calls)
+}
+
+func TestValidateProvisionNATGatewayRequest(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name    string
+		mutate  func(*ProvisionNATGatewayRequest)
+		wantErr error
+	}{
+		{"valid", func(*ProvisionNATGatewayRequest) {}, nil},
+		{"no gateway", func(r *ProvisionNATGatewayRequest) { r.Gateway = nil }, ErrNATGatewayProvisionGatewayRequired},
+		{"bad gateway", func(r *ProvisionNATGatewayRequest) { r.Gateway.Term = 7 }, ErrNATGatewayInvalidTerm},
+		{"unnamed filter", func(r *ProvisionNATGatewayRequest) { r.PacketFilters[0].Name = "" }, ErrNATGatewayProvisionPacketFilterNameEmpty},
+		{"unknown filter", func(r *ProvisionNATGatewayRequest) { r.VXCs[0].PacketFilterIn = "ssh" }, ErrNATGatewayProvisionPacketFilterUnknown},
+		{"duplicate vxc", func(r *ProvisionNATGatewayRequest) { r.VXCs = append(r.VXCs, r.VXCs[0]) }, ErrNATGatewayProvisionVXCKeyDuplicate},
+		{"nil vxc", func(r *ProvisionNATGatewayRequest) { r.VXCs[0].Request = nil }, ErrBuyVXCRequestNil},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			req := provisionTestRequest()
+			tc.mutate(req)
+			err := validateProvisionNATGatewayRequest(req)
+			if tc.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
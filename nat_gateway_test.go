@@ -1282,6 +1282,90 @@ func (suite *NATGatewayClientTestSuite) TestListNATGatewayIPRoutesPolling() {
 	suite.Equal(1, bgpCount)
 }
 
+func (suite *NATGatewayClientTestSuite) TestNATGatewayBGPNeighborRoutesOperation() {
+	ctx := context.Background()
+	natSvc := suite.client.NATGatewayService
+	productUID := "uid-op"
+
+	op, ok := natSvc.(*NATGatewayServiceOp)
+	suite.Require().True(ok)
+	op.pollOptions = &PollOptions{InitialDelay: time.Millisecond, Interval: time.Millisecond}
+
+	suite.mux.HandleFunc("/v3/products/nat_gateways/"+productUID+"/diagnostics/routes/bgp/neighbor", func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("RECEIVED", r.URL.Query().Get("direction"))
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":"op-neighbor"}`)
+	})
+	var opCalls atomic.Int32
+	suite.mux.HandleFunc("/v3/products/nat_gateways/"+productUID+"/diagnostics/routes/operation", func(w http.ResponseWriter, r *http.Request) {
+		suite.Equal("op-neighbor", r.URL.Query().Get("operationId"))
+		if opCalls.Add(1) == 1 {
+			fmt.Fprint(w, `{"message":"ok","terms":"","data":[]}`)
+			return
+		}
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":[
+			{"prefix":"10.0.0.0/24","protocol":"STATIC","nextHop":{"ip":"10.0.0.1","vxc":{"id":"vxc-1","name":"v1"}}},
+			{"prefix":"192.168.0.0/16","asPath":"65000","origin":"IGP","best":true,"nextHop":{"ip":"10.0.0.2","vxc":{"id":"vxc-2","name":"v2"}}}
+		]}`)
+	})
+
+	submitted, err := natSvc.SubmitNATGatewayBGPNeighborRoutesOperation(ctx, &NATGatewayBGPNeighborRoutesRequest{
+		ProductUID:    productUID,
+		PeerIPAddress: "10.0.0.2",
+		Direction:     BGPRouteDirectionReceived,
+	})
+	suite.Require().NoError(err)
+	suite.Equal(OperationRef{Kind: OperationKindNATGatewayBGPNeighborRoutes, ProductUID: productUID, OperationID: "op-neighbor"}, submitted.Ref())
+
+	resumed, err := natSvc.ResumeNATGatewayBGPRoutesOperation(submitted.Ref())
+	suite.Require().NoError(err)
+	routes, err := resumed.Wait(ctx, nil)
+	suite.Require().NoError(err)
+	suite.Require().Len(routes, 1)
+	suite.Equal("192.168.0.0/16", routes[0].Prefix)
+	suite.GreaterOrEqual(opCalls.Load(), int32(2))
+
+	_, err = natSvc.ResumeNATGatewayIPRoutesOperation(submitted.Ref())
+	suite.ErrorIs(err, ErrOperationKindMismatch)
+}
+
+func (suite *NATGatewayClientTestSuite) TestListNATGatewayIPRoutesTimeout() {
+	productUID := "uid-timeout"
+	op, ok := suite.client.NATGatewayService.(*NATGatewayServiceOp)
+	suite.Require().True(ok)
+	op.pollOptions = &PollOptions{InitialDelay: time.Millisecond, Interval: time.Millisecond, Timeout: 20 * time.Millisecond}
+
+	suite.mux.HandleFunc("/v3/products/nat_gateways/"+productUID+"/diagnostics/routes/ip", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":"op-slow"}`)
+	})
+	suite.mux.HandleFunc("/v3/products/nat_gateways/"+productUID+"/diagnostics/routes/operation", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":[]}`)
+	})
+
+	_, err := suite.client.NATGatewayService.ListNATGatewayIPRoutes(context.Background(), productUID, "")
+	suite.ErrorIs(err, ErrNATGatewayDiagnosticsTimeout)
+}
+
+func (suite *NATGatewayClientTestSuite) TestListNATGatewayIPRoutesTimeoutWithCallerDeadline() {
+	productUID := "uid-timeout-deadline"
+	op, ok := suite.client.NATGatewayService.(*NATGatewayServiceOp)
+	suite.Require().True(ok)
+	op.pollOptions = &PollOptions{InitialDelay: time.Millisecond, Interval: time.Millisecond, Timeout: 20 * time.Millisecond}
+
+	suite.mux.HandleFunc("/v3/products/nat_gateways/"+productUID+"/diagnostics/routes/ip", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":"op-slow"}`)
+	})
+	suite.mux.HandleFunc("/v3/products/nat_gateways/"+productUID+"/diagnostics/routes/operation", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message":"ok","terms":"","data":[]}`)
+	})
+
+	// The SDK timeout still bounds the wait when the caller's deadline is later.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err := suite.client.NATGatewayService.ListNATGatewayIPRoutes(ctx, productUID, "")
+	suite.ErrorIs(err, ErrNATGatewayDiagnosticsTimeout)
+	suite.NoError(ctx.Err())
+}
+
 // --- Prefix list round-trip ----------------------------------------------
 
 func (suite *NATGatewayClientTestSuite) TestPrefixListGeLeRoundTrip() {
@@ -25,6 +25,14 @@ type PartnerService interface {
 	FilterPartnerMegaportByDiversityZone(ctx context.Context, partners []*PartnerMegaport, diversityZone string) ([]*PartnerMegaport, error)
 	// FilterPartnerMegaportByMetro filters a list of partner megaports by metro name, using the client's LocationService to resolve metro-to-location-ID mapping.
 	FilterPartnerMegaportByMetro(ctx context.Context, partners []*PartnerMegaport, metro string) ([]*PartnerMegaport, error)
+	// FindOnRamps returns VXC-permitted partner ports matching a composite
+	// query, nearest to the source location first.
+	FindOnRamps(ctx context.Context, q *OnRampQuery) ([]*OnRampCandidate, error)
+	// SelectOnRamp returns the best partner port for a composite query.
+	SelectOnRamp(ctx context.Context, q *OnRampQuery) (*OnRampCandidate, error)
+	// SelectAWSEndpoint chooses the AWS partner port for a region and builds
+	// the B-End of a VXC to it with a validated AWS partner config.
+	SelectAWSEndpoint(ctx context.Context, req *AWSEndpointRequest) (*AWSEndpoint, error)
 }
 
 // NewPartnerService creates a new instance of the PartnerService.
@@ -0,0 +1,216 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Cloud on-ramp selector errors.
+var (
+	ErrOnRampQueryNil         = errors.New("on-ramp query is required")
+	ErrOnRampSourceRequired   = errors.New("on-ramp query requires a source location ID")
+	ErrOnRampSourceNoCoords   = errors.New("source location has no coordinates")
+	ErrOnRampQueryInvalid     = errors.New("on-ramp query speed, distance and limit must not be negative")
+	ErrOnRampDiversityZoneBad = errors.New("on-ramp diversity zone must be red or blue")
+)
+
+// OnRampQuery selects partner ports for a cloud connection. Only
+// VXC-permitted partner ports are considered. String matches are
+// case-insensitive.
+type OnRampQuery struct {
+	// SourceLocationID is the location of the A-End. Candidates are ranked
+	// by great-circle distance from it.
+	SourceLocationID int
+	// ConnectType matches PartnerMegaport.ConnectType exactly, e.g. "AWS",
+	// "AWSHC", "AZURE", "GOOGLE" or "ORACLE".
+	ConnectType string
+	// CompanyName matches a substring of PartnerMegaport.CompanyName.
+	CompanyName string
+	// Region matches a substring of the partner port's product name, for
+	// example "us-east-1" or "Sydney".
+	Region string
+	// MinSpeedMbps requires the partner port to support at least this speed.
+	MinSpeedMbps int
+	// DiversityZone restricts results to one zone.
+	DiversityZone string
+	// MaxDistanceKm drops candidates further than this from the source.
+	MaxDistanceKm float64
+	// Limit caps the number of candidates returned; zero returns all.
+	Limit int
+}
+
+// OnRampCandidate is a partner port matched by FindOnRamps.
+type OnRampCandidate struct {
+	Partner *PartnerMegaport
+	// Location is nil when the partner port's location is not in the v3
+	// locations list; such candidates sort last.
+	Location   *LocationV3
+	DistanceKm float64
+	// Endpoint is a B-End skeleton with the product UID, diversity zone and
+	// the partner config type for the connect type set. Provider-specific
+	// fields such as account IDs or keys must still be filled in.
+	Endpoint VXCOrderEndpointConfiguration
+}
+
+// FindOnRamps returns the VXC-permitted partner ports matching the query,
+// nearest to the source location first, then by partner Rank and product
+// UID.
+func (svc *PartnerServiceOp) FindOnRamps(ctx context.Context, q *OnRampQuery) ([]*OnRampCandidate, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	locations, err := svc.Client.LocationService.ListLocationsV3(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]*LocationV3, len(locations))
+	for _, l := range locations {
+		byID[l.ID] = l
+	}
+	source, ok := byID[q.SourceLocationID]
+	if !ok {
+		return nil, fmt.Errorf("source location %d: %w", q.SourceLocationID, ErrLocationNotFound)
+	}
+	if !source.hasCoordinates() {
+		return nil, ErrOnRampSourceNoCoords
+	}
+
+	partners, err := svc.ListPartnerMegaports(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*OnRampCandidate
+	for _, p := range q.filter(partners) {
+		c := &OnRampCandidate{Partner: p, Endpoint: onRampEndpoint(p)}
+		if loc, ok := byID[p.LocationId]; ok && loc.hasCoordinates() {
+			c.Location = loc
+			c.DistanceKm = source.DistanceKm(loc.Latitude, loc.Longitude)
+		} else if q.MaxDistanceKm > 0 {
+			continue
+		}
+		if q.MaxDistanceKm > 0 && c.DistanceKm > q.MaxDistanceKm {
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if (a.Location == nil) != (b.Location == nil) {
+			return a.Location != nil
+		}
+		if a.DistanceKm != b.DistanceKm {
+			return a.DistanceKm < b.DistanceKm
+		}
+		return partnerRankLess(a.Partner, b.Partner)
+	})
+	if q.Limit > 0 && len(candidates) > q.Limit {
+		candidates = candidates[:q.Limit]
+	}
+	return candidates, nil
+}
+
+// SelectOnRamp returns the best candidate from FindOnRamps, or
+// ErrNoPartnerPortsFound when nothing matches.
+func (svc *PartnerServiceOp) SelectOnRamp(ctx context.Context, q *OnRampQuery) (*OnRampCandidate, error) {
+	candidates, err := svc.FindOnRamps(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoPartnerPortsFound
+	}
+	return candidates[0], nil
+}
+
+func (q *OnRampQuery) validate() error {
+	if q == nil {
+		return ErrOnRampQueryNil
+	}
+	if q.SourceLocationID < 1 {
+		return ErrOnRampSourceRequired
+	}
+	return q.validateFilters()
+}
+
+// validateFilters checks the attribute filters, which SelectAWSEndpoint
+// also applies without a source location.
+func (q *OnRampQuery) validateFilters() error {
+	if q.MinSpeedMbps < 0 || q.MaxDistanceKm < 0 || q.Limit < 0 {
+		return ErrOnRampQueryInvalid
+	}
+	if q.DiversityZone != "" && !strings.EqualFold(q.DiversityZone, DiversityZoneRed) && !strings.EqualFold(q.DiversityZone, DiversityZoneBlue) {
+		return ErrOnRampDiversityZoneBad
+	}
+	return nil
+}
+
+// filter returns the partner ports passing every attribute filter except
+// distance, in order.
+func (q *OnRampQuery) filter(partners []*PartnerMegaport) []*PartnerMegaport {
+	var out []*PartnerMegaport
+	for _, p := range partners {
+		if q.matches(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// partnerRankLess orders partner ports by Rank, then product UID.
+func partnerRankLess(a, b *PartnerMegaport) bool {
+	if a.Rank != b.Rank {
+		return a.Rank < b.Rank
+	}
+	return a.ProductUID < b.ProductUID
+}
+
+// matches applies every attribute filter except distance.
+func (q *OnRampQuery) matches(p *PartnerMegaport) bool {
+	if p == nil || !p.VXCPermitted {
+		return false
+	}
+	if q.ConnectType != "" && !strings.EqualFold(p.ConnectType, q.ConnectType) {
+		return false
+	}
+	if q.CompanyName != "" && !strings.Contains(strings.ToLower(p.CompanyName), strings.ToLower(q.CompanyName)) {
+		return false
+	}
+	if q.Region != "" && !strings.Contains(strings.ToLower(p.ProductName), strings.ToLower(q.Region)) {
+		return false
+	}
+	if q.MinSpeedMbps > 0 && p.Speed < q.MinSpeedMbps {
+		return false
+	}
+	if q.DiversityZone != "" && !strings.EqualFold(p.DiversityZone, q.DiversityZone) {
+		return false
+	}
+	return true
+}
+
+// onRampEndpoint builds the B-End skeleton for a partner port.
+func onRampEndpoint(p *PartnerMegaport) VXCOrderEndpointConfiguration {
+	e := VXCOrderEndpointConfiguration{
+		ProductUID:    p.ProductUID,
+		DiversityZone: p.DiversityZone,
+	}
+	connectType := strings.ToUpper(p.ConnectType)
+	switch connectType {
+	case PARTNER_AWS, CONNECT_TYPE_AWS_HOSTED_CONNECTION:
+		e.PartnerConfig = &VXCPartnerConfigAWS{ConnectType: connectType}
+	case PARTNER_AZURE:
+		e.PartnerConfig = &VXCPartnerConfigAzure{ConnectType: connectType}
+	case PARTNER_GOOGLE:
+		e.PartnerConfig = &VXCPartnerConfigGoogle{ConnectType: connectType}
+	case PARTNER_OCI:
+		e.PartnerConfig = &VXCPartnerConfigOracle{ConnectType: connectType}
+	case PARTNER_IBM:
+		e.PartnerConfig = &VXCPartnerConfigIBM{ConnectType: connectType}
+	case connectTypeTransit:
+		e.PartnerConfig = &VXCPartnerConfigTransit{ConnectType: connectType}
+	}
+	return e
+}
@@ -0,0 +1,136 @@
+package megaport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const onRampLocationsJSON = `{"message": "List public locations", "data": [
+    {"id": 1, "name": "Sydney A", "status": "Active", "latitude": -33.92, "longitude": 151.19},
+    {"id": 2, "name": "Melbourne", "status": "Active", "latitude": -37.81, "longitude": 144.96},
+    {"id": 3, "name": "Sydney B", "status": "Active", "latitude": -33.87, "longitude": 151.21},
+    {"id": 4, "name": "Unknown", "status": "Active", "latitude": 0, "longitude": 0}
+]}`
+
+const onRampPartnersJSON = `{"message": "Partner ports", "data": [
+    {"productUid": "aws-syd-red", "title": "Asia Pacific (Sydney) (ap-southeast-2)", "connectType": "AWS", "companyName": "AWS", "locationId": 3, "diversityZone": "red", "speed": 10000, "rank": 2, "vxcPermitted": true},
+    {"productUid": "aws-syd-blue", "title": "Asia Pacific (Sydney) (ap-southeast-2)", "connectType": "AWS", "companyName": "AWS", "locationId": 3, "diversityZone": "blue", "speed": 10000, "rank": 1, "vxcPermitted": true},
+    {"productUid": "aws-mel", "title": "Asia Pacific (Melbourne) (ap-southeast-4)", "connectType": "AWS", "companyName": "AWS", "locationId": 2, "diversityZone": "red", "speed": 10000, "rank": 0, "vxcPermitted": true},
+    {"productUid": "aws-closed", "title": "Asia Pacific (Sydney) (ap-southeast-2)", "connectType": "AWS", "companyName": "AWS", "locationId": 1, "diversityZone": "red", "speed": 10000, "vxcPermitted": false},
+    {"productUid": "aws-slow", "title": "Asia Pacific (Sydney) (ap-southeast-2)", "connectType": "AWS", "companyName": "AWS", "locationId": 1, "diversityZone": "red", "speed": 1000, "vxcPermitted": true},
+    {"productUid": "aws-nowhere", "title": "Asia Pacific (Sydney) (ap-southeast-2)", "connectType": "AWS", "companyName": "AWS", "locationId": 4, "diversityZone": "red", "speed": 10000, "vxcPermitted": true},
+    {"productUid": "azure-syd", "title": "Azure Sydney", "connectType": "AZURE", "companyName": "Microsoft Azure", "locationId": 1, "diversityZone": "blue", "speed": 10000, "vxcPermitted": true}
+]}`
+
+// PartnerOnRampTestSuite tests the cloud on-ramp selector.
+type PartnerOnRampTestSuite struct {
+	ClientTestSuite
+}
+
+func TestPartnerOnRampTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(PartnerOnRampTestSuite))
+}
+
+func (suite *PartnerOnRampTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+
+	suite.mux.HandleFunc("/v3/locations", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, onRampLocationsJSON)
+	})
+	suite.mux.HandleFunc("/v2/dropdowns/partner/megaports", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprint(w, onRampPartnersJSON)
+	})
+}
+
+func (suite *PartnerOnRampTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func onRampUIDs(candidates []*OnRampCandidate) []string {
+	uids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		uids = append(uids, c.Partner.ProductUID)
+	}
+	return uids
+}
+
+func (suite *PartnerOnRampTestSuite) TestFindOnRamps() {
+	ctx := context.Background()
+	svc := suite.client.PartnerService
+
+	tests := []struct {
+		name  string
+		query *OnRampQuery
+		want  []string
+	}{
+		{"nearest then rank", &OnRampQuery{SourceLocationID: 1, ConnectType: "aws", MinSpeedMbps: 10000},
+			[]string{"aws-syd-blue", "aws-syd-red", "aws-mel", "aws-nowhere"}},
+		{"max distance", &OnRampQuery{SourceLocationID: 1, ConnectType: "AWS", MinSpeedMbps: 10000, MaxDistanceKm: 100},
+			[]string{"aws-syd-blue", "aws-syd-red"}},
+		{"zone and limit", &OnRampQuery{SourceLocationID: 1, ConnectType: "AWS", MinSpeedMbps: 10000, DiversityZone: "RED", Limit: 1},
+			[]string{"aws-syd-red"}},
+		{"region", &OnRampQuery{SourceLocationID: 1, Region: "ap-southeast-4"}, []string{"aws-mel"}},
+		{"company", &OnRampQuery{SourceLocationID: 2, CompanyName: "azure"}, []string{"azure-syd"}},
+		{"no speed filter", &OnRampQuery{SourceLocationID: 1, ConnectType: "AWS", MaxDistanceKm: 1},
+			[]string{"aws-slow"}},
+	}
+	for _, tc := range tests {
+		suite.Run(tc.name, func() {
+			got, err := svc.FindOnRamps(ctx, tc.query)
+			suite.Require().NoError(err)
+			suite.Equal(tc.want, onRampUIDs(got))
+		})
+	}
+}
+
+func (suite *PartnerOnRampTestSuite) TestSelectOnRamp() {
+	ctx := context.Background()
+	svc := suite.client.PartnerService
+
+	best, err := svc.SelectOnRamp(ctx, &OnRampQuery{SourceLocationID: 1, ConnectType: "AWS", MinSpeedMbps: 10000})
+	suite.Require().NoError(err)
+	suite.Equal(3, best.Location.ID)
+	suite.InDelta(6, best.DistanceKm, 1)
+	suite.Equal("aws-syd-blue", best.Endpoint.ProductUID)
+	suite.Equal("blue", best.Endpoint.DiversityZone)
+	suite.Equal(&VXCPartnerConfigAWS{ConnectType: "AWS"}, best.Endpoint.PartnerConfig)
+
+	best, err = svc.SelectOnRamp(ctx, &OnRampQuery{SourceLocationID: 1, ConnectType: "AZURE"})
+	suite.Require().NoError(err)
+	suite.Equal(&VXCPartnerConfigAzure{ConnectType: "AZURE"}, best.Endpoint.PartnerConfig)
+
+	_, err = svc.SelectOnRamp(ctx, &OnRampQuery{SourceLocationID: 1, ConnectType: "GOOGLE"})
+	suite.ErrorIs(err, ErrNoPartnerPortsFound)
+
+	errTests := []struct {
+		name    string
+		query   *OnRampQuery
+		wantErr error
+	}{
+		{"nil", nil, ErrOnRampQueryNil},
+		{"no source", &OnRampQuery{}, ErrOnRampSourceRequired},
+		{"negative", &OnRampQuery{SourceLocationID: 1, Limit: -1}, ErrOnRampQueryInvalid},
+		{"bad zone", &OnRampQuery{SourceLocationID: 1, DiversityZone: "green"}, ErrOnRampDiversityZoneBad},
+		{"unknown source", &OnRampQuery{SourceLocationID: 9}, ErrLocationNotFound},
+		{"source without coordinates", &OnRampQuery{SourceLocationID: 4}, ErrOnRampSourceNoCoords},
+	}
+	for _, tc := range errTests {
+		suite.Run(tc.name, func() {
+			_, err := svc.FindOnRamps(ctx, tc.query)
+			suite.ErrorIs(err, tc.wantErr)
+		})
+	}
+}
@@ -40,6 +40,10 @@ type PortService interface {
 	ListPortResourceTags(ctx context.Context, portID string) (map[string]string, error)
 	// UpdatePortResourceTags updates the resource tags for a port in the Megaport Port API.
 	UpdatePortResourceTags(ctx context.Context, portID string, tags map[string]string) error
+	// ListLAGs lists the LAGs among the account's ports.
+	ListLAGs(ctx context.Context) ([]*LAG, error)
+	// GetLAG returns the LAG that a port is a member of.
+	GetLAG(ctx context.Context, portUID string) (*LAG, error)
 }
 
 // NewPortService creates a new instance of the Port Service.
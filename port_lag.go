@@ -0,0 +1,193 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// LAG errors.
+var (
+	// ErrLAGNotFound is returned when a port is not a member of a LAG.
+	ErrLAGNotFound = errors.New("port is not a member of a LAG")
+	// ErrLAGInvalid is returned for a LAG without exactly one primary port or
+	// whose members differ in location or speed.
+	ErrLAGInvalid = errors.New("invalid LAG")
+)
+
+// LAG is a Link Aggregation Group: ports sharing an aggregation ID, one of
+// which is the primary that VXCs and IXs attach to.
+type LAG struct {
+	// ID is the ports' AggregationID, or their LAGID when it is unset.
+	ID int
+	// Primary is the primary port, or nil when no member is marked primary.
+	Primary *Port
+	// Members are all ports of the LAG including the primary, primary first
+	// and then by UID.
+	Members []*Port
+}
+
+// lagID returns the ID of the LAG a port belongs to, or zero.
+func lagID(p *Port) int {
+	if p.AggregationID != 0 {
+		return p.AggregationID
+	}
+	return p.LAGID
+}
+
+// GroupLAGs groups ports into LAGs, ordered by ID. Ports not in a LAG and
+// decommissioned or cancelled ports are ignored.
+func GroupLAGs(ports []*Port) []*LAG {
+	byID := make(map[int]*LAG)
+	for _, p := range ports {
+		if p == nil || lagID(p) == 0 || p.ProvisioningStatus == STATUS_DECOMMISSIONED || p.ProvisioningStatus == STATUS_CANCELLED {
+			continue
+		}
+		l, ok := byID[lagID(p)]
+		if !ok {
+			l = &LAG{ID: lagID(p)}
+			byID[l.ID] = l
+		}
+		l.Members = append(l.Members, p)
+		if p.LAGPrimary && l.Primary == nil {
+			l.Primary = p
+		}
+	}
+	lags := make([]*LAG, 0, len(byID))
+	for _, l := range byID {
+		sort.SliceStable(l.Members, func(i, j int) bool {
+			a, b := l.Members[i], l.Members[j]
+			if a.LAGPrimary != b.LAGPrimary {
+				return a.LAGPrimary
+			}
+			return a.UID < b.UID
+		})
+		lags = append(lags, l)
+	}
+	sort.Slice(lags, func(i, j int) bool { return lags[i].ID < lags[j].ID })
+	return lags
+}
+
+// MemberUIDs returns the UIDs of the members in order.
+func (l *LAG) MemberUIDs() []string {
+	uids := make([]string, len(l.Members))
+	for i, p := range l.Members {
+		uids[i] = p.UID
+	}
+	return uids
+}
+
+// CapacityMbps returns the sum of the member port speeds.
+func (l *LAG) CapacityMbps() int {
+	total := 0
+	for _, p := range l.Members {
+		total += p.PortSpeed
+	}
+	return total
+}
+
+// CommittedMbps returns the sum of the rate limits of the VXCs and IXs
+// attached to any member, each counted once. Decommissioned and cancelled
+// services are ignored.
+func (l *LAG) CommittedMbps() int {
+	var vxcs []*VXC
+	var ixs []*IX
+	for _, p := range l.Members {
+		vxcs = append(vxcs, p.AssociatedVXCs...)
+		ixs = append(ixs, p.AssociatedIXs...)
+	}
+	return committedMbps(vxcs, ixs)
+}
+
+// committedMbps sums the rate limits of the active VXCs and IXs, counting
+// each UID once.
+func committedMbps(vxcs []*VXC, ixs []*IX) int {
+	seen := make(map[string]bool)
+	total := 0
+	for _, v := range vxcs {
+		if v == nil || seen[v.UID] || v.ProvisioningStatus == STATUS_DECOMMISSIONED || v.ProvisioningStatus == STATUS_CANCELLED {
+			continue
+		}
+		seen[v.UID] = true
+		total += v.RateLimit
+	}
+	for _, ix := range ixs {
+		if ix == nil || seen[ix.ProductUID] || ix.ProvisioningStatus == STATUS_DECOMMISSIONED || ix.ProvisioningStatus == STATUS_CANCELLED {
+			continue
+		}
+		seen[ix.ProductUID] = true
+		total += ix.RateLimit
+	}
+	return total
+}
+
+// Oversubscription returns committed bandwidth as a ratio of capacity, or
+// zero for a LAG without capacity.
+func (l *LAG) Oversubscription() float64 {
+	capacity := l.CapacityMbps()
+	if capacity == 0 {
+		return 0
+	}
+	return float64(l.CommittedMbps()) / float64(capacity)
+}
+
+// Oversubscribed reports whether the attached services commit more
+// bandwidth than the members provide.
+func (l *LAG) Oversubscribed() bool {
+	return l.CommittedMbps() > l.CapacityMbps()
+}
+
+// Validate checks that the LAG has exactly one primary port and that all
+// members share its location and speed. All problems are returned joined.
+func (l *LAG) Validate() error {
+	var errs []error
+	primaries := 0
+	for _, p := range l.Members {
+		if p.LAGPrimary {
+			primaries++
+		}
+	}
+	if primaries != 1 {
+		errs = append(errs, fmt.Errorf("%w: LAG %d has %d primary ports", ErrLAGInvalid, l.ID, primaries))
+	}
+	if len(l.Members) == 0 {
+		return errors.Join(errs...)
+	}
+	ref := l.Members[0]
+	for _, p := range l.Members[1:] {
+		if p.LocationID != ref.LocationID {
+			errs = append(errs, fmt.Errorf("%w: LAG %d member %s is at location %d, not %d", ErrLAGInvalid, l.ID, p.UID, p.LocationID, ref.LocationID))
+		}
+		if p.PortSpeed != ref.PortSpeed {
+			errs = append(errs, fmt.Errorf("%w: LAG %d member %s is %d Mbps, not %d Mbps", ErrLAGInvalid, l.ID, p.UID, p.PortSpeed, ref.PortSpeed))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListLAGs lists the LAGs among the account's ports.
+func (svc *PortServiceOp) ListLAGs(ctx context.Context) ([]*LAG, error) {
+	ports, err := svc.ListPorts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return GroupLAGs(ports), nil
+}
+
+// GetLAG returns the LAG that portUID is a member of. It returns
+// ErrLAGNotFound when the port is not in a LAG.
+func (svc *PortServiceOp) GetLAG(ctx context.Context, portUID string) (*LAG, error) {
+	lags, err := svc.ListLAGs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lags {
+		for _, p := range l.Members {
+			if p.UID == portUID {
+				return l, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrLAGNotFound, portUID)
+}
@@ -0,0 +1,115 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestGroupLAGs(t *testing.T) {
+	t.Parallel()
+	ports := []*Port{
+		{UID: "single", PortSpeed: 10000, LocationID: 1},
+		{UID: "lag7-b", AggregationID: 7, PortSpeed: 10000, LocationID: 1},
+		{UID: "lag7-a", AggregationID: 7, LAGPrimary: true, PortSpeed: 10000, LocationID: 1,
+			AssociatedVXCs: []*VXC{{UID: "vxc-1", RateLimit: 15000}, {UID: "vxc-gone", RateLimit: 5000, ProvisioningStatus: STATUS_DECOMMISSIONED}},
+			AssociatedIXs:  []*IX{{ProductUID: "ix-1", RateLimit: 1000}}},
+		{UID: "lag7-c", AggregationID: 7, PortSpeed: 10000, LocationID: 1, AssociatedVXCs: []*VXC{{UID: "vxc-1", RateLimit: 15000}}},
+		{UID: "lag3-a", LAGID: 3, LAGPrimary: true, PortSpeed: 10000, LocationID: 1, AssociatedVXCs: []*VXC{{UID: "vxc-2", RateLimit: 25000}}},
+		{UID: "lag3-b", LAGID: 3, PortSpeed: 1000, LocationID: 2},
+		{UID: "lag3-old", LAGID: 3, PortSpeed: 1000, LocationID: 2, ProvisioningStatus: STATUS_CANCELLED},
+	}
+	lags := GroupLAGs(ports)
+	if len(lags) != 2 || lags[0].ID != 3 || lags[1].ID != 7 {
+		t.Fatalf("lags = %+v", lags)
+	}
+
+	lag := lags[1]
+	if lag.Primary == nil || lag.Primary.UID != "lag7-a" || fmt.Sprint(lag.MemberUIDs()) != "[lag7-a lag7-b lag7-c]" {
+		t.Fatalf("LAG 7 primary, members = %v, %v", lag.Primary, lag.MemberUIDs())
+	}
+	if err := lag.Validate(); err != nil {
+		t.Fatalf("LAG 7 Validate = %v", err)
+	}
+	if lag.CapacityMbps() != 30000 || lag.CommittedMbps() != 16000 || lag.Oversubscribed() {
+		t.Fatalf("LAG 7 capacity, committed = %d, %d", lag.CapacityMbps(), lag.CommittedMbps())
+	}
+
+	lag = lags[0]
+	err := lag.Validate()
+	if !errors.Is(err, ErrLAGInvalid) {
+		t.Fatalf("LAG 3 Validate = %v, want %v", err, ErrLAGInvalid)
+	}
+	for _, want := range []string{"lag3-b is at location 2, not 1", "lag3-b is 1000 Mbps, not 10000 Mbps"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("LAG 3 Validate = %v, missing %q", err, want)
+		}
+	}
+	if !lag.Oversubscribed() || lag.Oversubscription() != 25000.0/11000 {
+		t.Fatalf("LAG 3 oversubscription = %v", lag.Oversubscription())
+	}
+
+	noPrimary := GroupLAGs([]*Port{{UID: "x", LAGID: 9}, {UID: "y", LAGID: 9}})[0]
+	if noPrimary.Primary != nil || !errors.Is(noPrimary.Validate(), ErrLAGInvalid) {
+		t.Fatalf("no primary LAG = %+v, %v", noPrimary, noPrimary.Validate())
+	}
+}
+
+// LAGTestSuite tests ListLAGs and GetLAG against the products list.
+type LAGTestSuite struct {
+	ClientTestSuite
+}
+
+func TestLAGTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(LAGTestSuite))
+}
+
+func (suite *LAGTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+
+	suite.mux.HandleFunc("/v2/products", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprint(w, `{"message": "Products", "data": [
+            {"productUid": "lag-a", "productType": "MEGAPORT", "provisioningStatus": "LIVE", "portSpeed": 10000, "locationId": 1, "aggregationId": 5, "lagPrimary": true,
+                "associatedVxcs": [{"productUid": "vxc-1", "rateLimit": 8000, "provisioningStatus": "LIVE"}]},
+            {"productUid": "lag-b", "productType": "MEGAPORT", "provisioningStatus": "LIVE", "portSpeed": 10000, "locationId": 1, "aggregationId": 5},
+            {"productUid": "single", "productType": "MEGAPORT", "provisioningStatus": "LIVE", "portSpeed": 1000, "locationId": 1},
+            {"productUid": "mcr-1", "productType": "MCR2", "provisioningStatus": "LIVE", "portSpeed": 1000, "locationId": 1}
+        ]}`)
+	})
+}
+
+func (suite *LAGTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *LAGTestSuite) TestListLAGs() {
+	ctx := context.Background()
+	lags, err := suite.client.PortService.ListLAGs(ctx)
+	suite.Require().NoError(err)
+	suite.Require().Len(lags, 1)
+	suite.Equal(5, lags[0].ID)
+	suite.Equal([]string{"lag-a", "lag-b"}, lags[0].MemberUIDs())
+	suite.Equal(20000, lags[0].CapacityMbps())
+	suite.Equal(8000, lags[0].CommittedMbps())
+
+	lag, err := suite.client.PortService.GetLAG(ctx, "lag-b")
+	suite.Require().NoError(err)
+	suite.Equal("lag-a", lag.Primary.UID)
+
+	_, err = suite.client.PortService.GetLAG(ctx, "single")
+	suite.ErrorIs(err, ErrLAGNotFound)
+}
@@ -33,6 +33,33 @@ type ProductService interface {
 	UpdateProductResourceTags(ctx context.Context, productUID string, tagsReq *UpdateProductResourceTagsRequest) error
 	// GetProductType returns the type of the product based on the Product UID. If no product is found, it returns an error.
 	GetProductType(ctx context.Context, productUID string) (string, error)
+	// ValidateDiverseDeployment checks a set of redundant ports, MCRs or MVEs
+	// and their VXCs for single points of failure.
+	ValidateDiverseDeployment(ctx context.Context, productUIDs []string) (*DiversityReport, error)
+	// EstimateOrderCost prices a set of buy requests concurrently and returns
+	// a monthly and one-off breakdown per item and per currency.
+	EstimateOrderCost(ctx context.Context, req *OrderCostEstimateRequest) (*OrderCostEstimate, error)
+	// ContractRenewalReport lists contracts ending within a window, prices
+	// them on alternate terms and prepares the term changes.
+	ContractRenewalReport(ctx context.Context, req *ContractRenewalRequest) (*ContractRenewalReport, error)
+	// CostAllocationReport rolls up monthly spend by cost centre and
+	// resource tags and flags untagged or mis-tagged services.
+	CostAllocationReport(ctx context.Context, req *CostAllocationRequest) (*CostAllocationReport, error)
+	// AuditResourceTags checks the resource tags of every product against a
+	// tag policy.
+	AuditResourceTags(ctx context.Context, policy *TagPolicy, opts *TagAuditOptions) (*TagAuditReport, error)
+	// PlanResourceTagChanges computes a bulk tag merge or removal without
+	// writing anything.
+	PlanResourceTagChanges(ctx context.Context, productUIDs []string, edit *ResourceTagEdit) (*ResourceTagPlan, error)
+	// ApplyResourceTagPlan writes a tag plan, skipping products whose tags
+	// changed on the edited keys since planning.
+	ApplyResourceTagPlan(ctx context.Context, plan *ResourceTagPlan, opts *ResourceTagApplyOptions) ([]*ResourceTagApplyResult, error)
+	// GetVLANMap builds the VLAN usage of a port, MCR or MVE vNIC from its
+	// associated VXCs and IXs.
+	GetVLANMap(ctx context.Context, productUID string, vnicIndex int) (*VLANMap, error)
+	// AnalyzeCapacity computes the committed bandwidth of every active port,
+	// LAG, MCR and MVE from their associated VXCs and IXs.
+	AnalyzeCapacity(ctx context.Context) (*CapacityReport, error)
 	// GetProductPricing fetches pricing for a product configuration.
 	GetProductPricing(ctx context.Context, req PriceBookRequest) (*PriceBookDTO, error)
 	// GetProductPricingForCompany fetches pricing scoped to a specific company.
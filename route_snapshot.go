@@ -0,0 +1,389 @@
+package megaport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RouteTableKind identifies which Looking Glass table a RouteSnapshot holds.
+type RouteTableKind string
+
+const (
+	RouteTableKindIP          RouteTableKind = "IP"
+	RouteTableKindBGP         RouteTableKind = "BGP"
+	RouteTableKindBGPNeighbor RouteTableKind = "BGP_NEIGHBOR"
+)
+
+// RouteChangeField names a route attribute compared by DiffRouteSnapshots.
+type RouteChangeField string
+
+const (
+	RouteChangeFieldNextHop   RouteChangeField = "NEXT_HOP"
+	RouteChangeFieldASPath    RouteChangeField = "AS_PATH"
+	RouteChangeFieldLocalPref RouteChangeField = "LOCAL_PREF"
+	RouteChangeFieldMED       RouteChangeField = "MED"
+)
+
+// ErrRouteSnapshotMismatch is returned when diffing snapshots of different
+// products, route tables or BGP neighbors.
+var ErrRouteSnapshotMismatch = errors.New("route snapshots must be of the same product, route table and neighbor")
+
+// RouteEntry is a route normalised from any of the MCR or NAT Gateway Looking
+// Glass route types so that tables can be stored and compared uniformly.
+type RouteEntry struct {
+	Prefix string `json:"prefix"`
+	// Peer is the BGP neighbor the route was learned from or advertised to.
+	// It is empty for IP routes.
+	Peer      string `json:"peer,omitempty"`
+	NextHop   string `json:"nextHop,omitempty"`
+	Protocol  string `json:"protocol,omitempty"`
+	ASPath    []int  `json:"asPath,omitempty"`
+	LocalPref *int   `json:"localPref,omitempty"`
+	MED       *int   `json:"med,omitempty"`
+	Best      bool   `json:"best,omitempty"`
+}
+
+// RouteSnapshot is a point-in-time copy of a Looking Glass route table.
+// Snapshots serialise to JSON so that a pre-change table can be stored and
+// compared against a post-change table later.
+type RouteSnapshot struct {
+	ProductUID string         `json:"productUid"`
+	Kind       RouteTableKind `json:"kind"`
+	// Peer is the neighbor a BGP neighbor table was taken for.
+	Peer    string        `json:"peer,omitempty"`
+	TakenAt time.Time     `json:"takenAt"`
+	Routes  []*RouteEntry `json:"routes"`
+}
+
+// RouteChange describes a route present in both snapshots whose attributes differ.
+type RouteChange struct {
+	Prefix string
+	Peer   string
+	Before *RouteEntry
+	After  *RouteEntry
+	Fields []RouteChangeField
+}
+
+// RouteDiff is the result of DiffRouteSnapshots. Each slice is ordered by
+// peer, then prefix.
+type RouteDiff struct {
+	Added   []*RouteEntry
+	Removed []*RouteEntry
+	Changed []*RouteChange
+}
+
+// NewIPRouteSnapshot snapshots an MCR IP routing table as returned by ListIPRoutes.
+func NewIPRouteSnapshot(mcrUID string, routes []*LookingGlassIPRoute) *RouteSnapshot {
+	entries := make([]*RouteEntry, 0, len(routes))
+	for _, r := range routes {
+		if r == nil {
+			continue
+		}
+		entries = append(entries, &RouteEntry{
+			Prefix:    r.Prefix,
+			NextHop:   r.NextHop,
+			Protocol:  string(r.Protocol),
+			ASPath:    r.ASPath,
+			LocalPref: r.LocalPref,
+			MED:       r.MED,
+			Best:      r.Best != nil && *r.Best,
+		})
+	}
+	return newRouteSnapshot(mcrUID, RouteTableKindIP, "", entries)
+}
+
+// NewBGPRouteSnapshot snapshots an MCR BGP table as returned by ListBGPRoutes.
+// Routes are keyed by neighbor, so the same prefix from two peers is tracked
+// separately.
+func NewBGPRouteSnapshot(mcrUID string, routes []*LookingGlassBGPRoute) *RouteSnapshot {
+	entries := make([]*RouteEntry, 0, len(routes))
+	for _, r := range routes {
+		if r == nil {
+			continue
+		}
+		entries = append(entries, &RouteEntry{
+			Prefix:    r.Prefix,
+			Peer:      r.NeighborIP,
+			NextHop:   r.NextHop,
+			Protocol:  string(RouteProtocolBGP),
+			ASPath:    r.ASPath,
+			LocalPref: r.LocalPref,
+			MED:       r.MED,
+			Best:      r.Best,
+		})
+	}
+	return newRouteSnapshot(mcrUID, RouteTableKindBGP, "", entries)
+}
+
+// NewBGPNeighborRouteSnapshot snapshots the routes exchanged with a single MCR
+// BGP neighbor as returned by ListBGPNeighborRoutes. peer identifies the
+// neighbor in summaries, e.g. its address or session ID.
+func NewBGPNeighborRouteSnapshot(mcrUID, peer string, routes []*LookingGlassBGPNeighborRoute) *RouteSnapshot {
+	entries := make([]*RouteEntry, 0, len(routes))
+	for _, r := range routes {
+		if r == nil {
+			continue
+		}
+		entries = append(entries, &RouteEntry{
+			Prefix:    r.Prefix,
+			Peer:      peer,
+			NextHop:   r.NextHop,
+			Protocol:  string(RouteProtocolBGP),
+			ASPath:    r.ASPath,
+			LocalPref: r.LocalPref,
+			MED:       r.MED,
+			Best:      r.Best,
+		})
+	}
+	return newRouteSnapshot(mcrUID, RouteTableKindBGPNeighbor, peer, entries)
+}
+
+// NewNATGatewayIPRouteSnapshot snapshots a NAT Gateway IP routing table as
+// returned by ListNATGatewayIPRoutes.
+func NewNATGatewayIPRouteSnapshot(productUID string, routes []*NATGatewayIPRoute) *RouteSnapshot {
+	entries := make([]*RouteEntry, 0, len(routes))
+	for _, r := range routes {
+		if r == nil {
+			continue
+		}
+		entries = append(entries, &RouteEntry{
+			Prefix:   r.Prefix,
+			NextHop:  r.NextHop.IP,
+			Protocol: r.Protocol,
+		})
+	}
+	return newRouteSnapshot(productUID, RouteTableKindIP, "", entries)
+}
+
+// NewNATGatewayBGPRouteSnapshot snapshots NAT Gateway BGP routes as returned
+// by ListNATGatewayBGPRoutes, or, when peer is set, the routes exchanged with
+// that neighbor as returned by ListNATGatewayBGPNeighborRoutes. Unset local
+// preference and MED are recorded as absent rather than zero.
+func NewNATGatewayBGPRouteSnapshot(productUID, peer string, routes []*NATGatewayBGPRoute) *RouteSnapshot {
+	kind := RouteTableKindBGP
+	if peer != "" {
+		kind = RouteTableKindBGPNeighbor
+	}
+	entries := make([]*RouteEntry, 0, len(routes))
+	for _, r := range routes {
+		if r == nil {
+			continue
+		}
+		e := &RouteEntry{
+			Prefix:   r.Prefix,
+			Peer:     peer,
+			NextHop:  r.NextHop.IP,
+			Protocol: string(RouteProtocolBGP),
+			ASPath:   parseASPath(r.ASPath),
+			Best:     r.Best,
+		}
+		if e.Peer == "" {
+			e.Peer = r.Source
+		}
+		if r.LocalPref != 0 {
+			e.LocalPref = PtrTo(r.LocalPref)
+		}
+		if r.MED != 0 {
+			e.MED = PtrTo(r.MED)
+		}
+		entries = append(entries, e)
+	}
+	return newRouteSnapshot(productUID, kind, peer, entries)
+}
+
+// ReadRouteSnapshot decodes a snapshot previously written with WriteJSON.
+func ReadRouteSnapshot(r io.Reader) (*RouteSnapshot, error) {
+	s := &RouteSnapshot{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// WriteJSON encodes the snapshot as indented JSON.
+func (s *RouteSnapshot) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// DiffRouteSnapshots compares two snapshots of the same table, and of the
+// same neighbor for BGP neighbor tables. Routes are matched by peer and
+// prefix; where a table holds several paths for the same peer and prefix,
+// the best path (or else the first) is compared.
+func DiffRouteSnapshots(before, after *RouteSnapshot) (*RouteDiff, error) {
+	if before == nil || after == nil || before.ProductUID != after.ProductUID || before.Kind != after.Kind || before.Peer != after.Peer {
+		return nil, ErrRouteSnapshotMismatch
+	}
+	prev, next := before.index(), after.index()
+	diff := &RouteDiff{}
+	for key, a := range next {
+		b, ok := prev[key]
+		if !ok {
+			diff.Added = append(diff.Added, a)
+			continue
+		}
+		if fields := routeChangedFields(b, a); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, &RouteChange{Prefix: a.Prefix, Peer: a.Peer, Before: b, After: a, Fields: fields})
+		}
+	}
+	for key, b := range prev {
+		if _, ok := next[key]; !ok {
+			diff.Removed = append(diff.Removed, b)
+		}
+	}
+	sortRouteEntries(diff.Added)
+	sortRouteEntries(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		if diff.Changed[i].Peer != diff.Changed[j].Peer {
+			return diff.Changed[i].Peer < diff.Changed[j].Peer
+		}
+		return diff.Changed[i].Prefix < diff.Changed[j].Prefix
+	})
+	return diff, nil
+}
+
+// IsEmpty reports whether the diff contains no changes.
+func (d *RouteDiff) IsEmpty() bool {
+	return d == nil || len(d.Added)+len(d.Removed)+len(d.Changed) == 0
+}
+
+// Summary returns one human-readable line per peer and change type, e.g.
+// "14 prefixes withdrawn from peer 10.0.0.1". Lines are ordered by peer,
+// then withdrawn, added, changed.
+func (d *RouteDiff) Summary() []string {
+	if d.IsEmpty() {
+		return nil
+	}
+	type counts struct{ removed, added, changed int }
+	byPeer := map[string]*counts{}
+	get := func(peer string) *counts {
+		c, ok := byPeer[peer]
+		if !ok {
+			c = &counts{}
+			byPeer[peer] = c
+		}
+		return c
+	}
+	for _, r := range d.Removed {
+		get(r.Peer).removed++
+	}
+	for _, r := range d.Added {
+		get(r.Peer).added++
+	}
+	for _, c := range d.Changed {
+		get(c.Peer).changed++
+	}
+	peers := make([]string, 0, len(byPeer))
+	for p := range byPeer {
+		peers = append(peers, p)
+	}
+	sort.Strings(peers)
+
+	var lines []string
+	for _, p := range peers {
+		c := byPeer[p]
+		lines = appendRouteSummary(lines, c.removed, "withdrawn from", p)
+		lines = appendRouteSummary(lines, c.added, "added from", p)
+		lines = appendRouteSummary(lines, c.changed, "changed from", p)
+	}
+	return lines
+}
+
+// appendRouteSummary appends a summary line for n prefixes when n is non-zero.
+func appendRouteSummary(lines []string, n int, verb, peer string) []string {
+	if n == 0 {
+		return lines
+	}
+	noun := "prefixes"
+	if n == 1 {
+		noun = "prefix"
+	}
+	if peer == "" {
+		// IP tables carry no peer, so drop the trailing "from".
+		return append(lines, fmt.Sprintf("%d %s %s", n, noun, strings.TrimSuffix(verb, " from")))
+	}
+	return append(lines, fmt.Sprintf("%d %s %s peer %s", n, noun, verb, peer))
+}
+
+// newRouteSnapshot builds a snapshot with routes in a stable order.
+func newRouteSnapshot(productUID string, kind RouteTableKind, peer string, entries []*RouteEntry) *RouteSnapshot {
+	sortRouteEntries(entries)
+	return &RouteSnapshot{
+		ProductUID: productUID,
+		Kind:       kind,
+		Peer:       peer,
+		TakenAt:    time.Now().UTC(),
+		Routes:     entries,
+	}
+}
+
+// index keys the snapshot's routes by peer and prefix, preferring best paths.
+func (s *RouteSnapshot) index() map[string]*RouteEntry {
+	out := make(map[string]*RouteEntry, len(s.Routes))
+	for _, r := range s.Routes {
+		if r == nil {
+			continue
+		}
+		key := r.Peer + "|" + r.Prefix
+		if existing, ok := out[key]; ok && (existing.Best || !r.Best) {
+			continue
+		}
+		out[key] = r
+	}
+	return out
+}
+
+// routeChangedFields lists the compared attributes that differ between a and b.
+func routeChangedFields(a, b *RouteEntry) []RouteChangeField {
+	var fields []RouteChangeField
+	if a.NextHop != b.NextHop {
+		fields = append(fields, RouteChangeFieldNextHop)
+	}
+	if !slices.Equal(a.ASPath, b.ASPath) {
+		fields = append(fields, RouteChangeFieldASPath)
+	}
+	if !equalIntPtr(a.LocalPref, b.LocalPref) {
+		fields = append(fields, RouteChangeFieldLocalPref)
+	}
+	if !equalIntPtr(a.MED, b.MED) {
+		fields = append(fields, RouteChangeFieldMED)
+	}
+	return fields
+}
+
+func sortRouteEntries(entries []*RouteEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Peer != entries[j].Peer {
+			return entries[i].Peer < entries[j].Peer
+		}
+		return entries[i].Prefix < entries[j].Prefix
+	})
+}
+
+func equalIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// parseASPath converts a space-separated AS path such as "65000 65001" into
+// ASNs. Non-numeric tokens (AS-set braces, "i" origin markers) are skipped.
+func parseASPath(path string) []int {
+	var out []int
+	split := func(r rune) bool { return r == ' ' || r == ',' || r == '{' || r == '}' }
+	for _, tok := range strings.FieldsFunc(path, split) {
+		if asn, err := strconv.Atoi(tok); err == nil {
+			out = append(out, asn)
+		}
+	}
+	return out
+}
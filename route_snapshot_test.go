@@ -0,0 +1,127 @@
+package megaport
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDiffBGPRouteSnapshots(t *testing.T) {
+	t.Parallel()
+	before := NewBGPRouteSnapshot("mcr-1", []*LookingGlassBGPRoute{
+		{Prefix: "10.0.0.0/24", NextHop: "192.0.2.1", NeighborIP: "192.0.2.1", ASPath: []int{65001}, LocalPref: PtrTo(100), Best: true},
+		{Prefix: "10.0.1.0/24", NextHop: "192.0.2.1", NeighborIP: "192.0.2.1", ASPath: []int{65001}},
+		{Prefix: "10.0.2.0/24", NextHop: "192.0.2.1", NeighborIP: "192.0.2.1", ASPath: []int{65001}},
+		{Prefix: "10.0.3.0/24", NextHop: "192.0.2.5", NeighborIP: "192.0.2.5", ASPath: []int{65002}, MED: PtrTo(10)},
+	})
+	after := NewBGPRouteSnapshot("mcr-1", []*LookingGlassBGPRoute{
+		// Non-best duplicate path must not shadow the best path.
+		{Prefix: "10.0.0.0/24", NextHop: "192.0.2.9", NeighborIP: "192.0.2.1", ASPath: []int{65001, 65009}},
+		{Prefix: "10.0.0.0/24", NextHop: "192.0.2.1", NeighborIP: "192.0.2.1", ASPath: []int{65001}, LocalPref: PtrTo(200), Best: true},
+		{Prefix: "10.0.3.0/24", NextHop: "192.0.2.6", NeighborIP: "192.0.2.5", ASPath: []int{65002, 65002}, MED: PtrTo(20)},
+		{Prefix: "10.0.9.0/24", NextHop: "192.0.2.5", NeighborIP: "192.0.2.5", ASPath: []int{65002}},
+	})
+
+	diff, err := DiffRouteSnapshots(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Removed) != 2 || diff.Removed[0].Prefix != "10.0.1.0/24" || diff.Removed[1].Prefix != "10.0.2.0/24" {
+		t.Fatalf("Removed = %+v", diff.Removed)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Prefix != "10.0.9.0/24" {
+		t.Fatalf("Added = %+v", diff.Added)
+	}
+	if len(diff.Changed) != 2 {
+		t.Fatalf("Changed = %+v", diff.Changed)
+	}
+	if got := diff.Changed[0].Fields; len(got) != 1 || got[0] != RouteChangeFieldLocalPref {
+		t.Errorf("10.0.0.0/24 fields = %v", got)
+	}
+	want := []RouteChangeField{RouteChangeFieldNextHop, RouteChangeFieldASPath, RouteChangeFieldMED}
+	if got := diff.Changed[1].Fields; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("10.0.3.0/24 fields = %v, want %v", got, want)
+	}
+
+	wantSummary := []string{
+		"2 prefixes withdrawn from peer 192.0.2.1",
+		"1 prefix changed from peer 192.0.2.1",
+		"1 prefix added from peer 192.0.2.5",
+		"1 prefix changed from peer 192.0.2.5",
+	}
+	summary := diff.Summary()
+	if len(summary) != len(wantSummary) {
+		t.Fatalf("Summary = %q", summary)
+	}
+	for i := range wantSummary {
+		if summary[i] != wantSummary[i] {
+			t.Errorf("Summary[%d] = %q, want %q", i, summary[i], wantSummary[i])
+		}
+	}
+}
+
+func TestRouteSnapshotJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+	snap := NewBGPNeighborRouteSnapshot("mcr-1", "session-1", []*LookingGlassBGPNeighborRoute{
+		{Prefix: "10.0.0.0/24", NextHop: "192.0.2.1", ASPath: []int{65001}, MED: PtrTo(5)},
+	})
+	var buf bytes.Buffer
+	if err := snap.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadRouteSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Kind != RouteTableKindBGPNeighbor || loaded.Peer != "session-1" || !loaded.TakenAt.Equal(snap.TakenAt) {
+		t.Fatalf("loaded = %+v", loaded)
+	}
+	diff, err := DiffRouteSnapshots(snap, loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.IsEmpty() || diff.Summary() != nil {
+		t.Fatalf("round-trip diff = %+v", diff)
+	}
+}
+
+func TestNATGatewayRouteSnapshots(t *testing.T) {
+	t.Parallel()
+	before := NewNATGatewayIPRouteSnapshot("gw-1", []*NATGatewayIPRoute{
+		{Prefix: "0.0.0.0/0", Protocol: "STATIC", NextHop: NATGatewayRouteNextHop{IP: "10.0.0.1"}},
+		{Prefix: "10.1.0.0/16", Protocol: "BGP", NextHop: NATGatewayRouteNextHop{IP: "10.0.0.2"}},
+	})
+	after := NewNATGatewayIPRouteSnapshot("gw-1", []*NATGatewayIPRoute{
+		{Prefix: "0.0.0.0/0", Protocol: "STATIC", NextHop: NATGatewayRouteNextHop{IP: "10.0.0.1"}},
+	})
+	diff, err := DiffRouteSnapshots(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := diff.Summary(); len(s) != 1 || s[0] != "1 prefix withdrawn" {
+		t.Fatalf("Summary = %q", s)
+	}
+
+	bgp := NewNATGatewayBGPRouteSnapshot("gw-1", "10.0.0.2", []*NATGatewayBGPRoute{
+		{Prefix: "10.1.0.0/16", ASPath: "65000 {65001,65002}", LocalPref: 100, NextHop: NATGatewayRouteNextHop{IP: "10.0.0.2"}},
+	})
+	if bgp.Kind != RouteTableKindBGPNeighbor {
+		t.Fatalf("Kind = %s", bgp.Kind)
+	}
+	r := bgp.Routes[0]
+	if !slices.Equal(r.ASPath, []int{65000, 65001, 65002}) || r.LocalPref == nil || *r.LocalPref != 100 || r.MED != nil || r.Peer != "10.0.0.2" {
+		t.Fatalf("route = %+v", r)
+	}
+
+	if _, err := DiffRouteSnapshots(before, bgp); !errors.Is(err, ErrRouteSnapshotMismatch) {
+		t.Fatalf("mismatch err = %v", err)
+	}
+	if _, err := DiffRouteSnapshots(nil, after); !errors.Is(err, ErrRouteSnapshotMismatch) {
+		t.Fatalf("nil err = %v", err)
+	}
+	otherPeer := NewNATGatewayBGPRouteSnapshot("gw-1", "10.0.0.3", nil)
+	if _, err := DiffRouteSnapshots(bgp, otherPeer); !errors.Is(err, ErrRouteSnapshotMismatch) {
+		t.Fatalf("peer mismatch err = %v", err)
+	}
+}
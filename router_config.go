@@ -0,0 +1,357 @@
+package megaport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+	"text/template"
+)
+
+// Router config platforms with built-in templates.
+const (
+	ROUTER_PLATFORM_IOS_XE = "ios-xe"
+	ROUTER_PLATFORM_JUNOS  = "junos"
+	ROUTER_PLATFORM_EOS    = "eos"
+	ROUTER_PLATFORM_FRR    = "frr"
+)
+
+// Router config errors.
+var (
+	// ErrRouterPlatformUnknown is returned for a platform without a built-in template.
+	ErrRouterPlatformUnknown = errors.New("unknown router platform")
+	// ErrRouterConfigUnsupported is returned for a VXC with neither an MCR
+	// interface nor an AWS Virtual Interface to peer with.
+	ErrRouterConfigUnsupported = errors.New("VXC has no MCR interface or AWS Virtual Interface to configure a router for")
+	// ErrRouterConfigInterfaceRequired is returned when rendering a config
+	// without the customer router's interface name.
+	ErrRouterConfigInterfaceRequired = errors.New("router interface name is required")
+)
+
+// MCR BFD timers used when a BGP connection enables BFD without setting them.
+const (
+	mcrDefaultBFDInterval   = 300
+	mcrDefaultBFDMultiplier = 3
+)
+
+// RouterConfig is the customer router side of a VXC or IX: the interface
+// and VLAN subinterface facing Megaport, its addresses, and the BGP
+// neighbors reachable over it. Build one with RouterConfigFromVXC or
+// IXPeeringSummary.RouterConfig and render it with Write or WriteTemplate.
+type RouterConfig struct {
+	ServiceUID  string
+	ServiceName string
+	// Interface is the customer router's physical interface, such as
+	// "GigabitEthernet0/0/1", "xe-0/0/0", "Ethernet1" or "eth0".
+	Interface string
+	// VLAN is the customer end VLAN, or zero or less for untagged.
+	VLAN int
+	// Addresses are the customer addresses in CIDR form.
+	Addresses []string
+	// LocalASN is the customer ASN, taken from the first neighbor.
+	LocalASN  int
+	BFD       *RouterBFD
+	Neighbors []*RouterBGPNeighbor
+}
+
+// RouterBFD holds BFD timers in milliseconds.
+type RouterBFD struct {
+	TxInterval int
+	RxInterval int
+	Multiplier int
+}
+
+// RouterBGPNeighbor is a BGP session from the customer router.
+type RouterBGPNeighbor struct {
+	Description string
+	// Address is the neighbor's address without a prefix length.
+	Address string
+	// Version is 4 or 6.
+	Version   int
+	RemoteASN int
+	// LocalASN is the customer ASN for this session; templates use a
+	// local-as override when it differs from RouterConfig.LocalASN.
+	LocalASN int
+	Password string
+	BFD      bool
+}
+
+// Tagged reports whether the config uses a VLAN subinterface.
+func (c *RouterConfig) Tagged() bool {
+	return c.VLAN > 0
+}
+
+// NeighborsByVersion returns the neighbors of IP version 4 or 6.
+func (c *RouterConfig) NeighborsByVersion(version int) []*RouterBGPNeighbor {
+	var out []*RouterBGPNeighbor
+	for _, n := range c.Neighbors {
+		if n.Version == version {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// AddressesByVersion returns the addresses of IP version 4 or 6.
+func (c *RouterConfig) AddressesByVersion(version int) []string {
+	var out []string
+	for _, a := range c.Addresses {
+		if ixAddressVersion(a, 0) == version {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Versions returns the IP versions of the neighbors, 4 before 6.
+func (c *RouterConfig) Versions() []int {
+	var out []int
+	for _, v := range []int{4, 6} {
+		if len(c.NeighborsByVersion(v)) > 0 {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func (c *RouterConfig) addNeighbor(n *RouterBGPNeighbor) {
+	if len(c.Neighbors) == 0 {
+		c.LocalASN = n.LocalASN
+	}
+	c.Neighbors = append(c.Neighbors, n)
+}
+
+func (c *RouterConfig) addAddress(cidr string) {
+	for _, a := range c.Addresses {
+		if a == cidr {
+			return
+		}
+	}
+	c.Addresses = append(c.Addresses, cidr)
+}
+
+// RouterConfigFromVXC builds the router config for the customer end of a
+// live VXC. For an MCR VXC, the customer router peers with each BGP
+// connection of the MCR interfaces over the VLAN of the end that is not the
+// MCR.
+// For an AWS Virtual Interface from a port, the customer router peers with
+// Amazon over the A-End VLAN. An MCR-to-cloud VXC has no customer router
+// and returns ErrRouterConfigUnsupported.
+func RouterConfigFromVXC(vxc *VXC, iface string) (*RouterConfig, error) {
+	if vxc == nil {
+		return nil, ErrRouterConfigUnsupported
+	}
+	vr := vxc.VirtualRouterConnection()
+	switch {
+	case vr != nil && len(vxc.cspConnections()) > 1:
+		return nil, fmt.Errorf("%w: %s connects an MCR to a cloud", ErrRouterConfigUnsupported, vxc.UID)
+	case vr != nil && mcrBGPConfigured(vr):
+		return routerConfigFromMCR(vxc, vr, iface), nil
+	case vxc.AWSConnection() != nil:
+		return routerConfigFromAWS(vxc, vxc.AWSConnection(), iface)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrRouterConfigUnsupported, vxc.UID)
+}
+
+func routerConfigFromMCR(vxc *VXC, vr *CSPConnectionVirtualRouter, iface string) *RouterConfig {
+	c := &RouterConfig{
+		ServiceUID:  vxc.UID,
+		ServiceName: vxc.Name,
+		Interface:   iface,
+		VLAN:        mcrCustomerEnd(vxc, vr).VLAN,
+	}
+	mcrASN := 0
+	if vxc.Resources.VirtualRouter != nil {
+		mcrASN = vxc.Resources.VirtualRouter.MCRAsn
+	}
+	for _, in := range vr.Interfaces {
+		for _, bgp := range in.BGPConnections {
+			peer, err := netip.ParseAddr(bgp.PeerIpAddress)
+			if err != nil {
+				continue
+			}
+			// The MCR's peer is the customer router; give it the prefix
+			// length of the MCR interface subnet it sits in.
+			for _, a := range in.IPAddresses {
+				if p, err := netip.ParsePrefix(a); err == nil && p.Contains(peer) {
+					c.addAddress(netip.PrefixFrom(peer, p.Bits()).String())
+				}
+			}
+			remoteASN := mcrASN
+			if bgp.LocalAsn != nil {
+				remoteASN = *bgp.LocalAsn
+			}
+			description := bgp.Description
+			if description == "" {
+				description = vxc.Name
+			}
+			c.addNeighbor(&RouterBGPNeighbor{
+				Description: description,
+				Address:     bgp.LocalIpAddress,
+				Version:     ixAddressVersion(bgp.LocalIpAddress, 4),
+				RemoteASN:   remoteASN,
+				LocalASN:    bgp.PeerAsn,
+				Password:    bgp.Password,
+				BFD:         bgp.BfdEnabled,
+			})
+			if bgp.BfdEnabled && c.BFD == nil {
+				c.BFD = mcrBFD(in.BFD)
+			}
+		}
+	}
+	return c
+}
+
+// mcrCustomerEnd returns the end of an MCR VXC facing the customer router.
+// The API marks the MCR end with the resource type of the virtual router
+// connection; without one, a connection VLAN matching only the B-End puts
+// the MCR there. Otherwise the MCR is taken to be the A-End.
+func mcrCustomerEnd(vxc *VXC, vr *CSPConnectionVirtualRouter) VXCEndConfiguration {
+	a, b := vxc.AEndConfiguration, vxc.BEndConfiguration
+	switch vr.ResourceType {
+	case "a_csp_connection":
+		return b
+	case "b_csp_connection":
+		return a
+	}
+	if vr.VLAN != 0 && vr.VLAN == b.VLAN && vr.VLAN != a.VLAN {
+		return a
+	}
+	return b
+}
+
+func mcrBFD(b BfdConfig) *RouterBFD {
+	r := &RouterBFD{TxInterval: b.TxInterval, RxInterval: b.RxInterval, Multiplier: b.Multiplier}
+	if r.TxInterval == 0 {
+		r.TxInterval = mcrDefaultBFDInterval
+	}
+	if r.RxInterval == 0 {
+		r.RxInterval = mcrDefaultBFDInterval
+	}
+	if r.Multiplier == 0 {
+		r.Multiplier = mcrDefaultBFDMultiplier
+	}
+	return r
+}
+
+func routerConfigFromAWS(vxc *VXC, aws *CSPConnectionAWS, iface string) (*RouterConfig, error) {
+	customer := aws.CustomerIPAddress
+	if customer == "" {
+		customer = aws.CustomerAddress
+	}
+	amazon, _, _ := strings.Cut(aws.AmazonAddress, "/")
+	if _, err := netip.ParseAddr(amazon); err != nil || customer == "" {
+		return nil, fmt.Errorf("%w: %s has no peering addresses yet", ErrRouterConfigUnsupported, vxc.UID)
+	}
+	c := &RouterConfig{
+		ServiceUID:  vxc.UID,
+		ServiceName: vxc.Name,
+		Interface:   iface,
+		VLAN:        vxc.AEndConfiguration.VLAN,
+		Addresses:   []string{customer},
+	}
+	asn := aws.ASN
+	if asn == 0 {
+		asn = aws.PeerASN
+	}
+	c.addNeighbor(&RouterBGPNeighbor{
+		Description: vxc.Name,
+		Address:     amazon,
+		Version:     ixAddressVersion(amazon, 4),
+		RemoteASN:   aws.AmazonASN,
+		LocalASN:    asn,
+		Password:    aws.AuthKey,
+	})
+	return c, nil
+}
+
+// RouterConfig builds the router config for peering with the IX route
+// servers over iface.
+func (s *IXPeeringSummary) RouterConfig(iface string) *RouterConfig {
+	c := &RouterConfig{
+		ServiceUID:  s.ProductUID,
+		ServiceName: s.ProductName,
+		Interface:   iface,
+		VLAN:        s.VLAN,
+		LocalASN:    s.ASN,
+	}
+	for _, a := range []string{s.IPv4Address, s.IPv6Address} {
+		if a != "" {
+			c.addAddress(a)
+		}
+	}
+	for _, rs := range s.RouteServers {
+		c.addNeighbor(&RouterBGPNeighbor{
+			Description: fmt.Sprintf("%s %s", s.NetworkServiceType, rs.Name),
+			Address:     rs.PeerAddress,
+			Version:     rs.Version,
+			RemoteASN:   rs.PeerASN,
+			LocalASN:    rs.LocalASN,
+		})
+	}
+	return c
+}
+
+// Write renders the config with the built-in template for platform.
+func (c *RouterConfig) Write(w io.Writer, platform string) error {
+	tmpl, err := RouterConfigTemplate(platform)
+	if err != nil {
+		return err
+	}
+	return c.WriteTemplate(w, tmpl)
+}
+
+// WriteTemplate renders the config with a user-supplied template, which is
+// executed with the *RouterConfig as its data. Parse it with the functions
+// of RouterConfigFuncs to use them.
+func (c *RouterConfig) WriteTemplate(w io.Writer, tmpl *template.Template) error {
+	if c.Interface == "" {
+		return ErrRouterConfigInterfaceRequired
+	}
+	return tmpl.Execute(w, c)
+}
+
+// RouterConfigTemplate returns the built-in template for platform.
+func RouterConfigTemplate(platform string) (*template.Template, error) {
+	text, ok := routerConfigTemplates[strings.ToLower(platform)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrRouterPlatformUnknown, platform)
+	}
+	return template.New(platform).Funcs(RouterConfigFuncs()).Parse(text)
+}
+
+// RouterConfigFuncs returns the template functions available to the
+// built-in templates:
+//
+//	addr "10.0.0.2/30"      10.0.0.2
+//	prefixlen "10.0.0.2/30" 30
+//	netmask "10.0.0.2/30"   255.255.255.252
+//	is6 "2001:db8::1/64"    true
+func RouterConfigFuncs() template.FuncMap {
+	return template.FuncMap{
+		"addr": func(cidr string) string {
+			if p, err := netip.ParsePrefix(cidr); err == nil {
+				return p.Addr().String()
+			}
+			return cidr
+		},
+		"prefixlen": func(cidr string) int {
+			if p, err := netip.ParsePrefix(cidr); err == nil {
+				return p.Bits()
+			}
+			return -1
+		},
+		"netmask": func(cidr string) string {
+			p, err := netip.ParsePrefix(cidr)
+			if err != nil || !p.Addr().Is4() {
+				return ""
+			}
+			mask := ^uint32(0) << (32 - p.Bits())
+			return netip.AddrFrom4([4]byte{byte(mask >> 24), byte(mask >> 16), byte(mask >> 8), byte(mask)}).String()
+		},
+		"is6": func(address string) bool {
+			return ixAddressVersion(address, 4) == 6
+		},
+	}
+}
@@ -0,0 +1,202 @@
+package megaport
+
+// routerConfigTemplates are the built-in templates by platform, executed
+// with a *RouterConfig and the functions of RouterConfigFuncs.
+var routerConfigTemplates = map[string]string{
+	ROUTER_PLATFORM_IOS_XE: iosXERouterConfigTemplate,
+	ROUTER_PLATFORM_JUNOS:  junosRouterConfigTemplate,
+	ROUTER_PLATFORM_EOS:    eosRouterConfigTemplate,
+	ROUTER_PLATFORM_FRR:    frrRouterConfigTemplate,
+}
+
+const iosXERouterConfigTemplate = `interface {{.Interface}}{{if .Tagged}}.{{.VLAN}}{{end}}
+ description {{.ServiceName}} ({{.ServiceUID}})
+{{- if .Tagged}}
+ encapsulation dot1Q {{.VLAN}}
+{{- end}}
+{{- range $i, $a := .AddressesByVersion 4}}
+ ip address {{addr $a}} {{netmask $a}}{{if $i}} secondary{{end}}
+{{- end}}
+{{- range .AddressesByVersion 6}}
+ ipv6 address {{.}}
+{{- end}}
+{{- with .BFD}}
+ bfd interval {{.TxInterval}} min_rx {{.RxInterval}} multiplier {{.Multiplier}}
+{{- end}}
+ no shutdown
+!
+router bgp {{.LocalASN}}
+ no bgp default ipv4-unicast
+{{- range .Neighbors}}
+ neighbor {{.Address}} remote-as {{.RemoteASN}}
+ neighbor {{.Address}} description {{.Description}}
+{{- if ne .LocalASN $.LocalASN}}
+ neighbor {{.Address}} local-as {{.LocalASN}} no-prepend replace-as
+{{- end}}
+{{- if .Password}}
+ neighbor {{.Address}} password {{.Password}}
+{{- end}}
+{{- if .BFD}}
+ neighbor {{.Address}} fall-over bfd
+{{- end}}
+{{- end}}
+{{- range $v := .Versions}}
+ !
+ address-family ipv{{$v}} unicast
+{{- range $.NeighborsByVersion $v}}
+  neighbor {{.Address}} activate
+{{- end}}
+ exit-address-family
+{{- end}}
+!
+`
+
+const junosRouterConfigTemplate = `interfaces {
+    {{.Interface}} {
+{{- if .Tagged}}
+        vlan-tagging;
+{{- end}}
+        unit {{if .Tagged}}{{.VLAN}}{{else}}0{{end}} {
+            description {{printf "%s (%s)" .ServiceName .ServiceUID | printf "%q"}};
+{{- if .Tagged}}
+            vlan-id {{.VLAN}};
+{{- end}}
+{{- with .AddressesByVersion 4}}
+            family inet {
+{{- range .}}
+                address {{.}};
+{{- end}}
+            }
+{{- end}}
+{{- with .AddressesByVersion 6}}
+            family inet6 {
+{{- range .}}
+                address {{.}};
+{{- end}}
+            }
+{{- end}}
+        }
+    }
+}
+routing-options {
+    autonomous-system {{.LocalASN}};
+}
+protocols {
+    bgp {
+{{- range $v := .Versions}}
+        group megaport-ipv{{$v}} {
+            type external;
+            family {{if eq $v 6}}inet6{{else}}inet{{end}} {
+                unicast;
+            }
+{{- range $.NeighborsByVersion $v}}
+            neighbor {{.Address}} {
+                description {{printf "%q" .Description}};
+                peer-as {{.RemoteASN}};
+{{- if ne .LocalASN $.LocalASN}}
+                local-as {{.LocalASN}};
+{{- end}}
+{{- if .Password}}
+                authentication-key {{printf "%q" .Password}};
+{{- end}}
+{{- if and .BFD $.BFD}}
+                bfd-liveness-detection {
+                    minimum-interval {{$.BFD.TxInterval}};
+                    multiplier {{$.BFD.Multiplier}};
+                }
+{{- end}}
+            }
+{{- end}}
+        }
+{{- end}}
+    }
+}
+`
+
+const eosRouterConfigTemplate = `interface {{.Interface}}
+   no switchport
+{{- if .Tagged}}
+!
+interface {{.Interface}}.{{.VLAN}}
+   encapsulation dot1q vlan {{.VLAN}}
+{{- end}}
+   description {{.ServiceName}} ({{.ServiceUID}})
+{{- range $i, $a := .AddressesByVersion 4}}
+   ip address {{$a}}{{if $i}} secondary{{end}}
+{{- end}}
+{{- range .AddressesByVersion 6}}
+   ipv6 address {{.}}
+{{- end}}
+{{- with .BFD}}
+   bfd interval {{.TxInterval}} min-rx {{.RxInterval}} multiplier {{.Multiplier}}
+{{- end}}
+!
+router bgp {{.LocalASN}}
+{{- range .Neighbors}}
+   neighbor {{.Address}} remote-as {{.RemoteASN}}
+   neighbor {{.Address}} description {{.Description}}
+{{- if ne .LocalASN $.LocalASN}}
+   neighbor {{.Address}} local-as {{.LocalASN}} no-prepend replace-as
+{{- end}}
+{{- if .Password}}
+   neighbor {{.Address}} password 0 {{.Password}}
+{{- end}}
+{{- if .BFD}}
+   neighbor {{.Address}} bfd
+{{- end}}
+{{- end}}
+{{- range $v := .Versions}}
+   !
+   address-family ipv{{$v}}
+{{- range $.NeighborsByVersion $v}}
+      neighbor {{.Address}} activate
+{{- end}}
+{{- end}}
+!
+`
+
+const frrRouterConfigTemplate = `{{- if .Tagged -}}
+! ip link add link {{.Interface}} name {{.Interface}}.{{.VLAN}} type vlan id {{.VLAN}}
+{{end -}}
+interface {{.Interface}}{{if .Tagged}}.{{.VLAN}}{{end}}
+ description {{.ServiceName}} ({{.ServiceUID}})
+{{- range .Addresses}}
+ {{if is6 .}}ipv6{{else}}ip{{end}} address {{.}}
+{{- end}}
+!
+{{- with .BFD}}
+bfd
+{{- range $.Neighbors}}{{if .BFD}}
+ peer {{.Address}}
+  receive-interval {{$.BFD.RxInterval}}
+  transmit-interval {{$.BFD.TxInterval}}
+  detect-multiplier {{$.BFD.Multiplier}}
+ exit
+{{- end}}{{end}}
+!
+{{- end}}
+router bgp {{.LocalASN}}
+ no bgp default ipv4-unicast
+{{- range .Neighbors}}
+ neighbor {{.Address}} remote-as {{.RemoteASN}}
+ neighbor {{.Address}} description {{.Description}}
+{{- if ne .LocalASN $.LocalASN}}
+ neighbor {{.Address}} local-as {{.LocalASN}} no-prepend replace-as
+{{- end}}
+{{- if .Password}}
+ neighbor {{.Address}} password {{.Password}}
+{{- end}}
+{{- if .BFD}}
+ neighbor {{.Address}} bfd
+{{- end}}
+{{- end}}
+{{- range $v := .Versions}}
+ !
+ address-family ipv{{$v}} unicast
+{{- range $.NeighborsByVersion $v}}
+  neighbor {{.Address}} activate
+{{- end}}
+ exit-address-family
+{{- end}}
+!
+`
@@ -0,0 +1,210 @@
+package megaport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+const routerConfigTestMCRVXC = `{
+    "productUid": "vxc-mcr", "productName": "MCR to DC",
+    "aEnd": {"productUid": "mcr-1", "vlan": 0},
+    "bEnd": {"productUid": "port-1", "vlan": 120},
+    "resources": {
+        "virtual_router": {"mcrAsn": 133937},
+        "csp_connection": {"connectType": "VROUTER", "interfaces": [{
+            "ipAddresses": ["10.0.0.1/30", "2001:db8::1/126"],
+            "bfd": {"txInterval": 500, "rxInterval": 400},
+            "bgpConnections": [
+                {"peerAsn": 65000, "localIpAddress": "10.0.0.1", "peerIpAddress": "10.0.0.2", "password": "s3cret", "bfdEnabled": true, "description": "dc-v4"},
+                {"peerAsn": 65000, "localAsn": 64555, "localIpAddress": "2001:db8::1", "peerIpAddress": "2001:db8::2"}
+            ]
+        }]}
+    }
+}`
+
+func decodeRouterConfigTestVXC(t *testing.T, blob string) *VXC {
+	t.Helper()
+	vxc := &VXC{}
+	if err := json.Unmarshal([]byte(blob), vxc); err != nil {
+		t.Fatal(err)
+	}
+	return vxc
+}
+
+func TestRouterConfigFromVXC(t *testing.T) {
+	t.Parallel()
+	cfg, err := RouterConfigFromVXC(decodeRouterConfigTestVXC(t, routerConfigTestMCRVXC), "Ethernet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.VLAN != 120 || cfg.LocalASN != 65000 {
+		t.Fatalf("VLAN, LocalASN = %d, %d", cfg.VLAN, cfg.LocalASN)
+	}
+	if got := strings.Join(cfg.Addresses, ","); got != "10.0.0.2/30,2001:db8::2/126" {
+		t.Fatalf("Addresses = %s", got)
+	}
+	if *cfg.BFD != (RouterBFD{TxInterval: 500, RxInterval: 400, Multiplier: 3}) {
+		t.Fatalf("BFD = %+v", *cfg.BFD)
+	}
+	want := []RouterBGPNeighbor{
+		{Description: "dc-v4", Address: "10.0.0.1", Version: 4, RemoteASN: 133937, LocalASN: 65000, Password: "s3cret", BFD: true},
+		{Description: "MCR to DC", Address: "2001:db8::1", Version: 6, RemoteASN: 64555, LocalASN: 65000},
+	}
+	for i, n := range cfg.Neighbors {
+		if *n != want[i] {
+			t.Fatalf("neighbor %d = %+v, want %+v", i, *n, want[i])
+		}
+	}
+
+	aws := decodeCSPTestVXC(t, `{"connectType": "AWS", "asn": 65010, "amazonAsn": 64512, "authKey": "k",
+        "customer_address": "169.254.1.1/30", "amazon_address": "169.254.1.2/30"}`)
+	aws.AEndConfiguration.VLAN = 300
+	cfg, err = RouterConfigFromVXC(aws, "xe-0/0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.VLAN != 300 || cfg.Addresses[0] != "169.254.1.1/30" ||
+		*cfg.Neighbors[0] != (RouterBGPNeighbor{Address: "169.254.1.2", Version: 4, RemoteASN: 64512, LocalASN: 65010, Password: "k"}) {
+		t.Fatalf("AWS config = %+v, %+v", cfg, cfg.Neighbors[0])
+	}
+
+	for name, csp := range map[string]string{
+		"mcr to cloud": `[{"connectType": "VROUTER", "interfaces": [{"bgpConnections": [{"peerAsn": 64512}]}]}, {"connectType": "AWS", "amazon_address": "169.254.1.2/30", "customer_address": "169.254.1.1/30"}]`,
+		"mcr no bgp":   `{"connectType": "VROUTER", "interfaces": [{"ipAddresses": ["10.0.0.1/30"]}]}`,
+		"aws pending":  `{"connectType": "AWS", "ownerAccount": "123456789012"}`,
+		"azure":        `{"connectType": "AZURE", "service_key": "sk"}`,
+	} {
+		if _, err := RouterConfigFromVXC(decodeCSPTestVXC(t, csp), "eth0"); !errors.Is(err, ErrRouterConfigUnsupported) {
+			t.Errorf("%s: err = %v, want %v", name, err, ErrRouterConfigUnsupported)
+		}
+	}
+}
+
+func TestRouterConfigFromVXCMCREnd(t *testing.T) {
+	t.Parallel()
+	for name, tc := range map[string]struct {
+		aVLAN, bVLAN int
+		csp          string
+		want         int
+	}{
+		"mcr a-end":            {aVLAN: 0, bVLAN: 120, csp: `"resource_type": "a_csp_connection"`, want: 120},
+		"mcr b-end":            {aVLAN: 120, bVLAN: 0, csp: `"resource_type": "b_csp_connection"`, want: 120},
+		"mcr b-end by vlan":    {aVLAN: 300, bVLAN: 200, csp: `"vlan": 200`, want: 300},
+		"mcr a-end by vlan":    {aVLAN: 200, bVLAN: 300, csp: `"vlan": 200`, want: 300},
+		"mcr a-end by default": {aVLAN: 200, bVLAN: 300, csp: `"vlan": 0`, want: 300},
+	} {
+		vxc := decodeRouterConfigTestVXC(t, strings.Replace(routerConfigTestMCRVXC, `"connectType": "VROUTER"`, `"connectType": "VROUTER", `+tc.csp, 1))
+		vxc.AEndConfiguration.VLAN, vxc.BEndConfiguration.VLAN = tc.aVLAN, tc.bVLAN
+		cfg, err := RouterConfigFromVXC(vxc, "Ethernet1")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if cfg.VLAN != tc.want {
+			t.Errorf("%s: VLAN = %d, want %d", name, cfg.VLAN, tc.want)
+		}
+	}
+}
+
+func TestRouterConfigWrite(t *testing.T) {
+	t.Parallel()
+	cfg, err := RouterConfigFromVXC(decodeRouterConfigTestVXC(t, routerConfigTestMCRVXC), "Ethernet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		platform string
+		want     []string
+	}{
+		{ROUTER_PLATFORM_IOS_XE, []string{
+			"interface Ethernet1.120\n description MCR to DC (vxc-mcr)\n encapsulation dot1Q 120\n ip address 10.0.0.2 255.255.255.252\n ipv6 address 2001:db8::2/126\n bfd interval 500 min_rx 400 multiplier 3\n",
+			" neighbor 10.0.0.1 remote-as 133937\n",
+			" neighbor 10.0.0.1 password s3cret\n neighbor 10.0.0.1 fall-over bfd\n",
+			" neighbor 2001:db8::1 remote-as 64555\n neighbor 2001:db8::1 description MCR to DC\n",
+			" address-family ipv6 unicast\n  neighbor 2001:db8::1 activate\n exit-address-family\n",
+		}},
+		{ROUTER_PLATFORM_JUNOS, []string{
+			"        vlan-tagging;\n        unit 120 {\n            description \"MCR to DC (vxc-mcr)\";\n            vlan-id 120;\n",
+			"            family inet {\n                address 10.0.0.2/30;\n            }\n",
+			"    autonomous-system 65000;\n",
+			"        group megaport-ipv6 {\n            type external;\n            family inet6 {\n",
+			"                authentication-key \"s3cret\";\n                bfd-liveness-detection {\n                    minimum-interval 500;\n",
+		}},
+		{ROUTER_PLATFORM_EOS, []string{
+			"interface Ethernet1\n   no switchport\n!\ninterface Ethernet1.120\n   encapsulation dot1q vlan 120\n",
+			"   ip address 10.0.0.2/30\n",
+			"   neighbor 10.0.0.1 password 0 s3cret\n   neighbor 10.0.0.1 bfd\n",
+			"   address-family ipv4\n      neighbor 10.0.0.1 activate\n",
+		}},
+		{"FRR", []string{
+			"! ip link add link Ethernet1 name Ethernet1.120 type vlan id 120\ninterface Ethernet1.120\n",
+			" ip address 10.0.0.2/30\n ipv6 address 2001:db8::2/126\n!\n",
+			"bfd\n peer 10.0.0.1\n  receive-interval 400\n  transmit-interval 500\n  detect-multiplier 3\n exit\n!\n",
+			" neighbor 10.0.0.1 bfd\n",
+		}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.platform, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			if err := cfg.Write(&buf, tc.platform); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("config missing %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+
+	if err := cfg.Write(&bytes.Buffer{}, "vyos"); !errors.Is(err, ErrRouterPlatformUnknown) {
+		t.Fatalf("unknown platform err = %v", err)
+	}
+	tmpl := template.Must(template.New("custom").Funcs(RouterConfigFuncs()).Parse(
+		`{{range .Addresses}}{{addr .}}/{{prefixlen .}} {{netmask .}}|{{end}}`))
+	var buf bytes.Buffer
+	if err := cfg.WriteTemplate(&buf, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "10.0.0.2/30 255.255.255.252|2001:db8::2/126 |" {
+		t.Fatalf("custom template = %q", got)
+	}
+	noIface := *cfg
+	noIface.Interface = ""
+	if err := noIface.WriteTemplate(&buf, tmpl); !errors.Is(err, ErrRouterConfigInterfaceRequired) {
+		t.Fatalf("no interface err = %v", err)
+	}
+}
+
+func TestIXPeeringSummaryRouterConfig(t *testing.T) {
+	t.Parallel()
+	s := &IXPeeringSummary{
+		ProductUID: "ix-1", ProductName: "LAX IX", NetworkServiceType: "Los Angeles IX",
+		ASN: 65000, VLAN: 0, IPv4Address: "206.53.172.30/24", IPv6Address: "2001:504:30::ba06:5000:1/64",
+		RouteServers: []*IXRouteServerSession{
+			{Name: "rs1", Version: 4, PeerAddress: "206.53.172.1", PeerASN: 6939, LocalASN: 65000},
+			{Name: "rs1", Version: 6, PeerAddress: "2001:504:30::ba06:5939:1", PeerASN: 6939, LocalASN: 65000},
+		},
+	}
+	var buf bytes.Buffer
+	if err := s.RouterConfig("eth1").Write(&buf, ROUTER_PLATFORM_FRR); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"interface eth1\n description LAX IX (ix-1)\n ip address 206.53.172.30/24\n ipv6 address 2001:504:30::ba06:5000:1/64\n!\nrouter bgp 65000\n",
+		" neighbor 206.53.172.1 description Los Angeles IX rs1\n",
+		" address-family ipv6 unicast\n  neighbor 2001:504:30::ba06:5939:1 activate\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("config missing %q:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "local-as") || strings.Contains(buf.String(), "ip link") {
+		t.Errorf("unexpected local-as or VLAN link:\n%s", buf.String())
+	}
+}
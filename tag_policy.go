@@ -0,0 +1,437 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Resource tag policy errors.
+var (
+	ErrTagPolicyRuleInvalid   = errors.New("invalid tag policy rule")
+	ErrTagPolicyNil           = errors.New("tag policy is required")
+	ErrResourceTagEditEmpty   = errors.New("resource tag edit sets and removes nothing")
+	ErrResourceTagEditBadKey  = errors.New("resource tag keys must not be empty")
+	ErrResourceTagEditOverlap = errors.New("resource tag edit sets and removes the same key")
+	ErrResourceTagPlanNil     = errors.New("resource tag plan is required")
+	// ErrResourceTagConflict is returned for a product whose tags were
+	// changed by someone else, on a key the plan also changes, after the
+	// plan was made.
+	ErrResourceTagConflict = errors.New("resource tags changed since the plan was made")
+	// ErrResourceTagNATGatewayClear is returned for a NAT Gateway the plan
+	// would leave without tags; the gateway update keeps its tags when none
+	// are sent, so they cannot be cleared this way.
+	ErrResourceTagNATGatewayClear = errors.New("nat gateway resource tags cannot all be removed")
+)
+
+// TagViolationCode identifies why a product fails a tag rule.
+type TagViolationCode string
+
+const (
+	// TagViolationMissing is a required key that is absent or empty.
+	TagViolationMissing TagViolationCode = "MISSING"
+	// TagViolationNotAllowed is a value outside the rule's AllowedValues.
+	TagViolationNotAllowed TagViolationCode = "NOT_ALLOWED"
+	// TagViolationPattern is a value that does not match the rule's Pattern.
+	TagViolationPattern TagViolationCode = "PATTERN_MISMATCH"
+)
+
+// TagRule constrains one resource tag key. Keys and values are compared
+// exactly.
+type TagRule struct {
+	Key string
+	// Required fails products without the key or with an empty value.
+	Required bool
+	// AllowedValues, when set, lists the only values accepted.
+	AllowedValues []string
+	// Pattern, when set, is a regular expression the value must match.
+	// Anchor it with ^ and $ to match the whole value.
+	Pattern string
+	// ProductTypes restricts the rule to these product types, e.g.
+	// "MEGAPORT", "MCR2", "MVE", "VXC" or "NAT_GATEWAY", matched
+	// case-insensitively. Empty applies the rule to every product.
+	ProductTypes []string
+}
+
+// TagPolicy is a validated set of tag rules. Create it with NewTagPolicy.
+type TagPolicy struct {
+	rules   []TagRule
+	regexps []*regexp.Regexp
+}
+
+// TagViolation is a tag rule a product fails.
+type TagViolation struct {
+	Key   string
+	Value string
+	Code  TagViolationCode
+}
+
+// String returns the violation as CODE:key or CODE:key=value.
+func (v *TagViolation) String() string {
+	s := string(v.Code) + ":" + v.Key
+	if v.Value != "" {
+		s += "=" + v.Value
+	}
+	return s
+}
+
+// NewTagPolicy validates and compiles the rules. Each key may appear in one
+// rule only.
+func NewTagPolicy(rules ...TagRule) (*TagPolicy, error) {
+	p := &TagPolicy{}
+	seen := make(map[string]bool)
+	for i, r := range rules {
+		if strings.TrimSpace(r.Key) == "" {
+			return nil, fmt.Errorf("%w: rule %d has no key", ErrTagPolicyRuleInvalid, i)
+		}
+		if seen[r.Key] {
+			return nil, fmt.Errorf("%w: duplicate rule for key %q", ErrTagPolicyRuleInvalid, r.Key)
+		}
+		seen[r.Key] = true
+		var re *regexp.Regexp
+		if r.Pattern != "" {
+			var err error
+			if re, err = regexp.Compile(r.Pattern); err != nil {
+				return nil, fmt.Errorf("%w: key %q: %w", ErrTagPolicyRuleInvalid, r.Key, err)
+			}
+		}
+		p.rules = append(p.rules, r)
+		p.regexps = append(p.regexps, re)
+	}
+	return p, nil
+}
+
+// Rules returns a copy of the policy's rules.
+func (p *TagPolicy) Rules() []TagRule {
+	return append([]TagRule(nil), p.rules...)
+}
+
+// Check returns the rules the tags of a product of productType fail, in
+// rule order.
+func (p *TagPolicy) Check(productType string, tags map[string]string) []*TagViolation {
+	var violations []*TagViolation
+	for i, r := range p.rules {
+		if len(r.ProductTypes) > 0 && !containsFold(r.ProductTypes, productType) {
+			continue
+		}
+		value, ok := tags[r.Key]
+		if !ok || value == "" {
+			if r.Required {
+				violations = append(violations, &TagViolation{Key: r.Key, Code: TagViolationMissing})
+			}
+			continue
+		}
+		if len(r.AllowedValues) > 0 && !slices.Contains(r.AllowedValues, value) {
+			violations = append(violations, &TagViolation{Key: r.Key, Value: value, Code: TagViolationNotAllowed})
+		}
+		if re := p.regexps[i]; re != nil && !re.MatchString(value) {
+			violations = append(violations, &TagViolation{Key: r.Key, Value: value, Code: TagViolationPattern})
+		}
+	}
+	return violations
+}
+
+// TagAuditOptions configures AuditResourceTags.
+type TagAuditOptions struct {
+	// IncludeNATGateways adds NAT Gateways, which ListProducts does not
+	// return.
+	IncludeNATGateways bool
+	// Concurrency bounds parallel tag lookups. Zero uses 4.
+	Concurrency int
+}
+
+// TagAuditResult is the audit of one product.
+type TagAuditResult struct {
+	ProductUID  string
+	ProductName string
+	ProductType string
+	Tags        map[string]string
+	Violations  []*TagViolation
+}
+
+// TagAuditReport is the result of AuditResourceTags.
+type TagAuditReport struct {
+	// Results are ordered by product UID.
+	Results []*TagAuditResult
+}
+
+// NonCompliant returns the results with at least one violation.
+func (r *TagAuditReport) NonCompliant() []*TagAuditResult {
+	var out []*TagAuditResult
+	for _, res := range r.Results {
+		if len(res.Violations) > 0 {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// AuditResourceTags checks the resource tags of every port, MCR, MVE and
+// attached VXC, and optionally every NAT Gateway, against the policy. All
+// tag lookup errors are returned joined, each prefixed with the product UID.
+func (svc *ProductServiceOp) AuditResourceTags(ctx context.Context, policy *TagPolicy, opts *TagAuditOptions) (*TagAuditReport, error) {
+	if policy == nil {
+		return nil, ErrTagPolicyNil
+	}
+	if opts == nil {
+		opts = &TagAuditOptions{}
+	}
+	services, err := svc.listBillableServices(ctx, opts.IncludeNATGateways, "")
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*TagAuditResult, len(services))
+	errs := make([]error, len(services))
+	forEachConcurrently(len(services), opts.Concurrency, func(i int) {
+		s := services[i]
+		tags := fromProductResourceTags(s.resourceTags)
+		if s.resourceTags == nil {
+			t, err := svc.ListProductResourceTags(ctx, s.uid)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", s.uid, err)
+				return
+			}
+			tags = fromProductResourceTags(t)
+		}
+		results[i] = &TagAuditResult{
+			ProductUID:  s.uid,
+			ProductName: s.name,
+			ProductType: s.productType,
+			Tags:        tags,
+			Violations:  policy.Check(s.productType, tags),
+		}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ProductUID < results[j].ProductUID })
+	return &TagAuditReport{Results: results}, nil
+}
+
+// ResourceTagEdit is a bulk change to resource tags. Set merges keys into
+// the existing tags; Remove deletes keys. A key may not be both set and
+// removed.
+type ResourceTagEdit struct {
+	Set    map[string]string
+	Remove []string
+}
+
+// keys returns every key the edit touches.
+func (e *ResourceTagEdit) keys() []string {
+	keys := append([]string{}, e.Remove...)
+	for k := range e.Set {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (e *ResourceTagEdit) validate() error {
+	if e == nil || len(e.Set) == 0 && len(e.Remove) == 0 {
+		return ErrResourceTagEditEmpty
+	}
+	for _, k := range e.keys() {
+		if strings.TrimSpace(k) == "" {
+			return ErrResourceTagEditBadKey
+		}
+	}
+	for _, k := range e.Remove {
+		if _, ok := e.Set[k]; ok {
+			return fmt.Errorf("%w: %q", ErrResourceTagEditOverlap, k)
+		}
+	}
+	return nil
+}
+
+// apply returns tags with the edit applied, leaving tags unchanged.
+func (e *ResourceTagEdit) apply(tags map[string]string) map[string]string {
+	out := maps.Clone(tags)
+	if out == nil {
+		out = make(map[string]string)
+	}
+	for _, k := range e.Remove {
+		delete(out, k)
+	}
+	for k, v := range e.Set {
+		out[k] = v
+	}
+	return out
+}
+
+// ResourceTagPlanItem is the planned tag change for one product. Before is
+// the tag set read when planning; ApplyResourceTagPlan compares it with the
+// tags at apply time to detect conflicting changes. NATGateway marks a NAT
+// Gateway, whose tags are read and written through the gateway itself
+// rather than the product tag endpoints.
+type ResourceTagPlanItem struct {
+	ProductUID string
+	NATGateway bool
+	Before     map[string]string
+	After      map[string]string
+}
+
+// Changed reports whether applying the item changes the product's tags.
+func (i *ResourceTagPlanItem) Changed() bool {
+	return !maps.Equal(i.Before, i.After)
+}
+
+// ResourceTagPlan is a reviewable bulk tag change made by
+// PlanResourceTagChanges.
+type ResourceTagPlan struct {
+	Edit  ResourceTagEdit
+	Items []*ResourceTagPlanItem
+}
+
+// ResourceTagApplyStatus is the outcome of applying one plan item.
+type ResourceTagApplyStatus string
+
+const (
+	ResourceTagApplied   ResourceTagApplyStatus = "APPLIED"
+	ResourceTagUnchanged ResourceTagApplyStatus = "UNCHANGED"
+	ResourceTagConflict  ResourceTagApplyStatus = "CONFLICT"
+	ResourceTagFailed    ResourceTagApplyStatus = "FAILED"
+)
+
+// ResourceTagApplyResult is the outcome of applying one plan item. After is
+// the tag set written, which includes tags other teams added since the plan
+// was made.
+type ResourceTagApplyResult struct {
+	ProductUID string
+	Status     ResourceTagApplyStatus
+	After      map[string]string
+	Err        error
+}
+
+// ResourceTagApplyOptions configures ApplyResourceTagPlan.
+type ResourceTagApplyOptions struct {
+	// Concurrency bounds parallel updates. Zero uses 4.
+	Concurrency int
+}
+
+// PlanResourceTagChanges reads the current tags of each product and
+// computes the result of the edit without writing anything. Product UIDs may
+// include NAT Gateways, which are found by listing them. All lookup errors
+// are returned joined, each prefixed with the product UID.
+func (svc *ProductServiceOp) PlanResourceTagChanges(ctx context.Context, productUIDs []string, edit *ResourceTagEdit) (*ResourceTagPlan, error) {
+	if err := edit.validate(); err != nil {
+		return nil, err
+	}
+	gateways, err := svc.Client.NATGatewayService.ListNATGateways(ctx)
+	if err != nil {
+		return nil, err
+	}
+	natTags := make(map[string][]ResourceTag, len(gateways))
+	for _, gw := range gateways {
+		natTags[gw.ProductUID] = gw.ResourceTags
+	}
+	plan := &ResourceTagPlan{
+		Edit:  ResourceTagEdit{Set: maps.Clone(edit.Set), Remove: append([]string(nil), edit.Remove...)},
+		Items: make([]*ResourceTagPlanItem, len(productUIDs)),
+	}
+	errs := make([]error, len(productUIDs))
+	forEachConcurrently(len(productUIDs), 0, func(i int) {
+		uid := productUIDs[i]
+		tags, isNAT := natTags[uid]
+		if !isNAT {
+			var err error
+			if tags, err = svc.ListProductResourceTags(ctx, uid); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", uid, err)
+				return
+			}
+		}
+		before := fromProductResourceTags(tags)
+		plan.Items[i] = &ResourceTagPlanItem{ProductUID: uid, NATGateway: isNAT, Before: before, After: plan.Edit.apply(before)}
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// ApplyResourceTagPlan writes a plan with read-modify-write semantics. Each
+// product's tags are re-read first. Tags changed since planning on keys the
+// edit does not touch are kept and the edit is re-applied on top of them;
+// a change to a key the edit touches is reported as a conflict, wrapping
+// ErrResourceTagConflict, and the product is left alone. Results are in
+// plan order; the error joins every conflict and failure.
+func (svc *ProductServiceOp) ApplyResourceTagPlan(ctx context.Context, plan *ResourceTagPlan, opts *ResourceTagApplyOptions) ([]*ResourceTagApplyResult, error) {
+	if plan == nil {
+		return nil, ErrResourceTagPlanNil
+	}
+	if opts == nil {
+		opts = &ResourceTagApplyOptions{}
+	}
+	touched := plan.Edit.keys()
+	results := make([]*ResourceTagApplyResult, len(plan.Items))
+	forEachConcurrently(len(plan.Items), opts.Concurrency, func(i int) {
+		item := plan.Items[i]
+		res := &ResourceTagApplyResult{ProductUID: item.ProductUID}
+		results[i] = res
+		current, gw, err := svc.currentResourceTags(ctx, item)
+		if err != nil {
+			res.Status, res.Err = ResourceTagFailed, fmt.Errorf("%s: %w", item.ProductUID, err)
+			return
+		}
+		for _, k := range touched {
+			before, hadBefore := item.Before[k]
+			now, hasNow := current[k]
+			if hadBefore != hasNow || before != now {
+				res.Status, res.Err = ResourceTagConflict, fmt.Errorf("%s: key %q: %w", item.ProductUID, k, ErrResourceTagConflict)
+				return
+			}
+		}
+		res.After = plan.Edit.apply(current)
+		if maps.Equal(current, res.After) {
+			res.Status = ResourceTagUnchanged
+			return
+		}
+		if err := svc.writeResourceTags(ctx, item.ProductUID, gw, res.After); err != nil {
+			res.Status, res.Err = ResourceTagFailed, fmt.Errorf("%s: %w", item.ProductUID, err)
+			return
+		}
+		res.Status = ResourceTagApplied
+	})
+	errs := make([]error, len(results))
+	for i, r := range results {
+		errs[i] = r.Err
+	}
+	return results, errors.Join(errs...)
+}
+
+// currentResourceTags reads the tags of a plan item's product. For a NAT
+// Gateway it also returns the gateway, which writeResourceTags updates.
+func (svc *ProductServiceOp) currentResourceTags(ctx context.Context, item *ResourceTagPlanItem) (map[string]string, *NATGateway, error) {
+	if item.NATGateway {
+		gw, err := svc.Client.NATGatewayService.GetNATGateway(ctx, item.ProductUID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return fromProductResourceTags(gw.ResourceTags), gw, nil
+	}
+	tags, err := svc.ListProductResourceTags(ctx, item.ProductUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fromProductResourceTags(tags), nil, nil
+}
+
+// writeResourceTags replaces a product's tags, updating gw instead when the
+// product is a NAT Gateway.
+func (svc *ProductServiceOp) writeResourceTags(ctx context.Context, productUID string, gw *NATGateway, tags map[string]string) error {
+	if gw == nil {
+		return svc.UpdateProductResourceTags(ctx, productUID, &UpdateProductResourceTagsRequest{
+			ResourceTags: toProductResourceTags(tags),
+		})
+	}
+	if len(tags) == 0 {
+		return ErrResourceTagNATGatewayClear
+	}
+	req := natGatewayUpdateFromGateway(gw)
+	req.ResourceTags = toProductResourceTags(tags)
+	_, err := svc.Client.NATGatewayService.UpdateNATGateway(ctx, req)
+	return err
+}
@@ -0,0 +1,269 @@
+package megaport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestTagPolicyCheck(t *testing.T) {
+	t.Parallel()
+	policy, err := NewTagPolicy(
+		TagRule{Key: "team", Required: true},
+		TagRule{Key: "env", Required: true, AllowedValues: []string{"prod", "dev"}},
+		TagRule{Key: "ticket", Pattern: `^NET-[0-9]+$`},
+		TagRule{Key: "circuit", Required: true, ProductTypes: []string{"megaport"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		productType string
+		tags        map[string]string
+		want        []string
+	}{
+		{"compliant", "VXC", map[string]string{"team": "net", "env": "prod", "ticket": "NET-12"}, nil},
+		{"missing and empty", "VXC", map[string]string{"env": ""}, []string{"MISSING:team", "MISSING:env"}},
+		{"not allowed", "VXC", map[string]string{"team": "net", "env": "Prod"}, []string{"NOT_ALLOWED:env=Prod"}},
+		{"pattern", "VXC", map[string]string{"team": "net", "env": "dev", "ticket": "OPS-1"}, []string{"PATTERN_MISMATCH:ticket=OPS-1"}},
+		{"product type", "MEGAPORT", map[string]string{"team": "net", "env": "dev"}, []string{"MISSING:circuit"}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var got []string
+			for _, v := range policy.Check(tc.productType, tc.tags) {
+				got = append(got, v.String())
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("violations = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewTagPolicyErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		rules []TagRule
+	}{
+		{"empty key", []TagRule{{Key: " "}}},
+		{"duplicate key", []TagRule{{Key: "env"}, {Key: "env"}}},
+		{"bad pattern", []TagRule{{Key: "env", Pattern: "("}}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := NewTagPolicy(tc.rules...); !errors.Is(err, ErrTagPolicyRuleInvalid) {
+				t.Fatalf("err = %v", err)
+			}
+		})
+	}
+}
+
+// TagPolicyTestSuite tests the tag audit and the bulk tag plan and apply
+// against an in-memory tag store.
+type TagPolicyTestSuite struct {
+	ClientTestSuite
+
+	mu   sync.Mutex
+	tags map[string]map[string]string
+	puts map[string]int
+}
+
+func TestTagPolicyTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(TagPolicyTestSuite))
+}
+
+func (suite *TagPolicyTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+	suite.tags = map[string]map[string]string{
+		"port-1": {"team": "net"},
+		"vxc-1":  {"team": "net", "env": "dev"},
+		"mcr-1":  {"team": "net", "env": "prod"},
+		"nat-1":  {"team": "edge"},
+	}
+	suite.puts = make(map[string]int)
+
+	suite.mux.HandleFunc("/v2/products", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "Products", "data": [
+            {"productUid": "port-1", "productName": "Port 1", "productType": "MEGAPORT", "provisioningStatus": "LIVE",
+                "associatedVxcs": [{"productUid": "vxc-1", "productName": "VXC 1", "productType": "VXC", "provisioningStatus": "LIVE"}]},
+            {"productUid": "mcr-1", "productName": "MCR 1", "productType": "MCR2", "provisioningStatus": "LIVE"}
+        ]}`)
+	})
+	// natGateway renders nat-1 with its current tags. suite.mu must be held.
+	natGateway := func() string {
+		b, _ := json.Marshal(toProductResourceTags(suite.tags["nat-1"]))
+		return fmt.Sprintf(`{"productUid": "nat-1", "productName": "NAT 1", "provisioningStatus": "LIVE",
+            "locationId": 1, "speed": 1000, "term": 12, "resourceTags": %s}`, b)
+	}
+	suite.mux.HandleFunc("/v3/products/nat_gateways", func(w http.ResponseWriter, r *http.Request) {
+		suite.mu.Lock()
+		defer suite.mu.Unlock()
+		fmt.Fprintf(w, `{"message": "NAT Gateways", "data": [%s]}`, natGateway())
+	})
+	suite.mux.HandleFunc("/v3/products/nat_gateways/nat-1", func(w http.ResponseWriter, r *http.Request) {
+		suite.mu.Lock()
+		defer suite.mu.Unlock()
+		if r.Method == http.MethodPut {
+			var req UpdateNATGatewayRequest
+			suite.NoError(json.NewDecoder(r.Body).Decode(&req))
+			suite.Equal(1000, req.Speed, "the rest of the gateway is sent unchanged")
+			suite.tags["nat-1"] = fromProductResourceTags(req.ResourceTags)
+			suite.puts["nat-1"]++
+		}
+		fmt.Fprintf(w, `{"message": "NAT Gateway", "data": %s}`, natGateway())
+	})
+	suite.mux.HandleFunc("/v2/product/", func(w http.ResponseWriter, r *http.Request) {
+		uid := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v2/product/"), "/tags")
+		suite.mu.Lock()
+		defer suite.mu.Unlock()
+		current, ok := suite.tags[uid]
+		if !ok || uid == "nat-1" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			b, _ := json.Marshal(toProductResourceTags(current))
+			fmt.Fprintf(w, `{"message": "Tags", "data": {"resourceTags": %s}}`, b)
+		case http.MethodPut:
+			var req UpdateProductResourceTagsRequest
+			suite.NoError(json.NewDecoder(r.Body).Decode(&req))
+			suite.tags[uid] = fromProductResourceTags(req.ResourceTags)
+			suite.puts[uid]++
+			fmt.Fprint(w, `{"message": "Updated"}`)
+		}
+	})
+}
+
+func (suite *TagPolicyTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *TagPolicyTestSuite) TestAuditResourceTags() {
+	ctx := context.Background()
+	policy, err := NewTagPolicy(
+		TagRule{Key: "team", Required: true, AllowedValues: []string{"net"}},
+		TagRule{Key: "env", Required: true},
+	)
+	suite.Require().NoError(err)
+
+	report, err := suite.client.ProductService.AuditResourceTags(ctx, policy, &TagAuditOptions{IncludeNATGateways: true})
+	suite.Require().NoError(err)
+	suite.Require().Len(report.Results, 4)
+	got := make(map[string]string)
+	for _, r := range report.NonCompliant() {
+		var vs []string
+		for _, v := range r.Violations {
+			vs = append(vs, v.String())
+		}
+		got[r.ProductUID] = strings.Join(vs, ",")
+	}
+	suite.Equal(map[string]string{
+		"nat-1":  "NOT_ALLOWED:team=edge,MISSING:env",
+		"port-1": "MISSING:env",
+	}, got)
+
+	_, err = suite.client.ProductService.AuditResourceTags(ctx, nil, nil)
+	suite.ErrorIs(err, ErrTagPolicyNil)
+}
+
+func (suite *TagPolicyTestSuite) TestPlanAndApplyResourceTags() {
+	ctx := context.Background()
+	svc := suite.client.ProductService
+	suite.tags["vxc-1"]["owner"] = "alice"
+
+	plan, err := svc.PlanResourceTagChanges(ctx, []string{"port-1", "vxc-1", "mcr-1", "nat-1"}, &ResourceTagEdit{
+		Set:    map[string]string{"env": "prod"},
+		Remove: []string{"owner"},
+	})
+	suite.Require().NoError(err)
+	suite.Equal(map[string]string{"team": "net", "env": "prod"}, plan.Items[0].After)
+	suite.Equal(map[string]string{"team": "net", "env": "prod"}, plan.Items[1].After)
+	suite.True(plan.Items[1].Changed())
+	suite.False(plan.Items[2].Changed())
+	suite.True(plan.Items[3].NATGateway)
+	suite.Equal(map[string]string{"team": "edge", "env": "prod"}, plan.Items[3].After)
+	suite.Empty(suite.puts, "planning must not write")
+
+	// Another team tags port-1 on an unrelated key and changes env on vxc-1.
+	suite.mu.Lock()
+	suite.tags["port-1"]["cost"] = "42"
+	suite.tags["vxc-1"]["env"] = "qa"
+	suite.mu.Unlock()
+
+	results, err := svc.ApplyResourceTagPlan(ctx, plan, &ResourceTagApplyOptions{Concurrency: 1})
+	suite.ErrorIs(err, ErrResourceTagConflict)
+	suite.Require().Len(results, 4)
+
+	suite.Equal(ResourceTagApplied, results[0].Status)
+	suite.Equal(map[string]string{"team": "net", "env": "prod", "cost": "42"}, suite.tags["port-1"])
+	suite.Equal(ResourceTagConflict, results[1].Status)
+	suite.Equal(map[string]string{"team": "net", "env": "qa", "owner": "alice"}, suite.tags["vxc-1"])
+	suite.Equal(ResourceTagUnchanged, results[2].Status)
+	suite.Equal(ResourceTagApplied, results[3].Status)
+	suite.Equal(map[string]string{"team": "edge", "env": "prod"}, suite.tags["nat-1"])
+	suite.Equal(map[string]int{"port-1": 1, "nat-1": 1}, suite.puts)
+
+	// A product that disappears fails on its own.
+	delete(suite.tags, "mcr-1")
+	results, err = svc.ApplyResourceTagPlan(ctx, plan, nil)
+	suite.Error(err)
+	suite.Equal(ResourceTagFailed, results[2].Status)
+
+	// A NAT Gateway cannot be left without tags.
+	plan, err = svc.PlanResourceTagChanges(ctx, []string{"nat-1"}, &ResourceTagEdit{Remove: []string{"team", "env"}})
+	suite.Require().NoError(err)
+	results, err = svc.ApplyResourceTagPlan(ctx, plan, nil)
+	suite.ErrorIs(err, ErrResourceTagNATGatewayClear)
+	suite.Equal(ResourceTagFailed, results[0].Status)
+}
+
+func (suite *TagPolicyTestSuite) TestResourceTagEditErrors() {
+	ctx := context.Background()
+	svc := suite.client.ProductService
+
+	tests := []struct {
+		name    string
+		edit    *ResourceTagEdit
+		wantErr error
+	}{
+		{"nil", nil, ErrResourceTagEditEmpty},
+		{"empty", &ResourceTagEdit{}, ErrResourceTagEditEmpty},
+		{"blank key", &ResourceTagEdit{Remove: []string{""}}, ErrResourceTagEditBadKey},
+		{"overlap", &ResourceTagEdit{Set: map[string]string{"env": "prod"}, Remove: []string{"env"}}, ErrResourceTagEditOverlap},
+	}
+	for _, tc := range tests {
+		suite.Run(tc.name, func() {
+			_, err := svc.PlanResourceTagChanges(ctx, []string{"port-1"}, tc.edit)
+			suite.ErrorIs(err, tc.wantErr)
+		})
+	}
+
+	_, err := svc.PlanResourceTagChanges(ctx, []string{"port-1", "missing"}, &ResourceTagEdit{Remove: []string{"env"}})
+	suite.ErrorContains(err, "missing:")
+	_, err = svc.ApplyResourceTagPlan(ctx, nil, nil)
+	suite.ErrorIs(err, ErrResourceTagPlanNil)
+}
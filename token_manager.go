@@ -0,0 +1,303 @@
+package megaport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTokenRefreshWindow is how long before expiry a token obtained with
+// AccessKey and SecretKey is replaced.
+const defaultTokenRefreshWindow = time.Minute
+
+// loginTimeout bounds a login shared by concurrent Authorize calls, which
+// runs on no single caller's context.
+const loginTimeout = time.Minute
+
+type skipTokenRefreshKey string
+
+const (
+	skipTokenRefresh skipTokenRefreshKey = "skip_token_refresh"
+)
+
+// CachedToken is an access token held by a TokenCache.
+type CachedToken struct {
+	AccessToken string    `json:"accessToken"`
+	Expiry      time.Time `json:"expiry"`
+}
+
+// TokenCache persists access tokens between clients, for example across
+// short-lived CLI invocations. Keys identify the credentials and token
+// endpoint a token was issued for and never contain the secret key.
+// Implementations must be safe for concurrent use.
+type TokenCache interface {
+	// Load returns the token stored under key, or nil when there is none.
+	Load(ctx context.Context, key string) (*CachedToken, error)
+	// Store saves token under key, replacing any previous token.
+	Store(ctx context.Context, key string, token *CachedToken) error
+}
+
+// WithTokenCache sets a TokenCache that Authorize consults before logging in
+// and updates after every login. Cache errors are logged and otherwise
+// ignored.
+func WithTokenCache(cache TokenCache) ClientOpt {
+	return func(c *Client) error {
+		c.authMux.Lock()
+		defer c.authMux.Unlock()
+		c.tokenCache = cache
+		return nil
+	}
+}
+
+// WithTokenRefreshWindow sets how long before expiry a token is refreshed.
+// Once a token is within the window, Authorize logs in again and requests
+// built by NewRequest trigger the refresh first, so a request does not start
+// with a token about to expire. The default is one minute.
+func WithTokenRefreshWindow(d time.Duration) ClientOpt {
+	return func(c *Client) error {
+		if d < 0 {
+			return errors.New("token refresh window must not be negative")
+		}
+		c.authMux.Lock()
+		defer c.authMux.Unlock()
+		c.tokenRefreshWindow = d
+		return nil
+	}
+}
+
+// tokenFlight is a login shared by concurrent Authorize calls. Each caller
+// stops waiting when its own context ends; the login itself carries on for
+// the others.
+type tokenFlight struct {
+	done chan struct{}
+	info *AuthInfo
+	err  error
+}
+
+func (f *tokenFlight) wait(ctx context.Context) (*AuthInfo, error) {
+	select {
+	case <-f.done:
+		return f.info, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// tokenFreshLocked reports whether the current token is outside the refresh
+// window. The caller must hold authMux.
+func (c *Client) tokenFreshLocked() bool {
+	return c.accessToken != "" && time.Now().Add(c.tokenRefreshWindow).Before(c.tokenExpiry)
+}
+
+// managesTokens reports whether the client logs in with its own credentials
+// and so can refresh its token.
+func (c *Client) managesTokens() bool {
+	c.authMux.Lock()
+	defer c.authMux.Unlock()
+	return c.tokenProvider == nil && c.AccessKey != "" && c.SecretKey != ""
+}
+
+// refreshStaleToken refreshes a token obtained by Authorize once it enters
+// the refresh window. Clients that never authorized are left alone.
+func (c *Client) refreshStaleToken(ctx context.Context) error {
+	if ctx.Value(skipTokenRefresh) != nil || !c.managesTokens() {
+		return nil
+	}
+	c.authMux.Lock()
+	stale := c.accessToken != "" && !c.tokenFreshLocked()
+	c.authMux.Unlock()
+	if !stale {
+		return nil
+	}
+	if _, err := c.Authorize(ctx); err != nil {
+		return fmt.Errorf("refreshing access token: %w", err)
+	}
+	return nil
+}
+
+// unauthorizedRetry returns a copy of req carrying a fresh token, or nil
+// when req should not be retried. The token req was sent with is discarded
+// first, unless another goroutine has already replaced it.
+func (c *Client) unauthorizedRetry(ctx context.Context, req *http.Request) *http.Request {
+	if ctx.Value(skipTokenRefresh) != nil || !c.managesTokens() {
+		return nil
+	}
+	used, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return nil
+	}
+	c.authMux.Lock()
+	if c.accessToken == used {
+		c.accessToken = ""
+		c.tokenExpiry = time.Time{}
+		c.rejectedToken = used
+	}
+	c.authMux.Unlock()
+
+	info, err := c.Authorize(context.WithValue(ctx, skipTokenRefresh, true))
+	if err != nil {
+		c.Logger.WarnContext(ctx, "could not refresh access token after 401 response", slog.String("error", err.Error()))
+		return nil
+	}
+	retry := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil
+		}
+		retry.Body = body
+	}
+	retry.Header.Set("Authorization", "Bearer "+info.AccessToken)
+	c.Logger.DebugContext(ctx, "retrying request with refreshed access token", slog.String("path", req.URL.EscapedPath()))
+	return retry
+}
+
+// tokenCacheKey identifies the credentials and token endpoint without
+// exposing the secret key.
+func (c *Client) tokenCacheKey() string {
+	sum := sha256.Sum256([]byte(c.AccessKey + "\x00" + c.SecretKey))
+	endpoint := c.tokenURL
+	if endpoint == "" && c.BaseURL != nil {
+		endpoint = c.BaseURL.Host
+	}
+	return c.AccessKey + "@" + endpoint + "#" + hex.EncodeToString(sum[:8])
+}
+
+// loadCachedToken adopts a fresh token from the token cache, if any.
+func (c *Client) loadCachedToken(ctx context.Context) *AuthInfo {
+	c.authMux.Lock()
+	cache, rejected := c.tokenCache, c.rejectedToken
+	c.authMux.Unlock()
+	if cache == nil || c.AccessKey == "" {
+		return nil
+	}
+	tok, err := cache.Load(ctx, c.tokenCacheKey())
+	if err != nil {
+		c.Logger.WarnContext(ctx, "could not load cached access token", slog.String("error", err.Error()))
+		return nil
+	}
+	if tok == nil || tok.AccessToken == "" || tok.AccessToken == rejected {
+		return nil
+	}
+	c.authMux.Lock()
+	defer c.authMux.Unlock()
+	if !time.Now().Add(c.tokenRefreshWindow).Before(tok.Expiry) {
+		return nil
+	}
+	c.accessToken, c.tokenExpiry = tok.AccessToken, tok.Expiry
+	c.Logger.DebugContext(ctx, "using cached access token")
+	return &AuthInfo{Expiration: tok.Expiry, AccessToken: tok.AccessToken}
+}
+
+// storeCachedToken saves a newly issued token in the token cache, if any.
+func (c *Client) storeCachedToken(ctx context.Context, info *AuthInfo) {
+	c.authMux.Lock()
+	cache := c.tokenCache
+	c.authMux.Unlock()
+	if cache == nil {
+		return
+	}
+	if err := cache.Store(ctx, c.tokenCacheKey(), &CachedToken{AccessToken: info.AccessToken, Expiry: info.Expiration}); err != nil {
+		c.Logger.WarnContext(ctx, "could not cache access token", slog.String("error", err.Error()))
+	}
+}
+
+// FileTokenCache is a TokenCache backed by a JSON file readable only by its
+// owner. Expired tokens are dropped whenever the file is written. It is safe
+// for concurrent use within a process; across processes the last writer
+// wins, which at worst causes an extra login.
+type FileTokenCache struct {
+	path string
+	mu   sync.Mutex
+}
+
+var _ TokenCache = &FileTokenCache{}
+
+// NewFileTokenCache returns a FileTokenCache stored at path. The directory
+// is created on first write.
+func NewFileTokenCache(path string) *FileTokenCache {
+	return &FileTokenCache{path: path}
+}
+
+// Load returns the token stored under key, or nil when there is none.
+func (f *FileTokenCache) Load(_ context.Context, key string) (*CachedToken, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tokens, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	return tokens[key], nil
+}
+
+// Store saves token under key, replacing any previous token.
+func (f *FileTokenCache) Store(_ context.Context, key string, token *CachedToken) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tokens, err := f.read()
+	if err != nil {
+		// Replace an unreadable cache rather than failing every login.
+		tokens = nil
+	}
+	if tokens == nil {
+		tokens = make(map[string]*CachedToken)
+	}
+	now := time.Now()
+	for k, t := range tokens {
+		if t == nil || !now.Before(t.Expiry) {
+			delete(tokens, k)
+		}
+	}
+	tokens[key] = token
+
+	b, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func (f *FileTokenCache) read() (map[string]*CachedToken, error) {
+	b, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tokens map[string]*CachedToken
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		return nil, fmt.Errorf("reading token cache %s: %w", f.path, err)
+	}
+	return tokens, nil
+}
@@ -0,0 +1,251 @@
+package megaport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// TokenManagerTestSuite tests token refresh, single-flight logins, the
+// unauthorized retry and the file token cache.
+type TokenManagerTestSuite struct {
+	ClientTestSuite
+
+	logins    atomic.Int32
+	expiresIn atomic.Int32
+	// minToken is the lowest token number the API accepts.
+	minToken atomic.Int32
+	bodies   []string
+	mu       sync.Mutex
+}
+
+func TestTokenManagerTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(TokenManagerTestSuite))
+}
+
+func (suite *TokenManagerTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+	suite.logins.Store(0)
+	suite.expiresIn.Store(3600)
+	suite.minToken.Store(1)
+	suite.bodies = nil
+
+	suite.mux.HandleFunc("/test/token", func(w http.ResponseWriter, r *http.Request) {
+		n := suite.logins.Add(1)
+		// Give concurrent callers time to pile up behind the first login.
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": %d}`, n, suite.expiresIn.Load())
+	})
+	suite.mux.HandleFunc("/test/echo", func(w http.ResponseWriter, r *http.Request) {
+		var n int32
+		if _, err := fmt.Sscanf(r.Header.Get("Authorization"), "Bearer token-%d", &n); err != nil || n < suite.minToken.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "Unauthorized"}`)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		suite.mu.Lock()
+		suite.bodies = append(suite.bodies, string(b))
+		suite.mu.Unlock()
+		fmt.Fprintf(w, `{"token": %d}`, n)
+	})
+}
+
+func (suite *TokenManagerTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *TokenManagerTestSuite) newClient(opts ...ClientOpt) *Client {
+	opts = append([]ClientOpt{
+		WithBaseURL(suite.server.URL),
+		WithCredentials("key", "secret"),
+		WithTokenURL(suite.server.URL + "/test/token"),
+	}, opts...)
+	c, err := New(nil, opts...)
+	suite.Require().NoError(err)
+	return c
+}
+
+func (suite *TokenManagerTestSuite) echo(c *Client, body string) (int, error) {
+	req, err := c.NewRequest(ctx, http.MethodPost, "/test/echo", map[string]string{"body": body})
+	if err != nil {
+		return 0, err
+	}
+	var out struct {
+		Token int `json:"token"`
+	}
+	_, err = c.Do(ctx, req, &out)
+	return out.Token, err
+}
+
+func (suite *TokenManagerTestSuite) TestAuthorizeSingleFlight() {
+	c := suite.newClient()
+	var wg sync.WaitGroup
+	tokens := make([]string, 20)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			info, err := c.Authorize(ctx)
+			suite.NoError(err)
+			if info != nil {
+				tokens[i] = info.AccessToken
+			}
+		}(i)
+	}
+	wg.Wait()
+	suite.Equal(int32(1), suite.logins.Load())
+	for _, tok := range tokens {
+		suite.Equal("token-1", tok)
+	}
+
+	// A cancelled waiter gives up without affecting the token.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	c.loginFlight = &tokenFlight{done: make(chan struct{})}
+	c.tokenExpiry = time.Time{}
+	_, err := c.Authorize(cancelled)
+	suite.ErrorIs(err, context.Canceled)
+}
+
+func (suite *TokenManagerTestSuite) TestAuthorizeLeaderCancelled() {
+	c := suite.newClient()
+	leaderCtx, cancel := context.WithCancel(ctx)
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := c.Authorize(leaderCtx)
+		leaderErr <- err
+	}()
+	// Wait for the leader's login to start before joining it.
+	suite.Eventually(func() bool { return suite.logins.Load() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	suite.ErrorIs(<-leaderErr, context.Canceled)
+
+	// The shared login carries on for the callers still waiting.
+	info, err := c.Authorize(ctx)
+	suite.Require().NoError(err)
+	suite.Equal("token-1", info.AccessToken)
+	suite.Equal(int32(1), suite.logins.Load())
+}
+
+func (suite *TokenManagerTestSuite) TestProactiveRefresh() {
+	// Tokens last 30 minutes but are refreshed an hour before expiry, so
+	// every request built after the first login refreshes first.
+	suite.expiresIn.Store(1800)
+	c := suite.newClient(WithTokenRefreshWindow(time.Hour))
+
+	// Clients that have not authorized are not logged in implicitly.
+	_, err := c.NewRequest(ctx, http.MethodGet, "/test/echo", nil)
+	suite.Require().NoError(err)
+	suite.Equal(int32(0), suite.logins.Load())
+
+	_, err = c.Authorize(ctx)
+	suite.Require().NoError(err)
+	token, err := suite.echo(c, "a")
+	suite.Require().NoError(err)
+	suite.Equal(2, token)
+
+	_, err = New(nil, WithTokenRefreshWindow(-time.Second))
+	suite.Error(err)
+}
+
+func (suite *TokenManagerTestSuite) TestUnauthorizedRetry() {
+	c := suite.newClient()
+	_, err := c.Authorize(ctx)
+	suite.Require().NoError(err)
+
+	// The API revokes token-1; the request is retried once with token-2 and
+	// its body is resent.
+	suite.minToken.Store(2)
+	token, err := suite.echo(c, "payload")
+	suite.Require().NoError(err)
+	suite.Equal(2, token)
+	suite.Equal([]string{`{"body":"payload"}` + "\n"}, suite.bodies)
+
+	// A second 401 is returned rather than retried again.
+	suite.minToken.Store(100)
+	_, err = suite.echo(c, "again")
+	suite.Require().Error(err)
+	suite.Contains(err.Error(), "Unauthorized")
+	suite.Equal(int32(3), suite.logins.Load())
+
+	// Clients without credentials never retry.
+	plain := NewClient(nil, nil)
+	plain.BaseURL = c.BaseURL
+	plain.SetAccessToken("token-1", time.Time{})
+	_, err = suite.echo(plain, "x")
+	suite.Error(err)
+	suite.Equal(int32(3), suite.logins.Load())
+}
+
+func (suite *TokenManagerTestSuite) TestFileTokenCache() {
+	path := filepath.Join(suite.T().TempDir(), "cache", "tokens.json")
+	cache := NewFileTokenCache(path)
+
+	first := suite.newClient(WithTokenCache(cache))
+	_, err := first.Authorize(ctx)
+	suite.Require().NoError(err)
+	info, err := os.Stat(path)
+	suite.Require().NoError(err)
+	suite.Equal(os.FileMode(0o600), info.Mode().Perm())
+	b, _ := os.ReadFile(path)
+	suite.NotContains(string(b), "secret")
+
+	// A second process with the same credentials reuses the token.
+	second := suite.newClient(WithTokenCache(NewFileTokenCache(path)))
+	got, err := second.Authorize(ctx)
+	suite.Require().NoError(err)
+	suite.Equal("token-1", got.AccessToken)
+	suite.Equal(int32(1), suite.logins.Load())
+
+	// Different credentials do not share tokens.
+	other, err := New(nil, WithBaseURL(suite.server.URL), WithCredentials("key", "other"),
+		WithTokenURL(suite.server.URL+"/test/token"), WithTokenCache(cache))
+	suite.Require().NoError(err)
+	got, err = other.Authorize(ctx)
+	suite.Require().NoError(err)
+	suite.Equal("token-2", got.AccessToken)
+
+	// A token rejected by the API is not reloaded from the cache.
+	suite.minToken.Store(3)
+	token, err := suite.echo(second, "x")
+	suite.Require().NoError(err)
+	suite.Equal(3, token)
+	stored, err := cache.Load(ctx, second.tokenCacheKey())
+	suite.Require().NoError(err)
+	suite.Equal("token-3", stored.AccessToken)
+
+	// Expired entries are dropped on the next write.
+	suite.Require().NoError(cache.Store(ctx, "old", &CachedToken{AccessToken: "old", Expiry: time.Now().Add(-time.Hour)}))
+	suite.Require().NoError(cache.Store(ctx, "new", &CachedToken{AccessToken: "new", Expiry: time.Now().Add(time.Hour)}))
+	old, err := cache.Load(ctx, "old")
+	suite.Require().NoError(err)
+	suite.Nil(old)
+
+	// A corrupt cache is reported on load and replaced on store.
+	suite.Require().NoError(os.WriteFile(path, []byte("{"), 0o600))
+	_, err = cache.Load(ctx, "new")
+	suite.Error(err)
+	suite.NoError(cache.Store(ctx, "new", &CachedToken{AccessToken: "new", Expiry: time.Now().Add(time.Hour)}))
+	b, _ = os.ReadFile(path)
+	suite.True(strings.HasPrefix(string(b), "{"))
+	suite.Contains(string(b), `"new"`)
+}
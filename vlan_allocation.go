@@ -0,0 +1,378 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// VLAN_AUTO_ASSIGN asks the API to allocate a VLAN, or in a VLANRequest
+	// asks the VLANMap to pick the lowest free one.
+	VLAN_AUTO_ASSIGN = 0
+	// VLAN_UNTAGGED asks the API to untag the VXC end.
+	VLAN_UNTAGGED = -1
+	// VLAN_MIN and VLAN_MAX bound the VLANs that can be assigned to a VXC end.
+	VLAN_MIN = 2
+	VLAN_MAX = 4093
+)
+
+var (
+	// ErrVLANRequestInvalid is returned when a VLAN request is outside VLAN_MIN and VLAN_MAX.
+	ErrVLANRequestInvalid = fmt.Errorf("invalid VLAN request, VLANs must be between %d and %d", VLAN_MIN, VLAN_MAX)
+	// ErrVLANConflict is returned when a requested VLAN collides with one in use or reserved.
+	ErrVLANConflict = errors.New("VLAN already in use")
+	// ErrVLANExhausted is returned when no free VLAN is left for an auto-assigned request.
+	ErrVLANExhausted = errors.New("no free VLAN available")
+	// ErrVLANVerifyUnsupported is returned when VLANs are verified on a product other than a port.
+	ErrVLANVerifyUnsupported = errors.New("VLAN availability can only be verified on ports")
+	// ErrVLANMapProductType is returned when a VLAN map is requested for a product that carries no VXCs.
+	ErrVLANMapProductType = errors.New("VLAN maps are only available for ports, MCRs and MVEs")
+)
+
+// VLANUse is an outer and inner VLAN pair taken on an interface by a VXC,
+// an IX or a reservation. VLAN is VLAN_UNTAGGED for an untagged VXC end
+// and InnerVLAN is zero when the end is not QinQ.
+type VLANUse struct {
+	VLAN        int    `json:"vlan"`
+	InnerVLAN   int    `json:"innerVlan,omitempty"`
+	ProductUID  string `json:"productUid,omitempty"`
+	ProductName string `json:"productName,omitempty"`
+	// ProductType is VXC, IX or RESERVED for a VLANMap reservation.
+	ProductType string `json:"productType"`
+}
+
+func (u *VLANUse) String() string {
+	name := u.ProductUID
+	if name == "" {
+		name = u.ProductName
+	}
+	vlan := "untagged"
+	if u.VLAN != VLAN_UNTAGGED {
+		vlan = fmt.Sprintf("%d", u.VLAN)
+	}
+	if u.InnerVLAN != 0 {
+		vlan = fmt.Sprintf("%s.%d", vlan, u.InnerVLAN)
+	}
+	return fmt.Sprintf("%s by %s %s", vlan, u.ProductType, name)
+}
+
+// VLANRange is an inclusive range of VLANs.
+type VLANRange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// VLANMap is the VLAN usage of one port, MCR or MVE vNIC, built from the
+// product's associated VXCs and IXs. Reserve adds planned VXCs to the map
+// so a batch of orders can be allocated without collisions.
+type VLANMap struct {
+	ProductUID  string
+	ProductType string
+	// VNICIndex is the MVE vNIC the map covers; it is zero for ports and MCRs.
+	VNICIndex int
+
+	uses []*VLANUse
+}
+
+// NewVLANMap builds the VLAN usage of productUID from its associated VXCs
+// and IXs. For an MVE, only VXC ends on vnicIndex are counted. VXCs and IXs
+// that are decommissioned or cancelled are ignored.
+func NewVLANMap(productUID, productType string, vnicIndex int, vxcs []*VXC, ixs []*IX) *VLANMap {
+	m := &VLANMap{ProductUID: productUID, ProductType: productType, VNICIndex: vnicIndex}
+	mve := strings.EqualFold(productType, PRODUCT_MVE)
+	for _, v := range vxcs {
+		if v == nil || v.ProvisioningStatus == STATUS_DECOMMISSIONED || v.ProvisioningStatus == STATUS_CANCELLED {
+			continue
+		}
+		// Both ends count when a VXC loops back to the same product.
+		for _, end := range []VXCEndConfiguration{v.AEndConfiguration, v.BEndConfiguration} {
+			if end.UID != productUID || mve && end.NetworkInterfaceIndex != vnicIndex {
+				continue
+			}
+			vlan := end.VLAN
+			if vlan == 0 {
+				vlan = VLAN_UNTAGGED
+			}
+			m.uses = append(m.uses, &VLANUse{VLAN: vlan, InnerVLAN: end.InnerVLAN, ProductUID: v.UID, ProductName: v.Name, ProductType: "VXC"})
+		}
+	}
+	for _, ix := range ixs {
+		if ix == nil || ix.ProvisioningStatus == STATUS_DECOMMISSIONED || ix.ProvisioningStatus == STATUS_CANCELLED {
+			continue
+		}
+		m.uses = append(m.uses, &VLANUse{VLAN: ix.VLAN, ProductUID: ix.ProductUID, ProductName: ix.ProductName, ProductType: "IX"})
+	}
+	return m
+}
+
+// NewPortVLANMap builds the VLAN usage of a port.
+func NewPortVLANMap(p *Port) *VLANMap {
+	return NewVLANMap(p.UID, PRODUCT_MEGAPORT, 0, p.AssociatedVXCs, p.AssociatedIXs)
+}
+
+// NewMCRVLANMap builds the VLAN usage of an MCR.
+func NewMCRVLANMap(m *MCR) *VLANMap {
+	return NewVLANMap(m.UID, PRODUCT_MCR, 0, m.AssociatedVXCs, m.AssociatedIXs)
+}
+
+// NewMVEVLANMap builds the VLAN usage of one vNIC of an MVE.
+func NewMVEVLANMap(m *MVE, vnicIndex int) *VLANMap {
+	return NewVLANMap(m.UID, PRODUCT_MVE, vnicIndex, m.AssociatedVXCs, m.AssociatedIXs)
+}
+
+// GetVLANMap fetches a port, MCR or MVE and builds the VLAN usage of it, or
+// of vNIC vnicIndex for an MVE.
+func (svc *ProductServiceOp) GetVLANMap(ctx context.Context, productUID string, vnicIndex int) (*VLANMap, error) {
+	productType, err := svc.GetProductType(ctx, productUID)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(productType) {
+	case PRODUCT_MEGAPORT:
+		p, err := svc.Client.PortService.GetPort(ctx, productUID)
+		if err != nil {
+			return nil, err
+		}
+		return NewPortVLANMap(p), nil
+	case PRODUCT_MCR:
+		m, err := svc.Client.MCRService.GetMCR(ctx, productUID)
+		if err != nil {
+			return nil, err
+		}
+		return NewMCRVLANMap(m), nil
+	case PRODUCT_MVE:
+		m, err := svc.Client.MVEService.GetMVE(ctx, productUID)
+		if err != nil {
+			return nil, err
+		}
+		return NewMVEVLANMap(m, vnicIndex), nil
+	}
+	return nil, fmt.Errorf("%w: %s is %s", ErrVLANMapProductType, productUID, productType)
+}
+
+// Uses returns the VLANs in use and reserved, ordered by VLAN and inner VLAN
+// with untagged ends first.
+func (m *VLANMap) Uses() []*VLANUse {
+	uses := make([]*VLANUse, len(m.uses))
+	copy(uses, m.uses)
+	sort.SliceStable(uses, func(i, j int) bool {
+		if uses[i].VLAN != uses[j].VLAN {
+			return uses[i].VLAN < uses[j].VLAN
+		}
+		return uses[i].InnerVLAN < uses[j].InnerVLAN
+	})
+	return uses
+}
+
+// Check returns the uses that a VXC end on vlan and innerVLAN would collide
+// with. Outer VLANs may be shared only when every end on them is QinQ with a
+// distinct inner VLAN; a plain end takes the whole outer VLAN. Only one end
+// may be untagged.
+func (m *VLANMap) Check(vlan, innerVLAN int) []*VLANUse {
+	var conflicts []*VLANUse
+	for _, u := range m.uses {
+		if u.VLAN != vlan {
+			continue
+		}
+		if vlan == VLAN_UNTAGGED || innerVLAN == 0 || u.InnerVLAN == 0 || u.InnerVLAN == innerVLAN {
+			conflicts = append(conflicts, u)
+		}
+	}
+	return conflicts
+}
+
+// Conflicts returns the pairs of existing uses that already collide, for
+// example a QinQ VXC sharing an outer VLAN with a plain one.
+func (m *VLANMap) Conflicts() [][2]*VLANUse {
+	var pairs [][2]*VLANUse
+	uses := m.Uses()
+	for i, a := range uses {
+		for _, b := range uses[i+1:] {
+			if a.VLAN == b.VLAN && (a.VLAN == VLAN_UNTAGGED || a.InnerVLAN == 0 || b.InnerVLAN == 0 || a.InnerVLAN == b.InnerVLAN) {
+				pairs = append(pairs, [2]*VLANUse{a, b})
+			}
+		}
+	}
+	return pairs
+}
+
+// FreeRanges returns the outer VLANs within r that nothing uses. A zero r
+// covers VLAN_MIN to VLAN_MAX.
+func (m *VLANMap) FreeRanges(r VLANRange) []VLANRange {
+	r = r.orDefault()
+	used := make(map[int]bool)
+	for _, u := range m.uses {
+		used[u.VLAN] = true
+	}
+	return freeRanges(r, used)
+}
+
+// FreeInnerRanges returns the inner VLANs still free on outer VLAN vlan. It
+// returns nil when a plain end already takes vlan.
+func (m *VLANMap) FreeInnerRanges(vlan int) []VLANRange {
+	used := make(map[int]bool)
+	for _, u := range m.uses {
+		if u.VLAN != vlan {
+			continue
+		}
+		if u.InnerVLAN == 0 {
+			return nil
+		}
+		used[u.InnerVLAN] = true
+	}
+	return freeRanges(VLANRange{From: VLAN_MIN, To: VLAN_MAX}, used)
+}
+
+func (r VLANRange) orDefault() VLANRange {
+	if r.From == 0 && r.To == 0 {
+		return VLANRange{From: VLAN_MIN, To: VLAN_MAX}
+	}
+	return r
+}
+
+func freeRanges(r VLANRange, used map[int]bool) []VLANRange {
+	var free []VLANRange
+	for v := max(r.From, VLAN_MIN); v <= min(r.To, VLAN_MAX); v++ {
+		if used[v] {
+			continue
+		}
+		if n := len(free); n > 0 && free[n-1].To == v-1 {
+			free[n-1].To = v
+			continue
+		}
+		free = append(free, VLANRange{From: v, To: v})
+	}
+	return free
+}
+
+// VLANRequest is one planned VXC end needing a VLAN.
+type VLANRequest struct {
+	// Name identifies the request in reservations and errors.
+	Name string
+	// VLAN is a specific VLAN, VLAN_AUTO_ASSIGN to take the lowest free
+	// VLAN, or VLAN_UNTAGGED.
+	VLAN int
+	// InnerVLAN makes the end QinQ when non-zero. With VLAN_AUTO_ASSIGN the
+	// lowest outer VLAN where InnerVLAN is free is taken.
+	InnerVLAN int
+	// Range limits auto-assignment; zero means VLAN_MIN to VLAN_MAX.
+	Range VLANRange
+}
+
+// VLANReservation is the VLAN assigned to a VLANRequest.
+type VLANReservation struct {
+	Request   *VLANRequest
+	VLAN      int
+	InnerVLAN int
+	// Err is set when the request could not be satisfied.
+	Err error
+}
+
+// ApplyTo sets the reserved VLANs on a VXC order endpoint. The inner VLAN
+// is carried in the endpoint's MVE configuration, which is created if needed.
+func (r *VLANReservation) ApplyTo(end *VXCOrderEndpointConfiguration) {
+	end.VLAN = r.VLAN
+	if r.InnerVLAN != 0 {
+		if end.VXCOrderMVEConfig == nil {
+			end.VXCOrderMVEConfig = &VXCOrderMVEConfig{}
+		}
+		end.VXCOrderMVEConfig.InnerVLAN = r.InnerVLAN
+	}
+}
+
+// Reserve assigns VLANs to a batch of planned VXC ends. Requests for a
+// specific VLAN are placed before auto-assigned ones so an earlier
+// auto-assignment cannot take a VLAN asked for later in the batch. The batch
+// is all or nothing: reservations are added to the map only when every
+// request succeeds, otherwise the failed reservations carry their error and
+// the errors are joined.
+func (m *VLANMap) Reserve(reqs []*VLANRequest) ([]*VLANReservation, error) {
+	out := make([]*VLANReservation, len(reqs))
+	for i, req := range reqs {
+		out[i] = &VLANReservation{Request: req}
+	}
+	order := make([]int, len(reqs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return reqs[order[a]].VLAN != VLAN_AUTO_ASSIGN && reqs[order[b]].VLAN == VLAN_AUTO_ASSIGN
+	})
+
+	work := &VLANMap{ProductUID: m.ProductUID, ProductType: m.ProductType, VNICIndex: m.VNICIndex}
+	work.uses = append(work.uses, m.uses...)
+	var errs []error
+	for _, i := range order {
+		res := out[i]
+		res.VLAN, res.InnerVLAN, res.Err = work.reserveOne(res.Request)
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Request.Name, res.Err))
+			continue
+		}
+		work.uses = append(work.uses, &VLANUse{VLAN: res.VLAN, InnerVLAN: res.InnerVLAN, ProductName: res.Request.Name, ProductType: "RESERVED"})
+	}
+	if len(errs) > 0 {
+		return out, errors.Join(errs...)
+	}
+	m.uses = work.uses
+	return out, nil
+}
+
+func (m *VLANMap) reserveOne(req *VLANRequest) (int, int, error) {
+	if req.InnerVLAN != 0 && (req.InnerVLAN < VLAN_MIN || req.InnerVLAN > VLAN_MAX || req.VLAN == VLAN_UNTAGGED) {
+		return 0, 0, ErrVLANRequestInvalid
+	}
+	if req.VLAN != VLAN_AUTO_ASSIGN {
+		if req.VLAN != VLAN_UNTAGGED && (req.VLAN < VLAN_MIN || req.VLAN > VLAN_MAX) {
+			return 0, 0, ErrVLANRequestInvalid
+		}
+		if conflicts := m.Check(req.VLAN, req.InnerVLAN); len(conflicts) > 0 {
+			return 0, 0, fmt.Errorf("%w: %s", ErrVLANConflict, conflicts[0])
+		}
+		return req.VLAN, req.InnerVLAN, nil
+	}
+
+	r := req.Range.orDefault()
+	for v := max(r.From, VLAN_MIN); v <= min(r.To, VLAN_MAX); v++ {
+		if len(m.Check(v, req.InnerVLAN)) == 0 {
+			return v, req.InnerVLAN, nil
+		}
+	}
+	return 0, 0, ErrVLANExhausted
+}
+
+// Verify confirms with the API that the outer VLANs of reservations are
+// still free on the port, catching VXCs ordered since the map was built.
+// Untagged reservations are skipped, as are QinQ reservations sharing an
+// outer VLAN that existing VXCs already report as taken; each remaining
+// outer VLAN is checked once.
+func (m *VLANMap) Verify(ctx context.Context, ports PortService, reservations []*VLANReservation) error {
+	if !strings.EqualFold(m.ProductType, PRODUCT_MEGAPORT) {
+		return ErrVLANVerifyUnsupported
+	}
+	checked := make(map[int]bool)
+	for _, u := range m.uses {
+		if u.ProductType != "RESERVED" {
+			checked[u.VLAN] = true
+		}
+	}
+	var errs []error
+	for _, r := range reservations {
+		if r.Err != nil || r.VLAN == VLAN_UNTAGGED || checked[r.VLAN] {
+			continue
+		}
+		checked[r.VLAN] = true
+		ok, err := ports.CheckPortVLANAvailability(ctx, m.ProductUID, r.VLAN)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: checking VLAN %d: %w", r.Request.Name, r.VLAN, err))
+			continue
+		}
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %w: VLAN %d on port %s", r.Request.Name, ErrVLANConflict, r.VLAN, m.ProductUID))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,205 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func vlanTestVXC(uid string, a, b VXCEndConfiguration) *VXC {
+	return &VXC{UID: uid, Name: uid, ProvisioningStatus: SERVICE_LIVE, AEndConfiguration: a, BEndConfiguration: b}
+}
+
+func TestVLANMapCheckAndFreeRanges(t *testing.T) {
+	t.Parallel()
+	m := NewVLANMap("port-1", "MEGAPORT", 0, []*VXC{
+		vlanTestVXC("plain", VXCEndConfiguration{UID: "port-1", VLAN: 100}, VXCEndConfiguration{UID: "mcr-1", VLAN: 5}),
+		vlanTestVXC("qinq-1", VXCEndConfiguration{UID: "other"}, VXCEndConfiguration{UID: "port-1", VLAN: 200, InnerVLAN: 10}),
+		vlanTestVXC("qinq-2", VXCEndConfiguration{UID: "port-1", VLAN: 200, InnerVLAN: 11}, VXCEndConfiguration{UID: "other"}),
+		vlanTestVXC("untagged", VXCEndConfiguration{UID: "port-1"}, VXCEndConfiguration{UID: "other"}),
+		{UID: "gone", ProvisioningStatus: STATUS_DECOMMISSIONED, AEndConfiguration: VXCEndConfiguration{UID: "port-1", VLAN: 3}},
+	}, []*IX{{ProductUID: "ix-1", VLAN: 2, ProvisioningStatus: SERVICE_LIVE}})
+
+	tests := []struct {
+		name      string
+		vlan      int
+		innerVLAN int
+		want      []string
+	}{
+		{"free", 300, 0, nil},
+		{"plain taken", 100, 0, []string{"plain"}},
+		{"qinq on plain", 100, 7, []string{"plain"}},
+		{"plain on qinq", 200, 0, []string{"qinq-1", "qinq-2"}},
+		{"inner taken", 200, 11, []string{"qinq-2"}},
+		{"inner free", 200, 12, nil},
+		{"untagged", VLAN_UNTAGGED, 0, []string{"untagged"}},
+		{"ix", 2, 0, []string{"ix-1"}},
+		{"decommissioned", 3, 0, nil},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var got []string
+			for _, u := range m.Check(tc.vlan, tc.innerVLAN) {
+				got = append(got, u.ProductUID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Fatalf("Check(%d, %d) = %v, want %v", tc.vlan, tc.innerVLAN, got, tc.want)
+			}
+		})
+	}
+
+	if got := m.FreeRanges(VLANRange{From: 1, To: 201}); fmt.Sprint(got) != "[{3 99} {101 199} {201 201}]" {
+		t.Fatalf("FreeRanges = %v", got)
+	}
+	if got := m.FreeInnerRanges(200); fmt.Sprint(got) != "[{2 9} {12 4093}]" {
+		t.Fatalf("FreeInnerRanges = %v", got)
+	}
+	if got := m.FreeInnerRanges(100); got != nil {
+		t.Fatalf("FreeInnerRanges on a plain VLAN = %v", got)
+	}
+	if got := m.Conflicts(); len(got) != 0 {
+		t.Fatalf("Conflicts = %v", got)
+	}
+}
+
+func TestVLANMapMVEAndConflicts(t *testing.T) {
+	t.Parallel()
+	vxcs := []*VXC{
+		vlanTestVXC("vnic-0", VXCEndConfiguration{UID: "mve-1", VLAN: 100}, VXCEndConfiguration{UID: "other"}),
+		vlanTestVXC("vnic-1", VXCEndConfiguration{UID: "mve-1", VLAN: 100, InnerVLAN: 5, NetworkInterfaceIndex: 1}, VXCEndConfiguration{UID: "other"}),
+		vlanTestVXC("loop", VXCEndConfiguration{UID: "mve-1", VLAN: 100, NetworkInterfaceIndex: 1}, VXCEndConfiguration{UID: "mve-1", VLAN: 101, NetworkInterfaceIndex: 1}),
+	}
+	m := NewMVEVLANMap(&MVE{UID: "mve-1", AssociatedVXCs: vxcs}, 1)
+	var uses []string
+	for _, u := range m.Uses() {
+		uses = append(uses, u.String())
+	}
+	if want := "[100 by VXC loop 100.5 by VXC vnic-1 101 by VXC loop]"; fmt.Sprint(uses) != want {
+		t.Fatalf("Uses = %v, want %s", uses, want)
+	}
+	conflicts := m.Conflicts()
+	if len(conflicts) != 1 || conflicts[0][0].ProductUID != "loop" || conflicts[0][1].ProductUID != "vnic-1" {
+		t.Fatalf("Conflicts = %v", conflicts)
+	}
+}
+
+func TestVLANMapReserve(t *testing.T) {
+	t.Parallel()
+	m := NewVLANMap("port-1", "MEGAPORT", 0, []*VXC{
+		vlanTestVXC("plain", VXCEndConfiguration{UID: "port-1", VLAN: 2}, VXCEndConfiguration{UID: "other"}),
+		vlanTestVXC("qinq", VXCEndConfiguration{UID: "port-1", VLAN: 10, InnerVLAN: 1000}, VXCEndConfiguration{UID: "other"}),
+	}, nil)
+
+	res, err := m.Reserve([]*VLANRequest{
+		{Name: "auto-1"},
+		{Name: "fixed", VLAN: 3},
+		{Name: "auto-2"},
+		{Name: "qinq-auto", InnerVLAN: 1001, Range: VLANRange{From: 10, To: 20}},
+		{Name: "untagged", VLAN: VLAN_UNTAGGED},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range res {
+		got = append(got, fmt.Sprintf("%s=%d.%d", r.Request.Name, r.VLAN, r.InnerVLAN))
+	}
+	if want := "[auto-1=4.0 fixed=3.0 auto-2=5.0 qinq-auto=10.1001 untagged=-1.0]"; fmt.Sprint(got) != want {
+		t.Fatalf("reservations = %v, want %s", got, want)
+	}
+
+	var end VXCOrderEndpointConfiguration
+	res[3].ApplyTo(&end)
+	if end.VLAN != 10 || end.VXCOrderMVEConfig == nil || end.InnerVLAN != 1001 {
+		t.Fatalf("ApplyTo = %+v", end)
+	}
+
+	tests := []struct {
+		name    string
+		req     *VLANRequest
+		wantErr error
+	}{
+		{"reserved earlier", &VLANRequest{VLAN: 4}, ErrVLANConflict},
+		{"untagged taken", &VLANRequest{VLAN: VLAN_UNTAGGED}, ErrVLANConflict},
+		{"out of range", &VLANRequest{VLAN: 4094}, ErrVLANRequestInvalid},
+		{"untagged qinq", &VLANRequest{VLAN: VLAN_UNTAGGED, InnerVLAN: 5}, ErrVLANRequestInvalid},
+		{"exhausted", &VLANRequest{Range: VLANRange{From: 2, To: 5}}, ErrVLANExhausted},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			before := len(m.Uses())
+			res, err := m.Reserve([]*VLANRequest{{Name: "ok"}, tc.req})
+			if !errors.Is(err, tc.wantErr) || !errors.Is(res[1].Err, tc.wantErr) {
+				t.Fatalf("err = %v", err)
+			}
+			if len(m.Uses()) != before {
+				t.Fatal("a failed batch must not reserve anything")
+			}
+		})
+	}
+}
+
+// VLANMapTestSuite tests GetVLANMap and Verify against the product and
+// VLAN availability endpoints.
+type VLANMapTestSuite struct {
+	ClientTestSuite
+}
+
+func TestVLANMapTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(VLANMapTestSuite))
+}
+
+func (suite *VLANMapTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+
+	suite.mux.HandleFunc("/v2/product/port-1", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprint(w, `{"message": "Port", "data": {"productUid": "port-1", "productType": "MEGAPORT", "provisioningStatus": "LIVE",
+            "associatedVxcs": [{"productUid": "vxc-1", "productName": "VXC 1", "provisioningStatus": "LIVE",
+                "aEnd": {"productUid": "port-1", "vlan": 2}, "bEnd": {"productUid": "mcr-1", "vlan": 2}}],
+            "associatedIxs": [{"productUid": "ix-1", "productName": "IX 1", "provisioningStatus": "LIVE", "vlan": 3}]}}`)
+	})
+	suite.mux.HandleFunc("/v2/product/ix-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "IX", "data": {"productUid": "ix-1", "productType": "IX"}}`)
+	})
+	suite.mux.HandleFunc("/v2/product/port/port-1/vlan", func(w http.ResponseWriter, r *http.Request) {
+		// VLAN 5 was taken by an order placed after the map was built.
+		fmt.Fprint(w, `{"message": "VLANs", "data": [4, 6, 7]}`)
+	})
+}
+
+func (suite *VLANMapTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *VLANMapTestSuite) TestGetVLANMapAndVerify() {
+	ctx := context.Background()
+	m, err := suite.client.ProductService.GetVLANMap(ctx, "port-1", 0)
+	suite.Require().NoError(err)
+	suite.Equal("[{4 4093}]", fmt.Sprint(m.FreeRanges(VLANRange{})))
+
+	res, err := m.Reserve([]*VLANRequest{{Name: "a"}, {Name: "b"}, {Name: "c", VLAN: VLAN_UNTAGGED}})
+	suite.Require().NoError(err)
+	err = m.Verify(ctx, suite.client.PortService, res)
+	suite.Require().ErrorIs(err, ErrVLANConflict)
+	suite.Equal("b: VLAN already in use: VLAN 5 on port port-1", err.Error())
+
+	_, err = suite.client.ProductService.GetVLANMap(ctx, "ix-1", 0)
+	suite.ErrorIs(err, ErrVLANMapProductType)
+	suite.ErrorIs(NewMCRVLANMap(&MCR{UID: "mcr-1"}).Verify(ctx, suite.client.PortService, res), ErrVLANVerifyUnsupported)
+}
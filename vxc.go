@@ -25,10 +25,28 @@ type VXCService interface {
 	DeleteVXC(ctx context.Context, id string, req *DeleteVXCRequest) error
 	// UpdateVXC updates a VXC in the Megaport VXC API.
 	UpdateVXC(ctx context.Context, id string, req *UpdateVXCRequest) (*VXC, error)
+	// MoveVXC re-homes one or both ends of a VXC after checking the new ends,
+	// verifies the VXC and its BGP sessions afterwards and rolls back on failure.
+	MoveVXC(ctx context.Context, id string, req *MoveVXCRequest) (*MoveVXCResult, error)
+	// GetVXCCloudStatus evaluates whether the cloud provider has accepted a
+	// VXC and whether its BGP peering is configured.
+	GetVXCCloudStatus(ctx context.Context, id string, opts *CloudStatusOptions) (*CloudStatus, error)
+	// ListVXCCloudStatuses evaluates the cloud side readiness of every cloud
+	// VXC and summarizes the fleet.
+	ListVXCCloudStatuses(ctx context.Context, opts *CloudStatusOptions) (*CloudStatusReport, error)
 	// LookupPartnerPorts looks up available partner ports in the Megaport VXC API.
 	LookupPartnerPorts(ctx context.Context, req *LookupPartnerPortsRequest) (*LookupPartnerPortsResponse, error)
 	// ListPartnerPorts lists available partner ports in the Megaport VXC API.
 	ListPartnerPorts(ctx context.Context, req *ListPartnerPortsRequest) (*ListPartnerPortsResponse, error)
+	// BuildAzureVXCPair builds orders for a redundant pair of VXCs to the
+	// primary and secondary ports of an ExpressRoute service key.
+	BuildAzureVXCPair(ctx context.Context, req *AzureVXCPairRequest) (*AzureVXCPair, error)
+	// CheckVXCCapacity reports the capacity of both ends of a proposed VXC
+	// with its rate limit added.
+	CheckVXCCapacity(ctx context.Context, req *BuyVXCRequest) (*VXCCapacityCheck, error)
+	// CheckVXCRateLimitCapacity reports the capacity of both ends of a VXC
+	// with its rate limit changed.
+	CheckVXCRateLimitCapacity(ctx context.Context, id string, rateLimit int) (*VXCCapacityCheck, error)
 	// ListVXCResourceTags lists the resource tags for a VXC in the Megaport Products API.
 	ListVXCResourceTags(ctx context.Context, vxcID string) (map[string]string, error)
 	// UpdateVXCResourceTags updates the resource tags for a VXC in the Megaport Products API.
@@ -85,8 +103,8 @@ type DeleteVXCResponse struct {
 
 // UpdateVXCRequest represents a request to update a VXC in the Megaport VXC API.
 type UpdateVXCRequest struct {
-	AEndVLAN       *int    // A unique VLAN ID for this connection. Values can range from 2 to 4093. If this value is 0, the system allocates a valid VLAN. If the value is -1, the system untags the VLAN and sets it to null.
-	BEndVLAN       *int    // A unique VLAN ID for this connection. Values can range from 2 to 4093. If this value is 0, the system allocates a valid VLAN. If the value is -1, the system untags the VLAN and sets it to null.
+	AEndVLAN       *int    // A unique VLAN ID for this connection. Values can range from 2 to 4093. If this value is 0 (VLAN_AUTO_ASSIGN), the system allocates a valid VLAN. If the value is -1 (VLAN_UNTAGGED), the system untags the VLAN and sets it to null.
+	BEndVLAN       *int    // A unique VLAN ID for this connection. Values can range from 2 to 4093. If this value is 0 (VLAN_AUTO_ASSIGN), the system allocates a valid VLAN. If the value is -1 (VLAN_UNTAGGED), the system untags the VLAN and sets it to null.
 	AEndProductUID *string // When moving a VXC, this is the new A-End for the connection.
 	BEndProductUID *string // When moving a VXC, this is the new B-End for the connection.
 	RateLimit      *int    // A new speed for the connection.
@@ -163,6 +181,9 @@ func (svc *VXCServiceOp) BuyVXC(ctx context.Context, req *BuyVXCRequest) (*BuyVX
 	if !slices.Contains(VALID_CONTRACT_TERMS, req.Term) {
 		return nil, ErrInvalidTerm
 	}
+	if err := svc.enforceBuyVXCCapacity(ctx, req); err != nil {
+		return nil, err
+	}
 
 	buyOrder := createVXCOrder(req)
 
@@ -367,6 +388,9 @@ func (svc *VXCServiceOp) UpdateVXC(ctx context.Context, id string, req *UpdateVX
 	if req.CostCentre != nil && len(*req.CostCentre) > 255 {
 		return nil, ErrCostCentreTooLong
 	}
+	if err := svc.enforceUpdateVXCCapacity(ctx, id, req.RateLimit); err != nil {
+		return nil, err
+	}
 
 	path := fmt.Sprintf("/v3/product/%s/%s", PRODUCT_VXC, id)
 	url := svc.Client.BaseURL.JoinPath(path).String()
@@ -0,0 +1,302 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AWS Direct Connect virtual interface types.
+const (
+	AWS_VIF_PRIVATE = "private"
+	AWS_VIF_PUBLIC  = "public"
+	AWS_VIF_TRANSIT = "transit"
+)
+
+// AWS Direct Connect helper errors.
+var (
+	ErrAWSPartnerConfigNil     = errors.New("AWS partner config is required")
+	ErrAWSPartnerConfigInvalid = errors.New("invalid AWS partner config")
+	ErrAWSEndpointRequestNil   = errors.New("AWS endpoint request is required")
+	// ErrAWSAccountInvalid is returned for an owner account that is not a
+	// 12 digit AWS account ID.
+	ErrAWSAccountInvalid = errors.New("AWS owner account must be a 12 digit account ID")
+	// ErrAWSASNInvalid is returned for a customer or Amazon ASN that is
+	// reserved or out of range.
+	ErrAWSASNInvalid = errors.New("invalid AWS BGP ASN")
+	// ErrAWSAddressInvalid is returned when the customer and Amazon
+	// addresses are not two hosts of the same /30 or /31.
+	ErrAWSAddressInvalid = errors.New("invalid AWS BGP peer addresses")
+	// ErrAWSRegionRequired is returned when an AWS endpoint request has no region.
+	ErrAWSRegionRequired = errors.New("AWS endpoint request requires a region")
+)
+
+var awsAccountIDRegexp = regexp.MustCompile(`^[0-9]{12}$`)
+
+// AWSVIFSpec describes an AWS Direct Connect hosted virtual interface.
+type AWSVIFSpec struct {
+	// Type is AWS_VIF_PRIVATE (the default), AWS_VIF_PUBLIC or AWS_VIF_TRANSIT.
+	Type string
+	// OwnerAccount is the 12 digit ID of the AWS account that accepts the VIF.
+	OwnerAccount string
+	// ASN is the customer ASN. It is required for public VIFs; zero uses the
+	// MCR ASN for private and transit VIFs from an MCR.
+	ASN int
+	// AmazonASN is the ASN of the virtual private or Direct Connect gateway,
+	// a private ASN. Zero uses the AWS default. Not used by public VIFs.
+	AmazonASN int
+	// AuthKey is the optional BGP MD5 key. AWS generates one when empty.
+	AuthKey string
+	// CustomerIPAddress and AmazonIPAddress are the BGP peer addresses in
+	// CIDR form, two hosts of the same /30 or /31. Public VIFs require
+	// public addresses; private and transit VIFs may leave both empty to
+	// have AWS assign a 169.254.0.0/16 link-local pair.
+	CustomerIPAddress string
+	AmazonIPAddress   string
+	// Prefixes are the public prefixes advertised over a public VIF.
+	Prefixes []string
+	// Name is the name of the VIF in AWS.
+	Name string
+}
+
+// NewAWSVIFPartnerConfig builds the partner config of a hosted VIF after
+// validating it with ValidateAWSPartnerConfig.
+func NewAWSVIFPartnerConfig(spec AWSVIFSpec) (*VXCPartnerConfigAWS, error) {
+	vifType := strings.ToLower(spec.Type)
+	if vifType == "" {
+		vifType = AWS_VIF_PRIVATE
+	}
+	cfg := &VXCPartnerConfigAWS{
+		ConnectType:       CONNECT_TYPE_AWS_VIF,
+		Type:              vifType,
+		OwnerAccount:      spec.OwnerAccount,
+		ASN:               spec.ASN,
+		AmazonASN:         spec.AmazonASN,
+		AuthKey:           spec.AuthKey,
+		Prefixes:          strings.Join(spec.Prefixes, ","),
+		CustomerIPAddress: spec.CustomerIPAddress,
+		AmazonIPAddress:   spec.AmazonIPAddress,
+		ConnectionName:    spec.Name,
+	}
+	if err := ValidateAWSPartnerConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// NewAWSHostedConnectionPartnerConfig builds the partner config of a hosted
+// connection, on which VIFs are then created in AWS.
+func NewAWSHostedConnectionPartnerConfig(ownerAccount, name string) (*VXCPartnerConfigAWS, error) {
+	cfg := &VXCPartnerConfigAWS{
+		ConnectType:    CONNECT_TYPE_AWS_HOSTED_CONNECTION,
+		OwnerAccount:   ownerAccount,
+		ConnectionName: name,
+	}
+	if err := ValidateAWSPartnerConfig(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ValidateAWSPartnerConfig checks the fields an AWS partner config needs for
+// its connect type and VIF type: the account ID format, ASN ranges, the
+// customer and Amazon address pair, and public VIF prefixes. Hosted
+// connections take only the account and name. All problems are returned
+// joined.
+func ValidateAWSPartnerConfig(cfg *VXCPartnerConfigAWS) error {
+	if cfg == nil {
+		return ErrAWSPartnerConfigNil
+	}
+	var errs []error
+	fail := func(err error, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: %s", err, fmt.Sprintf(format, args...)))
+	}
+	if !awsAccountIDRegexp.MatchString(cfg.OwnerAccount) {
+		fail(ErrAWSAccountInvalid, "%q", cfg.OwnerAccount)
+	}
+
+	switch cfg.ConnectType {
+	case CONNECT_TYPE_AWS_HOSTED_CONNECTION:
+		if cfg.Type != "" || cfg.ASN != 0 || cfg.AmazonASN != 0 || cfg.AuthKey != "" || cfg.Prefixes != "" ||
+			cfg.CustomerIPAddress != "" || cfg.AmazonIPAddress != "" {
+			fail(ErrAWSPartnerConfigInvalid, "hosted connections take only an owner account and name; BGP is configured on VIFs in AWS")
+		}
+		return errors.Join(errs...)
+	case CONNECT_TYPE_AWS_VIF:
+	default:
+		fail(ErrAWSPartnerConfigInvalid, "connect type must be %s or %s", CONNECT_TYPE_AWS_VIF, CONNECT_TYPE_AWS_HOSTED_CONNECTION)
+		return errors.Join(errs...)
+	}
+
+	public := cfg.Type == AWS_VIF_PUBLIC
+	if cfg.Type != AWS_VIF_PRIVATE && cfg.Type != AWS_VIF_TRANSIT && !public {
+		fail(ErrAWSPartnerConfigInvalid, "VIF type must be %s, %s or %s", AWS_VIF_PRIVATE, AWS_VIF_PUBLIC, AWS_VIF_TRANSIT)
+	}
+
+	switch {
+	case cfg.ASN == 0 && public:
+		fail(ErrAWSASNInvalid, "public VIFs require a customer ASN")
+	case cfg.ASN != 0 && !validPeerASN(int64(cfg.ASN)):
+		fail(ErrAWSASNInvalid, "customer ASN %d", cfg.ASN)
+	}
+	switch {
+	case cfg.AmazonASN != 0 && public:
+		fail(ErrAWSASNInvalid, "public VIFs peer with the Amazon ASN and take no Amazon ASN")
+	case cfg.AmazonASN != 0 && !validAmazonASN(int64(cfg.AmazonASN)):
+		fail(ErrAWSASNInvalid, "Amazon ASN %d must be in 64512-65534 or 4200000000-4294967294", cfg.AmazonASN)
+	case cfg.AmazonASN != 0 && cfg.AmazonASN == cfg.ASN:
+		fail(ErrAWSASNInvalid, "customer and Amazon ASNs must differ")
+	}
+	if n := len(cfg.AuthKey); n != 0 && (n < 6 || n > 80 || strings.ContainsAny(cfg.AuthKey, " \t\n")) {
+		fail(ErrAWSPartnerConfigInvalid, "BGP auth key must be 6-80 characters without whitespace")
+	}
+
+	switch {
+	case cfg.CustomerIPAddress == "" && cfg.AmazonIPAddress == "":
+		if public {
+			fail(ErrAWSAddressInvalid, "public VIFs require customer and Amazon addresses")
+		}
+	default:
+		if err := validateAWSAddressPair(cfg.CustomerIPAddress, cfg.AmazonIPAddress, public); err != nil {
+			fail(ErrAWSAddressInvalid, "%s", err)
+		}
+	}
+
+	switch {
+	case !public && cfg.Prefixes != "":
+		fail(ErrAWSPartnerConfigInvalid, "prefixes are only advertised over public VIFs")
+	case public && cfg.Prefixes == "":
+		fail(ErrAWSPartnerConfigInvalid, "public VIFs require advertised prefixes")
+	case public:
+		for _, s := range strings.Split(cfg.Prefixes, ",") {
+			prefix, err := netip.ParsePrefix(strings.TrimSpace(s))
+			if err != nil || !isPublicAddr(prefix.Addr()) {
+				fail(ErrAWSPartnerConfigInvalid, "advertised prefix %q must be a public network", s)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateAWSAddressPair checks the customer and Amazon addresses are two
+// distinct hosts of the same IPv4 /30 or /31. Public VIFs need public
+// addresses. Private and transit VIFs are not limited to 169.254.0.0/16:
+// that is only the range AWS picks from when the addresses are left unset,
+// and AWS accepts customer-chosen addresses, such as RFC 1918 ones, in its
+// place.
+func validateAWSAddressPair(customer, amazon string, public bool) error {
+	if customer == "" || amazon == "" {
+		return errors.New("set both the customer and Amazon addresses or neither")
+	}
+	c, err := netip.ParsePrefix(customer)
+	if err != nil {
+		return err
+	}
+	a, err := netip.ParsePrefix(amazon)
+	if err != nil {
+		return err
+	}
+	if !c.Addr().Is4() || !a.Addr().Is4() || c.Bits() != a.Bits() || c.Bits() != 30 && c.Bits() != 31 {
+		return fmt.Errorf("%s and %s must both be IPv4 /30 or /31 addresses", customer, amazon)
+	}
+	if c.Masked() != a.Masked() || c.Addr() == a.Addr() {
+		return fmt.Errorf("%s and %s must be different hosts of the same subnet", customer, amazon)
+	}
+	if c.Bits() == 30 {
+		network, broadcast := c.Masked().Addr(), c.Masked().Addr().Next().Next().Next()
+		for _, addr := range []netip.Addr{c.Addr(), a.Addr()} {
+			if addr == network || addr == broadcast {
+				return fmt.Errorf("%s is the network or broadcast address of %s", addr, c.Masked())
+			}
+		}
+	}
+	if public && !isPublicAddr(c.Addr()) {
+		return fmt.Errorf("public VIF address %s must be public", customer)
+	}
+	return nil
+}
+
+// validAmazonASN reports whether asn is a private ASN AWS accepts for a
+// virtual private or Direct Connect gateway.
+func validAmazonASN(asn int64) bool {
+	return asn >= 64512 && asn <= 65534 || asn >= 4200000000 && asn <= 4294967294
+}
+
+// AWSEndpointRequest selects an AWS partner port and builds the B-End of a
+// VXC to it.
+type AWSEndpointRequest struct {
+	// Region matches a substring of the partner port's product name, for
+	// example "us-east-1".
+	Region string
+	// DiversityZone restricts the partner port to one zone.
+	DiversityZone string
+	// LocationID restricts the partner port to one location.
+	LocationID int
+	// MinSpeedMbps requires the partner port to support at least this speed.
+	MinSpeedMbps int
+	// PartnerConfig is built with NewAWSVIFPartnerConfig or
+	// NewAWSHostedConnectionPartnerConfig. Its connect type selects VIF or
+	// hosted connection partner ports.
+	PartnerConfig *VXCPartnerConfigAWS
+}
+
+// AWSEndpoint is the partner port chosen by SelectAWSEndpoint and the B-End
+// of a VXC to it.
+type AWSEndpoint struct {
+	Partner  *PartnerMegaport
+	Endpoint VXCOrderEndpointConfiguration
+}
+
+// SelectAWSEndpoint validates the partner config and chooses the
+// VXC-permitted AWS partner port of its connect type in the region, with the
+// lowest Rank and then product UID. It returns ErrNoPartnerPortsFound when
+// no port matches.
+func (svc *PartnerServiceOp) SelectAWSEndpoint(ctx context.Context, req *AWSEndpointRequest) (*AWSEndpoint, error) {
+	if req == nil {
+		return nil, ErrAWSEndpointRequestNil
+	}
+	if req.Region == "" {
+		return nil, ErrAWSRegionRequired
+	}
+	if err := ValidateAWSPartnerConfig(req.PartnerConfig); err != nil {
+		return nil, err
+	}
+	q := &OnRampQuery{
+		ConnectType:   req.PartnerConfig.ConnectType,
+		Region:        req.Region,
+		DiversityZone: req.DiversityZone,
+		MinSpeedMbps:  req.MinSpeedMbps,
+	}
+	if err := q.validateFilters(); err != nil {
+		return nil, err
+	}
+	partners, err := svc.ListPartnerMegaports(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*PartnerMegaport
+	for _, p := range q.filter(partners) {
+		if req.LocationID == 0 || p.LocationId == req.LocationID {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoPartnerPortsFound
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return partnerRankLess(candidates[i], candidates[j])
+	})
+	p := candidates[0]
+	return &AWSEndpoint{
+		Partner: p,
+		Endpoint: VXCOrderEndpointConfiguration{
+			ProductUID:    p.ProductUID,
+			DiversityZone: p.DiversityZone,
+			PartnerConfig: req.PartnerConfig,
+		},
+	}, nil
+}
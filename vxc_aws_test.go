@@ -0,0 +1,140 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestNewAWSVIFPartnerConfig(t *testing.T) {
+	t.Parallel()
+	private := AWSVIFSpec{OwnerAccount: "123456789012", ASN: 65000, AmazonASN: 64512, CustomerIPAddress: "169.254.10.1/30", AmazonIPAddress: "169.254.10.2/30"}
+	public := AWSVIFSpec{Type: AWS_VIF_PUBLIC, OwnerAccount: "123456789012", ASN: 65000, CustomerIPAddress: "203.0.113.1/30", AmazonIPAddress: "203.0.113.2/30", Prefixes: []string{"198.51.100.0/24"}}
+	with := func(s AWSVIFSpec, edit func(*AWSVIFSpec)) AWSVIFSpec {
+		edit(&s)
+		return s
+	}
+	tests := []struct {
+		name    string
+		spec    AWSVIFSpec
+		wantErr error
+	}{
+		{"private", private, nil},
+		{"private auto addresses", with(private, func(s *AWSVIFSpec) { s.CustomerIPAddress, s.AmazonIPAddress = "", "" }), nil},
+		{"private /31", with(private, func(s *AWSVIFSpec) { s.CustomerIPAddress, s.AmazonIPAddress = "10.0.0.0/31", "10.0.0.1/31" }), nil},
+		{"transit RFC 1918 /30", with(private, func(s *AWSVIFSpec) {
+			s.Type, s.CustomerIPAddress, s.AmazonIPAddress = AWS_VIF_TRANSIT, "192.168.5.1/30", "192.168.5.2/30"
+		}), nil},
+		{"transit 4 byte amazon ASN", with(private, func(s *AWSVIFSpec) { s.Type, s.AmazonASN = AWS_VIF_TRANSIT, 4200000001 }), nil},
+		{"public", public, nil},
+		{"bad type", with(private, func(s *AWSVIFSpec) { s.Type = "hosted" }), ErrAWSPartnerConfigInvalid},
+		{"short account", with(private, func(s *AWSVIFSpec) { s.OwnerAccount = "12345" }), ErrAWSAccountInvalid},
+		{"account with dashes", with(private, func(s *AWSVIFSpec) { s.OwnerAccount = "1234-5678-9012" }), ErrAWSAccountInvalid},
+		{"reserved ASN", with(private, func(s *AWSVIFSpec) { s.ASN = 23456 }), ErrAWSASNInvalid},
+		{"public amazon ASN", with(private, func(s *AWSVIFSpec) { s.AmazonASN = 7224 }), ErrAWSASNInvalid},
+		{"same ASNs", with(private, func(s *AWSVIFSpec) { s.ASN = 64512 }), ErrAWSASNInvalid},
+		{"short auth key", with(private, func(s *AWSVIFSpec) { s.AuthKey = "abc" }), ErrAWSPartnerConfigInvalid},
+		{"one address", with(private, func(s *AWSVIFSpec) { s.AmazonIPAddress = "" }), ErrAWSAddressInvalid},
+		{"different subnets", with(private, func(s *AWSVIFSpec) { s.AmazonIPAddress = "169.254.11.2/30" }), ErrAWSAddressInvalid},
+		{"same address", with(private, func(s *AWSVIFSpec) { s.AmazonIPAddress = s.CustomerIPAddress }), ErrAWSAddressInvalid},
+		{"network address", with(private, func(s *AWSVIFSpec) { s.CustomerIPAddress = "169.254.10.0/30" }), ErrAWSAddressInvalid},
+		{"wrong length", with(private, func(s *AWSVIFSpec) { s.CustomerIPAddress, s.AmazonIPAddress = "169.254.10.1/29", "169.254.10.2/29" }), ErrAWSAddressInvalid},
+		{"private prefixes", with(private, func(s *AWSVIFSpec) { s.Prefixes = []string{"198.51.100.0/24"} }), ErrAWSPartnerConfigInvalid},
+		{"public without ASN", with(public, func(s *AWSVIFSpec) { s.ASN = 0 }), ErrAWSASNInvalid},
+		{"public with amazon ASN", with(public, func(s *AWSVIFSpec) { s.AmazonASN = 64512 }), ErrAWSASNInvalid},
+		{"public without addresses", with(public, func(s *AWSVIFSpec) { s.CustomerIPAddress, s.AmazonIPAddress = "", "" }), ErrAWSAddressInvalid},
+		{"public link-local", with(public, func(s *AWSVIFSpec) { s.CustomerIPAddress, s.AmazonIPAddress = "169.254.10.1/30", "169.254.10.2/30" }), ErrAWSAddressInvalid},
+		{"public without prefixes", with(public, func(s *AWSVIFSpec) { s.Prefixes = nil }), ErrAWSPartnerConfigInvalid},
+		{"public private prefix", with(public, func(s *AWSVIFSpec) { s.Prefixes = []string{"10.0.0.0/8"} }), ErrAWSPartnerConfigInvalid},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := NewAWSVIFPartnerConfig(tc.spec)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if err == nil && (cfg.ConnectType != CONNECT_TYPE_AWS_VIF || cfg.Type == "") {
+				t.Fatalf("cfg = %+v", cfg)
+			}
+		})
+	}
+
+	cfg, err := NewAWSHostedConnectionPartnerConfig("123456789012", "hc")
+	if err != nil || cfg.ConnectType != CONNECT_TYPE_AWS_HOSTED_CONNECTION || cfg.ConnectionName != "hc" {
+		t.Fatalf("hosted connection = %+v, %v", cfg, err)
+	}
+	cfg.ASN = 65000
+	if err := ValidateAWSPartnerConfig(cfg); !errors.Is(err, ErrAWSPartnerConfigInvalid) {
+		t.Fatalf("hosted connection with ASN err = %v", err)
+	}
+	if _, err := NewAWSHostedConnectionPartnerConfig("", "hc"); !errors.Is(err, ErrAWSAccountInvalid) {
+		t.Fatalf("hosted connection without account err = %v", err)
+	}
+}
+
+// AWSEndpointTestSuite tests SelectAWSEndpoint against the partner port list.
+type AWSEndpointTestSuite struct {
+	ClientTestSuite
+}
+
+func TestAWSEndpointTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(AWSEndpointTestSuite))
+}
+
+func (suite *AWSEndpointTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+
+	suite.mux.HandleFunc("/v2/dropdowns/partner/megaports", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "Partner ports", "data": [
+            {"productUid": "vif-red-2", "title": "US East (N. Virginia) (us-east-1)", "connectType": "AWS", "locationId": 1, "diversityZone": "red", "speed": 10000, "rank": 2, "vxcPermitted": true},
+            {"productUid": "vif-red-1", "title": "US East (N. Virginia) (us-east-1)", "connectType": "AWS", "locationId": 1, "diversityZone": "red", "speed": 10000, "rank": 1, "vxcPermitted": true},
+            {"productUid": "vif-blue", "title": "US East (N. Virginia) (us-east-1)", "connectType": "AWS", "locationId": 2, "diversityZone": "blue", "speed": 10000, "rank": 0, "vxcPermitted": true},
+            {"productUid": "hc-blue", "title": "US East (N. Virginia) (us-east-1)", "connectType": "AWSHC", "locationId": 2, "diversityZone": "blue", "speed": 10000, "vxcPermitted": true},
+            {"productUid": "vif-west", "title": "US West (Oregon) (us-west-2)", "connectType": "AWS", "locationId": 3, "diversityZone": "red", "speed": 10000, "vxcPermitted": true}
+        ]}`)
+	})
+}
+
+func (suite *AWSEndpointTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *AWSEndpointTestSuite) TestSelectAWSEndpoint() {
+	ctx := context.Background()
+	vif, err := NewAWSVIFPartnerConfig(AWSVIFSpec{OwnerAccount: "123456789012", ASN: 65000})
+	suite.Require().NoError(err)
+
+	ep, err := suite.client.PartnerService.SelectAWSEndpoint(ctx, &AWSEndpointRequest{Region: "us-east-1", DiversityZone: "red", PartnerConfig: vif})
+	suite.Require().NoError(err)
+	suite.Equal("vif-red-1", ep.Partner.ProductUID)
+	suite.Equal(VXCOrderEndpointConfiguration{ProductUID: "vif-red-1", DiversityZone: "red", PartnerConfig: vif}, ep.Endpoint)
+
+	hc, err := NewAWSHostedConnectionPartnerConfig("123456789012", "")
+	suite.Require().NoError(err)
+	ep, err = suite.client.PartnerService.SelectAWSEndpoint(ctx, &AWSEndpointRequest{Region: "US-EAST-1", PartnerConfig: hc})
+	suite.Require().NoError(err)
+	suite.Equal("hc-blue", ep.Partner.ProductUID)
+
+	_, err = suite.client.PartnerService.SelectAWSEndpoint(ctx, &AWSEndpointRequest{Region: "us-east-1", LocationID: 3, PartnerConfig: vif})
+	suite.ErrorIs(err, ErrNoPartnerPortsFound)
+	_, err = suite.client.PartnerService.SelectAWSEndpoint(ctx, &AWSEndpointRequest{PartnerConfig: vif})
+	suite.ErrorIs(err, ErrAWSRegionRequired)
+	_, err = suite.client.PartnerService.SelectAWSEndpoint(ctx, &AWSEndpointRequest{Region: "us-east-1"})
+	suite.ErrorIs(err, ErrAWSPartnerConfigNil)
+	_, err = suite.client.PartnerService.SelectAWSEndpoint(ctx, &AWSEndpointRequest{Region: "us-east-1", DiversityZone: "green", PartnerConfig: vif})
+	suite.ErrorIs(err, ErrOnRampDiversityZoneBad)
+}
@@ -0,0 +1,289 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Azure ExpressRoute peering types.
+const (
+	AZURE_PEERING_PRIVATE   = "private"
+	AZURE_PEERING_MICROSOFT = "microsoft"
+)
+
+// Azure ExpressRoute helper errors.
+var (
+	ErrAzurePeeringInvalid = errors.New("invalid Azure ExpressRoute peering")
+	// ErrAzurePeeringSubnet is returned for a peering subnet that is not a
+	// /30 IPv4 or /126 IPv6 network.
+	ErrAzurePeeringSubnet = errors.New("Azure peering subnets must be /30 IPv4 or /126 IPv6 networks")
+	// ErrAzurePeeringOverlap is returned when two peering subnets overlap.
+	ErrAzurePeeringOverlap = errors.New("Azure peering subnets overlap")
+	// ErrAzurePeeringASN is returned for a peer ASN that is reserved or out of range.
+	ErrAzurePeeringASN = errors.New("invalid Azure peer ASN")
+	// ErrAzureVXCPairRequestNil is returned when BuildAzureVXCPair is called with a nil request.
+	ErrAzureVXCPairRequestNil = errors.New("Azure VXC pair request cannot be nil")
+	// ErrAzurePortsUnavailable is returned when the service key has no free
+	// primary or secondary port with enough speed.
+	ErrAzurePortsUnavailable = errors.New("ExpressRoute service key has no free primary and secondary ports")
+)
+
+// AzurePeering describes an ExpressRoute private or Microsoft peering.
+type AzurePeering struct {
+	// Type is AZURE_PEERING_PRIVATE or AZURE_PEERING_MICROSOFT.
+	Type string
+	// PeerASN is the customer ASN. It is required for Microsoft peering;
+	// zero leaves it to the MCR ASN for private peering.
+	PeerASN int
+	// PrimarySubnet and SecondarySubnet are the /30 IPv4 or /126 IPv6
+	// networks of the primary and secondary BGP sessions. Microsoft peering
+	// requires public addresses.
+	PrimarySubnet   string
+	SecondarySubnet string
+	// Prefixes are the public prefixes advertised over Microsoft peering.
+	Prefixes []string
+	// SharedKey is the optional BGP MD5 key.
+	SharedKey string
+	// VLAN is the peering VLAN. Zero lets Megaport assign it.
+	VLAN int
+}
+
+// OrderConfig returns the peering as it is sent in a VXC order.
+func (p AzurePeering) OrderConfig() PartnerOrderAzurePeeringConfig {
+	cfg := PartnerOrderAzurePeeringConfig{
+		Type:            strings.ToLower(p.Type),
+		PrimarySubnet:   p.PrimarySubnet,
+		SecondarySubnet: p.SecondarySubnet,
+		Prefixes:        strings.Join(p.Prefixes, ","),
+		SharedKey:       p.SharedKey,
+		VLAN:            p.VLAN,
+	}
+	if p.PeerASN != 0 {
+		cfg.PeerASN = strconv.Itoa(p.PeerASN)
+	}
+	return cfg
+}
+
+// NewAzurePartnerConfig builds the partner config of an ExpressRoute VXC
+// after validating the peerings with ValidateAzurePeerings.
+func NewAzurePartnerConfig(serviceKey string, peerings ...AzurePeering) (*VXCPartnerConfigAzure, error) {
+	if serviceKey == "" {
+		return nil, fmt.Errorf("%w: service key is required", ErrAzurePeeringInvalid)
+	}
+	cfg := &VXCPartnerConfigAzure{ConnectType: PARTNER_AZURE, ServiceKey: serviceKey}
+	for _, p := range peerings {
+		cfg.Peers = append(cfg.Peers, p.OrderConfig())
+	}
+	if err := ValidateAzurePeerings(cfg.Peers); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ValidatePeerings validates the peerings of an existing ExpressRoute
+// connection with ValidateAzurePeerings.
+func (c *CSPConnectionAzure) ValidatePeerings() error {
+	return ValidateAzurePeerings(azurePartnerConfig(c).Peers)
+}
+
+// ValidateAzurePeerings checks each peering's type, subnets, ASN, prefixes
+// and VLAN, and that no two peerings share a type or VLAN or have
+// overlapping subnets. All problems are returned joined, each prefixed with
+// the peering type.
+func ValidateAzurePeerings(peers []PartnerOrderAzurePeeringConfig) error {
+	var errs []error
+	types := map[string]bool{}
+	vlans := map[int]string{}
+	type subnet struct {
+		peering string
+		prefix  netip.Prefix
+	}
+	var subnets []subnet
+	for _, p := range peers {
+		kind := strings.ToLower(p.Type)
+		fail := func(err error, format string, args ...any) {
+			errs = append(errs, fmt.Errorf("%s peering: %w: %s", p.Type, err, fmt.Sprintf(format, args...)))
+		}
+		switch {
+		case kind != AZURE_PEERING_PRIVATE && kind != AZURE_PEERING_MICROSOFT:
+			fail(ErrAzurePeeringInvalid, "type must be %s or %s", AZURE_PEERING_PRIVATE, AZURE_PEERING_MICROSOFT)
+			continue
+		case types[kind]:
+			fail(ErrAzurePeeringInvalid, "duplicate peering type")
+			continue
+		}
+		types[kind] = true
+
+		if p.VLAN != 0 {
+			if p.VLAN < VLAN_MIN || p.VLAN > VLAN_MAX {
+				fail(ErrAzurePeeringInvalid, "VLAN %d is outside %d-%d", p.VLAN, VLAN_MIN, VLAN_MAX)
+			} else if other, ok := vlans[p.VLAN]; ok {
+				fail(ErrAzurePeeringInvalid, "VLAN %d is also used by %s peering", p.VLAN, other)
+			} else {
+				vlans[p.VLAN] = p.Type
+			}
+		}
+
+		if p.PeerASN == "" {
+			if kind == AZURE_PEERING_MICROSOFT {
+				fail(ErrAzurePeeringASN, "peer ASN is required")
+			}
+		} else if asn, err := strconv.ParseInt(p.PeerASN, 10, 64); err != nil || !validAzurePeerASN(asn) {
+			fail(ErrAzurePeeringASN, "%s", p.PeerASN)
+		}
+
+		var pair []netip.Prefix
+		for _, s := range []string{p.PrimarySubnet, p.SecondarySubnet} {
+			prefix, err := parseAzurePeeringSubnet(s)
+			if err != nil {
+				fail(ErrAzurePeeringSubnet, "%s", err)
+				continue
+			}
+			if kind == AZURE_PEERING_MICROSOFT && !isPublicAddr(prefix.Addr()) {
+				fail(ErrAzurePeeringSubnet, "Microsoft peering subnet %s must be public", prefix)
+				continue
+			}
+			pair = append(pair, prefix)
+		}
+		if len(pair) == 2 && pair[0].Addr().Is4() != pair[1].Addr().Is4() {
+			fail(ErrAzurePeeringSubnet, "primary and secondary subnets must be the same address family")
+		}
+		for _, prefix := range pair {
+			for _, other := range subnets {
+				if prefix.Overlaps(other.prefix) {
+					fail(ErrAzurePeeringOverlap, "%s overlaps %s of %s peering", prefix, other.prefix, other.peering)
+				}
+			}
+			subnets = append(subnets, subnet{p.Type, prefix})
+		}
+
+		switch {
+		case kind == AZURE_PEERING_PRIVATE && p.Prefixes != "":
+			fail(ErrAzurePeeringInvalid, "advertised prefixes are only used by Microsoft peering")
+		case kind == AZURE_PEERING_MICROSOFT && p.Prefixes == "":
+			fail(ErrAzurePeeringInvalid, "Microsoft peering requires advertised prefixes")
+		case kind == AZURE_PEERING_MICROSOFT:
+			for _, s := range strings.Split(p.Prefixes, ",") {
+				prefix, err := netip.ParsePrefix(strings.TrimSpace(s))
+				if err != nil || !isPublicAddr(prefix.Addr()) {
+					fail(ErrAzurePeeringInvalid, "advertised prefix %q must be a public network", s)
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func parseAzurePeeringSubnet(s string) (netip.Prefix, error) {
+	if s == "" {
+		return netip.Prefix{}, errors.New("subnet is required")
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	if bits := prefix.Bits(); prefix.Addr().Is4() && bits != 30 || prefix.Addr().Is6() && bits != 126 {
+		return netip.Prefix{}, fmt.Errorf("%s has the wrong prefix length", s)
+	}
+	if prefix.Masked() != prefix {
+		return netip.Prefix{}, fmt.Errorf("%s is not a network address", s)
+	}
+	return prefix, nil
+}
+
+// validAzurePeerASN is validPeerASN excluding the ASNs used by Microsoft.
+func validAzurePeerASN(asn int64) bool {
+	switch {
+	case asn == 8074, asn == 8075, asn == 12076, asn >= 65515 && asn <= 65520:
+		return false
+	}
+	return validPeerASN(asn)
+}
+
+// AzureVXCPairRequest describes a redundant pair of VXCs to an ExpressRoute
+// circuit.
+type AzureVXCPairRequest struct {
+	ServiceKey string
+	// Base is copied into both orders. Its name is suffixed with the port
+	// role and its B-End is replaced.
+	Base BuyVXCRequest
+	// PrimaryAEndUID and SecondaryAEndUID are the A-End products of the
+	// primary and secondary VXCs. An empty SecondaryAEndUID uses the primary.
+	PrimaryAEndUID   string
+	SecondaryAEndUID string
+	Peerings         []AzurePeering
+}
+
+// AzureVXCPair is a pair of VXC orders to the primary and secondary ports of
+// an ExpressRoute circuit.
+type AzureVXCPair struct {
+	Primary   *BuyVXCRequest
+	Secondary *BuyVXCRequest
+}
+
+// BuildAzureVXCPair validates the peerings, looks up the ports of the
+// service key and returns orders for a VXC to each of its free primary and
+// secondary ports with at least Base.RateLimit capacity. Nothing is bought.
+func (svc *VXCServiceOp) BuildAzureVXCPair(ctx context.Context, req *AzureVXCPairRequest) (*AzureVXCPair, error) {
+	if req == nil {
+		return nil, ErrAzureVXCPairRequestNil
+	}
+	if req.PrimaryAEndUID == "" {
+		return nil, fmt.Errorf("%w: primary A-End is required", ErrAzurePeeringInvalid)
+	}
+	partnerConfig, err := NewAzurePartnerConfig(req.ServiceKey, req.Peerings...)
+	if err != nil {
+		return nil, err
+	}
+	lookup, err := svc.ListPartnerPorts(ctx, &ListPartnerPortsRequest{Key: req.ServiceKey, Partner: PARTNER_AZURE})
+	if err != nil {
+		return nil, err
+	}
+	var primary, secondary *PartnerLookupItem
+	for i := range lookup.Data.Megaports {
+		m := &lookup.Data.Megaports[i]
+		if m.VXC != 0 || m.PortSpeed < req.Base.RateLimit {
+			continue
+		}
+		switch {
+		case strings.EqualFold(m.Type, "primary") && primary == nil:
+			primary = m
+		case strings.EqualFold(m.Type, "secondary") && secondary == nil:
+			secondary = m
+		}
+	}
+	if primary == nil || secondary == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAzurePortsUnavailable, req.ServiceKey)
+	}
+	secondaryAEnd := req.SecondaryAEndUID
+	if secondaryAEnd == "" {
+		secondaryAEnd = req.PrimaryAEndUID
+	}
+	// Each order gets its own copy of the base request's tags, MVE config
+	// and the partner config so that editing one leaves the other intact.
+	order := func(aEnd, role string, port *PartnerLookupItem) *BuyVXCRequest {
+		r := req.Base
+		r.PortUID = aEnd
+		r.VXCName = diverseName(req.Base.VXCName, role)
+		r.ResourceTags = maps.Clone(req.Base.ResourceTags)
+		if mve := req.Base.AEndConfiguration.VXCOrderMVEConfig; mve != nil {
+			c := *mve
+			r.AEndConfiguration.VXCOrderMVEConfig = &c
+		}
+		r.AEndConfiguration.ProductUID = aEnd
+		cfg := *partnerConfig
+		cfg.Peers = slices.Clone(partnerConfig.Peers)
+		r.BEndConfiguration = VXCOrderEndpointConfiguration{ProductUID: port.ProductUID, PartnerConfig: &cfg}
+		return &r
+	}
+	return &AzureVXCPair{
+		Primary:   order(req.PrimaryAEndUID, "Primary", primary),
+		Secondary: order(secondaryAEnd, "Secondary", secondary),
+	}, nil
+}
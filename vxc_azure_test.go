@@ -0,0 +1,159 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestValidateAzurePeerings(t *testing.T) {
+	t.Parallel()
+	private := PartnerOrderAzurePeeringConfig{Type: "private", PeerASN: "65000", PrimarySubnet: "10.0.0.0/30", SecondarySubnet: "10.0.0.4/30", VLAN: 100}
+	microsoft := PartnerOrderAzurePeeringConfig{Type: "microsoft", PeerASN: "64900", PrimarySubnet: "203.0.113.0/30", SecondarySubnet: "203.0.113.4/30", Prefixes: "198.51.100.0/24", VLAN: 101}
+	with := func(p PartnerOrderAzurePeeringConfig, edit func(*PartnerOrderAzurePeeringConfig)) PartnerOrderAzurePeeringConfig {
+		edit(&p)
+		return p
+	}
+	tests := []struct {
+		name    string
+		peers   []PartnerOrderAzurePeeringConfig
+		wantErr error
+	}{
+		{"valid", []PartnerOrderAzurePeeringConfig{private, microsoft}, nil},
+		{"ipv6", []PartnerOrderAzurePeeringConfig{with(private, func(p *PartnerOrderAzurePeeringConfig) {
+			p.PrimarySubnet, p.SecondarySubnet = "fd00::/126", "fd00::4/126"
+		})}, nil},
+		{"private without ASN", []PartnerOrderAzurePeeringConfig{with(private, func(p *PartnerOrderAzurePeeringConfig) { p.PeerASN = "" })}, nil},
+		{"bad type", []PartnerOrderAzurePeeringConfig{with(private, func(p *PartnerOrderAzurePeeringConfig) { p.Type = "public" })}, ErrAzurePeeringInvalid},
+		{"duplicate type", []PartnerOrderAzurePeeringConfig{private, with(private, func(p *PartnerOrderAzurePeeringConfig) {
+			p.PrimarySubnet, p.SecondarySubnet, p.VLAN = "10.1.0.0/30", "10.1.0.4/30", 200
+		})}, ErrAzurePeeringInvalid},
+		{"wrong length", []PartnerOrderAzurePeeringConfig{with(private, func(p *PartnerOrderAzurePeeringConfig) { p.PrimarySubnet = "10.0.0.0/29" })}, ErrAzurePeeringSubnet},
+		{"not network", []PartnerOrderAzurePeeringConfig{with(private, func(p *PartnerOrderAzurePeeringConfig) { p.PrimarySubnet = "10.0.0.1/30" })}, ErrAzurePeeringSubnet},
+		{"mixed family", []PartnerOrderAzurePeeringConfig{with(private, func(p *PartnerOrderAzurePeeringConfig) { p.SecondarySubnet = "fd00::/126" })}, ErrAzurePeeringSubnet},
+		{"primary overlaps secondary", []PartnerOrderAzurePeeringConfig{with(private, func(p *PartnerOrderAzurePeeringConfig) { p.SecondarySubnet = p.PrimarySubnet })}, ErrAzurePeeringOverlap},
+		{"overlap across peerings", []PartnerOrderAzurePeeringConfig{with(private, func(p *PartnerOrderAzurePeeringConfig) {
+			p.PrimarySubnet, p.SecondarySubnet = "203.0.113.8/30", "203.0.113.4/30"
+		}), microsoft}, ErrAzurePeeringOverlap},
+		{"microsoft private subnet", []PartnerOrderAzurePeeringConfig{with(microsoft, func(p *PartnerOrderAzurePeeringConfig) { p.PrimarySubnet = "10.9.0.0/30" })}, ErrAzurePeeringSubnet},
+		{"microsoft without ASN", []PartnerOrderAzurePeeringConfig{with(microsoft, func(p *PartnerOrderAzurePeeringConfig) { p.PeerASN = "" })}, ErrAzurePeeringASN},
+		{"microsoft without prefixes", []PartnerOrderAzurePeeringConfig{with(microsoft, func(p *PartnerOrderAzurePeeringConfig) { p.Prefixes = "" })}, ErrAzurePeeringInvalid},
+		{"private prefixes", []PartnerOrderAzurePeeringConfig{with(private, func(p *PartnerOrderAzurePeeringConfig) { p.Prefixes = "198.51.100.0/24" })}, ErrAzurePeeringInvalid},
+		{"reserved ASN", []PartnerOrderAzurePeeringConfig{with(private, func(p *PartnerOrderAzurePeeringConfig) { p.PeerASN = "65515" })}, ErrAzurePeeringASN},
+		{"microsoft ASN", []PartnerOrderAzurePeeringConfig{with(private, func(p *PartnerOrderAzurePeeringConfig) { p.PeerASN = "12076" })}, ErrAzurePeeringASN},
+		{"ASN not a number", []PartnerOrderAzurePeeringConfig{with(private, func(p *PartnerOrderAzurePeeringConfig) { p.PeerASN = "AS65000" })}, ErrAzurePeeringASN},
+		{"same VLAN", []PartnerOrderAzurePeeringConfig{private, with(microsoft, func(p *PartnerOrderAzurePeeringConfig) { p.VLAN = 100 })}, ErrAzurePeeringInvalid},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateAzurePeerings(tc.peers)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+
+	conn := &CSPConnectionAzure{Peers: []CSPConnectionAzurePeeringConfig{
+		{Type: "private", PeerASN: 65000, PrimarySubnet: "10.0.0.0/30", SecondarySubnet: "10.0.0.0/30"},
+	}}
+	if err := conn.ValidatePeerings(); !errors.Is(err, ErrAzurePeeringOverlap) {
+		t.Fatalf("ValidatePeerings = %v, want %v", err, ErrAzurePeeringOverlap)
+	}
+}
+
+// AzureVXCPairTestSuite tests BuildAzureVXCPair against the partner lookup
+// endpoint.
+type AzureVXCPairTestSuite struct {
+	ClientTestSuite
+}
+
+func TestAzureVXCPairTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(AzureVXCPairTestSuite))
+}
+
+func (suite *AzureVXCPairTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+
+	suite.mux.HandleFunc("/v2/secure/azure/sk-1", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprint(w, `{"message": "Lookup", "data": {"service_key": "sk-1", "bandwidth": 1000, "megaports": [
+            {"productUid": "azure-p-used", "type": "primary", "vxc": 7, "portSpeed": 10000},
+            {"productUid": "azure-p", "type": "primary", "portSpeed": 10000},
+            {"productUid": "azure-s", "type": "secondary", "portSpeed": 10000}
+        ]}}`)
+	})
+	suite.mux.HandleFunc("/v2/secure/azure/sk-taken", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "Lookup", "data": {"service_key": "sk-taken", "megaports": [
+            {"productUid": "azure-p", "type": "primary", "portSpeed": 10000},
+            {"productUid": "azure-s", "type": "secondary", "vxc": 8, "portSpeed": 10000}
+        ]}}`)
+	})
+}
+
+func (suite *AzureVXCPairTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *AzureVXCPairTestSuite) TestBuildAzureVXCPair() {
+	ctx := context.Background()
+	peering := AzurePeering{Type: AZURE_PEERING_PRIVATE, PeerASN: 65000, PrimarySubnet: "10.0.0.0/30", SecondarySubnet: "10.0.0.4/30", VLAN: 100}
+	pair, err := suite.client.VXCService.BuildAzureVXCPair(ctx, &AzureVXCPairRequest{
+		ServiceKey: "sk-1",
+		Base: BuyVXCRequest{
+			VXCName: "ER", RateLimit: 1000, Term: 12, ResourceTags: map[string]string{"env": "prod"},
+			AEndConfiguration: VXCOrderEndpointConfiguration{VLAN: 300, VXCOrderMVEConfig: &VXCOrderMVEConfig{NetworkInterfaceIndex: 1}},
+		},
+		PrimaryAEndUID:   "mcr-red",
+		SecondaryAEndUID: "mcr-blue",
+		Peerings:         []AzurePeering{peering},
+	})
+	suite.Require().NoError(err)
+
+	suite.Equal("ER (Primary)", pair.Primary.VXCName)
+	suite.Equal("mcr-red", pair.Primary.PortUID)
+	suite.Equal("mcr-red", pair.Primary.AEndConfiguration.ProductUID)
+	suite.Equal(300, pair.Primary.AEndConfiguration.VLAN)
+	suite.Equal("azure-p", pair.Primary.BEndConfiguration.ProductUID)
+	suite.Equal("ER (Secondary)", pair.Secondary.VXCName)
+	suite.Equal("mcr-blue", pair.Secondary.AEndConfiguration.ProductUID)
+	suite.Equal("azure-s", pair.Secondary.BEndConfiguration.ProductUID)
+	suite.Equal(&VXCPartnerConfigAzure{
+		ConnectType: PARTNER_AZURE,
+		ServiceKey:  "sk-1",
+		Peers: []PartnerOrderAzurePeeringConfig{
+			{Type: "private", PeerASN: "65000", PrimarySubnet: "10.0.0.0/30", SecondarySubnet: "10.0.0.4/30", VLAN: 100},
+		},
+	}, pair.Secondary.BEndConfiguration.PartnerConfig)
+
+	// The orders do not share tags, MVE or partner configs.
+	suite.NotSame(pair.Primary.BEndConfiguration.PartnerConfig, pair.Secondary.BEndConfiguration.PartnerConfig)
+	suite.NotSame(pair.Primary.AEndConfiguration.VXCOrderMVEConfig, pair.Secondary.AEndConfiguration.VXCOrderMVEConfig)
+	pair.Primary.ResourceTags["env"] = "dev"
+	pair.Primary.BEndConfiguration.PartnerConfig.(*VXCPartnerConfigAzure).Peers[0].VLAN = 200
+	suite.Equal("prod", pair.Secondary.ResourceTags["env"])
+	suite.Equal(100, pair.Secondary.BEndConfiguration.PartnerConfig.(*VXCPartnerConfigAzure).Peers[0].VLAN)
+
+	_, err = suite.client.VXCService.BuildAzureVXCPair(ctx, &AzureVXCPairRequest{ServiceKey: "sk-taken", PrimaryAEndUID: "mcr-red"})
+	suite.ErrorIs(err, ErrAzurePortsUnavailable)
+
+	peering.PeerASN = 0xFFFFFFFF
+	_, err = suite.client.VXCService.BuildAzureVXCPair(ctx, &AzureVXCPairRequest{ServiceKey: "sk-1", PrimaryAEndUID: "mcr-red", Peerings: []AzurePeering{peering}})
+	suite.ErrorIs(err, ErrAzurePeeringASN)
+
+	_, err = suite.client.VXCService.BuildAzureVXCPair(ctx, nil)
+	suite.ErrorIs(err, ErrAzureVXCPairRequestNil)
+}
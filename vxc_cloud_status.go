@@ -0,0 +1,372 @@
+package megaport
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// CloudStatusState is the cloud side readiness of a VXC to a cloud provider.
+type CloudStatusState string
+
+const (
+	// CloudStatusPending is a VXC that is not yet configured or live at Megaport.
+	CloudStatusPending CloudStatusState = "PENDING"
+	// CloudStatusAwaitingAcceptance is a VXC the cloud provider has not yet
+	// accepted or attached.
+	CloudStatusAwaitingAcceptance CloudStatusState = "AWAITING_ACCEPTANCE"
+	// CloudStatusMissingPeering is an accepted VXC without the BGP peering
+	// it needs.
+	CloudStatusMissingPeering CloudStatusState = "MISSING_PEERING"
+	// CloudStatusConnected is an accepted VXC whose BGP peering is managed
+	// in the cloud provider and not visible to Megaport.
+	CloudStatusConnected CloudStatusState = "CONNECTED"
+	// CloudStatusBGPConfigured is an accepted VXC with BGP peering configured.
+	CloudStatusBGPConfigured CloudStatusState = "BGP_CONFIGURED"
+)
+
+// CloudStatus is the cloud side readiness of one VXC, evaluated from its CSP
+// connection state and, optionally, a partner lookup of its cloud key.
+type CloudStatus struct {
+	VXCUID  string
+	VXCName string
+	// Provider is PARTNER_AWS, PARTNER_AZURE, PARTNER_GOOGLE, PARTNER_OCI
+	// or PARTNER_IBM.
+	Provider string
+	// ConnectType is the connectType of the VXC's cloud CSP connection.
+	ConnectType string
+	State       CloudStatusState
+	// Details explain the state, e.g. what is missing.
+	Details []string
+	// PartnerLookup is the lookup of the VXC's Azure service key, Google
+	// pairing key or Oracle virtual circuit ID, when one was made.
+	PartnerLookup *PartnerLookup
+	// LookupErr is the error of a failed partner lookup. The state is then
+	// evaluated from the CSP connection alone.
+	LookupErr error
+}
+
+// Ready reports whether the cloud side of the VXC needs no further action.
+func (s *CloudStatus) Ready() bool {
+	return s.State == CloudStatusConnected || s.State == CloudStatusBGPConfigured
+}
+
+func (s *CloudStatus) set(state CloudStatusState, format string, args ...any) {
+	s.State = state
+	if format != "" {
+		s.Details = append(s.Details, fmt.Sprintf(format, args...))
+	}
+}
+
+// EvaluateCloudStatus evaluates the cloud side readiness of a VXC from its
+// CSP connections. When lookup is the partner lookup of the VXC's cloud key
+// it is used to confirm the VXC is attached and, for Azure, which peerings
+// exist on the ExpressRoute circuit. It returns nil for a VXC that does not
+// connect to AWS, Azure, Google, Oracle or IBM.
+func EvaluateCloudStatus(vxc *VXC, lookup *PartnerLookup) *CloudStatus {
+	if vxc == nil {
+		return nil
+	}
+	s := &CloudStatus{VXCUID: vxc.UID, VXCName: vxc.Name, PartnerLookup: lookup}
+	vr := vxc.VirtualRouterConnection()
+	var evaluate func()
+	if c := vxc.AWSConnection(); c != nil {
+		s.Provider, s.ConnectType = PARTNER_AWS, c.ConnectType
+		evaluate = func() { evaluateAWSCloudStatus(s, c, vr) }
+	} else if c := vxc.AWSHostedConnection(); c != nil {
+		s.Provider, s.ConnectType = PARTNER_AWS, c.ConnectType
+		evaluate = func() { evaluateAWSHCCloudStatus(s, c, vr) }
+	} else if c := vxc.AzureConnection(); c != nil {
+		s.Provider, s.ConnectType = PARTNER_AZURE, c.ConnectType
+		evaluate = func() { evaluateAzureCloudStatus(s, vxc, c, vr) }
+	} else if c := vxc.GoogleConnection(); c != nil {
+		s.Provider, s.ConnectType = PARTNER_GOOGLE, c.ConnectType
+		evaluate = func() {
+			if evaluateCloudAttachment(s, vxc, "Google pairing key", c.PairingKey, c.Megaports, googleMegaportVXCs) {
+				evaluateRouterPeering(s, vr, "BGP is configured on the Google Cloud Router")
+			}
+		}
+	} else if c := vxc.OracleConnection(); c != nil {
+		s.Provider, s.ConnectType = PARTNER_OCI, c.ConnectType
+		evaluate = func() {
+			if evaluateCloudAttachment(s, vxc, "Oracle virtual circuit ID", c.VirtualCircuitId, c.Megaports, oracleMegaportVXCs) {
+				evaluateRouterPeering(s, vr, "BGP is configured on the Oracle FastConnect virtual circuit")
+			}
+		}
+	} else if c := vxc.IBMConnection(); c != nil {
+		s.Provider, s.ConnectType = PARTNER_IBM, c.ConnectType
+		evaluate = func() { evaluateIBMCloudStatus(s, c, vr) }
+	} else {
+		return nil
+	}
+
+	if !slices.Contains(SERVICE_STATE_READY, vxc.ProvisioningStatus) {
+		s.set(CloudStatusPending, "VXC is %s", vxc.ProvisioningStatus)
+		return s
+	}
+	evaluate()
+	return s
+}
+
+func evaluateAWSCloudStatus(s *CloudStatus, c *CSPConnectionAWS, vr *CSPConnectionVirtualRouter) {
+	if c.VIFID == "" {
+		s.set(CloudStatusAwaitingAcceptance, "no virtual interface ID reported; accept the VIF in AWS account %s", c.OwnerAccount)
+		return
+	}
+	if c.ASN == 0 && c.PeerASN == 0 || c.AmazonASN == 0 {
+		s.set(CloudStatusMissingPeering, "virtual interface %s has no BGP ASNs", c.VIFID)
+		return
+	}
+	if vr != nil && !mcrBGPConfigured(vr) {
+		s.set(CloudStatusMissingPeering, "MCR end has no BGP connection")
+		return
+	}
+	s.set(CloudStatusBGPConfigured, "")
+}
+
+func evaluateAWSHCCloudStatus(s *CloudStatus, c *CSPConnectionAWSHC, vr *CSPConnectionVirtualRouter) {
+	if c.ConnectionID == "" {
+		s.set(CloudStatusAwaitingAcceptance, "no hosted connection ID reported; accept the connection in AWS account %s", c.OwnerAccount)
+		return
+	}
+	evaluateRouterPeering(s, vr, "virtual interfaces on the hosted connection are managed in AWS")
+}
+
+func evaluateAzureCloudStatus(s *CloudStatus, vxc *VXC, c *CSPConnectionAzure, vr *CSPConnectionVirtualRouter) {
+	if !evaluateCloudAttachment(s, vxc, "ExpressRoute service key", c.ServiceKey, c.Megaports, azureMegaportVXCs) {
+		return
+	}
+	type peering struct{ kind, primarySubnet string }
+	var peerings []peering
+	if s.PartnerLookup != nil {
+		for _, p := range s.PartnerLookup.Peers {
+			peerings = append(peerings, peering{p.Type, p.PrimarySubnet})
+		}
+	} else {
+		for _, p := range c.Peers {
+			peerings = append(peerings, peering{p.Type, p.PrimarySubnet})
+		}
+	}
+	if len(peerings) == 0 {
+		// Without a lookup, peerings configured directly in Azure for a port
+		// end are not visible in the CSP connection.
+		if s.PartnerLookup == nil && vr == nil {
+			s.set(CloudStatusConnected, "ExpressRoute peerings are managed in Azure")
+			return
+		}
+		s.set(CloudStatusMissingPeering, "no ExpressRoute peering is configured")
+		return
+	}
+	for _, p := range peerings {
+		if p.primarySubnet == "" {
+			s.set(CloudStatusMissingPeering, "%s peering has no subnets", p.kind)
+			return
+		}
+	}
+	if vr != nil && !mcrBGPConfigured(vr) {
+		s.set(CloudStatusMissingPeering, "MCR end has no BGP connection")
+		return
+	}
+	s.set(CloudStatusBGPConfigured, "")
+}
+
+func evaluateIBMCloudStatus(s *CloudStatus, c *CSPConnectionIBM, vr *CSPConnectionVirtualRouter) {
+	if c.AccountID == "" {
+		s.set(CloudStatusAwaitingAcceptance, "no IBM Cloud account ID reported")
+		return
+	}
+	if vr != nil {
+		evaluateRouterPeering(s, vr, "")
+		return
+	}
+	if c.CustomerASN == 0 || c.CustomerIPAddress == "" || c.ProviderIPAddress == "" {
+		s.set(CloudStatusMissingPeering, "Direct Link has no customer ASN or BGP addresses")
+		return
+	}
+	s.set(CloudStatusBGPConfigured, "")
+}
+
+// evaluateCloudAttachment checks a key-based cloud connection is attached to
+// the VXC, preferring the partner lookup over the CSP connection's own port
+// list. It reports whether the VXC is attached.
+func evaluateCloudAttachment[M any](s *CloudStatus, vxc *VXC, keyName, key string, megaports []M, megaportVXC func(M) int) bool {
+	if key == "" {
+		s.set(CloudStatusAwaitingAcceptance, "no %s reported", keyName)
+		return false
+	}
+	var vxcIDs []int
+	if s.PartnerLookup != nil {
+		for _, m := range s.PartnerLookup.Megaports {
+			vxcIDs = append(vxcIDs, m.VXC)
+		}
+	} else {
+		for _, m := range megaports {
+			vxcIDs = append(vxcIDs, megaportVXC(m))
+		}
+		if len(vxcIDs) == 0 {
+			// Nothing to compare with, so trust the key.
+			return true
+		}
+	}
+	for _, id := range vxcIDs {
+		if id != 0 && (id == vxc.ID || id == vxc.ServiceID) {
+			return true
+		}
+	}
+	s.set(CloudStatusAwaitingAcceptance, "%s is not attached to this VXC on any partner port", keyName)
+	return false
+}
+
+func azureMegaportVXCs(m CSPConnectionAzureMegaport) int   { return m.VXC }
+func googleMegaportVXCs(m CSPConnectionGoogleMegaport) int { return m.VXC }
+func oracleMegaportVXCs(m CSPConnectionOracleMegaport) int { return m.VXC }
+
+// evaluateRouterPeering sets the state of an accepted VXC whose cloud side
+// peering is not visible to Megaport: BGP configured or missing peering on an
+// MCR end, and connected otherwise.
+func evaluateRouterPeering(s *CloudStatus, vr *CSPConnectionVirtualRouter, connectedDetail string) {
+	switch {
+	case vr == nil:
+		s.set(CloudStatusConnected, connectedDetail)
+	case mcrBGPConfigured(vr):
+		s.set(CloudStatusBGPConfigured, "")
+	default:
+		s.set(CloudStatusMissingPeering, "MCR end has no BGP connection")
+	}
+}
+
+func mcrBGPConfigured(vr *CSPConnectionVirtualRouter) bool {
+	for _, iface := range vr.Interfaces {
+		if len(iface.BGPConnections) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// cloudStatusLookupKey returns the partner and key to look up for a VXC, or
+// empty strings for a cloud without a key-based lookup.
+func cloudStatusLookupKey(vxc *VXC) (partner, key string) {
+	if c := vxc.AzureConnection(); c != nil {
+		return PARTNER_AZURE, c.ServiceKey
+	}
+	if c := vxc.GoogleConnection(); c != nil {
+		return PARTNER_GOOGLE, c.PairingKey
+	}
+	if c := vxc.OracleConnection(); c != nil {
+		return PARTNER_OCI, c.VirtualCircuitId
+	}
+	return "", ""
+}
+
+// CloudStatusOptions configures GetVXCCloudStatus and ListVXCCloudStatuses.
+type CloudStatusOptions struct {
+	// LookupPartner looks up the Azure service key, Google pairing key or
+	// Oracle virtual circuit ID of live VXCs to confirm they are attached.
+	LookupPartner bool
+	// Concurrency bounds parallel partner lookups. Zero uses 4.
+	Concurrency int
+}
+
+// CloudStatusSummary counts cloud VXCs by state and provider.
+type CloudStatusSummary struct {
+	Total      int
+	Ready      int
+	ByState    map[CloudStatusState]int
+	ByProvider map[string]map[CloudStatusState]int
+}
+
+// CloudStatusReport is the result of ListVXCCloudStatuses.
+type CloudStatusReport struct {
+	// Statuses are ordered by VXC UID.
+	Statuses []*CloudStatus
+	Summary  *CloudStatusSummary
+}
+
+// NotReady returns the statuses of VXCs that still need action.
+func (r *CloudStatusReport) NotReady() []*CloudStatus {
+	var out []*CloudStatus
+	for _, s := range r.Statuses {
+		if !s.Ready() {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// SummarizeCloudStatuses counts statuses by state and provider.
+func SummarizeCloudStatuses(statuses []*CloudStatus) *CloudStatusSummary {
+	sum := &CloudStatusSummary{
+		ByState:    map[CloudStatusState]int{},
+		ByProvider: map[string]map[CloudStatusState]int{},
+	}
+	for _, s := range statuses {
+		sum.Total++
+		if s.Ready() {
+			sum.Ready++
+		}
+		sum.ByState[s.State]++
+		if sum.ByProvider[s.Provider] == nil {
+			sum.ByProvider[s.Provider] = map[CloudStatusState]int{}
+		}
+		sum.ByProvider[s.Provider][s.State]++
+	}
+	return sum
+}
+
+// GetVXCCloudStatus gets a VXC and evaluates its cloud side readiness. It
+// returns ErrCSPConnectionNotFound for a VXC that does not connect to a
+// cloud provider.
+func (svc *VXCServiceOp) GetVXCCloudStatus(ctx context.Context, id string, opts *CloudStatusOptions) (*CloudStatus, error) {
+	vxc, err := svc.GetVXC(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s := svc.cloudStatus(ctx, vxc, opts)
+	if s == nil {
+		return nil, fmt.Errorf("%w: %s", ErrCSPConnectionNotFound, id)
+	}
+	return s, nil
+}
+
+// ListVXCCloudStatuses evaluates the cloud side readiness of every active
+// VXC that connects to a cloud provider and summarizes the fleet. Failed
+// partner lookups are recorded on each status rather than returned.
+func (svc *VXCServiceOp) ListVXCCloudStatuses(ctx context.Context, opts *CloudStatusOptions) (*CloudStatusReport, error) {
+	vxcs, err := svc.ListVXCs(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	vxcs = slices.DeleteFunc(vxcs, func(v *VXC) bool {
+		return v.ProvisioningStatus == STATUS_DECOMMISSIONED || v.ProvisioningStatus == STATUS_CANCELLED
+	})
+	if opts == nil {
+		opts = &CloudStatusOptions{}
+	}
+	statuses := make([]*CloudStatus, len(vxcs))
+	forEachConcurrently(len(vxcs), opts.Concurrency, func(i int) {
+		statuses[i] = svc.cloudStatus(ctx, vxcs[i], opts)
+	})
+	statuses = slices.DeleteFunc(statuses, func(s *CloudStatus) bool { return s == nil })
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].VXCUID < statuses[j].VXCUID })
+	return &CloudStatusReport{Statuses: statuses, Summary: SummarizeCloudStatuses(statuses)}, nil
+}
+
+func (svc *VXCServiceOp) cloudStatus(ctx context.Context, vxc *VXC, opts *CloudStatusOptions) *CloudStatus {
+	s := EvaluateCloudStatus(vxc, nil)
+	if s == nil || s.State == CloudStatusPending || opts == nil || !opts.LookupPartner {
+		return s
+	}
+	partner, key := cloudStatusLookupKey(vxc)
+	if key == "" {
+		return s
+	}
+	res, err := svc.ListPartnerPorts(ctx, &ListPartnerPortsRequest{Partner: partner, Key: key})
+	if err != nil {
+		s.LookupErr = err
+		s.Details = append(s.Details, fmt.Sprintf("partner lookup failed: %v", err))
+		return s
+	}
+	return EvaluateCloudStatus(vxc, &res.Data)
+}
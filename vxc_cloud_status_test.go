@@ -0,0 +1,158 @@
+package megaport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEvaluateCloudStatus(t *testing.T) {
+	t.Parallel()
+	const mcrBGP = `{"connectType": "VROUTER", "interfaces": [{"ipAddresses": ["10.0.0.1/30"], "bgpConnections": [{"peerAsn": 64512}]}]}`
+	const mcrNoBGP = `{"connectType": "VROUTER", "interfaces": [{"ipAddresses": ["10.0.0.1/30"]}]}`
+	tests := []struct {
+		name      string
+		status    string
+		csp       string
+		lookup    *PartnerLookup
+		wantState CloudStatusState
+		wantNil   bool
+	}{
+		{name: "not cloud", csp: mcrBGP, wantNil: true},
+		{name: "pending", status: "DEPLOYABLE", csp: `{"connectType": "AWS", "vif_id": "dxvif-1"}`, wantState: CloudStatusPending},
+		{name: "aws no vif", csp: `{"connectType": "AWS", "ownerAccount": "123"}`, wantState: CloudStatusAwaitingAcceptance},
+		{name: "aws no asn", csp: `{"connectType": "AWS", "vif_id": "dxvif-1"}`, wantState: CloudStatusMissingPeering},
+		{name: "aws bgp", csp: `{"connectType": "AWS", "vif_id": "dxvif-1", "peerAsn": 65000, "amazonAsn": 64512}`, wantState: CloudStatusBGPConfigured},
+		{name: "aws mcr no bgp", csp: `[` + mcrNoBGP + `, {"connectType": "AWS", "vif_id": "dxvif-1", "peerAsn": 65000, "amazonAsn": 64512}]`, wantState: CloudStatusMissingPeering},
+		{name: "aws hosted pending", csp: `{"connectType": "AWSHC", "ownerAccount": "123"}`, wantState: CloudStatusAwaitingAcceptance},
+		{name: "aws hosted port", csp: `{"connectType": "AWSHC", "connectionId": "dxcon-1"}`, wantState: CloudStatusConnected},
+		{name: "aws hosted mcr", csp: `[` + mcrBGP + `, {"connectType": "AWSHC", "connectionId": "dxcon-1"}]`, wantState: CloudStatusBGPConfigured},
+		{name: "azure port", csp: `{"connectType": "AZURE", "service_key": "sk"}`, wantState: CloudStatusConnected},
+		{name: "azure mcr no peers", csp: `[` + mcrBGP + `, {"connectType": "AZURE", "service_key": "sk"}]`, wantState: CloudStatusMissingPeering},
+		{name: "azure peers", csp: `{"connectType": "AZURE", "service_key": "sk", "peers": [{"type": "private", "primary_subnet": "10.0.0.0/30"}]}`, wantState: CloudStatusBGPConfigured},
+		{name: "azure incomplete peer", csp: `{"connectType": "AZURE", "service_key": "sk", "peers": [{"type": "private"}]}`, wantState: CloudStatusMissingPeering},
+		{name: "azure other vxc", csp: `{"connectType": "AZURE", "service_key": "sk", "megaports": [{"port": 1, "vxc": 99}]}`, wantState: CloudStatusAwaitingAcceptance},
+		{
+			name:      "azure lookup without peering",
+			csp:       `{"connectType": "AZURE", "service_key": "sk", "peers": [{"type": "private", "primary_subnet": "10.0.0.0/30"}]}`,
+			lookup:    &PartnerLookup{Megaports: []PartnerLookupItem{{VXC: 10}}},
+			wantState: CloudStatusMissingPeering,
+		},
+		{
+			name:      "azure lookup not attached",
+			csp:       `{"connectType": "AZURE", "service_key": "sk"}`,
+			lookup:    &PartnerLookup{Megaports: []PartnerLookupItem{{VXC: 0}, {VXC: 11}}},
+			wantState: CloudStatusAwaitingAcceptance,
+		},
+		{name: "google no key", csp: `{"connectType": "GOOGLE"}`, wantState: CloudStatusAwaitingAcceptance},
+		{name: "google attached", csp: `{"connectType": "GOOGLE", "pairingKey": "pk", "megaports": [{"port": 1, "vxc": 10}]}`, wantState: CloudStatusConnected},
+		{name: "oracle mcr no bgp", csp: `[` + mcrNoBGP + `, {"connectType": "ORACLE", "virtualCircuitId": "ocid1"}]`, wantState: CloudStatusMissingPeering},
+		{name: "ibm", csp: `{"connectType": "IBM", "account_id": "a", "customer_asn": 65000, "customer_ip_address": "169.254.0.1/30", "provider_ip_address": "169.254.0.2/30"}`, wantState: CloudStatusBGPConfigured},
+		{name: "ibm no account", csp: `{"connectType": "IBM"}`, wantState: CloudStatusAwaitingAcceptance},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			vxc := decodeCSPTestVXC(t, tc.csp)
+			vxc.ID, vxc.ProvisioningStatus = 10, SERVICE_LIVE
+			if tc.status != "" {
+				vxc.ProvisioningStatus = tc.status
+			}
+			got := EvaluateCloudStatus(vxc, tc.lookup)
+			if tc.wantNil {
+				if got != nil {
+					t.Fatalf("got %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.State != tc.wantState {
+				t.Fatalf("got %+v, want state %s", got, tc.wantState)
+			}
+			if !got.Ready() && len(got.Details) == 0 {
+				t.Fatal("a status that is not ready must explain why")
+			}
+		})
+	}
+}
+
+// CloudStatusTestSuite tests the fleet cloud status report against the
+// products and partner lookup endpoints.
+type CloudStatusTestSuite struct {
+	ClientTestSuite
+}
+
+func TestCloudStatusTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(CloudStatusTestSuite))
+}
+
+func (suite *CloudStatusTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+
+	suite.mux.HandleFunc("/v2/products", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "Products", "data": [{"productUid": "port-1", "productType": "MEGAPORT", "provisioningStatus": "LIVE", "associatedVxcs": [
+            {"productId": 1, "productUid": "vxc-aws", "provisioningStatus": "LIVE", "resources": {"csp_connection": {"connectType": "AWS", "ownerAccount": "123"}}},
+            {"productId": 2, "productUid": "vxc-azure", "provisioningStatus": "LIVE", "resources": {"csp_connection": {"connectType": "AZURE", "service_key": "sk-2"}}},
+            {"productId": 3, "productUid": "vxc-google", "provisioningStatus": "LIVE", "resources": {"csp_connection": {"connectType": "GOOGLE", "pairingKey": "pk-3"}}},
+            {"productId": 4, "productUid": "vxc-plain", "provisioningStatus": "LIVE", "resources": {}},
+            {"productId": 5, "productUid": "vxc-gone", "provisioningStatus": "DECOMMISSIONED", "resources": {"csp_connection": {"connectType": "AWS"}}}
+        ]}]}`)
+	})
+	suite.mux.HandleFunc("/v2/secure/azure/sk-2", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodGet)
+		fmt.Fprint(w, `{"message": "Lookup", "data": {"service_key": "sk-2", "megaports": [{"port": 1, "vxc": 2}, {"port": 2}],
+            "peers": [{"type": "private", "peer_asn": 65000, "primary_subnet": "10.0.0.0/30", "secondary_subnet": "10.0.0.4/30"}]}}`)
+	})
+	suite.mux.HandleFunc("/v2/product/vxc-plain", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "VXC", "data": {"productId": 4, "productUid": "vxc-plain", "provisioningStatus": "LIVE"}}`)
+	})
+	suite.mux.HandleFunc("/v2/secure/google/pk-3", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"message": "Invalid pairing key"}`)
+	})
+}
+
+func (suite *CloudStatusTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *CloudStatusTestSuite) TestListVXCCloudStatuses() {
+	ctx := context.Background()
+	report, err := suite.client.VXCService.ListVXCCloudStatuses(ctx, &CloudStatusOptions{LookupPartner: true})
+	suite.Require().NoError(err)
+	suite.Require().Len(report.Statuses, 3)
+
+	aws, azure, google := report.Statuses[0], report.Statuses[1], report.Statuses[2]
+	suite.Equal("vxc-aws", aws.VXCUID)
+	suite.Equal(CloudStatusAwaitingAcceptance, aws.State)
+	suite.Equal(CloudStatusBGPConfigured, azure.State)
+	suite.NotNil(azure.PartnerLookup)
+	suite.Equal(CloudStatusConnected, google.State)
+	suite.Error(google.LookupErr)
+
+	suite.Equal(&CloudStatusSummary{
+		Total:   3,
+		Ready:   2,
+		ByState: map[CloudStatusState]int{CloudStatusAwaitingAcceptance: 1, CloudStatusBGPConfigured: 1, CloudStatusConnected: 1},
+		ByProvider: map[string]map[CloudStatusState]int{
+			PARTNER_AWS:    {CloudStatusAwaitingAcceptance: 1},
+			PARTNER_AZURE:  {CloudStatusBGPConfigured: 1},
+			PARTNER_GOOGLE: {CloudStatusConnected: 1},
+		},
+	}, report.Summary)
+	suite.Equal([]*CloudStatus{aws}, report.NotReady())
+
+	_, err = suite.client.VXCService.GetVXCCloudStatus(ctx, "vxc-plain", nil)
+	suite.ErrorIs(err, ErrCSPConnectionNotFound)
+}
@@ -0,0 +1,206 @@
+package megaport
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var (
+	// ErrCSPConnectionNotFound is returned when a VXC has no cloud CSP connection.
+	ErrCSPConnectionNotFound = errors.New("VXC has no cloud CSP connection")
+	// ErrCSPConnectionUnsupported is returned when a CSP connection has no order-side partner config.
+	ErrCSPConnectionUnsupported = errors.New("CSP connection type has no equivalent partner config")
+)
+
+// cspConnections returns the decoded CSP connections of the VXC.
+func (v *VXC) cspConnections() []CSPConnectionConfig {
+	if v == nil || v.Resources == nil || v.Resources.CSPConnection == nil {
+		return nil
+	}
+	return v.Resources.CSPConnection.CSPConnection
+}
+
+// findCSPConnection returns the first CSP connection of type T, which the
+// decoder stores by value but callers may have set by pointer.
+func findCSPConnection[T any](v *VXC) *T {
+	for _, c := range v.cspConnections() {
+		switch t := any(c).(type) {
+		case T:
+			return &t
+		case *T:
+			if t != nil {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// AWSConnection returns the AWS Virtual Interface state of the VXC, or nil.
+func (v *VXC) AWSConnection() *CSPConnectionAWS {
+	return findCSPConnection[CSPConnectionAWS](v)
+}
+
+// AWSHostedConnection returns the AWS Hosted Connection state of the VXC, or nil.
+func (v *VXC) AWSHostedConnection() *CSPConnectionAWSHC {
+	return findCSPConnection[CSPConnectionAWSHC](v)
+}
+
+// AzureConnection returns the Azure ExpressRoute state of the VXC, or nil.
+func (v *VXC) AzureConnection() *CSPConnectionAzure {
+	return findCSPConnection[CSPConnectionAzure](v)
+}
+
+// GoogleConnection returns the Google Cloud Interconnect state of the VXC, or nil.
+func (v *VXC) GoogleConnection() *CSPConnectionGoogle {
+	return findCSPConnection[CSPConnectionGoogle](v)
+}
+
+// OracleConnection returns the Oracle FastConnect state of the VXC, or nil.
+func (v *VXC) OracleConnection() *CSPConnectionOracle {
+	return findCSPConnection[CSPConnectionOracle](v)
+}
+
+// IBMConnection returns the IBM Cloud Direct Link state of the VXC, or nil.
+func (v *VXC) IBMConnection() *CSPConnectionIBM {
+	return findCSPConnection[CSPConnectionIBM](v)
+}
+
+// TransitConnection returns the Megaport Internet state of the VXC, or nil.
+func (v *VXC) TransitConnection() *CSPConnectionTransit {
+	return findCSPConnection[CSPConnectionTransit](v)
+}
+
+// VirtualRouterConnection returns the MCR virtual router state of the VXC, or nil.
+func (v *VXC) VirtualRouterConnection() *CSPConnectionVirtualRouter {
+	return findCSPConnection[CSPConnectionVirtualRouter](v)
+}
+
+// CloudPartnerConfig returns the order-side partner config reproducing the
+// VXC's cloud end, converted from its first CSP connection other than an
+// MCR virtual router. It returns ErrCSPConnectionNotFound when there is none.
+func (v *VXC) CloudPartnerConfig() (VXCPartnerConfiguration, error) {
+	for _, c := range v.cspConnections() {
+		switch c.(type) {
+		case CSPConnectionVirtualRouter, *CSPConnectionVirtualRouter:
+			continue
+		}
+		return PartnerConfigFromCSPConnection(c)
+	}
+	return nil, ErrCSPConnectionNotFound
+}
+
+// PartnerConfigFromCSPConnection converts the observed state of a CSP
+// connection into the partner config that would order the same connection,
+// for drift detection and for importing existing VXCs. Values the API
+// generates, such as VIF IDs and bandwidth lists, are dropped; secrets the
+// API does not return, such as Azure shared keys, are left empty.
+func PartnerConfigFromCSPConnection(c CSPConnectionConfig) (VXCPartnerConfiguration, error) {
+	switch t := c.(type) {
+	case CSPConnectionAWS:
+		return awsPartnerConfig(&t), nil
+	case *CSPConnectionAWS:
+		return awsPartnerConfig(t), nil
+	case CSPConnectionAWSHC:
+		return awsHCPartnerConfig(&t), nil
+	case *CSPConnectionAWSHC:
+		return awsHCPartnerConfig(t), nil
+	case CSPConnectionAzure:
+		return azurePartnerConfig(&t), nil
+	case *CSPConnectionAzure:
+		return azurePartnerConfig(t), nil
+	case CSPConnectionGoogle:
+		return &VXCPartnerConfigGoogle{ConnectType: PARTNER_GOOGLE, PairingKey: t.PairingKey}, nil
+	case *CSPConnectionGoogle:
+		return &VXCPartnerConfigGoogle{ConnectType: PARTNER_GOOGLE, PairingKey: t.PairingKey}, nil
+	case CSPConnectionOracle:
+		return &VXCPartnerConfigOracle{ConnectType: PARTNER_OCI, VirtualCircuitId: t.VirtualCircuitId}, nil
+	case *CSPConnectionOracle:
+		return &VXCPartnerConfigOracle{ConnectType: PARTNER_OCI, VirtualCircuitId: t.VirtualCircuitId}, nil
+	case CSPConnectionIBM:
+		return ibmPartnerConfig(&t), nil
+	case *CSPConnectionIBM:
+		return ibmPartnerConfig(t), nil
+	case CSPConnectionTransit, *CSPConnectionTransit:
+		return &VXCPartnerConfigTransit{ConnectType: connectTypeTransit}, nil
+	case CSPConnectionVirtualRouter:
+		return vrouterPartnerConfig(&t), nil
+	case *CSPConnectionVirtualRouter:
+		return vrouterPartnerConfig(t), nil
+	}
+	return nil, fmt.Errorf("%w: %T", ErrCSPConnectionUnsupported, c)
+}
+
+func awsPartnerConfig(c *CSPConnectionAWS) *VXCPartnerConfigAWS {
+	asn := c.ASN
+	if asn == 0 {
+		asn = c.PeerASN
+	}
+	customerIP := c.CustomerIPAddress
+	if customerIP == "" {
+		customerIP = c.CustomerAddress
+	}
+	return &VXCPartnerConfigAWS{
+		ConnectType:       CONNECT_TYPE_AWS_VIF,
+		Type:              c.Type,
+		OwnerAccount:      c.OwnerAccount,
+		ASN:               asn,
+		AmazonASN:         c.AmazonASN,
+		AuthKey:           c.AuthKey,
+		CustomerIPAddress: customerIP,
+		AmazonIPAddress:   c.AmazonAddress,
+		ConnectionName:    c.Name,
+	}
+}
+
+func awsHCPartnerConfig(c *CSPConnectionAWSHC) *VXCPartnerConfigAWS {
+	return &VXCPartnerConfigAWS{
+		ConnectType:    CONNECT_TYPE_AWS_HOSTED_CONNECTION,
+		OwnerAccount:   c.OwnerAccount,
+		ConnectionName: c.Name,
+	}
+}
+
+func azurePartnerConfig(c *CSPConnectionAzure) *VXCPartnerConfigAzure {
+	cfg := &VXCPartnerConfigAzure{ConnectType: PARTNER_AZURE, ServiceKey: c.ServiceKey}
+	for _, p := range c.Peers {
+		peer := PartnerOrderAzurePeeringConfig{
+			Type:            p.Type,
+			PrimarySubnet:   p.PrimarySubnet,
+			SecondarySubnet: p.SecondarySubnet,
+			Prefixes:        p.Prefixes,
+			SharedKey:       p.SharedKey,
+			VLAN:            p.VLAN,
+		}
+		if p.PeerASN != 0 {
+			peer.PeerASN = strconv.Itoa(p.PeerASN)
+		}
+		cfg.Peers = append(cfg.Peers, peer)
+	}
+	return cfg
+}
+
+func ibmPartnerConfig(c *CSPConnectionIBM) *VXCPartnerConfigIBM {
+	return &VXCPartnerConfigIBM{
+		ConnectType:       PARTNER_IBM,
+		AccountID:         c.AccountID,
+		CustomerASN:       c.CustomerASN,
+		CustomerIPAddress: c.CustomerIPAddress,
+		ProviderIPAddress: c.ProviderIPAddress,
+	}
+}
+
+func vrouterPartnerConfig(c *CSPConnectionVirtualRouter) *VXCOrderVrouterPartnerConfig {
+	cfg := &VXCOrderVrouterPartnerConfig{}
+	for _, iface := range c.Interfaces {
+		cfg.Interfaces = append(cfg.Interfaces, PartnerConfigInterface{
+			IpAddresses:    iface.IPAddresses,
+			IpRoutes:       iface.IPRoutes,
+			NatIpAddresses: iface.NatIPAddresses,
+			Bfd:            iface.BFD,
+			BgpConnections: iface.BGPConnections,
+		})
+	}
+	return cfg
+}
@@ -0,0 +1,132 @@
+package megaport
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func decodeCSPTestVXC(t *testing.T, cspConnection string) *VXC {
+	t.Helper()
+	vxc := &VXC{}
+	if err := json.Unmarshal([]byte(`{"productUid": "vxc-1", "resources": {"csp_connection": `+cspConnection+`}}`), vxc); err != nil {
+		t.Fatal(err)
+	}
+	return vxc
+}
+
+func TestVXCCSPConnectionAccessors(t *testing.T) {
+	t.Parallel()
+	vxc := decodeCSPTestVXC(t, `[
+        {"connectType": "VROUTER", "resource_type": "a_csp_connection", "vlan": 100,
+            "interfaces": [{"ipAddresses": ["10.0.0.1/30"], "natIpAddresses": ["10.0.1.1"]}]},
+        {"connectType": "AWS", "resource_type": "b_csp_connection", "type": "private", "ownerAccount": "123456789012",
+            "peerAsn": 65000, "amazonAsn": 64512, "customer_address": "10.0.0.1/30", "amazon_address": "10.0.0.2/30",
+            "name": "vif-1", "vif_id": "dxvif-abc"}
+    ]`)
+
+	aws := vxc.AWSConnection()
+	if aws == nil || aws.OwnerAccount != "123456789012" {
+		t.Fatalf("AWSConnection = %+v", aws)
+	}
+	if vr := vxc.VirtualRouterConnection(); vr == nil || vr.VLAN != 100 {
+		t.Fatalf("VirtualRouterConnection = %+v", vr)
+	}
+	if azure := vxc.AzureConnection(); azure != nil {
+		t.Fatalf("AzureConnection = %+v, want nil", azure)
+	}
+	if ibm := (*VXC)(nil).IBMConnection(); ibm != nil {
+		t.Fatalf("IBMConnection on nil VXC = %+v", ibm)
+	}
+
+	cfg, err := vxc.CloudPartnerConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &VXCPartnerConfigAWS{
+		ConnectType:       CONNECT_TYPE_AWS_VIF,
+		Type:              "private",
+		OwnerAccount:      "123456789012",
+		ASN:               65000,
+		AmazonASN:         64512,
+		CustomerIPAddress: "10.0.0.1/30",
+		AmazonIPAddress:   "10.0.0.2/30",
+		ConnectionName:    "vif-1",
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("CloudPartnerConfig = %+v, want %+v", cfg, want)
+	}
+
+	onlyVRouter := decodeCSPTestVXC(t, `{"connectType": "VROUTER", "resource_type": "a_csp_connection"}`)
+	if _, err := onlyVRouter.CloudPartnerConfig(); !errors.Is(err, ErrCSPConnectionNotFound) {
+		t.Fatalf("CloudPartnerConfig err = %v, want %v", err, ErrCSPConnectionNotFound)
+	}
+}
+
+func TestPartnerConfigFromCSPConnection(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		cspConnection string
+		want          VXCPartnerConfiguration
+		wantErr       error
+	}{
+		{
+			name: "azure",
+			cspConnection: `{"connectType": "AZURE", "service_key": "key-1", "vlan": 0, "peers": [
+                {"type": "private", "peer_asn": 65001, "primary_subnet": "10.0.0.0/30", "secondary_subnet": "10.0.0.4/30", "vlan": 200},
+                {"type": "microsoft", "primary_subnet": "1.1.1.0/30", "secondary_subnet": "1.1.1.4/30", "prefixes": "1.1.2.0/24", "vlan": 201}
+            ]}`,
+			want: &VXCPartnerConfigAzure{ConnectType: PARTNER_AZURE, ServiceKey: "key-1", Peers: []PartnerOrderAzurePeeringConfig{
+				{Type: "private", PeerASN: "65001", PrimarySubnet: "10.0.0.0/30", SecondarySubnet: "10.0.0.4/30", VLAN: 200},
+				{Type: "microsoft", PrimarySubnet: "1.1.1.0/30", SecondarySubnet: "1.1.1.4/30", Prefixes: "1.1.2.0/24", VLAN: 201},
+			}},
+		},
+		{
+			name:          "ibm single object",
+			cspConnection: `{"connectType": "IBM", "account_id": "abc", "customer_asn": 65002, "customer_ip_address": "169.254.0.1/30", "provider_ip_address": "169.254.0.2/30"}`,
+			want:          &VXCPartnerConfigIBM{ConnectType: "IBM", AccountID: "abc", CustomerASN: 65002, CustomerIPAddress: "169.254.0.1/30", ProviderIPAddress: "169.254.0.2/30"},
+		},
+		{
+			name:          "aws hosted connection",
+			cspConnection: `{"connectType": "AWSHC", "ownerAccount": "123456789012", "name": "hc-1", "connectionId": "dxcon-abc"}`,
+			want:          &VXCPartnerConfigAWS{ConnectType: CONNECT_TYPE_AWS_HOSTED_CONNECTION, OwnerAccount: "123456789012", ConnectionName: "hc-1"},
+		},
+		{
+			name:          "google",
+			cspConnection: `{"connectType": "GOOGLE", "pairingKey": "pk-1/us-west2/1", "bandwidths": [50, 100]}`,
+			want:          &VXCPartnerConfigGoogle{ConnectType: PARTNER_GOOGLE, PairingKey: "pk-1/us-west2/1"},
+		},
+		{
+			name:          "oracle",
+			cspConnection: `{"connectType": "ORACLE", "virtualCircuitId": "ocid1.virtualcircuit.oc1"}`,
+			want:          &VXCPartnerConfigOracle{ConnectType: PARTNER_OCI, VirtualCircuitId: "ocid1.virtualcircuit.oc1"},
+		},
+		{
+			name:          "other",
+			cspConnection: `{"connectType": "SOMETHING_NEW"}`,
+			wantErr:       ErrCSPConnectionUnsupported,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			vxc := decodeCSPTestVXC(t, tc.cspConnection)
+			got, err := vxc.CloudPartnerConfig()
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+
+	// Connections set by pointer convert the same as decoded ones.
+	got, err := PartnerConfigFromCSPConnection(&CSPConnectionTransit{ConnectType: connectTypeTransit})
+	if err != nil || !reflect.DeepEqual(got, &VXCPartnerConfigTransit{ConnectType: connectTypeTransit}) {
+		t.Fatalf("transit = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,434 @@
+package megaport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMoveVXCTimeout      = 15 * time.Minute
+	defaultMoveVXCPollInterval = 15 * time.Second
+	defaultMoveVXCBGPTimeout   = 5 * time.Minute
+)
+
+var (
+	// ErrMoveVXCRequestNil is returned when MoveVXC is called with a nil request.
+	ErrMoveVXCRequestNil = errors.New("move VXC request cannot be nil")
+	// ErrMoveVXCNoChange is returned when a move request does not change either end.
+	ErrMoveVXCNoChange = errors.New("move VXC request does not change either end")
+	// ErrMoveVXCTargetNotReady is returned when the new end is not live.
+	ErrMoveVXCTargetNotReady = errors.New("move target is not ready")
+	// ErrMoveVXCTargetType is returned when the new end is not a port, MCR or MVE.
+	ErrMoveVXCTargetType = errors.New("move target must be a port, MCR or MVE")
+	// ErrMoveVXCLocation is returned when the new end is in a different location from the current one.
+	ErrMoveVXCLocation = errors.New("move target is in a different location")
+	// ErrMoveVXCRateLimit is returned when the VXC rate limit exceeds the speed of the new end.
+	ErrMoveVXCRateLimit = errors.New("VXC rate limit exceeds the move target speed")
+	// ErrMoveVXCTimeout is returned when the VXC does not become ready on its new end in time.
+	ErrMoveVXCTimeout = errors.New("timed out waiting for VXC move")
+	// ErrMoveVXCBGPDown is returned when BGP sessions that were up before the move do not come back up.
+	ErrMoveVXCBGPDown = errors.New("BGP sessions did not recover after VXC move")
+)
+
+// MoveVXCEnd describes the new location of one end of a VXC.
+type MoveVXCEnd struct {
+	// ProductUID is the port, MCR or MVE the end moves to.
+	ProductUID string
+	// VLAN for the end on its new product. Nil keeps the current VLAN;
+	// VLAN_AUTO_ASSIGN and VLAN_UNTAGGED are passed to the API unchanged.
+	VLAN *int
+	// InnerVLAN for the end on its new product. Nil keeps the current one.
+	InnerVLAN *int
+	// VNICIndex selects the vNIC when ProductUID is an MVE. Nil keeps the
+	// current index.
+	VNICIndex *int
+}
+
+// MoveVXCRequest is a request to re-home one or both ends of a VXC.
+type MoveVXCRequest struct {
+	AEnd *MoveVXCEnd
+	BEnd *MoveVXCEnd
+
+	// AllowLocationChange permits a new end in a different location from
+	// the current one.
+	AllowLocationChange bool
+	// SkipBGPCheck skips the looking glass check of MCR ends after the move.
+	SkipBGPCheck bool
+	// DisableRollback leaves the VXC on its new ends when the move fails.
+	DisableRollback bool
+
+	// Timeout bounds the wait for the VXC to be ready after the move and
+	// after a rollback. The default is 15 minutes.
+	Timeout time.Duration
+	// BGPTimeout bounds the wait for BGP sessions to come back up. The
+	// default is 5 minutes.
+	BGPTimeout time.Duration
+	// PollInterval is the delay between status checks. The default is 15
+	// seconds.
+	PollInterval time.Duration
+}
+
+// MoveVXCResult records a VXC move.
+type MoveVXCResult struct {
+	// Before and After are the VXC before the move and after the workflow
+	// finished, which is on the original ends when the move was rolled back.
+	Before *VXC
+	After  *VXC
+	// BGPSessionsBefore lists the sessions on the VXC's MCR ends that were up
+	// before the move; BGPSessionsAfter lists the same sessions afterwards.
+	BGPSessionsBefore []*LookingGlassBGPSession
+	BGPSessionsAfter  []*LookingGlassBGPSession
+	// RolledBack is set when the move failed and the VXC was moved back.
+	RolledBack bool
+	// RollbackErr is set when the rollback itself failed.
+	RollbackErr error
+}
+
+// moveVXCEnd is the planned state of one VXC end.
+type moveVXCEnd struct {
+	uid   string
+	vlan  int
+	inner int
+	vnic  int
+	// mve and qinq are set when vnic and inner must be sent to the API.
+	mve  bool
+	qinq bool
+	// original is the end before the move, used for rollback.
+	original *moveVXCEnd
+}
+
+func (e *moveVXCEnd) originalOrNil() *moveVXCEnd {
+	if e == nil {
+		return nil
+	}
+	return e.original
+}
+
+// moveVXCUpdate builds the VXC update placing the given ends; nil ends are
+// left alone.
+func moveVXCUpdate(a, b *moveVXCEnd) *UpdateVXCRequest {
+	update := &UpdateVXCRequest{}
+	set := func(e *moveVXCEnd, uid **string, vlan, inner, vnic **int) {
+		if e == nil {
+			return
+		}
+		*uid, *vlan = PtrTo(e.uid), PtrTo(e.vlan)
+		if e.qinq {
+			*inner = PtrTo(e.inner)
+		}
+		if e.mve {
+			*vnic = PtrTo(e.vnic)
+		}
+	}
+	set(a, &update.AEndProductUID, &update.AEndVLAN, &update.AEndInnerVLAN, &update.AVnicIndex)
+	set(b, &update.BEndProductUID, &update.BEndVLAN, &update.BEndInnerVLAN, &update.BVnicIndex)
+	return update
+}
+
+// moveTarget is a product a VXC end is moving to.
+type moveTarget struct {
+	productType string
+	status      string
+	locationID  int
+	speed       int
+	vxcs        []*VXC
+	ixs         []*IX
+}
+
+// MoveVXC moves one or both ends of a VXC to another port, MCR or MVE vNIC.
+// Before changing anything it checks that each new end is live, in the same
+// location as the current end, fast enough for the VXC rate limit and free
+// of VLAN collisions, confirming port VLANs with the availability endpoint.
+// It then applies the move, waits for the VXC to be ready on its new ends
+// and, where an end is an MCR, waits for the BGP sessions that were up
+// before the move to come back up. If any step after the update fails the
+// VXC is moved back to its original ends unless DisableRollback is set; the
+// rollback still runs when ctx has ended, bounded by Timeout.
+func (svc *VXCServiceOp) MoveVXC(ctx context.Context, id string, req *MoveVXCRequest) (*MoveVXCResult, error) {
+	if req == nil {
+		return nil, ErrMoveVXCRequestNil
+	}
+	before, err := svc.GetVXC(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	res := &MoveVXCResult{Before: before}
+
+	var ends [2]*moveVXCEnd
+	for i, e := range []struct {
+		name    string
+		move    *MoveVXCEnd
+		current VXCEndConfiguration
+	}{{"A-End", req.AEnd, before.AEndConfiguration}, {"B-End", req.BEnd, before.BEndConfiguration}} {
+		if e.move == nil || e.move.ProductUID == "" {
+			continue
+		}
+		end := &moveVXCEnd{uid: e.move.ProductUID, vlan: e.current.VLAN, inner: e.current.InnerVLAN, vnic: e.current.NetworkInterfaceIndex}
+		if e.move.VLAN != nil {
+			end.vlan = *e.move.VLAN
+		}
+		if e.move.InnerVLAN != nil {
+			end.inner = *e.move.InnerVLAN
+		}
+		if e.move.VNICIndex != nil {
+			end.vnic = *e.move.VNICIndex
+		}
+		original := &moveVXCEnd{uid: e.current.UID, vlan: e.current.VLAN, inner: e.current.InnerVLAN, vnic: e.current.NetworkInterfaceIndex}
+		if *end == *original {
+			continue
+		}
+		target, err := svc.checkMoveTarget(ctx, before, e.current, end, req.AllowLocationChange)
+		if err != nil {
+			return res, fmt.Errorf("%s: %w", e.name, err)
+		}
+		end.mve = strings.EqualFold(target.productType, PRODUCT_MVE)
+		// An MVE end on vNIC 0 needs no index: the API defaults to it.
+		original.mve = e.current.NetworkInterfaceIndex != 0
+		end.qinq = end.inner != 0 || original.inner != 0
+		original.qinq = end.qinq
+		end.original = original
+		ends[i] = end
+	}
+	if ends[0] == nil && ends[1] == nil {
+		return res, ErrMoveVXCNoChange
+	}
+	update := moveVXCUpdate(ends[0], ends[1])
+	rollback := moveVXCUpdate(ends[0].originalOrNil(), ends[1].originalOrNil())
+
+	if !req.SkipBGPCheck {
+		res.BGPSessionsBefore, err = svc.moveBGPSessions(ctx, before, nil)
+		if err != nil {
+			return res, fmt.Errorf("capturing BGP sessions: %w", err)
+		}
+	}
+
+	svc.Client.Logger.DebugContext(ctx, "moving VXC", slog.String("vxc_id", id))
+	if _, err := svc.UpdateVXC(ctx, id, update); err != nil {
+		// The update was rejected, so there is nothing to roll back.
+		return res, fmt.Errorf("updating VXC: %w", err)
+	}
+
+	moveErr := svc.verifyMove(ctx, id, req, update, res)
+	if moveErr == nil {
+		return res, nil
+	}
+	if req.DisableRollback {
+		return res, moveErr
+	}
+
+	svc.Client.Logger.WarnContext(ctx, "VXC move failed, rolling back", slog.String("vxc_id", id), slog.String("error", moveErr.Error()))
+	res.RolledBack = true
+	// Verification may have failed because ctx ended, so roll back on a
+	// context that outlives it, bounded by the move timeout instead.
+	timeout := req.Timeout
+	if timeout == 0 {
+		timeout = defaultMoveVXCTimeout
+	}
+	rollbackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	if _, err := svc.UpdateVXC(rollbackCtx, id, rollback); err != nil {
+		res.RollbackErr = err
+	} else if after, err := svc.waitForVXCEnds(rollbackCtx, id, req, rollback); err != nil {
+		res.RollbackErr = err
+	} else {
+		res.After = after
+	}
+	if res.RollbackErr != nil {
+		return res, errors.Join(moveErr, fmt.Errorf("rolling back: %w", res.RollbackErr))
+	}
+	return res, moveErr
+}
+
+// checkMoveTarget runs the pre-checks for one end moving to targetUID.
+func (svc *VXCServiceOp) checkMoveTarget(ctx context.Context, vxc *VXC, current VXCEndConfiguration, end *moveVXCEnd, allowLocationChange bool) (*moveTarget, error) {
+	targetUID, vlan, inner := end.uid, end.vlan, end.inner
+	target, err := svc.getMoveTarget(ctx, targetUID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(SERVICE_STATE_READY, target.status) {
+		return nil, fmt.Errorf("%w: %s is %s", ErrMoveVXCTargetNotReady, targetUID, target.status)
+	}
+	if !allowLocationChange && current.LocationID != 0 && target.locationID != current.LocationID {
+		return nil, fmt.Errorf("%w: %s is in location %d, the current end is in %d", ErrMoveVXCLocation, targetUID, target.locationID, current.LocationID)
+	}
+	if target.speed > 0 && vxc.RateLimit > target.speed {
+		return nil, fmt.Errorf("%w: %d Mbps on %s with speed %d Mbps", ErrMoveVXCRateLimit, vxc.RateLimit, targetUID, target.speed)
+	}
+
+	if vlan == VLAN_AUTO_ASSIGN {
+		return target, nil
+	}
+	// The VXC's own ends do not collide with it.
+	others := make([]*VXC, 0, len(target.vxcs))
+	for _, v := range target.vxcs {
+		if v != nil && v.UID != vxc.UID {
+			others = append(others, v)
+		}
+	}
+	vlans := NewVLANMap(targetUID, target.productType, end.vnic, others, target.ixs)
+	if conflicts := vlans.Check(vlan, inner); len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %s on %s", ErrVLANConflict, conflicts[0], targetUID)
+	}
+	if strings.EqualFold(target.productType, PRODUCT_MEGAPORT) && vlan != VLAN_UNTAGGED && len(vlans.Check(vlan, 0)) == 0 {
+		ok, err := svc.Client.PortService.CheckPortVLANAvailability(ctx, targetUID, vlan)
+		if err != nil {
+			return nil, fmt.Errorf("checking VLAN %d on %s: %w", vlan, targetUID, err)
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: VLAN %d on port %s", ErrVLANConflict, vlan, targetUID)
+		}
+	}
+	return target, nil
+}
+
+func (svc *VXCServiceOp) getMoveTarget(ctx context.Context, uid string) (*moveTarget, error) {
+	productType, err := svc.Client.ProductService.GetProductType(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(productType) {
+	case PRODUCT_MEGAPORT:
+		p, err := svc.Client.PortService.GetPort(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		return &moveTarget{productType: PRODUCT_MEGAPORT, status: p.ProvisioningStatus, locationID: p.LocationID, speed: p.PortSpeed, vxcs: p.AssociatedVXCs, ixs: p.AssociatedIXs}, nil
+	case PRODUCT_MCR:
+		m, err := svc.Client.MCRService.GetMCR(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		return &moveTarget{productType: PRODUCT_MCR, status: m.ProvisioningStatus, locationID: m.LocationID, speed: m.PortSpeed, vxcs: m.AssociatedVXCs, ixs: m.AssociatedIXs}, nil
+	case PRODUCT_MVE:
+		m, err := svc.Client.MVEService.GetMVE(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		return &moveTarget{productType: PRODUCT_MVE, status: m.ProvisioningStatus, locationID: m.LocationID, vxcs: m.AssociatedVXCs, ixs: m.AssociatedIXs}, nil
+	}
+	return nil, fmt.Errorf("%w: %s is %s", ErrMoveVXCTargetType, uid, productType)
+}
+
+// verifyMove waits for the VXC to be ready on its new ends and for its BGP
+// sessions to recover.
+func (svc *VXCServiceOp) verifyMove(ctx context.Context, id string, req *MoveVXCRequest, update *UpdateVXCRequest, res *MoveVXCResult) error {
+	after, err := svc.waitForVXCEnds(ctx, id, req, update)
+	if err != nil {
+		return err
+	}
+	res.After = after
+	if req.SkipBGPCheck || len(res.BGPSessionsBefore) == 0 {
+		return nil
+	}
+
+	timeout := req.BGPTimeout
+	if timeout == 0 {
+		timeout = defaultMoveVXCBGPTimeout
+	}
+	return svc.pollMove(ctx, req, timeout, func() (bool, error) {
+		res.BGPSessionsAfter, err = svc.moveBGPSessions(ctx, after, res.BGPSessionsBefore)
+		if err != nil {
+			return false, err
+		}
+		for _, s := range res.BGPSessionsAfter {
+			if s.Status != BGPSessionStatusUp {
+				return false, nil
+			}
+		}
+		return len(res.BGPSessionsAfter) == len(res.BGPSessionsBefore), nil
+	}, ErrMoveVXCBGPDown)
+}
+
+// waitForVXCEnds polls the VXC until it is ready on the ends set in want.
+func (svc *VXCServiceOp) waitForVXCEnds(ctx context.Context, id string, req *MoveVXCRequest, want *UpdateVXCRequest) (*VXC, error) {
+	timeout := req.Timeout
+	if timeout == 0 {
+		timeout = defaultMoveVXCTimeout
+	}
+	var vxc *VXC
+	err := svc.pollMove(ctx, req, timeout, func() (bool, error) {
+		var err error
+		vxc, err = svc.GetVXC(ctx, id)
+		if err != nil {
+			return false, err
+		}
+		return slices.Contains(SERVICE_STATE_READY, vxc.ProvisioningStatus) &&
+			(want.AEndProductUID == nil || vxc.AEndConfiguration.UID == *want.AEndProductUID) &&
+			(want.BEndProductUID == nil || vxc.BEndConfiguration.UID == *want.BEndProductUID), nil
+	}, ErrMoveVXCTimeout)
+	return vxc, err
+}
+
+// pollMove calls check until it reports done, returning timeoutErr once
+// timeout has passed.
+func (svc *VXCServiceOp) pollMove(ctx context.Context, req *MoveVXCRequest, timeout time.Duration, check func() (bool, error), timeoutErr error) error {
+	interval := req.PollInterval
+	if interval == 0 {
+		interval = defaultMoveVXCPollInterval
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		done, err := check()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		wait := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			wait.Stop()
+			return ctx.Err()
+		case <-deadline.C:
+			wait.Stop()
+			return timeoutErr
+		case <-wait.C:
+		}
+	}
+}
+
+// moveBGPSessions lists the looking glass sessions of vxc on its MCR ends.
+// With no previous sessions it returns those that are up; otherwise it
+// returns the current state of the previous sessions, omitting any that
+// have disappeared.
+func (svc *VXCServiceOp) moveBGPSessions(ctx context.Context, vxc *VXC, previous []*LookingGlassBGPSession) ([]*LookingGlassBGPSession, error) {
+	var sessions []*LookingGlassBGPSession
+	seen := make(map[string]bool)
+	for _, uid := range []string{vxc.AEndConfiguration.UID, vxc.BEndConfiguration.UID} {
+		if uid == "" || seen[uid] {
+			continue
+		}
+		seen[uid] = true
+		productType, err := svc.Client.ProductService.GetProductType(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(productType, PRODUCT_MCR) {
+			continue
+		}
+		all, err := svc.Client.MCRLookingGlassService.ListBGPSessions(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range all {
+			if s.VXCID != vxc.ID {
+				continue
+			}
+			if previous == nil && s.Status == BGPSessionStatusUp {
+				sessions = append(sessions, s)
+			}
+			if previous != nil && slices.ContainsFunc(previous, func(p *LookingGlassBGPSession) bool { return p.NeighborAddress == s.NeighborAddress }) {
+				sessions = append(sessions, s)
+			}
+		}
+	}
+	return sessions, nil
+}
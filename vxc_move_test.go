@@ -0,0 +1,199 @@
+package megaport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+var moveVXCTargets = map[string]string{
+	"port-1": `{"productUid": "port-1", "productType": "MEGAPORT", "provisioningStatus": "LIVE", "locationId": 1, "portSpeed": 10000}`,
+	"port-2": `{"productUid": "port-2", "productType": "MEGAPORT", "provisioningStatus": "LIVE", "locationId": 1, "portSpeed": 10000,
+        "associatedVxcs": [{"productUid": "vxc-other", "provisioningStatus": "LIVE", "aEnd": {"productUid": "port-2", "vlan": 200}, "bEnd": {"productUid": "x"}}]}`,
+	"port-far":  `{"productUid": "port-far", "productType": "MEGAPORT", "provisioningStatus": "LIVE", "locationId": 2, "portSpeed": 10000}`,
+	"port-slow": `{"productUid": "port-slow", "productType": "MEGAPORT", "provisioningStatus": "LIVE", "locationId": 1, "portSpeed": 50}`,
+	"port-new":  `{"productUid": "port-new", "productType": "MEGAPORT", "provisioningStatus": "DEPLOYABLE", "locationId": 1, "portSpeed": 10000}`,
+	"mcr-1":     `{"productUid": "mcr-1", "productType": "MCR2", "provisioningStatus": "LIVE", "locationId": 1, "portSpeed": 1000}`,
+	"ix-1":      `{"productUid": "ix-1", "productType": "IX", "provisioningStatus": "LIVE"}`,
+}
+
+// MoveVXCTestSuite tests MoveVXC against a VXC whose ends move as soon as
+// the update is accepted.
+type MoveVXCTestSuite struct {
+	ClientTestSuite
+
+	mu      sync.Mutex
+	aEnd    string
+	aVLAN   int
+	updates []*VXCUpdate
+	// bgpDownAfterMove reports the MCR session down once the VXC has moved.
+	bgpDownAfterMove bool
+}
+
+func TestMoveVXCTestSuite(t *testing.T) {
+	t.Parallel()
+	suite.Run(t, new(MoveVXCTestSuite))
+}
+
+func (suite *MoveVXCTestSuite) SetupTest() {
+	suite.mux = http.NewServeMux()
+	suite.server = httptest.NewServer(suite.mux)
+
+	suite.client = NewClient(nil, nil)
+	url, _ := url.Parse(suite.server.URL)
+	suite.client.BaseURL = url
+	suite.aEnd, suite.aVLAN = "port-1", 100
+	suite.updates = nil
+	suite.bgpDownAfterMove = false
+
+	suite.mux.HandleFunc("/v2/product/", func(w http.ResponseWriter, r *http.Request) {
+		uid := strings.TrimPrefix(r.URL.Path, "/v2/product/")
+		if uid == "vxc-1" {
+			suite.mu.Lock()
+			defer suite.mu.Unlock()
+			fmt.Fprintf(w, `{"message": "VXC", "data": {"productId": 10, "productUid": "vxc-1", "productType": "VXC", "provisioningStatus": "LIVE", "rateLimit": 100,
+                "aEnd": {"productUid": %q, "vlan": %d, "locationId": 1}, "bEnd": {"productUid": "mcr-1", "locationId": 1}}}`, suite.aEnd, suite.aVLAN)
+			return
+		}
+		data, ok := moveVXCTargets[uid]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"message": "Product", "data": %s}`, data)
+	})
+	suite.mux.HandleFunc("/v2/product/port/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"message": "VLANs", "data": [100, 101, 102]}`)
+	})
+	suite.mux.HandleFunc("/v3/product/vxc/vxc-1", func(w http.ResponseWriter, r *http.Request) {
+		suite.testMethod(r, http.MethodPut)
+		update := &VXCUpdate{}
+		suite.NoError(json.NewDecoder(r.Body).Decode(update))
+		suite.mu.Lock()
+		suite.updates = append(suite.updates, update)
+		suite.aEnd, suite.aVLAN = update.AEndProductUID, *update.AEndVLAN
+		suite.mu.Unlock()
+		fmt.Fprint(w, `{"message": "Updated", "data": {}}`)
+	})
+	suite.mux.HandleFunc("/v2/product/mcr2/mcr-1/lookingGlass/bgpSessions", func(w http.ResponseWriter, r *http.Request) {
+		suite.mu.Lock()
+		status := "UP"
+		if suite.bgpDownAfterMove && suite.aEnd != "port-1" {
+			status = "DOWN"
+		}
+		suite.mu.Unlock()
+		fmt.Fprintf(w, `{"message": "Sessions", "data": [
+            {"sessionId": "s1", "neighborAddress": "10.0.0.1", "status": %q, "vxcId": 10},
+            {"sessionId": "s2", "neighborAddress": "10.0.0.5", "status": "DOWN", "vxcId": 10},
+            {"sessionId": "s3", "neighborAddress": "10.0.0.9", "status": "UP", "vxcId": 11}
+        ]}`, status)
+	})
+}
+
+func (suite *MoveVXCTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *MoveVXCTestSuite) TestMoveVXC() {
+	ctx := context.Background()
+	res, err := suite.client.VXCService.MoveVXC(ctx, "vxc-1", &MoveVXCRequest{
+		AEnd:         &MoveVXCEnd{ProductUID: "port-2"},
+		PollInterval: time.Millisecond,
+	})
+	suite.Require().NoError(err)
+	suite.False(res.RolledBack)
+	suite.Equal("port-1", res.Before.AEndConfiguration.UID)
+	suite.Equal("port-2", res.After.AEndConfiguration.UID)
+	suite.Require().Len(res.BGPSessionsBefore, 1)
+	suite.Equal("10.0.0.1", res.BGPSessionsBefore[0].NeighborAddress)
+	suite.Require().Len(res.BGPSessionsAfter, 1)
+
+	suite.Require().Len(suite.updates, 1)
+	suite.Equal("port-2", suite.updates[0].AEndProductUID)
+	suite.Equal(100, *suite.updates[0].AEndVLAN)
+	suite.Empty(suite.updates[0].BEndProductUID)
+	suite.Nil(suite.updates[0].AVnicIndex)
+	suite.Nil(suite.updates[0].AEndInnerVLAN)
+}
+
+func (suite *MoveVXCTestSuite) TestMoveVXCPreChecks() {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		end     *MoveVXCEnd
+		wantErr error
+	}{
+		{"no change", &MoveVXCEnd{ProductUID: "port-1"}, ErrMoveVXCNoChange},
+		{"not ready", &MoveVXCEnd{ProductUID: "port-new"}, ErrMoveVXCTargetNotReady},
+		{"location", &MoveVXCEnd{ProductUID: "port-far"}, ErrMoveVXCLocation},
+		{"rate limit", &MoveVXCEnd{ProductUID: "port-slow"}, ErrMoveVXCRateLimit},
+		{"VLAN in use", &MoveVXCEnd{ProductUID: "port-2", VLAN: PtrTo(200)}, ErrVLANConflict},
+		{"VLAN unavailable", &MoveVXCEnd{ProductUID: "port-2", VLAN: PtrTo(300)}, ErrVLANConflict},
+		{"target type", &MoveVXCEnd{ProductUID: "ix-1"}, ErrMoveVXCTargetType},
+	}
+	for _, tc := range tests {
+		suite.Run(tc.name, func() {
+			_, err := suite.client.VXCService.MoveVXC(ctx, "vxc-1", &MoveVXCRequest{AEnd: tc.end})
+			suite.ErrorIs(err, tc.wantErr)
+		})
+	}
+	suite.Empty(suite.updates, "failed pre-checks must not update the VXC")
+
+	_, err := suite.client.VXCService.MoveVXC(ctx, "vxc-1", nil)
+	suite.ErrorIs(err, ErrMoveVXCRequestNil)
+}
+
+func (suite *MoveVXCTestSuite) TestMoveVXCRollback() {
+	ctx := context.Background()
+	suite.bgpDownAfterMove = true
+	req := &MoveVXCRequest{
+		AEnd:         &MoveVXCEnd{ProductUID: "port-2", VLAN: PtrTo(101)},
+		PollInterval: time.Millisecond,
+		BGPTimeout:   20 * time.Millisecond,
+	}
+	res, err := suite.client.VXCService.MoveVXC(ctx, "vxc-1", req)
+	suite.Require().ErrorIs(err, ErrMoveVXCBGPDown)
+	suite.True(res.RolledBack)
+	suite.NoError(res.RollbackErr)
+	suite.Equal("port-1", res.After.AEndConfiguration.UID)
+	suite.Equal(100, res.After.AEndConfiguration.VLAN)
+	suite.Require().Len(suite.updates, 2)
+	suite.Equal("port-1", suite.updates[1].AEndProductUID)
+	suite.Equal(100, *suite.updates[1].AEndVLAN)
+
+	// Without rollback the VXC stays on the new end.
+	suite.updates = nil
+	req.DisableRollback = true
+	res, err = suite.client.VXCService.MoveVXC(ctx, "vxc-1", req)
+	suite.ErrorIs(err, ErrMoveVXCBGPDown)
+	suite.False(res.RolledBack)
+	suite.Len(suite.updates, 1)
+	suite.Equal("port-2", suite.aEnd)
+}
+
+func (suite *MoveVXCTestSuite) TestMoveVXCRollbackAfterContextExpires() {
+	suite.bgpDownAfterMove = true
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	res, err := suite.client.VXCService.MoveVXC(ctx, "vxc-1", &MoveVXCRequest{
+		AEnd:         &MoveVXCEnd{ProductUID: "port-2", VLAN: PtrTo(101)},
+		PollInterval: time.Millisecond,
+		BGPTimeout:   time.Minute,
+	})
+	suite.Require().ErrorIs(err, context.DeadlineExceeded)
+	suite.True(res.RolledBack)
+	suite.NoError(res.RollbackErr)
+	suite.Require().NotNil(res.After)
+	suite.Equal("port-1", res.After.AEndConfiguration.UID)
+	suite.Require().Len(suite.updates, 2)
+	suite.Equal("port-1", suite.updates[1].AEndProductUID)
+}
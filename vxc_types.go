@@ -11,6 +11,7 @@ const PARTNER_AZURE string = "AZURE"
 const PARTNER_GOOGLE string = "GOOGLE"
 const PARTNER_AWS string = "AWS"
 const PARTNER_OCI string = "ORACLE"
+const PARTNER_IBM string = "IBM"
 
 // VXC represents a Virtual Cross Connect in the Megaport VXC API.
 type VXC struct {
@@ -711,6 +712,16 @@ func (c *CSPConnection) UnmarshalJSON(data []byte) error {
 				return err
 			}
 			c.CSPConnection = append(c.CSPConnection, oracle)
+		case "IBM":
+			marshaled, err := json.Marshal(cn)
+			if err != nil {
+				return err
+			}
+			ibm := CSPConnectionIBM{}
+			if err := json.Unmarshal(marshaled, &ibm); err != nil {
+				return err
+			}
+			c.CSPConnection = append(c.CSPConnection, ibm)
 		default: // Any other cases will be marshaled into a map[string]interface{}
 			marshaled, err := json.Marshal(cn)
 			if err != nil {